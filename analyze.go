@@ -0,0 +1,193 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Sizes of the lists in an AnalysisReport
+const (
+	analysisTopValues      = 5  // Most common values listed per field
+	analysisPoolCandidates = 10 // String pooling candidates listed
+)
+
+// AnalysisReport describes the values of a document, to find what makes it
+// large before choosing options: which fields repeat few values, which hold
+// unique ones, which top-level keys take the most bytes and how much string
+// pooling would save
+type AnalysisReport struct {
+	// Fields describes the scalar values of each field path, sorted by path
+	Fields []FieldAnalysis `json:"fields"`
+	// KeyBytes maps each top-level key to the bytes its members take in the
+	// compact JSON encoding; for an array of objects, summed over its elements
+	KeyBytes map[string]int `json:"key-bytes"`
+	// StringPool estimates what StringPooling would save
+	StringPool StringPoolEstimate `json:"string-pool"`
+}
+
+// FieldAnalysis describes the scalar values found at a field path, such as
+// "user.status"; array indices are left out of paths, so the elements of an
+// array are counted together
+type FieldAnalysis struct {
+	Path string `json:"path"`
+	// Count is the number of values at the path
+	Count int `json:"count"`
+	// Cardinality is the number of distinct values at the path
+	Cardinality int `json:"cardinality"`
+	// TopValues lists the most common values, most common first
+	TopValues []ValueCount `json:"top-values"`
+}
+
+// ValueCount is a value, in its JSON encoding, and how often it occurs
+type ValueCount struct {
+	Value json.RawMessage `json:"value"`
+	Count int             `json:"count"`
+}
+
+// StringPoolEstimate estimates the effect of StringPooling with the default
+// minimum of two occurrences
+type StringPoolEstimate struct {
+	// Strings is the number of distinct strings that would be pooled
+	Strings int `json:"strings"`
+	// SavedBytes is the estimated size reduction after adding the _strings
+	// table, negative when the table costs more than pooling saves
+	SavedBytes int `json:"saved-bytes"`
+	// Candidates lists the strings saving the most, most first
+	Candidates []PoolCandidate `json:"candidates"`
+}
+
+// PoolCandidate is a repeated string and the bytes pooling it would save
+type PoolCandidate struct {
+	Value      string `json:"value"`
+	Count      int    `json:"count"`
+	SavedBytes int    `json:"saved-bytes"`
+}
+
+// Analyze returns an AnalysisReport of data without slimming it. Like
+// ExtractSchema it accepts any value, which it describes by its JSON encoding;
+// a value that cannot be encoded gives an empty report.
+func Analyze(data interface{}) AnalysisReport {
+	report := AnalysisReport{Fields: []FieldAnalysis{}, KeyBytes: map[string]int{}, StringPool: StringPoolEstimate{Candidates: []PoolCandidate{}}}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return report
+	}
+	var doc interface{}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return report
+	}
+
+	values := make(map[string]map[string]int) // path -> encoded value -> count
+	collectFieldValues(doc, "", values)
+	for _, path := range slices.Sorted(maps.Keys(values)) {
+		report.Fields = append(report.Fields, analyzeField(path, values[path]))
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		addKeyBytes(report.KeyBytes, v)
+	case []interface{}:
+		for _, elem := range v {
+			if obj, ok := elem.(map[string]interface{}); ok {
+				addKeyBytes(report.KeyBytes, obj)
+			}
+		}
+	}
+
+	report.StringPool = estimateStringPool(doc)
+	return report
+}
+
+// collectFieldValues counts the encoded scalar values of data by field path
+func collectFieldValues(data interface{}, path string, values map[string]map[string]int) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			collectFieldValues(child, childPath, values)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectFieldValues(child, path, values)
+		}
+	default:
+		if path == "" {
+			return // A scalar document has no fields
+		}
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		if values[path] == nil {
+			values[path] = make(map[string]int)
+		}
+		values[path][string(encoded)]++
+	}
+}
+
+// analyzeField returns the FieldAnalysis of the value counts of a path
+func analyzeField(path string, counts map[string]int) FieldAnalysis {
+	field := FieldAnalysis{Path: path, Cardinality: len(counts)}
+	for _, n := range counts {
+		field.Count += n
+	}
+	top := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+	for _, value := range top[:min(len(top), analysisTopValues)] {
+		field.TopValues = append(field.TopValues, ValueCount{Value: json.RawMessage(value), Count: counts[value]})
+	}
+	return field
+}
+
+// addKeyBytes adds the size of each "key":value member of obj to keyBytes
+func addKeyBytes(keyBytes map[string]int, obj map[string]interface{}) {
+	for key, value := range obj {
+		keyBytes[key] += encodedSize(key) + 1 + encodedSize(value)
+	}
+}
+
+// estimateStringPool estimates the bytes StringPooling would save on doc,
+// counting strings the way the Slimmer does when building its pool
+func estimateStringPool(doc interface{}) StringPoolEstimate {
+	s := New(Config{StringPooling: true})
+	counts := make(map[string]int)
+	s.collectStatsRecursive(doc, "", counts, make(map[string]map[string]int))
+
+	estimate := StringPoolEstimate{Candidates: []PoolCandidate{}}
+	var candidates []PoolCandidate
+	for _, str := range slices.Sorted(maps.Keys(counts)) {
+		count := counts[str]
+		if count < s.Config.StringPoolMinOccurrences || len(str) <= 3 {
+			continue
+		}
+		// Each occurrence becomes the pool index, and the string is stored
+		// once in _strings
+		size := encodedSize(str)
+		index := len(strconv.Itoa(len(candidates)))
+		candidates = append(candidates, PoolCandidate{Value: str, Count: count, SavedBytes: count*(size-index) - size - 1})
+	}
+	if len(candidates) == 0 {
+		return estimate
+	}
+
+	estimate.Strings = len(candidates)
+	estimate.SavedBytes = 1 - len(`,"_strings":[]`) // One comma fewer than strings
+	for _, c := range candidates {
+		estimate.SavedBytes += c.SavedBytes
+	}
+	slices.SortStableFunc(candidates, func(a, b PoolCandidate) int {
+		return b.SavedBytes - a.SavedBytes
+	})
+	estimate.Candidates = candidates[:min(len(candidates), analysisPoolCandidates)]
+	return estimate
+}
@@ -0,0 +1,92 @@
+package slimjson
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	var orders []interface{}
+	for i := 0; i < 20; i++ {
+		status := "shipped"
+		if i%4 == 0 {
+			status = "pending"
+		}
+		orders = append(orders, map[string]interface{}{
+			"id":     fmt.Sprintf("order-%03d", i),
+			"status": status,
+			"total":  float64(i),
+		})
+	}
+	data := map[string]interface{}{"orders": orders, "owner": "Ada"}
+
+	report := Analyze(data)
+	fields := make(map[string]FieldAnalysis)
+	for _, f := range report.Fields {
+		fields[f.Path] = f
+	}
+
+	// A repeated value
+	status := fields["orders.status"]
+	if status.Count != 20 || status.Cardinality != 2 {
+		t.Errorf("orders.status: expected 20 values, 2 distinct, got %+v", status)
+	}
+	if len(status.TopValues) != 2 || string(status.TopValues[0].Value) != `"shipped"` || status.TopValues[0].Count != 15 {
+		t.Errorf("orders.status: expected \"shipped\" 15 times first, got %+v", status.TopValues)
+	}
+
+	// A high-cardinality field
+	id := fields["orders.id"]
+	if id.Count != 20 || id.Cardinality != 20 {
+		t.Errorf("orders.id: expected 20 distinct values, got %+v", id)
+	}
+	if len(id.TopValues) != analysisTopValues || id.TopValues[0].Count != 1 {
+		t.Errorf("orders.id: expected %d values listed once, got %+v", analysisTopValues, id.TopValues)
+	}
+
+	if got, want := report.KeyBytes["owner"], len(`"owner":"Ada"`); got != want {
+		t.Errorf("KeyBytes[owner]: expected %d, got %d", want, got)
+	}
+	if report.KeyBytes["orders"] <= report.KeyBytes["owner"] {
+		t.Errorf("Expected orders to take the most bytes, got %v", report.KeyBytes)
+	}
+
+	pool := report.StringPool
+	if pool.Strings != 2 || pool.SavedBytes <= 0 {
+		t.Errorf("Expected the two statuses to be worth pooling, got %+v", pool)
+	}
+	if len(pool.Candidates) == 0 || pool.Candidates[0].Value != "shipped" {
+		t.Errorf("Expected \"shipped\" to save the most, got %+v", pool.Candidates)
+	}
+	_, plain := New(Config{}).SlimWithStats(data)
+	_, pooled := New(Config{StringPooling: true}).SlimWithStats(data)
+	if got := plain.SlimmedBytes - pooled.SlimmedBytes; got != pool.SavedBytes {
+		t.Errorf("Expected the estimate %d to match the actual saving %d", pool.SavedBytes, got)
+	}
+}
+
+func TestAnalyzeEdgeCases(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   interface{}
+		fields int
+		keys   int
+	}{
+		{"Scalar", "text", 0, 0},
+		{"Empty object", map[string]interface{}{}, 0, 0},
+		{"Array of objects", []interface{}{map[string]interface{}{"a": 1.0}, map[string]interface{}{"a": 2.0, "b": true}}, 2, 2},
+		{"Unencodable", map[string]interface{}{"f": func() {}}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Analyze(tt.data)
+			if len(report.Fields) != tt.fields || len(report.KeyBytes) != tt.keys {
+				t.Errorf("Expected %d fields and %d keys, got %+v", tt.fields, tt.keys, report)
+			}
+			if report.StringPool.Strings != 0 || report.StringPool.Candidates == nil {
+				t.Errorf("Expected no pool candidates, got %+v", report.StringPool)
+			}
+		})
+	}
+}
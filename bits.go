@@ -0,0 +1,34 @@
+package slimjson
+
+import "encoding/base64"
+
+// minBitsLength is the shortest array of booleans BoolArrayPacking packs
+const minBitsLength = 16
+
+// applyBitPacking returns arr as {"_bits": "...", "n": len(arr)}, the standard
+// base64 of a bitmap where element i is bit i%8 (least significant first) of
+// byte i/8, or nil to leave arr as it is: when it holds values other than
+// bools, has fewer than minBitsLength elements, or the bitmap is not smaller in
+// compact JSON (by at least MinSavingsBytes, if set).
+func (s *Slimmer) applyBitPacking(arr []interface{}) interface{} {
+	if len(arr) < minBitsLength {
+		return nil
+	}
+
+	bitmap := make([]byte, (len(arr)+7)/8)
+	for i, v := range arr {
+		b, ok := v.(bool)
+		if !ok {
+			return nil
+		}
+		if b {
+			bitmap[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	bits := map[string]interface{}{"_bits": base64.StdEncoding.EncodeToString(bitmap), "n": len(arr)}
+	if encodedSize(arr)-encodedSize(bits) < max(s.Config.MinSavingsBytes, 1) {
+		return nil
+	}
+	return bits
+}
@@ -0,0 +1,116 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBoolArrayPacking(t *testing.T) {
+	bools := func(n int, f func(i int) bool) []interface{} {
+		arr := make([]interface{}, n)
+		for i := range arr {
+			arr[i] = f(i)
+		}
+		return arr
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		input    interface{}
+		expected string
+	}{
+		{
+			name:     "Least significant bit first",
+			config:   Config{BoolArrayPacking: true},
+			input:    bools(16, func(i int) bool { return i == 0 || i == 9 }),
+			expected: `{"_bits":"AQI=","n":16}`,
+		},
+		{
+			name:     "Kept whole despite the list limit and deduplication",
+			config:   Config{BoolArrayPacking: true, MaxListLength: 5, DeduplicateArrays: true},
+			input:    map[string]interface{}{"present": bools(20, func(i int) bool { return i%2 == 0 })},
+			expected: `{"present":{"_bits":"VVUF","n":20}}`,
+		},
+		{
+			name:     "Shorter than 16 elements",
+			config:   Config{BoolArrayPacking: true},
+			input:    bools(15, func(int) bool { return true }),
+			expected: `[true,true,true,true,true,true,true,true,true,true,true,true,true,true,true]`,
+		},
+		{
+			name:     "Not only booleans",
+			config:   Config{BoolArrayPacking: true, DecimalPlaces: -1},
+			input:    append(bools(16, func(int) bool { return false }), 1),
+			expected: `[false,false,false,false,false,false,false,false,false,false,false,false,false,false,false,false,1]`,
+		},
+		{
+			name:     "Smaller run-length encoding wins",
+			config:   Config{BoolArrayPacking: true, RunLengthEncode: true},
+			input:    bools(100, func(i int) bool { return i < 50 }),
+			expected: `{"_rle":[[true,50],[false,50]]}`,
+		},
+		{
+			name:     "Smaller bitmap wins",
+			config:   Config{BoolArrayPacking: true, RunLengthEncode: true},
+			input:    bools(16, func(i int) bool { return i%4 < 2 }),
+			expected: `{"_bits":"MzM=","n":16}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := New(tt.config).Slim(tt.input)
+			got, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Slim() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnslimBoolArrayPacking(t *testing.T) {
+	flags := make([]interface{}, 100)
+	for i := range flags {
+		flags[i] = i%3 == 0 || i%7 == 0
+	}
+	original := map[string]interface{}{"flags": flags}
+
+	slimmed := New(Config{BoolArrayPacking: true, Lossless: true}).Slim(original)
+	encoded, err := json.Marshal(slimmed)
+	if err != nil {
+		t.Fatalf("Failed to marshal slimmed: %v", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal slimmed: %v", err)
+	}
+	packed, ok := decoded.(map[string]interface{})["flags"].(map[string]interface{})
+	if !ok || packed["n"] != 100.0 {
+		t.Fatalf("Expected 100 packed flags, got %s", encoded)
+	}
+
+	for _, slim := range []interface{}{slimmed, decoded} {
+		restored, err := Unslim(slim)
+		if err != nil {
+			t.Fatalf("Unslim() error: %v", err)
+		}
+		if !reflect.DeepEqual(restored, original) {
+			t.Errorf("Unslim() = %v, want %v", restored, original)
+		}
+	}
+
+	for _, bad := range []string{`{"_bits": "AQI=", "n": 17}`, `{"_bits": "AQI=", "n": 8}`, `{"_bits": "not base64!", "n": 8}`, `{"_bits": 1, "n": 8}`, `{"_bits": "AQ==", "n": -1}`} {
+		var data interface{}
+		if err := json.Unmarshal([]byte(bad), &data); err != nil {
+			t.Fatalf("Failed to unmarshal %s: %v", bad, err)
+		}
+		if _, err := Unslim(data); err == nil {
+			t.Errorf("Expected an error for %s", bad)
+		}
+	}
+}
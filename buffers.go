@@ -0,0 +1,41 @@
+package slimjson
+
+import "sync"
+
+// maxPooledList is the capacity above which a working list is left to the
+// garbage collector instead of returned to listPool, so one huge array does
+// not keep its buffer alive
+const maxPooledList = 1 << 16
+
+// listPool holds the working lists pruneArray collects pruned elements in,
+// shared by all Slimmers. Only lists that never escape into a result are put
+// back; elements are cleared first, so no document is kept alive by the pool.
+var listPool = sync.Pool{
+	New: func() any { return new([]interface{}) },
+}
+
+// getList returns an empty list with a capacity of at least n from listPool
+func getList(n int) *[]interface{} {
+	list := listPool.Get().(*[]interface{})
+	if cap(*list) < n {
+		*list = make([]interface{}, 0, n)
+	}
+	return list
+}
+
+// putList clears the elements of list and returns it to listPool; the
+// elements used must be within its length
+func putList(list *[]interface{}) {
+	if cap(*list) > maxPooledList {
+		return
+	}
+	clear(*list)
+	*list = (*list)[:0]
+	listPool.Put(list)
+}
+
+// inList reports whether list shares the backing array of the pooled list,
+// and so must be copied before it escapes
+func inList(list []interface{}, pooled *[]interface{}) bool {
+	return cap(list) > 0 && cap(*pooled) > 0 && &list[:1][0] == &(*pooled)[:1][0]
+}
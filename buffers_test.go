@@ -0,0 +1,40 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestListPoolResultsDoNotShare(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"Whole arrays", Config{}, `{"a":["x",null,"y","z"],"b":[[1,2,3],[],"w"]}`},
+		{"Sampled arrays", Config{MaxListLength: 2}, `{"a":["x",null],"b":[[1,2],[]]}`},
+		{"Truncation summaries", Config{MaxListLength: 2, TruncationSummaries: true}, `{"a":["x",null,{"_omitted":2}],"b":[[1,2,{"_omitted":1}],[],{"_omitted":1}]}`},
+		{"Stripped elements", Config{StripEmpty: true}, `{"a":["x","y","z"],"b":[[1,2,3],"w"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := map[string]interface{}{
+				"a": []interface{}{"x", nil, "y", "z"},
+				"b": []interface{}{[]interface{}{1.0, 2.0, 3.0}, []interface{}{}, "w"},
+			}
+			result := New(tt.config).Slim(input)
+			// Slimming more documents reuses the pooled lists
+			for range 3 {
+				New(tt.config).Slim(map[string]interface{}{"c": []interface{}{"q", "r", "s", "t", "u"}})
+			}
+			got, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
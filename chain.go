@@ -0,0 +1,124 @@
+package slimjson
+
+import "slices"
+
+// metadataFields are the fields Slim adds to its output. Later passes of a
+// Chain keep them as they are, like PreserveFields.
+var metadataFields = []string{
+	"_strings", "_enums", "_nulls", "_bools", "_schema", "_data", "_cols", "_range", "_matrix", "_rle", "_bits",
+	"_defaults", "_keys", "_flat", "_checksum", "_slimjson", "_truncated", "_omitted",
+}
+
+// rootTables are the root metadata fields a pass cannot add again, by the
+// option that writes them. A later pass of a Chain skips the option when an
+// earlier pass already wrote the field, since a second table would replace
+// the first one, which the earlier pass's output still refers to.
+var rootTables = []struct {
+	field  string
+	option func(c *Config) *bool
+}{
+	{"_strings", func(c *Config) *bool { return &c.StringPooling }},
+	{"_enums", func(c *Config) *bool { return &c.EnumDetection }},
+	{"_nulls", func(c *Config) *bool { return &c.NullCompression }},
+	{"_keys", func(c *Config) *bool { return &c.ShortenKeys }},
+	{"_checksum", func(c *Config) *bool { return &c.Checksum }},
+}
+
+// Chain runs Slimmers in sequence, each slimming the result of the one before,
+// for instance a pass removing known noise followed by one optimizing for LLMs.
+//
+// Order matters: only the first pass sees the original document. Later passes
+// see slimmed data, so their BlockList does not match keys an earlier pass
+// shortened, their limits count pooled strings as numbers, and values an earlier
+// pass removed cannot be kept. The metadata of earlier passes (_strings, _schema,
+// _keys...) is kept as is and never pooled, truncated or removed again, and a
+// later pass skips string pooling, enum detection, null compression, key
+// shortening and checksums when an earlier pass already wrote that table. The
+// _slimjson marker lists the features of every pass.
+type Chain []*Slimmer
+
+// NewChain creates a Chain with a Slimmer for each config, applied in order.
+func NewChain(cfgs ...Config) Chain {
+	chain := make(Chain, len(cfgs))
+	for i, cfg := range cfgs {
+		chain[i] = New(cfg)
+	}
+	return chain
+}
+
+// Slim runs every Slimmer of the chain on data and returns the final result.
+func (c Chain) Slim(data interface{}) interface{} {
+	for i, s := range c {
+		if i == 0 {
+			data = s.Slim(data)
+		} else {
+			data = s.slimChained(data)
+		}
+	}
+	return data
+}
+
+// slimChained slims data, the result of an earlier pass of a Chain, keeping
+// its metadata
+func (s *Slimmer) slimChained(data interface{}) interface{} {
+	orig := s.Config
+	defer func() { s.Config = orig }()
+	s.Config.PreserveFields = append(slices.Clone(orig.PreserveFields), metadataFields...)
+
+	root, _ := data.(map[string]interface{})
+	if om, ok := data.(*OrderedMap); ok {
+		root = om.Values
+	}
+	for _, table := range rootTables {
+		if _, ok := root[table.field]; ok {
+			*table.option(&s.Config) = false
+		}
+	}
+	earlier, hasMarker := root["_slimjson"]
+
+	result := s.Slim(data)
+	if !hasMarker {
+		return result
+	}
+	// The marker of this pass replaced the earlier one, or was not written
+	marker := mergeMarkers(earlier, orig)
+	switch r := result.(type) {
+	case map[string]interface{}:
+		r["_slimjson"] = marker
+	case *OrderedMap:
+		r.Set("_slimjson", marker)
+	}
+	return result
+}
+
+// mergeMarkers returns the _slimjson marker earlier with the features of c
+// added, in formatFeatures order. An invalid marker is returned as it is.
+func mergeMarkers(earlier interface{}, c Config) interface{} {
+	m, ok := earlier.(map[string]interface{})
+	if !ok || checkVersion(m) != nil {
+		return earlier
+	}
+	enabled := make(map[string]bool)
+	switch features := m["features"].(type) {
+	case []string:
+		for _, f := range features {
+			enabled[f] = true
+		}
+	case []interface{}:
+		for _, f := range features {
+			if name, ok := f.(string); ok {
+				enabled[name] = true
+			}
+		}
+	}
+	for _, f := range c.features() {
+		enabled[f] = true
+	}
+	var features []string
+	for _, f := range formatFeatures {
+		if enabled[f.key] {
+			features = append(features, f.key)
+		}
+	}
+	return map[string]interface{}{"v": FormatVersion, "features": features}
+}
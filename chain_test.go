@@ -0,0 +1,96 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		cfgs     []Config
+		expected string
+	}{
+		{
+			name: "Blocklist then type inference",
+			input: `{"users":[{"id":1,"name":"Ann","avatar":"a.png","debug":{"trace":1}},` +
+				`{"id":2,"name":"Bob","avatar":"b.png","debug":{"trace":2}},{"id":3,"name":"Cy","avatar":"c.png"}]}`,
+			cfgs: []Config{
+				{BlockList: []string{"avatar", "debug"}, DecimalPlaces: -1},
+				{TypeInference: true, DecimalPlaces: -1},
+			},
+			expected: `{"users":{"_data":[[1,"Ann"],[2,"Bob"],[3,"Cy"]],"_schema":["id","name"]}}`,
+		},
+		{
+			// The second pool would replace the first, and the second pass must
+			// not shorten pooled strings or the pool itself
+			name:  "Earlier metadata is kept",
+			input: `{"a":["alpha","alpha","omega"],"b":{"w":true,"x":"omega","y":true,"z":false},"c":"a long string"}`,
+			cfgs: []Config{
+				{StringPooling: true, BoolCompression: true, EmitVersion: true, DecimalPlaces: -1},
+				{StringPooling: true, MaxStringLength: 4, ShortenKeys: true, EmitVersion: true, DecimalPlaces: -1},
+			},
+			expected: `{"_slimjson":{"features":["bool-compression","string-pooling","shorten-keys"],"v":1},` +
+				`"_strings":["alpha","omega"],"a":[0,0,1],"b":{"_bools":{"flags":3,"keys":["w","y","z"]},"x":1},"c":"a..."}`,
+		},
+		{
+			name:     "Single pass",
+			input:    `{"a":[1,2,3]}`,
+			cfgs:     []Config{{MaxListLength: 2, DecimalPlaces: -1}},
+			expected: `{"a":[1,2]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input interface{}
+			if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			got, err := json.Marshal(NewChain(tt.cfgs...).Slim(input))
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestChainUnslim(t *testing.T) {
+	input := `{"records":[{"id":1,"status":"active","secret":"x"},{"id":2,"status":"active","secret":"y"},` +
+		`{"id":3,"status":"pending","secret":"z"}],"owner":"active"}`
+	chain := NewChain(
+		Config{BlockList: []string{"secret"}, StringPooling: true, DecimalPlaces: -1},
+		Config{TypeInference: true, StringPooling: true, ShortenKeys: true, DecimalPlaces: -1},
+	)
+
+	var original interface{}
+	if err := json.Unmarshal([]byte(input), &original); err != nil {
+		t.Fatalf("Failed to unmarshal input: %v", err)
+	}
+	slimmed, err := json.Marshal(chain.Slim(original))
+	if err != nil {
+		t.Fatalf("Failed to marshal result: %v", err)
+	}
+	var stored interface{}
+	if err := json.Unmarshal(slimmed, &stored); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	restored, err := Unslim(stored)
+	if err != nil {
+		t.Fatalf("Unslim() error: %v", err)
+	}
+
+	var want interface{}
+	if err := json.Unmarshal([]byte(`{"records":[{"id":1,"status":"active"},{"id":2,"status":"active"},`+
+		`{"id":3,"status":"pending"}],"owner":"active"}`), &want); err != nil {
+		t.Fatalf("Failed to unmarshal expected document: %v", err)
+	}
+	if !reflect.DeepEqual(restored, want) {
+		t.Errorf("Expected %v, got %v (slimmed %s)", want, restored, slimmed)
+	}
+}
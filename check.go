@@ -0,0 +1,100 @@
+package slimjson
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// ConfigProblem is a problem found in a config file by CheckConfigFile
+type ConfigProblem struct {
+	Profile string // Profile the problem is in, or "" for the file as a whole
+	Pos     string // Where the problem is, e.g. "line 12", or "" if unknown
+	Message string
+	Warning bool // The profile works, but may not do what was meant
+}
+
+// String formats the problem as "line 12: profile api: message"
+func (c ConfigProblem) String() string {
+	var parts []string
+	if c.Pos != "" {
+		parts = append(parts, c.Pos)
+	}
+	if c.Warning {
+		parts = append(parts, "warning")
+	}
+	if c.Profile != "" && !strings.HasPrefix(c.Pos, "profile ") { // YAML and JSON positions name the profile
+		parts = append(parts, "profile "+c.Profile)
+	}
+	return strings.Join(append(parts, c.Message), ": ")
+}
+
+// CheckConfigFile parses a config file like ParseConfig and validates every
+// profile with Config.Validate, returning the problems in file order. A file
+// that cannot be parsed has a single problem, whose message includes where
+// parsing stopped. Profiles that replace a built-in profile get a warning.
+func CheckConfigFile(path string) []ConfigProblem {
+	return ConfigParser{Strict: true}.Check(path)
+}
+
+// Check is like CheckConfigFile, with the parser's settings
+func (p ConfigParser) Check(path string) []ConfigProblem {
+	sections, err := p.readSections(path)
+	if err != nil {
+		return []ConfigProblem{{Message: err.Error()}}
+	}
+	profiles, err := p.resolveProfiles(sections)
+	if err != nil {
+		return []ConfigProblem{{Message: err.Error()}}
+	}
+
+	var problems []ConfigProblem
+	for _, sec := range sections {
+		if _, builtin := GetBuiltinProfiles()[strings.ToLower(sec.name)]; builtin {
+			problems = append(problems, ConfigProblem{
+				Profile: sec.name, Pos: sec.pos, Warning: true,
+				Message: "replaces the built-in profile " + strings.ToLower(sec.name),
+			})
+		}
+		err := profiles[sec.name].Config.Validate()
+		if err == nil {
+			continue
+		}
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			problems = append(problems, ConfigProblem{Profile: sec.name, Pos: sec.problemPos(e.Error()), Message: e.Error()})
+		}
+	}
+	return problems
+}
+
+// problemPos returns where the first config file key named in a Validate
+// message is set in the section, or the position of the section if the key
+// is not set there, for instance because it is inherited
+func (sec *profileSection) problemPos(msg string) string {
+	keys := make(map[string]string, len(sec.params))
+	for _, param := range sec.params {
+		if key := paramKey(param); keys[key] == "" {
+			keys[key] = param.pos
+		}
+	}
+	words := strings.FieldsFunc(msg, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+	for _, word := range words {
+		if pos, ok := keys[word]; ok {
+			return pos
+		}
+	}
+	return sec.pos
+}
+
+// paramKey returns the config file key of the Config field param sets, so
+// aliases such as depth map to max-depth
+func paramKey(param configParam) string {
+	name := paramField(param)
+	if field, ok := reflect.TypeOf(Config{}).FieldByName(name); ok {
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		return key
+	}
+	return name
+}
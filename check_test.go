@@ -0,0 +1,104 @@
+package slimjson
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckConfigFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected []string
+	}{
+		{
+			name: "Valid",
+			file: ".slimjson",
+			content: `[api]
+depth=3
+`,
+		},
+		{
+			name: "Invalid options",
+			file: ".slimjson",
+			content: `# Profiles
+[api]
+depth=-1
+list-len=5
+
+[short]
+extends=api
+max-string-length=10
+truncate-boundary=sentence
+lossless=true
+`,
+			expected: []string{
+				"line 3: profile api: max-depth must not be negative, got -1",
+				"line 6: profile short: max-depth must not be negative, got -1",
+				`line 9: profile short: unknown truncate-boundary "sentence" (expected one of rune, grapheme, word)`,
+				"line 10: profile short: lossless: max-list-length shortens arrays",
+				"line 10: profile short: lossless: max-string-length truncates strings",
+			},
+		},
+		{
+			name: "Replaced built-in profile",
+			file: ".slimjson",
+			content: `[medium]
+override=true
+depth=4
+sample-size=-2
+`,
+			expected: []string{
+				"line 1: warning: profile medium: replaces the built-in profile medium",
+				"line 4: profile medium: sample-size must not be negative, got -2",
+			},
+		},
+		{
+			name: "Syntax error",
+			file: ".slimjson",
+			content: `[api]
+depth=3
+
+not a parameter
+`,
+			expected: []string{"invalid syntax at line 4: not a parameter"},
+		},
+		{
+			name: "Invalid value",
+			file: ".slimjson",
+			content: `[api]
+depth=deep
+`,
+			expected: []string{"error at line 2: invalid depth value: deep"},
+		},
+		{
+			name: "YAML",
+			file: ".slimjson.yaml",
+			content: `api:
+  max-depth: 2
+  report-depth-elision: true
+  max-list-length: -1
+`,
+			expected: []string{"profile api, key max-list-length: max-list-length must not be negative, got -1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, problem := range CheckConfigFile(path) {
+				got = append(got, problem.String())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
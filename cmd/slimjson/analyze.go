@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tradik/slimjson"
+)
+
+// analyzeInput reads a JSON document from in and writes its
+// slimjson.AnalysisReport to out as JSON
+func analyzeInput(in io.Reader, out io.Writer, pretty bool) error {
+	var data interface{}
+	if err := json.NewDecoder(in).Decode(&data); err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return fmt.Errorf("decoding JSON: %w", err)
+	}
+
+	encoder := json.NewEncoder(out)
+	if pretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(slimjson.Analyze(data))
+}
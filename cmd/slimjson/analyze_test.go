@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestAnalyzeInput(t *testing.T) {
+	input := `[{"status": "active", "id": 1}, {"status": "active", "id": 2}]`
+	var out bytes.Buffer
+	if err := analyzeInput(strings.NewReader(input), &out, false); err != nil {
+		t.Fatalf("analyzeInput() error: %v", err)
+	}
+	var report slimjson.AnalysisReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected a JSON report, got %q: %v", out.String(), err)
+	}
+	if len(report.Fields) != 2 || report.Fields[1].Path != "status" || report.Fields[1].Cardinality != 1 {
+		t.Errorf("Unexpected fields %+v", report.Fields)
+	}
+	if report.StringPool.Strings != 1 {
+		t.Errorf("Expected \"active\" as a pooling candidate, got %+v", report.StringPool)
+	}
+
+	if err := analyzeInput(strings.NewReader("{"), &out, false); err == nil || !strings.Contains(err.Error(), "decoding JSON") {
+		t.Errorf("Expected a decoding error, got %v", err)
+	}
+}
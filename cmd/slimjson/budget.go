@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Exit codes for output that breaks a budget. The output is still written.
+const (
+	exitOverBudget = 2 // Output exceeds -max-bytes or -max-tokens
+	exitNotSmaller = 3 // Output is not smaller than the input, with -fail-if-larger
+)
+
+// budgetError reports output that breaks a budget set on the command line
+type budgetError struct {
+	code int // Exit code
+	msg  string
+}
+
+func (e *budgetError) Error() string { return e.msg }
+
+// budgetCode returns the exit code of a budget error, or 0 for other errors
+func budgetCode(err error) int {
+	var be *budgetError
+	if errors.As(err, &be) {
+		return be.code
+	}
+	return 0
+}
+
+// budgeted reports whether the output size is checked against a budget
+func (o *options) budgeted() bool {
+	return o.maxBytes > 0 || o.maxTokens > 0 || o.failIfLarger
+}
+
+// warnExpansion prints a warning to w if the output in st is larger than the input
+func (o *options) warnExpansion(w io.Writer, label string, st *runStats) {
+	if st.slimBytes <= st.origBytes {
+		return
+	}
+	profile := ""
+	if o.profile != "" {
+		profile = " with profile " + o.profile
+	}
+	_, _ = fmt.Fprintf(w, "Warning: %s: output is larger than the input%s, %d -> %d bytes (ratio %.2f)\n",
+		label, profile, st.origBytes, st.slimBytes, float64(st.slimBytes)/float64(max(st.origBytes, 1)))
+}
+
+// checkBudget returns a *budgetError if the sizes in st break -max-bytes or
+// -max-tokens, or -fail-if-larger when the output is not smaller than the input.
+// Budgets are checked in that order.
+func (o *options) checkBudget(st *runStats) error {
+	switch {
+	case o.maxBytes > 0 && st.slimBytes > o.maxBytes:
+		return &budgetError{exitOverBudget, fmt.Sprintf("output is %d bytes, over the -max-bytes budget of %d", st.slimBytes, o.maxBytes)}
+	case o.maxTokens > 0 && st.slimTokens > o.maxTokens:
+		return &budgetError{exitOverBudget, fmt.Sprintf("output is ~%d tokens, over the -max-tokens budget of %d", st.slimTokens, o.maxTokens)}
+	case o.failIfLarger && st.slimBytes >= st.origBytes:
+		return &budgetError{exitNotSmaller, fmt.Sprintf("output is %d bytes, not smaller than the %d byte input", st.slimBytes, st.origBytes)}
+	}
+	return nil
+}
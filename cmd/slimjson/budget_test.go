@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestBudget(t *testing.T) {
+	medium := slimjson.GetBuiltinProfiles()["medium"]
+	medium.DecimalPlaces = -1
+	metadata := slimjson.Config{DecimalPlaces: -1, NullCompression: true, EmitVersion: true}
+
+	tests := []struct {
+		name     string
+		fixture  string // File in testing/fixtures, or inline JSON
+		config   slimjson.Config
+		options  options
+		wantCode int
+		wantErr  string
+	}{
+		{name: "No budget", fixture: "users.json", config: medium},
+		{name: "Within byte budget", fixture: "users.json", config: medium, options: options{maxBytes: 1 << 20}},
+		{name: "Over byte budget", fixture: "users.json", config: medium, options: options{maxBytes: 100}, wantCode: exitOverBudget, wantErr: "over the -max-bytes budget of 100"},
+		{name: "Within token budget", fixture: "resume.json", config: medium, options: options{maxTokens: 100000}},
+		{name: "Over token budget", fixture: "resume.json", config: medium, options: options{maxTokens: 50}, wantCode: exitOverBudget, wantErr: "over the -max-tokens budget of 50"},
+		{name: "Smaller output", fixture: "schema-resume.json", config: medium, options: options{failIfLarger: true}},
+		{name: "Metadata makes tiny input larger", fixture: `{"a":null}`, config: metadata, options: options{failIfLarger: true}, wantCode: exitNotSmaller, wantErr: "not smaller than the 10 byte input"},
+		{name: "Byte budget checked first", fixture: `{"a":null}`, config: metadata, options: options{maxBytes: 5, failIfLarger: true}, wantCode: exitOverBudget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := []byte(tt.fixture)
+			if !strings.HasPrefix(tt.fixture, "{") {
+				var err error
+				if input, err = os.ReadFile(filepath.Join("../../testing/fixtures", tt.fixture)); err != nil {
+					t.Fatalf("Failed to read fixture: %v", err)
+				}
+			}
+
+			var out bytes.Buffer
+			err := tt.options.slim(bytes.NewReader(input), &out, nil, "slimjson", tt.config)
+			if code := budgetCode(err); code != tt.wantCode {
+				t.Fatalf("Exit code = %d, want %d (error: %v)", code, tt.wantCode, err)
+			}
+			if tt.wantCode == 0 && err != nil {
+				t.Fatalf("slim() error: %v", err)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %q, want %q", err, tt.wantErr)
+			}
+			if out.Len() == 0 {
+				t.Error("Expected the output to be written")
+			}
+		})
+	}
+
+	t.Run("Batch", func(t *testing.T) {
+		dir := t.TempDir()
+		small, large := filepath.Join(dir, "small.json"), filepath.Join(dir, "large.json")
+		if err := os.WriteFile(small, []byte(`{"a": 1}`), 0o644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+		if err := os.WriteFile(large, []byte(`{"a": "`+strings.Repeat("x", 200)+`"}`), 0o644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+
+		o := &options{maxBytes: 50, suffix: ".slim.json"}
+		var errOut bytes.Buffer
+		if failed := processFiles([]string{small, large}, o, medium, &bytes.Buffer{}, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		if o.budgetExit != exitOverBudget {
+			t.Errorf("Expected budget exit code %d, got %d", exitOverBudget, o.budgetExit)
+		}
+		if !strings.Contains(errOut.String(), large+": output is") || strings.Contains(errOut.String(), small) {
+			t.Errorf("Expected an error for the large file only, got %q", errOut.String())
+		}
+		if _, err := os.Stat(filepath.Join(dir, "large.slim.json")); err != nil {
+			t.Errorf("Expected the output over budget to be written: %v", err)
+		}
+	})
+}
+
+func TestWarnOnExpansion(t *testing.T) {
+	heavy := slimjson.Config{DecimalPlaces: -1, StringPooling: true, EnumDetection: true, EmitVersion: true, WarnOnExpansion: true}
+	tests := []struct {
+		name     string
+		input    string
+		config   slimjson.Config
+		options  options
+		expected string
+	}{
+		{name: "Tiny input under a heavy profile", input: `{"s":["abcd","abcd"]}`, config: heavy, options: options{profile: "heavy"},
+			expected: "Warning: in.json: output is larger than the input with profile heavy, 21 -> "},
+		{name: "Smaller output", input: `{"s": ["abcd", "abcd"], "debug": true}`, config: slimjson.Config{BlockList: []string{"debug"}, WarnOnExpansion: true}},
+		{name: "Not enabled", input: `{"s":["abcd","abcd"]}`, config: slimjson.Config{DecimalPlaces: -1, StringPooling: true, EnumDetection: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			if err := tt.options.slim(strings.NewReader(tt.input), &out, &errOut, "in.json", tt.config); err != nil {
+				t.Fatalf("slim() error: %v", err)
+			}
+			if !strings.HasPrefix(errOut.String(), tt.expected) || (tt.expected == "") != (errOut.Len() == 0) {
+				t.Errorf("Expected a warning starting with %q, got %q", tt.expected, errOut.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tradik/slimjson"
+)
+
+// checkDecompress validates the flags used with -decompress or -unslim
+func (o *options) checkDecompress(files []string) error {
+	name := "-decompress"
+	if o.unslim {
+		name = "-unslim"
+	}
+	switch {
+	case o.unslim && o.decompress:
+		return errors.New("-unslim cannot be combined with -decompress")
+	case len(files) > 1 || o.outDir != "":
+		return fmt.Errorf("%s supports a single input, written to stdout, -o or -in-place", name)
+	case o.ndjson:
+		return fmt.Errorf("%s cannot be combined with -ndjson", name)
+	case o.diff:
+		return fmt.Errorf("%s cannot be combined with -diff", name)
+	case o.explain != "":
+		return fmt.Errorf("%s cannot be combined with -explain", name)
+	}
+	return nil
+}
+
+// unslimInput restores one slimmed JSON document read from in with Unslim and
+// writes it to out. Documents slimmed with transforms Unslim cannot reverse are
+// rejected rather than written half restored, unless warn is set: then they
+// are reported to warn, and their metadata is written as it is.
+func unslimInput(in io.Reader, out io.Writer, pretty bool, warn io.Writer) error {
+	var doc interface{}
+	if err := json.NewDecoder(in).Decode(&doc); err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return fmt.Errorf("decoding JSON: %w", err)
+	}
+	if err := slimjson.CheckReversible(doc); err != nil && warn == nil {
+		return fmt.Errorf("cannot decompress: %w", err)
+	} else if err != nil {
+		_, _ = fmt.Fprintf(warn, "Warning: %v; its metadata is kept as is\n", err)
+	}
+	restored, err := slimjson.Unslim(doc)
+	if err != nil {
+		return fmt.Errorf("cannot decompress: %w", err)
+	}
+
+	enc := json.NewEncoder(out)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(restored); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestDecompressRoundTrip(t *testing.T) {
+	for _, fixture := range []string{"users.json", "resume.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			input, err := os.ReadFile("../../testing/fixtures/" + fixture)
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+			cfg := slimjson.Config{
+				Lossless: true, Checksum: true, EmitVersion: true, DecimalPlaces: -1,
+				StringPooling: true, TypeInference: true, ShortenKeys: true, NumberDeltaEncoding: true, DetectDefaults: true,
+			}
+
+			var slimmed, restored bytes.Buffer
+			if err := (&options{}).slim(bytes.NewReader(input), &slimmed, nil, fixture, cfg); err != nil {
+				t.Fatalf("slim() error: %v", err)
+			}
+			if slimmed.Len() >= len(input) {
+				t.Errorf("Expected slimmed output smaller than %d bytes, got %d", len(input), slimmed.Len())
+			}
+			if err := (&options{decompress: true}).slim(&slimmed, &restored, nil, fixture, cfg); err != nil {
+				t.Fatalf("decompress error: %v", err)
+			}
+
+			var want, got interface{}
+			if err := json.Unmarshal(input, &want); err != nil {
+				t.Fatalf("Failed to unmarshal fixture: %v", err)
+			}
+			if err := json.Unmarshal(restored.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal restored output: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Decompressed output differs from %s:\n%s", fixture, restored.String())
+			}
+		})
+	}
+}
+
+func TestUnslimFlag(t *testing.T) {
+	users, err := os.ReadFile("../../testing/fixtures/users.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "users.json", input: string(users)},
+		{name: "Bools and enums", input: `{"users":[{"id":1,"name":"Ann","status":"active","admin":true,"manager":null},` +
+			`{"id":2,"name":"Bob","status":"inactive","admin":false,"manager":null},{"id":3,"name":"Cy","status":"active","admin":false,"manager":"Ann"}],` +
+			`"settings":{"dark":true,"beta":false,"email":true,"theme":"active","owner":null}}`},
+	}
+	cfg := slimjson.Config{
+		EmitVersion: true, DecimalPlaces: -1, NullCompression: true,
+		StringPooling: true, TypeInference: true, ShortenKeys: true, NumberDeltaEncoding: true, DetectDefaults: true,
+		BoolCompression: true, EnumDetection: true,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var slimmed, restored, errOut bytes.Buffer
+			if err := (&options{}).slim(strings.NewReader(tt.input), &slimmed, nil, tt.name, cfg); err != nil {
+				t.Fatalf("slim() error: %v", err)
+			}
+			if err := (&options{unslim: true}).slim(&slimmed, &restored, &errOut, tt.name, cfg); err != nil {
+				t.Fatalf("unslim error: %v", err)
+			}
+			if !strings.Contains(errOut.String(), "Warning: input was slimmed with null-compression") {
+				t.Errorf("Expected a null-compression warning, got %q", errOut.String())
+			}
+
+			var want, got interface{}
+			if err := json.Unmarshal([]byte(tt.input), &want); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal(restored.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to unmarshal restored output: %v", err)
+			}
+			if obj, ok := got.(map[string]interface{}); ok {
+				delete(obj, "_nulls") // informational, kept as is
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Unslimmed output differs from the input:\n%s", restored.String())
+			}
+		})
+	}
+}
+
+func TestUnslimInputErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "Null compression", input: `{"s":"a","_nulls":["t"]}`, wantErr: "cannot decompress: input was slimmed with null-compression"},
+		{name: "Truncated", input: `{"a":{"_truncated":{"depth":1,"keys":["b"]}}}`, wantErr: "truncation-summaries"},
+		{name: "Marker", input: `{"a":1,"_slimjson":{"v":1,"features":["string-pooling","timestamp-compression"]}}`, wantErr: "with timestamp-compression,"},
+		{name: "Newer version", input: `{"a":1,"_slimjson":{"v":9,"features":[]}}`, wantErr: "unsupported _slimjson version 9"},
+		{name: "Invalid JSON", input: `{"a":`, wantErr: "decoding JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := unslimInput(strings.NewReader(tt.input), &out, false, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("unslimInput() error = %v, want %q", err, tt.wantErr)
+			}
+			if out.Len() > 0 {
+				t.Errorf("Expected no output on error, got %s", out.String())
+			}
+		})
+	}
+}
+
+func TestCheckDecompress(t *testing.T) {
+	tests := []struct {
+		name    string
+		options options
+		files   []string
+		wantErr string
+	}{
+		{name: "Stdin", options: options{}},
+		{name: "Output file", options: options{output: "out.json"}, files: []string{"a.slim.json"}},
+		{name: "In place", options: options{inPlace: true}, files: []string{"a.slim.json"}},
+		{name: "Several files", files: []string{"a.json", "b.json"}, wantErr: "single input"},
+		{name: "Output directory", options: options{outDir: "out"}, files: []string{"a.json"}, wantErr: "single input"},
+		{name: "NDJSON", options: options{ndjson: true}, wantErr: "-ndjson"},
+		{name: "Unslim diff", options: options{unslim: true, diff: true}, wantErr: "-unslim cannot be combined with -diff"},
+		{name: "Unslim and decompress", options: options{unslim: true, decompress: true}, wantErr: "-decompress"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.checkDecompress(tt.files)
+			if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkDecompress() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
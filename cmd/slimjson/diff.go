@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tradik/slimjson"
+)
+
+// diffInput slims a JSON document read from in and writes a report of the
+// lossy changes to out instead of the slimmed JSON. format is "text" or "json".
+func diffInput(in io.Reader, out io.Writer, cfg slimjson.Config, format string, pretty bool) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid -diff-format %q (expected text or json)", format)
+	}
+
+	var data interface{}
+	if err := json.NewDecoder(in).Decode(&data); err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return fmt.Errorf("decoding JSON: %w", err)
+	}
+
+	_, changes := slimjson.New(cfg).Explain(data)
+
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		if pretty {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(changes)
+	}
+	return writeDiffText(out, changes)
+}
+
+// writeDiffText prints changes as an indented tree of their paths,
+// followed by a one-line summary
+func writeDiffText(w io.Writer, changes []slimjson.Change) error {
+	var b strings.Builder
+	var prev []string
+	counts := make(map[string]int)
+
+	for _, c := range changes {
+		counts[c.Kind]++
+
+		segments := []string{"(root)"}
+		if c.Path != "" {
+			segments = strings.Split(c.Path, ".")
+		}
+
+		// Print parent segments not shared with the previous path
+		common := 0
+		for common < len(prev) && common < len(segments)-1 && prev[common] == segments[common] {
+			common++
+		}
+		for i := common; i < len(segments)-1; i++ {
+			fmt.Fprintf(&b, "%s%s\n", strings.Repeat("  ", i), segments[i])
+		}
+
+		last := len(segments) - 1
+		fmt.Fprintf(&b, "%s%s: %s\n", strings.Repeat("  ", last), segments[last], describeChange(c))
+		prev = segments
+	}
+
+	if len(changes) == 0 {
+		b.WriteString("No changes\n")
+	} else {
+		fmt.Fprintf(&b, "\n%d changes: %d removed, %d arrays truncated, %d strings truncated\n",
+			len(changes), counts[slimjson.ChangeRemoved], counts[slimjson.ChangeTruncatedArray], counts[slimjson.ChangeTruncatedString])
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func describeChange(c slimjson.Change) string {
+	switch c.Kind {
+	case slimjson.ChangeTruncatedArray:
+		return fmt.Sprintf("array truncated %d -> %d items (%s)", c.From, c.To, c.Reason)
+	case slimjson.ChangeTruncatedString:
+		return fmt.Sprintf("string truncated %d -> %d chars (%s)", c.From, c.To, c.Reason)
+	default:
+		return fmt.Sprintf("%s (%s)", c.Kind, c.Reason)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+var update = flag.Bool("update", false, "Update golden files")
+
+func TestDiffInputGolden(t *testing.T) {
+	input, err := os.ReadFile("../../testing/fixtures/users.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	cfg := slimjson.Config{
+		MaxDepth:        4,
+		MaxListLength:   3,
+		MaxStringLength: 20,
+		StripEmpty:      true,
+		DecimalPlaces:   -1,
+		BlockList:       []string{"email", "phone"},
+	}
+
+	var out bytes.Buffer
+	if err := diffInput(bytes.NewReader(input), &out, cfg, "text", false); err != nil {
+		t.Fatalf("diffInput() error: %v", err)
+	}
+
+	golden := "testdata/diff_users.golden"
+	if *update {
+		if err := os.WriteFile(golden, out.Bytes(), 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if out.String() != string(expected) {
+		t.Errorf("diffInput() output differs from %s:\n%s", golden, out.String())
+	}
+}
+
+func TestDiffInputFormats(t *testing.T) {
+	input := `{"name": "test", "password": "secret"}`
+	cfg := slimjson.Config{BlockList: []string{"password"}}
+
+	var out bytes.Buffer
+	if err := diffInput(strings.NewReader(input), &out, cfg, "json", false); err != nil {
+		t.Fatalf("diffInput() error: %v", err)
+	}
+	var changes []slimjson.Change
+	if err := json.Unmarshal(out.Bytes(), &changes); err != nil {
+		t.Fatalf("Expected JSON report, got %q: %v", out.String(), err)
+	}
+	if len(changes) != 1 || changes[0].Path != "password" || changes[0].Reason != "blocked" {
+		t.Errorf("Unexpected changes: %+v", changes)
+	}
+
+	out.Reset()
+	if err := diffInput(strings.NewReader(`{"name": "test"}`), &out, cfg, "text", false); err != nil {
+		t.Fatalf("diffInput() error: %v", err)
+	}
+	if out.String() != "No changes\n" {
+		t.Errorf("Expected no changes, got %q", out.String())
+	}
+
+	if err := diffInput(strings.NewReader(input), &out, cfg, "yaml", false); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/tradik/slimjson"
+)
+
+// explainFlag is the format of the -explain report: "" (off), "text" or "json".
+// A bare -explain means text.
+type explainFlag string
+
+func (f *explainFlag) String() string { return string(*f) }
+
+func (f *explainFlag) Set(value string) error {
+	switch value {
+	case "true", "text":
+		*f = "text"
+	case "false":
+		*f = ""
+	case "json":
+		*f = "json"
+	default:
+		return fmt.Errorf("expected text or json, got %q", value)
+	}
+	return nil
+}
+
+func (f *explainFlag) IsBoolFlag() bool { return true }
+
+// explainReasons orders the groups of the text report, with headings.
+// Sample strategies, which only truncate arrays, follow in name order.
+var explainReasons = []struct{ reason, heading string }{
+	{"blocked", "Blocked fields"},
+	{"drop-if", "Fields dropped by -drop-if"},
+	{"empty", "Empty values stripped"},
+	{"max-depth", "Subtrees cut by -depth"},
+	{"deduplicate", "Duplicates removed"},
+	{"max-list-length", "Arrays truncated by -list-len"},
+	{"max-string-length", "Strings truncated by -string-len"},
+}
+
+// explainInput slims the JSON documents read from in, writes them to out
+// like slimInput and writes a report of the lossy changes, grouped by reason,
+// to report. format is "text" or "json"; the JSON report has one object per
+// document mapping each reason to its changes. If st is non-nil, the input
+// and output sizes are added to it.
+func explainInput(in io.Reader, out, report io.Writer, st *runStats, cfg slimjson.Config, format string, pretty bool) error {
+	var raw bytes.Buffer
+	if st != nil {
+		in = io.TeeReader(in, &raw)
+	}
+
+	slimmer := slimjson.New(cfg)
+	dec := json.NewDecoder(in)
+	var docs [][]slimjson.Change
+	var slimBytes, slimTokens int
+	for {
+		var buf bytes.Buffer
+		changes, err := slimmer.ExplainNext(dec, &buf)
+		if err == io.EOF {
+			if len(docs) == 0 {
+				return err
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("processing JSON document %d: %w", len(docs)+1, err)
+		}
+		if pretty {
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+				return fmt.Errorf("encoding JSON: %w", err)
+			}
+			buf = indented
+		}
+		if _, err := out.Write(buf.Bytes()); err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+		docs = append(docs, changes)
+		slimBytes += buf.Len()
+		slimTokens += slimjson.EstimateTokens(buf.Bytes())
+	}
+	if st != nil {
+		st.origBytes, st.origTokens = raw.Len(), slimjson.EstimateTokens(raw.Bytes())
+		st.slimBytes, st.slimTokens = slimBytes, slimTokens
+	}
+	return writeExplainReport(report, docs, format, pretty)
+}
+
+// writeExplainReport writes the changes of each document to report, as text
+// or, for format "json", as one object per document
+func writeExplainReport(report io.Writer, docs [][]slimjson.Change, format string, pretty bool) error {
+	if format == "json" {
+		encoder := json.NewEncoder(report)
+		if pretty {
+			encoder.SetIndent("", "  ")
+		}
+		for _, changes := range docs {
+			if err := encoder.Encode(groupChanges(changes)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var b strings.Builder
+	for i, changes := range docs {
+		if len(docs) > 1 {
+			fmt.Fprintf(&b, "Document %d:\n", i+1)
+		}
+		writeExplainText(&b, changes)
+	}
+	_, err := io.WriteString(report, b.String())
+	return err
+}
+
+// groupChanges maps each reason to its changes, in path order
+func groupChanges(changes []slimjson.Change) map[string][]slimjson.Change {
+	groups := make(map[string][]slimjson.Change)
+	for _, c := range changes {
+		groups[c.Reason] = append(groups[c.Reason], c)
+	}
+	return groups
+}
+
+// writeExplainText writes the changes of one document grouped by reason,
+// each group headed by a title and its number of changes
+func writeExplainText(b *strings.Builder, changes []slimjson.Change) {
+	if len(changes) == 0 {
+		b.WriteString("No changes\n")
+		return
+	}
+
+	groups := groupChanges(changes)
+	var order []string
+	for _, r := range explainReasons {
+		order = append(order, r.reason)
+	}
+	var samples []string
+	for reason := range groups {
+		if !slices.Contains(order, reason) {
+			samples = append(samples, reason)
+		}
+	}
+	slices.Sort(samples)
+
+	for _, reason := range append(order, samples...) {
+		group := groups[reason]
+		if len(group) == 0 {
+			continue
+		}
+		heading := "Arrays sampled by " + reason
+		if i := slices.Index(order, reason); i >= 0 {
+			heading = explainReasons[i].heading
+		}
+		fmt.Fprintf(b, "%s (%d):\n", heading, len(group))
+		for _, c := range group {
+			path := c.Path
+			if path == "" {
+				path = "(root)"
+			}
+			switch c.Kind {
+			case slimjson.ChangeTruncatedArray:
+				fmt.Fprintf(b, "  %s: %d -> %d items\n", path, c.From, c.To)
+			case slimjson.ChangeTruncatedString:
+				fmt.Fprintf(b, "  %s: %d -> %d chars\n", path, c.From, c.To)
+			default:
+				fmt.Fprintf(b, "  %s\n", path)
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestExplainInputGolden(t *testing.T) {
+	input, err := os.ReadFile("../../testing/fixtures/users.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	cfg := slimjson.Config{
+		MaxDepth:        4,
+		MaxListLength:   3,
+		MaxStringLength: 20,
+		StripEmpty:      true,
+		DecimalPlaces:   -1,
+		BlockList:       []string{"email", "phone"},
+	}
+
+	var out, report bytes.Buffer
+	if err := explainInput(bytes.NewReader(input), &out, &report, nil, cfg, "text", false); err != nil {
+		t.Fatalf("explainInput() error: %v", err)
+	}
+
+	var plain bytes.Buffer
+	if err := slimInput(bytes.NewReader(input), &plain, nil, cfg, false, false); err != nil {
+		t.Fatalf("slimInput() error: %v", err)
+	}
+	if out.String() != plain.String() {
+		t.Errorf("explainInput() JSON differs from slimInput():\n%s\n%s", out.String(), plain.String())
+	}
+
+	golden := "testdata/explain_users.golden"
+	if *update {
+		if err := os.WriteFile(golden, report.Bytes(), 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if report.String() != string(expected) {
+		t.Errorf("explainInput() report differs from %s:\n%s", golden, report.String())
+	}
+}
+
+func TestExplainInput(t *testing.T) {
+	cfg := slimjson.Config{BlockList: []string{"password"}, MaxListLength: 2, SampleStrategy: "first_last", StripEmpty: true}
+
+	t.Run("JSON report", func(t *testing.T) {
+		input := `{"name": "test", "password": "secret", "tags": [1, 2, 3, 4], "note": ""}`
+		var out, report bytes.Buffer
+		if err := explainInput(strings.NewReader(input), &out, &report, nil, cfg, "json", false); err != nil {
+			t.Fatalf("explainInput() error: %v", err)
+		}
+		if expected := "{\"name\":\"test\",\"tags\":[1,4]}\n"; out.String() != expected {
+			t.Errorf("Output = %q, want %q", out.String(), expected)
+		}
+		var groups map[string][]slimjson.Change
+		if err := json.Unmarshal(report.Bytes(), &groups); err != nil {
+			t.Fatalf("Expected JSON report, got %q: %v", report.String(), err)
+		}
+		if len(groups) != 3 || groups["blocked"][0].Path != "password" || groups["empty"][0].Path != "note" ||
+			groups["first_last"][0].From != 4 || groups["first_last"][0].To != 2 {
+			t.Errorf("Unexpected report %s", report.String())
+		}
+	})
+
+	t.Run("Several documents", func(t *testing.T) {
+		var out, report bytes.Buffer
+		if err := explainInput(strings.NewReader("{\"id\": 1, \"password\": 1}\n{\"id\": 2}\n"), &out, &report, nil, cfg, "text", false); err != nil {
+			t.Fatalf("explainInput() error: %v", err)
+		}
+		if expected := "{\"id\":1}\n{\"id\":2}\n"; out.String() != expected {
+			t.Errorf("Output = %q, want %q", out.String(), expected)
+		}
+		expected := "Document 1:\nBlocked fields (1):\n  password\nDocument 2:\nNo changes\n"
+		if report.String() != expected {
+			t.Errorf("Report = %q, want %q", report.String(), expected)
+		}
+	})
+}
+
+func TestExplainFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{args: nil, expected: ""},
+		{args: []string{"-explain"}, expected: "text"},
+		{args: []string{"-explain=json"}, expected: "json"},
+		{args: []string{"-explain=false"}, expected: ""},
+		{args: []string{"-explain=xml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var o options
+			fs := flag.NewFlagSet("slimjson", flag.ContinueOnError)
+			fs.SetOutput(&bytes.Buffer{})
+			defineFlags(fs, &o)
+			err := fs.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(o.explain) != tt.expected {
+				t.Errorf("explain = %q, want %q", o.explain, tt.expected)
+			}
+		})
+	}
+}
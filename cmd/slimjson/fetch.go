@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// headerFlag collects the repeatable -H "Name: value" flag
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	var lines []string
+	for name, values := range h {
+		for _, v := range values {
+			lines = append(lines, name+": "+v)
+		}
+	}
+	return strings.Join(lines, ", ")
+}
+
+func (h headerFlag) Set(value string) error {
+	name, v, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf(`expected "Name: value", got %q`, value)
+	}
+	http.Header(h).Add(name, strings.TrimSpace(v))
+	return nil
+}
+
+// isURL reports whether the input argument is an http:// or https:// URL
+func isURL(arg string) bool {
+	lower := strings.ToLower(arg)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// checkURL validates the flags used with a URL input argument
+func (o *options) checkURL(args []string) error {
+	hasURL := false
+	for _, arg := range args {
+		hasURL = hasURL || isURL(arg)
+	}
+	switch {
+	case !hasURL:
+		return nil
+	case len(args) > 1:
+		return errors.New("a URL must be the only input")
+	case o.inPlace || o.outDir != "" || o.suffix != "" || o.watch:
+		return errors.New("-in-place, -out-dir, -suffix and -watch need input files, not a URL")
+	case o.timeout < 0:
+		return errors.New("-timeout must not be negative")
+	}
+	return nil
+}
+
+// fetchContentTypes are the media types accepted from a URL besides JSON
+// (application/json and */*+json). Responses without a Content-Type are
+// accepted too, and read like stdin.
+var fetchContentTypes = []string{
+	"application/x-ndjson", "application/jsonl", "application/x-jsonlines",
+	"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml",
+	"application/gzip", "application/x-gzip",
+}
+
+// fetchURL requests url with GET and returns the response body and its media
+// type, following redirects. The timeout (0 for none) covers the whole request,
+// including reading the body. Responses other than 2xx and content that is not
+// JSON, NDJSON, YAML or gzip are errors.
+func fetchURL(url string, timeout time.Duration, header http.Header) (io.ReadCloser, string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid URL %s: %w", url, err)
+	}
+	req.Header = header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetching %s: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_ = resp.Body.Close()
+		return nil, "", fmt.Errorf("fetching %s: server responded %s", url, resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return resp.Body, "", nil
+	}
+	if !fetchableContentType(contentType) {
+		_ = resp.Body.Close()
+		return nil, "", fmt.Errorf("fetching %s: unsupported Content-Type %s, expected JSON", url, contentType)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return resp.Body, mediaType, nil
+}
+
+// isYAMLMediaType reports whether a fetched media type is YAML
+func isYAMLMediaType(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "/yaml") || strings.HasSuffix(mediaType, "/x-yaml")
+}
+
+// isJSONContentType reports whether the Content-Type header denotes JSON
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// fetchableContentType reports whether a response with the Content-Type header
+// contentType can be slimmed
+func fetchableContentType(contentType string) bool {
+	if isJSONContentType(contentType) {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range fetchContentTypes {
+		if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestFetchURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = io.WriteString(w, `{"users":[{"id":1,"avatar":"a.png"}]}`)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/users", http.StatusFound)
+	})
+	mux.HandleFunc("/manifest", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = io.WriteString(w, "kind: Pod\n")
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, "<html></html>")
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	auth := http.Header{"Authorization": {"Bearer secret"}}
+	tests := []struct {
+		name      string
+		path      string
+		header    http.Header
+		timeout   time.Duration
+		want      string
+		mediaType string
+		wantErr   string
+	}{
+		{name: "Success", path: "/users", header: auth, want: `{"users":[{"id":1}]}`, mediaType: "application/json"},
+		{name: "Redirect", path: "/old", header: auth, want: `{"users":[{"id":1}]}`, mediaType: "application/json"},
+		{name: "YAML", path: "/manifest", want: `{"kind":"Pod"}`, mediaType: "application/yaml"},
+		{name: "Unauthorized", path: "/users", wantErr: "server responded 401 Unauthorized"},
+		{name: "Not found", path: "/missing", wantErr: "server responded 404 Not Found"},
+		{name: "Not JSON", path: "/page", wantErr: "unsupported Content-Type text/html"},
+		{name: "Timeout", path: "/slow", timeout: 50 * time.Millisecond, wantErr: "Client.Timeout exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, mediaType, err := fetchURL(server.URL+tt.path, tt.timeout, tt.header)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetchURL() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchURL() error: %v", err)
+			}
+			defer func() { _ = body.Close() }()
+			if mediaType != tt.mediaType {
+				t.Errorf("Expected media type %s, got %s", tt.mediaType, mediaType)
+			}
+
+			o := &options{}
+			if isYAMLMediaType(mediaType) {
+				o.inFormat = "yaml"
+			}
+			var out bytes.Buffer
+			if err := o.slim(body, &out, nil, tt.path, slimjson.Config{BlockList: []string{"avatar"}}); err != nil {
+				t.Fatalf("slim() error: %v", err)
+			}
+			if got := strings.TrimSpace(out.String()); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHeaderFlag(t *testing.T) {
+	o := &options{}
+	fs := flag.NewFlagSet("slimjson", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	defineFlags(fs, o)
+
+	if err := fs.Parse([]string{"-H", "Authorization: Bearer x", "-H", "Accept:application/json", "-H", "X-Tag: a", "-H", "X-Tag: b"}); err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	header := http.Header(o.headers)
+	if header.Get("Authorization") != "Bearer x" || header.Get("Accept") != "application/json" || len(header.Values("X-Tag")) != 2 {
+		t.Errorf("Unexpected headers: %v", header)
+	}
+	if err := fs.Parse([]string{"-H", "no colon"}); err == nil {
+		t.Error("Expected an error for a header without a colon")
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		options options
+		args    []string
+		wantErr string
+	}{
+		{name: "File", options: options{inPlace: true}, args: []string{"a.json"}},
+		{name: "URL", args: []string{"https://example.com/a.json"}},
+		{name: "URL to file", options: options{output: "a.json"}, args: []string{"HTTP://example.com/a"}},
+		{name: "Several inputs", args: []string{"https://example.com/a", "b.json"}, wantErr: "only input"},
+		{name: "In place", options: options{inPlace: true}, args: []string{"https://example.com/a"}, wantErr: "need input files"},
+		{name: "Negative timeout", options: options{timeout: -time.Second}, args: []string{"https://example.com/a"}, wantErr: "-timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.checkURL(tt.args)
+			if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkURL() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/tradik/slimjson"
+)
+
+// expandArgs expands the glob patterns in args, for shells that pass them on
+// unexpanded. Arguments that match no file are kept, so opening them reports
+// the error for that argument.
+func expandArgs(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			files = append(files, arg)
+			continue
+		}
+		if _, err := os.Stat(arg); err == nil {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			matches = []string{arg}
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// checkBatch validates the flags used when processing files in batch mode
+func (o *options) checkBatch(files []string) error {
+	switch {
+	case o.jobs < 0:
+		return errors.New("-jobs must not be negative")
+	case o.inPlace && len(files) == 0:
+		return errors.New("-in-place requires file arguments, stdin cannot be rewritten")
+	case o.outDir != "" && len(files) == 0:
+		return errors.New("-out-dir requires file arguments")
+	case o.suffix != "" && len(files) == 0:
+		return errors.New("-suffix requires file arguments")
+	case o.inPlace && (o.outDir != "" || o.suffix != ""):
+		return errors.New("-in-place cannot be combined with -out-dir or -suffix")
+	case o.output != "" && (o.inPlace || o.outDir != "" || o.suffix != ""):
+		return errors.New("-o cannot be combined with -in-place, -out-dir or -suffix")
+	case o.output != "" && len(files) > 1:
+		return errors.New("-o supports a single input, use -out-dir for several files")
+	case o.diff:
+		return errors.New("-diff writes to stdout and supports a single input")
+	case o.explain != "" && len(files) > 1:
+		return errors.New("-explain supports a single input")
+	}
+	return nil
+}
+
+// fileResult is the outcome of processing one file in batch mode
+type fileResult struct {
+	out    bytes.Buffer // Slimmed JSON written to stdout
+	report bytes.Buffer // -stats line and -explain report
+	err    error
+}
+
+// processFiles slims the files with o.jobs workers (0 for one per CPU). Each
+// result is written next to its file with o.suffix, into o.outDir, to o.output,
+// over the input with o.inPlace, or otherwise to out, in argument order.
+// Errors are reported to errOut per file without stopping the run. With
+// -stats, a line per file is followed by the total of the successful files.
+// Files over a budget are written and reported, and the highest budget exit
+// code is kept in o.budgetExit. It returns the number of failed files.
+func processFiles(files []string, o *options, cfg slimjson.Config, out, errOut io.Writer) int {
+	if o.outDir != "" {
+		if err := os.MkdirAll(o.outDir, 0o755); err != nil {
+			_, _ = fmt.Fprintf(errOut, "Error: %v\n", err)
+			return len(files)
+		}
+	}
+	if o.stats && len(files) > 1 {
+		o.total = &runStats{}
+		start := time.Now()
+		defer func() {
+			o.total.elapsed = time.Since(start)
+			o.total.print(errOut, "total")
+			o.total = nil
+		}()
+	}
+
+	results := make([]chan *fileResult, len(files))
+	for i := range results {
+		results[i] = make(chan *fileResult, 1)
+	}
+	jobs := make(chan int)
+	go func() {
+		for i := range files {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+	workers := o.jobs
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	for w := 0; w < min(workers, len(files)); w++ {
+		go func() {
+			for i := range jobs {
+				r := &fileResult{}
+				r.err = processFile(files[i], o, cfg, &r.out, &r.report)
+				results[i] <- r
+			}
+		}()
+	}
+
+	// Results are written in argument order, as each becomes available
+	failed := 0
+	for i, file := range files {
+		r := <-results[i]
+		_, _ = errOut.Write(r.report.Bytes())
+		if code := budgetCode(r.err); code != 0 {
+			_, _ = fmt.Fprintf(errOut, "Error: %s: %v\n", file, r.err)
+			o.budgetExit = max(o.budgetExit, code)
+		} else if r.err != nil {
+			_, _ = fmt.Fprintf(errOut, "Error: %s: %v\n", file, r.err)
+			failed++
+			continue
+		}
+		_, _ = out.Write(r.out.Bytes())
+	}
+	return failed
+}
+
+func processFile(file string, o *options, cfg slimjson.Config, out, errOut io.Writer) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if isGzipName(file) && !bytes.HasPrefix(data, gzipMagic) {
+		return fmt.Errorf("reading gzip input: %w", gzip.ErrHeader)
+	}
+	if o.inFormat == "" && isYAMLName(file) {
+		yo := *o
+		yo.inFormat = "yaml"
+		o = &yo
+	}
+	path := outputPath(file, o)
+	if path == "" {
+		if err := o.slimCompressed(bytes.NewReader(data), out, errOut, file, cfg, o.gzipOut); err != nil {
+			if err == io.EOF {
+				return errors.New("no JSON document")
+			}
+			return err
+		}
+		return nil
+	}
+	return slimToFile(bytes.NewReader(data), path, file, o, cfg, errOut)
+}
+
+// slimToFile slims the input read from in and writes it to path with
+// writeFileAtomic, compressed with gzip for -gzip-out or a path ending in .gz.
+// Stats, prefixed with label, and the -explain report are written to errOut.
+// Output over a budget is written before its *budgetError is returned.
+func slimToFile(in io.Reader, path, label string, o *options, cfg slimjson.Config, errOut io.Writer) error {
+	var out bytes.Buffer
+	err := o.slimCompressed(in, &out, errOut, label, cfg, o.gzipOut || isGzipName(path))
+	if err == io.EOF {
+		return errors.New("no JSON document")
+	}
+	if err != nil && budgetCode(err) == 0 {
+		return err
+	}
+	if werr := writeFileAtomic(path, out.Bytes()); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// outputPath returns where the slimmed version of file is written, or "" for stdout
+func outputPath(file string, o *options) string {
+	switch {
+	case o.inPlace:
+		return file
+	case o.output != "":
+		return o.output
+	case o.suffix != "":
+		base := trimGzipExt(file)
+		name := strings.TrimSuffix(base, filepath.Ext(base)) + o.suffix
+		if o.outDir != "" {
+			return filepath.Join(o.outDir, filepath.Base(name))
+		}
+		return name
+	case o.outDir != "":
+		name := slimName(file)
+		if o.gzipOut && !isGzipName(name) {
+			name += ".gz"
+		}
+		return filepath.Join(o.outDir, filepath.Base(name))
+	}
+	return ""
+}
+
+// slimName turns "data.json" into "data.slim.json", and "data.json.gz" into
+// "data.slim.json.gz"
+func slimName(file string) string {
+	if isGzipName(file) {
+		return slimName(trimGzipExt(file)) + file[len(file)-len(".gz"):]
+	}
+	ext := filepath.Ext(file)
+	if ext == "" {
+		ext = ".json"
+	}
+	return strings.TrimSuffix(file, filepath.Ext(file)) + ".slim" + ext
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path,
+// so an interrupted or failed run never leaves a partially written file and
+// an existing file at path keeps its permissions
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".slimjson-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		_ = os.Chmod(tmp.Name(), info.Mode().Perm())
+	} else {
+		_ = os.Chmod(tmp.Name(), 0o644)
+	}
+	return os.Rename(tmp.Name(), path)
+}
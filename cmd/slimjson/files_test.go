@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestProcessFiles(t *testing.T) {
+	cfg := slimjson.Config{StripEmpty: true, DecimalPlaces: -1, BlockList: []string{"secret"}}
+	inputs := map[string]string{
+		"a.json": `{"name": "a", "secret": "x", "empty": ""}`,
+		"b.json": `{"name": "b", "tags": []}`,
+	}
+	expected := map[string]string{
+		"a.json": "{\"name\":\"a\"}\n",
+		"b.json": "{\"name\":\"b\"}\n",
+	}
+
+	writeInputs := func(t *testing.T) (string, []string) {
+		dir := t.TempDir()
+		var files []string
+		for name, content := range inputs {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("Failed to write input: %v", err)
+			}
+			files = append(files, path)
+		}
+		return dir, files
+	}
+
+	checkOutput := func(t *testing.T, path, want string) {
+		t.Helper()
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), got, want)
+		}
+	}
+
+	t.Run("Stdout in argument order", func(t *testing.T) {
+		dir, _ := writeInputs(t)
+		files := []string{filepath.Join(dir, "b.json"), filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")}
+		var out, errOut bytes.Buffer
+		if failed := processFiles(files, &options{jobs: 3}, cfg, &out, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		if want := expected["b.json"] + expected["a.json"] + expected["b.json"]; out.String() != want {
+			t.Errorf("Expected %q, got %q", want, out.String())
+		}
+	})
+
+	t.Run("Suffix", func(t *testing.T) {
+		dir, files := writeInputs(t)
+		var errOut bytes.Buffer
+		if failed := processFiles(files, &options{suffix: ".slim.json", jobs: 1}, cfg, io.Discard, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		checkOutput(t, filepath.Join(dir, "a.slim.json"), expected["a.json"])
+		checkOutput(t, filepath.Join(dir, "b.slim.json"), expected["b.json"])
+	})
+
+	t.Run("Output directory", func(t *testing.T) {
+		_, files := writeInputs(t)
+		outDir := filepath.Join(t.TempDir(), "out")
+		var errOut bytes.Buffer
+		if failed := processFiles(files, &options{outDir: outDir}, cfg, io.Discard, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		checkOutput(t, filepath.Join(outDir, "a.slim.json"), expected["a.json"])
+		checkOutput(t, filepath.Join(outDir, "b.slim.json"), expected["b.json"])
+	})
+
+	t.Run("In place", func(t *testing.T) {
+		dir, files := writeInputs(t)
+		var errOut bytes.Buffer
+		if failed := processFiles(files, &options{inPlace: true}, cfg, io.Discard, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		checkOutput(t, filepath.Join(dir, "a.json"), expected["a.json"])
+		checkOutput(t, filepath.Join(dir, "b.json"), expected["b.json"])
+	})
+
+	t.Run("Output file", func(t *testing.T) {
+		_, files := writeInputs(t)
+		output := filepath.Join(t.TempDir(), "out.json")
+		var errOut bytes.Buffer
+		if failed := processFiles(files[:1], &options{output: output}, cfg, io.Discard, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		checkOutput(t, output, expected[filepath.Base(files[0])])
+	})
+
+	t.Run("Output file from stdin", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "out.json")
+		if err := slimToFile(strings.NewReader(inputs["a.json"]), output, "slimjson", &options{output: output}, cfg, nil); err != nil {
+			t.Fatalf("slimToFile() error: %v", err)
+		}
+		checkOutput(t, output, expected["a.json"])
+	})
+
+	t.Run("In place keeps permissions", func(t *testing.T) {
+		dir, files := writeInputs(t)
+		path := filepath.Join(dir, "a.json")
+		if err := os.Chmod(path, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		var errOut bytes.Buffer
+		if failed := processFiles(files, &options{inPlace: true}, cfg, io.Discard, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("Unwritable output leaves files untouched", func(t *testing.T) {
+		dir, files := writeInputs(t)
+		var errOut bytes.Buffer
+		output := filepath.Join(dir, "missing", "out.json")
+		if failed := processFiles(files[:1], &options{output: output}, cfg, io.Discard, &errOut); failed != 1 {
+			t.Errorf("Expected 1 failure, got %d", failed)
+		}
+		checkOutput(t, files[0], inputs[filepath.Base(files[0])])
+
+		if os.Geteuid() == 0 {
+			t.Skip("Read-only directories are writable as root")
+		}
+		if err := os.Chmod(dir, 0o555); err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = os.Chmod(dir, 0o755) }()
+		if failed := processFiles(files, &options{inPlace: true}, cfg, io.Discard, &errOut); failed != len(files) {
+			t.Errorf("Expected %d failures, got %d", len(files), failed)
+		}
+		checkOutput(t, filepath.Join(dir, "a.json"), inputs["a.json"])
+		checkOutput(t, filepath.Join(dir, "b.json"), inputs["b.json"])
+	})
+
+	t.Run("Errors do not abort the run", func(t *testing.T) {
+		dir, files := writeInputs(t)
+		bad := filepath.Join(dir, "bad.json")
+		if err := os.WriteFile(bad, []byte(`{"broken":`), 0o644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+		files = append([]string{bad, filepath.Join(dir, "missing.json")}, files...)
+
+		var out, errOut bytes.Buffer
+		if failed := processFiles(files, &options{}, cfg, &out, &errOut); failed != 2 {
+			t.Errorf("Expected 2 failures, got %d", failed)
+		}
+		if !strings.Contains(errOut.String(), "Error: "+bad+":") || !strings.Contains(errOut.String(), "missing.json") {
+			t.Errorf("Expected per-file errors, got %q", errOut.String())
+		}
+		if out.String() != expected[filepath.Base(files[2])]+expected[filepath.Base(files[3])] {
+			t.Errorf("Expected the valid files on stdout, got %q", out.String())
+		}
+
+		errOut.Reset()
+		if failed := processFiles(files, &options{suffix: ".slim.json"}, cfg, io.Discard, &errOut); failed != 2 {
+			t.Errorf("Expected 2 failures, got %d", failed)
+		}
+		checkOutput(t, filepath.Join(dir, "a.slim.json"), expected["a.json"])
+		checkOutput(t, filepath.Join(dir, "b.slim.json"), expected["b.json"])
+	})
+
+	t.Run("Stats with total", func(t *testing.T) {
+		_, files := writeInputs(t)
+		var out, errOut bytes.Buffer
+		if failed := processFiles(files, &options{stats: true}, cfg, &out, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		if want := expected[filepath.Base(files[0])] + expected[filepath.Base(files[1])]; out.String() != want {
+			t.Errorf("Expected only JSON on stdout, got %q", out.String())
+		}
+		lines := strings.Split(strings.TrimSuffix(errOut.String(), "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected 3 stats lines, got %q", errOut.String())
+		}
+		for i, file := range files {
+			if !strings.HasPrefix(lines[i], file+": ") {
+				t.Errorf("Expected a line for %s, got %q", file, lines[i])
+			}
+		}
+		total := inputs["a.json"] + inputs["b.json"]
+		if prefix := fmt.Sprintf("total: %d -> ", len(total)); !strings.HasPrefix(lines[2], prefix) {
+			t.Errorf("Expected total line starting %q, got %q", prefix, lines[2])
+		}
+		if !strings.Contains(lines[2], ", 1 fields blocked, ") {
+			t.Errorf("Expected the blocked field in the total, got %q", lines[2])
+		}
+	})
+}
+
+func TestCheckBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		options options
+		files   []string
+		wantErr string
+	}{
+		{name: "In place", options: options{inPlace: true}, files: []string{"a.json", "b.json"}},
+		{name: "Output file", options: options{output: "out.json"}, files: []string{"a.json"}},
+		{name: "Output file from stdin", options: options{output: "out.json"}},
+		{name: "In place from stdin", options: options{inPlace: true}, wantErr: "stdin cannot be rewritten"},
+		{name: "Output directory from stdin", options: options{outDir: "out"}, wantErr: "-out-dir requires file arguments"},
+		{name: "In place and output directory", options: options{inPlace: true, outDir: "out"}, files: []string{"a.json"}, wantErr: "cannot be combined"},
+		{name: "Output file and in place", options: options{output: "out.json", inPlace: true}, files: []string{"a.json"}, wantErr: "-o cannot be combined"},
+		{name: "Suffix from stdin", options: options{suffix: ".slim.json"}, wantErr: "-suffix requires file arguments"},
+		{name: "Suffix and in place", options: options{suffix: ".slim.json", inPlace: true}, files: []string{"a.json"}, wantErr: "cannot be combined"},
+		{name: "Negative jobs", options: options{jobs: -1}, files: []string{"a.json"}, wantErr: "-jobs"},
+		{name: "Output file with several inputs", options: options{output: "out.json"}, files: []string{"a.json", "b.json"}, wantErr: "-o supports a single input"},
+		{name: "Diff", options: options{diff: true}, files: []string{"a.json", "b.json"}, wantErr: "-diff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.checkBatch(tt.files)
+			if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkBatch() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "b.json", "c.txt", "[x].json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	join := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "Plain files", args: join("c.txt", "a.json"), expected: join("c.txt", "a.json")},
+		{name: "Pattern", args: join("*.json", "c.txt"), expected: join("[x].json", "a.json", "b.json", "c.txt")},
+		{name: "Existing file with pattern characters", args: join("[x].json"), expected: join("[x].json")},
+		{name: "No matches", args: join("*.yaml"), expected: join("*.yaml")},
+		{name: "Invalid pattern", args: join("[.json"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expandArgs() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSlimName(t *testing.T) {
+	tests := map[string]string{
+		"data.json":      "data.slim.json",
+		"dir/data.jsonl": "dir/data.slim.jsonl",
+		"data":           "data.slim.json",
+	}
+	for in, want := range tests {
+		if got := slimName(in); got != want {
+			t.Errorf("slimName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
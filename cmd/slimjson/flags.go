@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tradik/slimjson"
+)
+
+// options holds the parsed command-line flags
+type options struct {
+	daemon          bool
+	configFile      string
+	port            int
+	listen          string
+	tlsCert         string
+	tlsKey          string
+	socketMode      fileModeFlag
+	maxBody         int64
+	maxBatch        int
+	maxBatchBody    int64
+	batchWorkers    int
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	shutdownTimeout time.Duration
+	maxHeaderBytes  int
+	maxConcurrent   int
+	requestTimeout  time.Duration
+	authToken       string
+	corsOrigins     string
+	persistProfiles bool
+	logFormat       string
+	profile         string
+	saveAs          string
+	initConfig      bool
+	force           bool
+	checkConfig     bool
+	version         bool
+	list            bool
+	pretty          bool
+	stats           bool
+	verbose         bool
+	diff            bool
+	explain         explainFlag
+	diffFormat      string
+	recommend       bool
+	analyze         bool
+	recommendTarget float64
+	outDir          string
+	suffix          string
+	jobs            int
+	output          string
+	inPlace         bool
+	watch           bool
+	ndjson          bool
+	stream          bool
+	total           *runStats // Sum of the -stats figures of a batch
+	maxBytes        int
+	maxTokens       int
+	failIfLarger    bool
+	budgetExit      int // Highest budget exit code of a batch
+	decompress      bool
+	unslim          bool
+	gzipOut         bool
+	inFormat        string
+	outFormat       string
+	blockList       string
+	preserve        string
+	dropIf          string
+	emptyValues     string
+	fieldDecs       string
+	timeout         time.Duration // Of fetching a URL input
+	headers         headerFlag    // Sent when fetching a URL input
+
+	// cfg receives compression flags directly
+	cfg slimjson.Config
+}
+
+// flagConfigKeys maps compression flag names to the config keys they set
+var flagConfigKeys = map[string]string{
+	"depth":                   "max-depth",
+	"depth-marker":            "depth-truncation-marker",
+	"depth-elision":           "report-depth-elision",
+	"list-len":                "max-list-length",
+	"string-len":              "max-string-length",
+	"truncate-boundary":       "truncate-boundary",
+	"string-unit":             "string-length-unit",
+	"truncation-suffix":       "truncation-suffix",
+	"max-output-bytes":        "max-output-bytes",
+	"strip-empty":             "strip-empty",
+	"empty-values":            "empty-values",
+	"keep-null":               "keep-null",
+	"block":                   "block-list",
+	"preserve":                "preserve-fields",
+	"drop-if":                 "drop-if",
+	"drop-if-ignore-case":     "drop-if-ignore-case",
+	"sort-keys":               "sort-keys",
+	"preserve-key-order":      "preserve-key-order",
+	"truncation-summaries":    "truncation-summaries",
+	"decimal-places":          "decimal-places",
+	"field-decimals":          "field-decimal-places",
+	"deduplicate":             "deduplicate-arrays",
+	"sample-strategy":         "sample-strategy",
+	"sample-size":             "sample-size",
+	"random-seed":             "random-seed",
+	"sample-sort-key":         "sample-sort-key",
+	"sample-counts":           "sample-counts",
+	"sample-stratify-key":     "sample-stratify-key",
+	"null-compression":        "null-compression",
+	"type-inference":          "type-inference",
+	"type-inference-columnar": "type-inference-columnar",
+	"matrix-columnar":         "matrix-columnar",
+	"rle":                     "run-length-encode",
+	"bool-compression":        "bool-compression",
+	"bool-array-packing":      "bool-array-packing",
+	"timestamp-compression":   "timestamp-compression",
+	"string-pooling":          "string-pooling",
+	"string-pool-min":         "string-pool-min",
+	"shorten-keys":            "shorten-keys",
+	"min-savings":             "min-savings-bytes",
+	"lossless":                "lossless",
+	"checksum":                "checksum",
+	"emit-version":            "emit-version",
+	"warn-on-expansion":       "warn-on-expansion",
+	"parallelism":             "parallelism",
+	"number-delta":            "number-delta",
+	"number-delta-threshold":  "number-delta-threshold",
+	"enum-detection":          "enum-detection",
+	"enum-max-values":         "enum-max-values",
+	"inline-enum-threshold":   "inline-enum-threshold",
+	"strip-html":              "strip-html",
+	"strip-markdown":          "strip-markdown",
+	"decode-html-entities":    "decode-html-entities",
+	"normalize-unicode":       "normalize-unicode",
+	"clean-whitespace":        "clean-whitespace",
+	"normalize-quotes":        "normalize-quotes",
+	"strip-emoji":             "strip-emoji",
+	"collapse-whitespace":     "collapse-whitespace",
+	"keep-newlines":           "keep-newlines",
+	"flatten":                 "flatten",
+	"flatten-max-depth":       "flatten-max-depth",
+	"flatten-chains":          "flatten-single-key-chains",
+	"detect-defaults":         "detect-defaults",
+}
+
+// defineFlags registers all command-line flags on fs, storing values in o
+func defineFlags(fs *flag.FlagSet, o *options) {
+	fs.BoolVar(&o.daemon, "d", false, "Run as HTTP daemon")
+	fs.BoolVar(&o.daemon, "daemon", false, "Run as HTTP daemon")
+	fs.StringVar(&o.configFile, "c", "", "Path to custom config file")
+	fs.StringVar(&o.configFile, "config", "", "Path to custom config file")
+	fs.IntVar(&o.port, "port", 8080, "Port for daemon mode")
+	fs.StringVar(&o.listen, "listen", "", "Daemon address instead of -port: host:port, or unix:///path for a Unix socket")
+	fs.StringVar(&o.tlsCert, "tls-cert", "", "PEM certificate file the daemon serves HTTPS with, together with -tls-key")
+	fs.StringVar(&o.tlsKey, "tls-key", "", "PEM private key file of -tls-cert")
+	fs.Var(&o.socketMode, "socket-mode", "Octal file mode of the -listen Unix socket, e.g. 0660")
+	fs.Int64Var(&o.maxBody, "max-body", 10<<20, "Maximum /slim request body size in bytes (0 for unlimited)")
+	fs.Int64Var(&o.maxBody, "max-body-size", 10<<20, "Maximum /slim request body size in bytes (0 for unlimited)")
+	fs.IntVar(&o.maxBatch, "max-batch", 1000, "Maximum number of documents of a /slim/batch request (0 for unlimited)")
+	fs.Int64Var(&o.maxBatchBody, "max-batch-body", 0, "Maximum /slim/batch request body size in bytes (0 for the -max-body limit)")
+	fs.IntVar(&o.batchWorkers, "batch-workers", 0, "Documents of a /slim/batch request slimmed concurrently (0 for the number of CPUs)")
+	fs.DurationVar(&o.readTimeout, "read-timeout", 30*time.Second, "Daemon timeout for reading a request, e.g. 10s (0 for none)")
+	fs.DurationVar(&o.writeTimeout, "write-timeout", 60*time.Second, "Daemon timeout for handling a request and writing the response (0 for none)")
+	fs.DurationVar(&o.idleTimeout, "idle-timeout", 120*time.Second, "Daemon timeout for idle keep-alive connections (0 for the read timeout)")
+	fs.DurationVar(&o.shutdownTimeout, "shutdown-timeout", 15*time.Second, "Daemon grace period for requests in flight on SIGINT or SIGTERM (0 for no limit)")
+	fs.IntVar(&o.maxHeaderBytes, "max-header-bytes", 1<<20, "Maximum daemon request header size in bytes")
+	fs.IntVar(&o.maxConcurrent, "max-concurrent", 0, "Maximum daemon requests served at a time, others get 429 (0 for unlimited)")
+	fs.DurationVar(&o.requestTimeout, "request-timeout", 0, "Daemon time limit for slimming a request, after which it gets 504, e.g. 10s (0 for none)")
+	fs.StringVar(&o.authToken, "auth-token", "", "Bearer token daemon requests other than /health must send (default $SLIMJSON_AUTH_TOKEN)")
+	fs.StringVar(&o.corsOrigins, "cors-origins", "", "Comma-separated origins browsers may call the daemon from, or * for any")
+	fs.BoolVar(&o.persistProfiles, "persist-profiles", false, "Write profiles changed through the daemon API back to the config file")
+	fs.StringVar(&o.logFormat, "log-format", "text", "Format of the daemon log, with one line per request: text or json")
+	fs.StringVar(&o.profile, "profile", "", "Use predefined profile: light, medium, aggressive, ai-optimized")
+	fs.StringVar(&o.saveAs, "save-profile", "", "Save the configuration from the other flags as a profile in ./.slimjson and exit")
+	fs.BoolVar(&o.initConfig, "init", false, "Write a commented ./.slimjson listing every parameter and the built-in profiles, and exit")
+	fs.BoolVar(&o.force, "force", false, "Let -init overwrite an existing .slimjson")
+	fs.BoolVar(&o.version, "version", false, "Print the version, commit and Go version and exit")
+	fs.BoolVar(&o.checkConfig, "check-config", false, "Validate every profile of the config file, report problems with their lines and exit 1 if there are errors")
+	fs.BoolVar(&o.list, "list-profiles", false, "List available profiles with their settings and exit")
+	fs.BoolVar(&o.pretty, "pretty", false, "Pretty print output")
+	fs.BoolVar(&o.stats, "stats", false, "Print size, estimated token reduction, blocked fields, truncated arrays and time to stderr")
+	fs.IntVar(&o.maxBytes, "max-bytes", 0, "Exit with code 2 if the output exceeds N bytes, still writing it (0 for no limit)")
+	fs.IntVar(&o.maxTokens, "max-tokens", 0, "Exit with code 2 if the output exceeds an estimated N tokens, still writing it (0 for no limit)")
+	fs.BoolVar(&o.failIfLarger, "fail-if-larger", false, "Exit with code 3 if the output is not smaller than the input")
+	fs.BoolVar(&o.verbose, "v", false, "Print the config file in use to stderr")
+	fs.StringVar(&o.suffix, "suffix", "", "Write each file's output next to it, replacing its extension with this suffix (e.g. .slim.json)")
+	fs.IntVar(&o.jobs, "jobs", 0, "Number of files processed in parallel (0 for one per CPU)")
+	fs.StringVar(&o.outDir, "out-dir", "", "Write <name>.slim.json files to this directory")
+	fs.StringVar(&o.output, "o", "", "Write the slimmed JSON to this file instead of stdout")
+	fs.StringVar(&o.output, "output", "", "Write the slimmed JSON to this file instead of stdout")
+	fs.BoolVar(&o.inPlace, "w", false, "Overwrite input files with the slimmed JSON")
+	fs.BoolVar(&o.inPlace, "in-place", false, "Overwrite input files with the slimmed JSON")
+	fs.BoolVar(&o.watch, "watch", false, "Slim the input files again whenever they change, until interrupted")
+	fs.BoolVar(&o.decompress, "u", false, "Restore slimmed JSON with Unslim instead of slimming it")
+	fs.BoolVar(&o.decompress, "decompress", false, "Restore slimmed JSON with Unslim instead of slimming it")
+	fs.BoolVar(&o.unslim, "unslim", false, "Like -decompress, but warn about metadata Unslim cannot expand (such as _nulls) and keep it instead of failing")
+	fs.BoolVar(&o.gzipOut, "gzip-out", false, "Compress the output with gzip (automatic for output files ending in .gz)")
+	fs.StringVar(&o.inFormat, "in-format", "", "Input format: json or yaml (default: yaml for .yaml and .yml files, json otherwise)")
+	fs.StringVar(&o.outFormat, "out-format", "json", "Output format: json or yaml")
+	fs.DurationVar(&o.timeout, "timeout", 30*time.Second, "Timeout for fetching a URL input, e.g. 10s (0 for none)")
+	o.headers = make(headerFlag)
+	fs.Var(o.headers, "H", "Header sent when fetching a URL input, e.g. 'Authorization: Bearer TOKEN' (repeatable)")
+	fs.BoolVar(&o.ndjson, "ndjson", false, "Read and write newline-delimited JSON, one document per line")
+	fs.BoolVar(&o.stream, "stream", true, "Slim each of several concatenated JSON documents (-stream=false rejects data after the first)")
+	fs.BoolVar(&o.diff, "diff", false, "Print removed fields, truncated arrays and shortened strings instead of JSON")
+	fs.StringVar(&o.diffFormat, "diff-format", "text", "Format of the -diff report: text or json")
+	fs.BoolVar(&o.recommend, "recommend", false, "Print a config suggested for documents like the input instead of slimming it")
+	fs.Float64Var(&o.recommendTarget, "recommend-target", 50, "Reduction in percent -recommend aims for")
+	fs.BoolVar(&o.analyze, "analyze", false, "Print per-field value counts, bytes per top-level key and string pooling savings of the input instead of slimming it")
+	fs.Var(&o.explain, "explain", "Print the slimmed JSON and a report of what was removed, grouped by reason, to stderr (-explain=json for JSON)")
+	fs.StringVar(&o.blockList, "block", "", "Comma-separated list of field names to remove")
+	fs.StringVar(&o.preserve, "preserve", "", "Comma-separated list of field names or paths kept verbatim, overriding every other option")
+	fs.StringVar(&o.emptyValues, "empty-values", "", "More values -strip-empty removes, e.g. \"N/A;-\" (0 and false only if listed)")
+	fs.StringVar(&o.dropIf, "drop-if", "", "Remove fields equal to a value, e.g. \"status:ok;error:none\"")
+	fs.StringVar(&o.fieldDecs, "field-decimals", "", "Decimal places of single fields, overriding -decimal-places, e.g. \"price:2;lat:6\"")
+
+	cfg := &o.cfg
+	fs.IntVar(&cfg.MaxDepth, "depth", 5, "Maximum nesting depth (0 for unlimited)")
+	fs.StringVar(&cfg.DepthTruncationMarker, "depth-marker", "", "Replace values cut by -depth with null, empty ({} or []) or ellipsis (\"...\")")
+	fs.BoolVar(&cfg.ReportDepthElision, "depth-elision", false, "Replace objects and arrays cut by -depth with {\"_depth_elided\": N}, their number of keys or elements")
+	fs.IntVar(&cfg.MaxListLength, "list-len", 10, "Maximum list length (0 for unlimited)")
+	fs.IntVar(&cfg.MaxStringLength, "string-len", 0, "Maximum string length in characters/runes (0 for unlimited)")
+	fs.StringVar(&cfg.StringLengthUnit, "string-unit", "", "Unit of -string-len: runes (default), bytes or tokens (as estimated for -stats)")
+	fs.Func("truncation-suffix", "Appended to strings shortened by -string-len, counting toward the limit (default \"...\", empty for none)", func(s string) error {
+		cfg.TruncationSuffix = &s
+		return nil
+	})
+	fs.StringVar(&cfg.TruncateBoundary, "truncate-boundary", "", "Cut strings shortened by -string-len at any rune, between grapheme clusters (grapheme) or at the last space (word)")
+	fs.IntVar(&cfg.MaxOutputBytes, "max-output-bytes", 0, "Tighten -list-len and -string-len until the output fits in N bytes (0 for unlimited)")
+	fs.BoolVar(&cfg.StripEmpty, "strip-empty", true, "Remove nulls, empty strings, empty arrays/objects")
+	fs.BoolVar(&cfg.KeepNull, "keep-null", false, "Keep explicit nulls under -strip-empty, still removing empty strings, arrays and objects")
+	fs.BoolVar(&cfg.DropIfEqualsIgnoreCase, "drop-if-ignore-case", false, "Compare -drop-if strings case-insensitively")
+	fs.BoolVar(&cfg.SortKeys, "sort-keys", false, "Sort object keys for canonical output")
+	fs.BoolVar(&cfg.PreserveKeyOrder, "preserve-key-order", false, "Keep object keys in their input order")
+	fs.BoolVar(&cfg.TruncationSummaries, "truncation-summaries", false, "Describe content cut by -depth and -list-len with _truncated/_omitted")
+	fs.IntVar(&cfg.DecimalPlaces, "decimal-places", -1, "Round floats to N decimal places (-1 for no rounding)")
+	fs.BoolVar(&cfg.DeduplicateArrays, "deduplicate", false, "Remove duplicate values from arrays")
+	fs.StringVar(&cfg.SampleStrategy, "sample-strategy", "none", "Array sampling: none, first_last, random, representative, largest, smallest, frequency, stratified")
+	fs.IntVar(&cfg.SampleSize, "sample-size", 0, "Number of items when sampling (0 = use list-len)")
+	fs.Int64Var(&cfg.RandomSeed, "random-seed", 0, "Seed for reproducible random sampling (0 for a random seed)")
+	fs.StringVar(&cfg.SampleSortKey, "sample-sort-key", "", "Object field ranking elements for largest/smallest sampling")
+	fs.BoolVar(&cfg.SampleCounts, "sample-counts", false, "Annotate values kept by frequency sampling with their counts")
+	fs.StringVar(&cfg.SampleStratifyKey, "sample-stratify-key", "", "Object field grouping elements for stratified sampling")
+	fs.BoolVar(&cfg.NullCompression, "null-compression", false, "Track removed null fields in _nulls array")
+	fs.BoolVar(&cfg.TypeInference, "type-inference", false, "Convert uniform arrays to schema+data format")
+	fs.BoolVar(&cfg.TypeInferenceColumnar, "type-inference-columnar", false, "Emit type-inferred arrays column-major (_schema+_cols)")
+	fs.BoolVar(&cfg.MatrixColumnar, "matrix-columnar", false, "Store uniform numeric 2D arrays column-major (_matrix)")
+	fs.BoolVar(&cfg.RunLengthEncode, "rle", false, "Replace runs of repeated values with [value, count] pairs (_rle)")
+	fs.BoolVar(&cfg.BoolCompression, "bool-compression", false, "Convert booleans to bit flags")
+	fs.BoolVar(&cfg.BoolArrayPacking, "bool-array-packing", false, "Pack arrays of 16+ booleans into a base64 bitmap (_bits)")
+	fs.BoolVar(&cfg.TimestampCompression, "timestamp-compression", false, "Convert ISO timestamps to unix timestamps")
+	fs.BoolVar(&cfg.StringPooling, "string-pooling", false, "Deduplicate repeated strings using string pool")
+	fs.IntVar(&cfg.StringPoolMinOccurrences, "string-pool-min", 2, "Minimum occurrences for string pooling")
+	fs.BoolVar(&cfg.Lossless, "lossless", false, "Reject options that lose data, so the output can be restored exactly")
+	fs.BoolVar(&cfg.Checksum, "checksum", false, "Store a SHA-256 hash of the input in _checksum for Unslim to verify (requires -lossless)")
+	fs.BoolVar(&cfg.EmitVersion, "emit-version", false, "Add a _slimjson marker with the format version and the enabled format options")
+	fs.BoolVar(&cfg.WarnOnExpansion, "warn-on-expansion", false, "Print a warning to stderr when the output is larger than the input")
+	fs.IntVar(&cfg.Parallelism, "parallelism", 0, "Goroutines pruning the elements of arrays with 512 or more elements, with the same output (0 for serial)")
+	fs.BoolVar(&cfg.ShortenKeys, "shorten-keys", false, "Replace repeated object keys with short aliases listed in _keys")
+	fs.IntVar(&cfg.MinSavingsBytes, "min-savings", 0, "Use schemas, pools, bit flags and ranges only where they save at least N bytes (0 for always)")
+	fs.BoolVar(&cfg.NumberDeltaEncoding, "number-delta", false, "Use delta encoding for sequential numbers")
+	fs.IntVar(&cfg.NumberDeltaThreshold, "number-delta-threshold", 5, "Minimum array size for delta encoding")
+	fs.BoolVar(&cfg.EnumDetection, "enum-detection", false, "Convert repeated categorical values to enums")
+	fs.IntVar(&cfg.EnumMaxValues, "enum-max-values", 10, "Maximum unique values to consider as enum")
+	fs.IntVar(&cfg.InlineEnumThreshold, "inline-enum-threshold", 0, "Only list fields occurring more than N times in _enums (0 for all)")
+	fs.BoolVar(&cfg.StripHTML, "strip-html", false, "Remove HTML tags and comments from strings, keeping the visible text, before -string-len")
+	fs.BoolVar(&cfg.StripMarkdown, "strip-markdown", false, "Remove Markdown headings, emphasis, link URLs and code fences from strings, keeping code as is")
+	fs.BoolVar(&cfg.DecodeHTMLEntities, "decode-html-entities", false, "Replace HTML entities such as &amp; and &nbsp; in strings with their characters")
+	fs.StringVar(&cfg.NormalizeUnicode, "normalize-unicode", "", "Normalize strings to NFC, NFD or NFKC before -strip-emoji and -string-len (none by default)")
+	fs.BoolVar(&cfg.CleanWhitespace, "clean-whitespace", false, "Replace Unicode spaces with ASCII spaces and remove zero-width characters from strings")
+	fs.BoolVar(&cfg.NormalizeQuotes, "normalize-quotes", false, "Replace curly quotes, dashes and ellipses in strings with ASCII")
+	fs.BoolVar(&cfg.StripUTF8Emoji, "strip-emoji", false, "Remove emoji and non-ASCII characters from strings")
+	fs.BoolVar(&cfg.CollapseWhitespace, "collapse-whitespace", false, "Collapse runs of spaces, tabs and newlines in strings to one space and trim them, before -string-len")
+	fs.BoolVar(&cfg.CollapseWhitespaceKeepNewlines, "keep-newlines", false, "With -collapse-whitespace, collapse runs with a line break to a newline instead of a space")
+	fs.BoolVar(&cfg.Flatten, "flatten", false, "Merge nested objects into dotted keys")
+	fs.IntVar(&cfg.FlattenMaxDepth, "flatten-max-depth", 0, "Maximum segments in a flattened key (0 for unlimited)")
+	fs.BoolVar(&cfg.FlattenSingleKeyChains, "flatten-chains", false, "Merge single-key objects into dotted keys ({\"a\":{\"b\":{...}}} -> {\"a.b\":{...}})")
+	fs.BoolVar(&cfg.DetectDefaults, "detect-defaults", false, "Factor the most common field values out of object arrays into _defaults")
+}
+
+// flagConfig returns the Config described by the command-line flags
+func (o *options) flagConfig() (slimjson.Config, error) {
+	cfg := o.cfg
+	if o.blockList != "" {
+		cfg.BlockList = strings.Split(o.blockList, ",")
+	}
+	if o.preserve != "" {
+		cfg.PreserveFields = strings.Split(o.preserve, ",")
+	}
+	if o.emptyValues != "" {
+		cfg.EmptyValues = slimjson.ParseEmptyValues(o.emptyValues)
+	}
+	if o.dropIf != "" {
+		dropRules, err := slimjson.ParseDropIf(o.dropIf)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid -drop-if value: %w", err)
+		}
+		cfg.DropIfEquals = dropRules
+	}
+	if o.fieldDecs != "" {
+		places, err := slimjson.ParseFieldDecimalPlaces(o.fieldDecs)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid -field-decimals value: %w", err)
+		}
+		cfg.FieldDecimalPlaces = places
+	}
+	return cfg, nil
+}
+
+// losslessConfig returns cfg with the lossy defaults of -depth, -list-len and
+// -strip-empty turned off, keeping the flags set on the command line
+func losslessConfig(cfg slimjson.Config, fs *flag.FlagSet) slimjson.Config {
+	return slimjson.Config{Lossless: true, DecimalPlaces: -1}.Merge(cfg, overrideKeys(fs))
+}
+
+// slim processes one input according to the output mode flags. Gzip input is
+// decompressed, YAML is read and written with -in-format and -out-format yaml,
+// and input whose first two lines are standalone JSON values is read as NDJSON
+// without -ndjson.
+// The -stats line, prefixed with label, and the -explain report are written
+// to errOut, if it is not nil. Output over a -max-bytes, -max-tokens or
+// -fail-if-larger budget is written and then reported with a *budgetError.
+func (o *options) slim(in io.Reader, out, errOut io.Writer, label string, cfg slimjson.Config) error {
+	in, err := gunzip(in)
+	if err != nil {
+		return err
+	}
+	if o.unslim {
+		warn := errOut
+		if warn == nil {
+			warn = io.Discard
+		}
+		return unslimInput(in, out, o.pretty, warn)
+	}
+	if o.decompress {
+		return unslimInput(in, out, o.pretty, nil)
+	}
+
+	stats := o.stats && errOut != nil
+	var st *runStats
+	if stats || o.budgeted() || cfg.WarnOnExpansion {
+		st = &runStats{}
+		cfg = st.count(cfg)
+	}
+	start := time.Now()
+	br := bufio.NewReaderSize(in, 64<<10)
+	switch {
+	case o.inFormat == "yaml" || o.outFormat == "yaml":
+		var report io.Writer
+		if o.explain != "" && errOut != nil {
+			report = errOut
+		}
+		err = o.slimYAML(br, out, report, st, cfg)
+	case o.explain != "" && errOut != nil:
+		err = explainInput(br, out, errOut, st, cfg, string(o.explain), o.pretty)
+	case o.ndjson || looksLikeNDJSON(br):
+		err = slimNDJSON(br, out, st, cfg)
+	default:
+		err = slimInput(br, out, st, cfg, o.pretty, o.stream)
+	}
+
+	// Inputs with output are summarized even if some documents failed
+	if stats && (err == nil || st.slimBytes > 0) {
+		st.elapsed = time.Since(start)
+		st.print(errOut, label)
+		if o.total != nil {
+			o.total.add(st)
+		}
+	}
+	if err == nil && cfg.WarnOnExpansion && errOut != nil {
+		o.warnExpansion(errOut, label, st)
+	}
+	if err == nil && st != nil {
+		return o.checkBudget(st)
+	}
+	return err
+}
+
+// overrideKeys returns the config keys of compression flags explicitly set on the
+// command line, so that both enabling and disabling a feature overrides a profile.
+func overrideKeys(fs *flag.FlagSet) []string {
+	var keys []string
+	fs.Visit(func(f *flag.Flag) {
+		if key, ok := flagConfigKeys[f.Name]; ok {
+			keys = append(keys, key)
+		}
+	})
+	return keys
+}
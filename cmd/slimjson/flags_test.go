@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestProfileFlagOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want func(cfg *slimjson.Config)
+	}{
+		{
+			name: "Profile only",
+			args: []string{"-profile", "light"},
+			want: func(cfg *slimjson.Config) {},
+		},
+		{
+			name: "Disable a profile default",
+			args: []string{"-profile", "light", "-strip-empty=false"},
+			want: func(cfg *slimjson.Config) { cfg.StripEmpty = false },
+		},
+		{
+			name: "Enable a feature",
+			args: []string{"-profile", "light", "-bool-compression", "-depth", "3"},
+			want: func(cfg *slimjson.Config) {
+				cfg.BoolCompression = true
+				cfg.MaxDepth = 3
+			},
+		},
+		{
+			name: "Explicit flag equal to its default",
+			args: []string{"-profile", "light", "-list-len", "10"},
+			want: func(cfg *slimjson.Config) { cfg.MaxListLength = 10 },
+		},
+		{
+			name: "Block list",
+			args: []string{"-profile", "light", "-block", "a,b"},
+			want: func(cfg *slimjson.Config) { cfg.BlockList = []string{"a", "b"} },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("slimjson", flag.ContinueOnError)
+			o := &options{}
+			defineFlags(fs, o)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+
+			flagCfg, err := o.flagConfig()
+			if err != nil {
+				t.Fatalf("flagConfig() error: %v", err)
+			}
+			got := getProfile(o.profile, nil).Merge(flagCfg, overrideKeys(fs))
+
+			want := slimjson.GetBuiltinProfiles()[o.profile]
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("config = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLosslessConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    slimjson.Config
+		wantErr string
+	}{
+		{
+			name: "Lossy defaults are turned off",
+			args: []string{"-lossless", "-string-pooling", "-type-inference"},
+			want: slimjson.Config{Lossless: true, DecimalPlaces: -1, StringPooling: true, TypeInference: true},
+		},
+		{
+			name:    "Explicit lossy flags are kept and rejected",
+			args:    []string{"-lossless", "-string-len", "100"},
+			want:    slimjson.Config{Lossless: true, DecimalPlaces: -1, MaxStringLength: 100},
+			wantErr: "lossless: max-string-length",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("slimjson", flag.ContinueOnError)
+			o := &options{}
+			defineFlags(fs, o)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			flagCfg, err := o.flagConfig()
+			if err != nil {
+				t.Fatalf("flagConfig() error: %v", err)
+			}
+
+			got := losslessConfig(flagCfg, fs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("config = %+v, want %+v", got, tt.want)
+			}
+			err = got.Validate()
+			if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
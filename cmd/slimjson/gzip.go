@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tradik/slimjson"
+)
+
+// gzipMagic are the first bytes of every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// gunzip returns a reader of the decompressed input if in starts with the
+// gzip magic bytes, or of in itself otherwise
+func gunzip(in io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(in)
+	if magic, _ := br.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+		return br, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("reading gzip input: %w", err)
+	}
+	return zr, nil
+}
+
+// isGzipName reports whether a file name has the .gz extension
+func isGzipName(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".gz")
+}
+
+// trimGzipExt removes a .gz extension from name
+func trimGzipExt(name string) string {
+	if isGzipName(name) {
+		return name[:len(name)-len(".gz")]
+	}
+	return name
+}
+
+// slimCompressed slims in like o.slim and, with compress, writes the output
+// to out as a gzip stream
+func (o *options) slimCompressed(in io.Reader, out, errOut io.Writer, label string, cfg slimjson.Config, compress bool) error {
+	if !compress {
+		return o.slim(in, out, errOut, label, cfg)
+	}
+	zw := gzip.NewWriter(out)
+	err := o.slim(in, zw, errOut, label, cfg)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+// gzipBytes compresses data with gzip
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// gunzipBytes decompresses gzip data
+func gunzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected gzip output: %v", err)
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to decompress: %v", err)
+	}
+	return plain
+}
+
+func TestGzip(t *testing.T) {
+	cfg := slimjson.Config{MaxDepth: 3, MaxListLength: 2, StripEmpty: true, DecimalPlaces: -1, BlockList: []string{"password"}}
+	fixture, err := os.ReadFile("../../testing/fixtures/users.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	ndjson := []byte("{\"id\": 1, \"password\": \"x\"}\n{\"id\": 2, \"tags\": []}\n")
+
+	// plain slims the uncompressed input, for comparison
+	plain := func(t *testing.T, o *options, data []byte) []byte {
+		t.Helper()
+		var out bytes.Buffer
+		if err := o.slim(bytes.NewReader(data), &out, nil, "plain", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+		return out.Bytes()
+	}
+
+	t.Run("Stdin", func(t *testing.T) {
+		for name, data := range map[string][]byte{"JSON": fixture, "NDJSON": ndjson} {
+			o := &options{stream: true}
+			var out bytes.Buffer
+			if err := o.slim(bytes.NewReader(gzipBytes(t, data)), &out, nil, "gzip", cfg); err != nil {
+				t.Fatalf("%s: slim() error: %v", name, err)
+			}
+			if want := plain(t, o, data); !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("%s: expected %q, got %q", name, want, out.Bytes())
+			}
+		}
+	})
+
+	t.Run("Gzip output to stdout", func(t *testing.T) {
+		o := &options{stream: true, gzipOut: true}
+		var out bytes.Buffer
+		if err := o.slimCompressed(bytes.NewReader(fixture), &out, nil, "gzip", cfg, o.gzipOut); err != nil {
+			t.Fatalf("slimCompressed() error: %v", err)
+		}
+		if got, want := gunzipBytes(t, out.Bytes()), plain(t, &options{stream: true}, fixture); !bytes.Equal(got, want) {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("Output file ending in .gz", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "users.slim.json.gz")
+		o := &options{stream: true, output: path}
+		if err := slimToFile(bytes.NewReader(fixture), path, "users", o, cfg, nil); err != nil {
+			t.Fatalf("slimToFile() error: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if want := plain(t, o, fixture); !bytes.Equal(gunzipBytes(t, got), want) {
+			t.Errorf("Expected %q, got %q", want, gunzipBytes(t, got))
+		}
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		dir := t.TempDir()
+		inputs := map[string][]byte{"users.json.gz": gzipBytes(t, fixture), "events.ndjson.gz": gzipBytes(t, ndjson), "plain.json": fixture}
+		var files []string
+		for name, data := range inputs {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatalf("Failed to write input: %v", err)
+			}
+			files = append(files, path)
+		}
+
+		outDir := filepath.Join(dir, "slim")
+		o := &options{stream: true, outDir: outDir}
+		var out, errOut bytes.Buffer
+		if failed := processFiles(files, o, cfg, &out, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		expected := map[string][]byte{
+			"users.slim.json.gz":    plain(t, o, fixture),
+			"events.slim.ndjson.gz": plain(t, o, ndjson),
+			"plain.slim.json":       plain(t, o, fixture),
+		}
+		for name, want := range expected {
+			got, err := os.ReadFile(filepath.Join(outDir, name))
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if isGzipName(name) {
+				got = gunzipBytes(t, got)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s = %q, want %q", name, got, want)
+			}
+		}
+
+		// -gzip-out compresses every output, adding .gz to the names
+		o = &options{stream: true, outDir: filepath.Join(dir, "gz"), gzipOut: true}
+		if failed := processFiles(files, o, cfg, &out, &errOut); failed != 0 {
+			t.Fatalf("Expected no failures, got %d: %s", failed, errOut.String())
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "gz", "plain.slim.json.gz"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if want := expected["plain.slim.json"]; !bytes.Equal(gunzipBytes(t, got), want) {
+			t.Errorf("Expected %q, got %q", want, gunzipBytes(t, got))
+		}
+	})
+
+	t.Run("Invalid gzip file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data.json.gz")
+		if err := os.WriteFile(path, []byte(`{"id": 1}`), 0o644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+		var out bytes.Buffer
+		err := processFile(path, &options{}, cfg, &out, nil)
+		if err == nil || !strings.Contains(err.Error(), "invalid header") {
+			t.Errorf("Expected invalid gzip header error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/tradik/slimjson"
+)
+
+// initConfig writes a commented .slimjson template to dir and returns its
+// path. An existing file is only replaced with force.
+func initConfig(dir string, force bool) (string, error) {
+	var buf bytes.Buffer
+	if err := slimjson.WriteConfigTemplate(&buf); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, ".slimjson")
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return "", fmt.Errorf("%s already exists (use -force to overwrite it)", path)
+	} else if err != nil {
+		return "", err
+	}
+	_, err = f.Write(buf.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return path, err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestInitConfig(t *testing.T) {
+	dir := t.TempDir()
+	path, err := initConfig(dir, false)
+	if err != nil {
+		t.Fatalf("initConfig() error: %v", err)
+	}
+	if path != filepath.Join(dir, ".slimjson") {
+		t.Errorf("Expected .slimjson in %s, got %s", dir, path)
+	}
+
+	got, err := slimjson.ParseConfigFile(path)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() error: %v", err)
+	}
+	if want := slimjson.GetBuiltinProfiles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the built-in profiles, got %+v", got)
+	}
+
+	// An existing file is kept without -force
+	if err := os.WriteFile(path, []byte("[mine]\nmax-depth=2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := initConfig(dir, false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an error for the existing file, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "[mine]\nmax-depth=2\n" {
+		t.Errorf("Expected the existing file to be kept, got:\n%s", data)
+	}
+
+	if _, err := initConfig(dir, true); err != nil {
+		t.Fatalf("initConfig() with force error: %v", err)
+	}
+	got, err = slimjson.ParseConfigFile(path)
+	if _, ok := got["mine"]; err != nil || ok || len(got) != 4 {
+		t.Errorf("Expected the template to replace the file, got %v (%v)", got, err)
+	}
+}
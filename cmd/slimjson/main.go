@@ -2,16 +2,28 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"maps"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/tradik/slimjson"
+	"github.com/tradik/slimjson/server"
 )
 
 // getProfile returns a configuration profile (built-in or from config file)
@@ -21,15 +33,14 @@ func getProfile(name string, customProfiles map[string]slimjson.Config) slimjson
 		return cfg
 	}
 
-	// Then check built-in profiles
-	builtinProfiles := slimjson.GetBuiltinProfiles()
-	if cfg, ok := builtinProfiles[strings.ToLower(name)]; ok {
+	// Then check built-in and registered profiles
+	if cfg, ok := slimjson.GetProfile(name); ok {
 		return cfg
 	}
 
 	// Profile not found
 	fmt.Fprintf(os.Stderr, "Unknown profile: %s\n", name)
-	fmt.Fprintf(os.Stderr, "\nBuilt-in profiles: light, medium, aggressive, ai-optimized\n")
+	fmt.Fprintf(os.Stderr, "\nBuilt-in profiles: %s\n", strings.Join(server.BuiltinProfileNames, ", "))
 
 	if len(customProfiles) > 0 {
 		fmt.Fprintf(os.Stderr, "\nCustom profiles from .slimjson:\n")
@@ -38,53 +49,197 @@ func getProfile(name string, customProfiles map[string]slimjson.Config) slimjson
 		}
 	}
 
+	fmt.Fprintf(os.Stderr, "\nRun slimjson -list-profiles for descriptions and settings\n")
 	os.Exit(1)
 	return slimjson.Config{}
 }
 
+// validateProfiles checks every profile, so a bad profile is reported at startup
+func validateProfiles(profiles map[string]slimjson.Config) error {
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		if err := profiles[name].Validate(); err != nil {
+			return fmt.Errorf("profile %s: %s", name, oneLine(err))
+		}
+	}
+	return nil
+}
+
+// oneLine joins the problems reported by Config.Validate into a single line
+func oneLine(err error) string {
+	return strings.ReplaceAll(err.Error(), "\n", "; ")
+}
+
 // printUsage prints the usage information
 func printUsage() {
 	fmt.Fprintf(os.Stderr, `slimjson - JSON optimizer for AI/LLM contexts
 
 Usage:
   slimjson [options] [file]              Process JSON file or stdin
+  slimjson [options] file1 file2 ...     Write file1.slim.json, file2.slim.json, ...
+  slimjson [options] https://...         Fetch and process JSON from a URL
   slimjson -d [options]                  Run as HTTP daemon
+  slimjson init [-force]                 Write a commented .slimjson template to the current directory
+  slimjson -version                      Print the version, commit and Go version
   slimjson -h                            Show this help
 
 Daemon Mode:
   -d, -daemon                Run as HTTP daemon listening on specified port
   -port int                  Port for daemon mode (default: 8080)
+  -listen string             Address instead of -port: host:port, or unix:///path for a Unix socket
+                             (a stale socket file is replaced)
+  -socket-mode string        Octal file mode of the Unix socket, e.g. 0660 (default: per umask)
+  -tls-cert, -tls-key string PEM certificate and key files to serve HTTPS with
+  -max-body, -max-body-size int
+                             Maximum /slim request body size in bytes (default: 10485760, 0 = unlimited)
+  -max-batch int             Maximum documents per /slim/batch request (default: 1000, 0 = unlimited)
+  -max-batch-body int        Maximum /slim/batch request body size in bytes (default: 0 = -max-body)
+  -batch-workers int         Documents of a batch slimmed concurrently (default: 0 = number of CPUs)
+  -read-timeout duration     Timeout for reading a request (default: 30s, 0 = none)
+  -write-timeout duration    Timeout for handling a request and writing the response (default: 60s, 0 = none)
+  -idle-timeout duration     Timeout for idle keep-alive connections (default: 120s, 0 = read timeout)
+  -shutdown-timeout duration
+                             Grace period for requests in flight on SIGINT or SIGTERM (default: 15s, 0 = no limit)
+  -max-header-bytes int      Maximum request header size in bytes (default: 1048576)
+  -max-concurrent int        Maximum requests served at a time, others get 429 (default: 0 = unlimited)
+  -request-timeout duration  Time limit for slimming a request, then 504 (default: 0 = none)
+  -auth-token string         Require "Authorization: Bearer <token>" on every endpoint but /health
+                             (default: $SLIMJSON_AUTH_TOKEN)
+  -cors-origins string       Comma-separated origins browsers may call the API from, or * for any
+  -persist-profiles          Write profiles changed with PUT or DELETE /profiles/{name} back to the
+                             config file (-c, the one found or ./.slimjson), which must be INI
+  -log-format string         Format of the log, which has a line per request with its X-Request-ID:
+                             text or json (default: text)
 
 Configuration:
-  -c, -config string         Path to custom config file (takes priority over .slimjson)
+  -c, -config string         Path to custom config file, INI, .yaml or .json (takes priority over .slimjson)
   -profile string            Use predefined profile: light, medium, aggressive, ai-optimized
+  -save-profile string       Save the configuration from the other flags as a profile in ./.slimjson and exit
+  -init                      Write a commented ./.slimjson listing every parameter with its default
+                             and the built-in profiles, and exit (same as slimjson init)
+  -force                     Let -init overwrite an existing .slimjson
+  -check-config              Validate every profile of the config file (-c or the one found) without
+                             reading input, print problems with their lines and exit 1 on errors
+  -list-profiles             List built-in, registered and config file profiles with their settings and exit
+  -v                         Print the config file in use to stderr
 
 Basic Options:
   -depth int                 Maximum nesting depth (default: 5, 0 = unlimited)
+  -depth-marker string       Replace values cut by -depth with null, empty ({} or []) or ellipsis
+                             ("...") (default: null)
+  -depth-elision             Replace objects and arrays cut by -depth with {"_depth_elided": N},
+                             their number of keys or elements
   -list-len int              Maximum list length (default: 10, 0 = unlimited)
   -string-len int            Maximum string length (default: 0 = unlimited)
+  -string-unit string        Unit of -string-len: runes, bytes or tokens (about 4 bytes, as estimated
+                             by -stats) (default: runes)
+  -truncation-suffix string  Appended to strings shortened by -string-len, counting toward the limit
+                             (default: "...", empty for none)
+  -truncate-boundary string  Cut strings shortened by -string-len at any rune, without splitting emoji
+                             or accented letters (grapheme), or at the last space (word) (default: rune)
+  -max-output-bytes int      Tighten -list-len and -string-len until the output fits in N bytes (default: 0 = unlimited)
   -strip-empty               Remove nulls, empty strings, empty arrays/objects (default: true)
+  -empty-values string       More values -strip-empty removes, e.g. "N/A;-" (0 and false only if listed)
+  -keep-null                 Keep explicit nulls under -strip-empty (default: false)
   -block string              Comma-separated list of field names to remove
+  -preserve string           Comma-separated field names or paths kept verbatim (e.g. id,user.signature)
+  -drop-if string            Remove fields equal to a value, e.g. "status:ok;error:none"
+  -drop-if-ignore-case       Compare -drop-if strings case-insensitively
   -pretty                    Pretty print output
+  -stream                    Slim each of several concatenated JSON documents (default: true;
+                             -stream=false rejects data after the first document)
+  -u, -decompress            Restore slimmed JSON with Unslim instead of slimming it
+  -unslim                    Like -decompress, but warn about metadata Unslim cannot expand (such as
+                             _nulls) and keep it instead of failing
+  -in-format string          Input format: json, yaml (default: yaml for .yaml and .yml files)
+  -out-format string         Output format: json, yaml (default: json)
+  -ndjson                    Read and write newline-delimited JSON, one document per line
+                             (detected when the first two lines are separate JSON values)
+  -timeout duration          Timeout for fetching an http:// or https:// input (default: 30s, 0 = none)
+  -H string                  Header sent when fetching a URL, e.g. 'Authorization: Bearer TOKEN' (repeatable)
+  -o, -output string         Write the slimmed JSON to this file instead of stdout
+  -gzip-out                  Compress the output with gzip (automatic when -o ends in .gz);
+                             gzip input is always detected and decompressed
+  -suffix string             Write each file's output next to it with this suffix instead of its extension
+  -out-dir string            Write <name>.slim.json files (or <name><suffix>) to this directory
+  -jobs int                  Files processed in parallel (default: 0 = one per CPU)
+  -w, -in-place              Overwrite input files with the slimmed JSON
+  -watch                     Slim the input files again whenever they change, printing -stats
+                             per run, until interrupted
+  -stats                     Print size, token reduction, blocked fields, truncated arrays,
+                             subtrees cut by -depth and time to stderr (with a total for several files)
+  -diff                      Print removed fields, truncated arrays and shortened strings instead of JSON
+  -diff-format string        Format of the -diff report: text, json (default: text)
+  -recommend                 Print a config suggested for documents like the input, as JSON, and
+                             the reduction it achieves on the input to stderr, instead of slimming it
+  -recommend-target float    Reduction in percent -recommend aims for, tightening limits if the
+                             reversible options fall short (default: 50)
+  -analyze                   Print a JSON report of the input instead of slimming it: the value
+                             counts, cardinality and most common values of each field, the bytes
+                             of each top-level key and what string pooling would save
+  -explain                   Print the slimmed JSON and, to stderr, what was removed grouped by
+                             reason (-explain=json for a JSON report)
+  -max-bytes int             Exit with code 2 if the output exceeds N bytes (default: 0 = no limit)
+  -max-tokens int            Exit with code 2 if the output exceeds ~N tokens (default: 0 = no limit)
+  -fail-if-larger            Exit with code 3 if the output is not smaller than the input
+  -sort-keys                 Sort object keys for canonical, byte-identical output
+  -preserve-key-order        Keep object keys in their input order
+  -truncation-summaries      Describe content cut by -depth and -list-len with _truncated/_omitted
 
 Optimization Options:
   -decimal-places int        Round floats to N decimal places (default: -1 = no rounding)
+  -field-decimals string     Decimal places of single fields, overriding -decimal-places
+                             (e.g. "price:2;lat:6")
   -deduplicate               Remove duplicate values from arrays
-  -sample-strategy string    Array sampling: none, first_last, random, representative (default: none)
+  -sample-strategy string    Array sampling: none, first_last, random, representative, largest, smallest, frequency, stratified (default: none)
   -sample-size int           Number of items when sampling (default: 0 = use list-len)
+  -random-seed int           Seed for reproducible random sampling (default: 0 = random)
+  -sample-sort-key string    Object field ranking elements for largest/smallest sampling
+  -sample-counts             Annotate values kept by frequency sampling with their counts
+  -sample-stratify-key string  Object field grouping elements for stratified sampling
 
 Advanced Compression:
   -null-compression          Track removed null fields in _nulls array
   -type-inference            Convert uniform arrays to schema+data format
+  -type-inference-columnar   Emit type-inferred arrays column-major (_schema+_cols)
+  -matrix-columnar           Store uniform numeric 2D arrays column-major (_matrix)
+  -rle                       Replace runs of repeated values with [value, count] pairs (_rle)
   -bool-compression          Convert booleans to bit flags
+  -bool-array-packing        Pack arrays of 16+ booleans into a base64 bitmap (_bits)
   -timestamp-compression     Convert ISO timestamps to unix timestamps
   -string-pooling            Deduplicate repeated strings using string pool
   -string-pool-min int       Minimum occurrences for string pooling (default: 2)
+  -lossless                  Reject options that lose data, so Unslim restores the exact input
+  -checksum                  Store a SHA-256 hash of the input in _checksum for Unslim to verify (requires -lossless)
+  -emit-version              Add a _slimjson marker with the format version and the enabled format options
+  -warn-on-expansion         Print a warning to stderr when the output is larger than the input
+  -parallelism int           Goroutines pruning the elements of arrays of 512+ elements, such as a large
+                             top-level array, with the same output (default: 0 = serial)
+  -shorten-keys              Replace repeated object keys with short aliases listed in _keys
+  -min-savings int           Use schemas, pools, bit flags and ranges only where they save at least N bytes
   -number-delta              Use delta encoding for sequential numbers
   -number-delta-threshold int Minimum array size for delta encoding (default: 5)
   -enum-detection            Convert repeated categorical values to enums
   -enum-max-values int       Maximum unique values to consider as enum (default: 10)
+  -inline-enum-threshold int Only list fields occurring more than N times in _enums (default: 0 = all)
+  -strip-html                Remove HTML tags and comments from strings, keeping the visible text,
+                             before -string-len
+  -strip-markdown            Remove Markdown headings, emphasis, link URLs and code fences from strings,
+                             keeping the text and code
+  -decode-html-entities      Replace HTML entities such as &amp; and &nbsp; in strings with their characters
+  -normalize-unicode string  Normalize strings to NFC, NFD or NFKC before -strip-emoji and
+                             -string-len (default: none; NFKC folds full-width and other
+                             compatibility characters)
+  -clean-whitespace          Replace Unicode spaces (no-break, ideographic...) with ASCII spaces and
+                             remove zero-width characters from strings
+  -normalize-quotes          Replace curly quotes, en/em dashes and ellipses in strings with ASCII
   -strip-emoji               Remove emoji and non-ASCII characters from strings
+  -collapse-whitespace       Collapse runs of spaces, tabs and newlines in strings to one space and trim
+                             them, before -string-len
+  -keep-newlines             With -collapse-whitespace, collapse runs with a line break to a newline
+  -flatten                   Merge nested objects into dotted keys (data.attributes.name)
+  -flatten-max-depth int     Maximum segments in a flattened key (default: 0 = unlimited)
+  -flatten-chains            Merge single-key objects into dotted keys (data.result.item), not counted by -depth
+  -detect-defaults           Factor the most common field values out of object arrays into _defaults
 
 Examples:
   # Process file with medium profile
@@ -96,163 +251,249 @@ Examples:
   # Use custom config file
   slimjson -c /path/to/config.slimjson -profile my-profile data.json
 
+  # Fetch an API response and slim it
+  slimjson -profile ai-optimized -H "Authorization: Bearer $TOKEN" https://api.github.com/users/octocat
+
   # Process stdin with custom settings
   cat data.json | slimjson -depth 3 -list-len 5 -pretty
 
+  # Slim a newline-delimited log stream
+  tail -f events.ndjson | slimjson -ndjson -profile aggressive
+
+  # Slim several files into a separate directory
+  slimjson -profile medium -out-dir slim/ *.json
+
+  # Slim matching files in parallel, writing logs/<name>.slim.json
+  slimjson -profile medium -suffix .slim.json -jobs 4 'logs/*.json'
+
+  # Write to a file, and restore it
+  slimjson -profile medium -o data.slim.json data.json
+  slimjson -lossless -string-pooling -o data.slim.json data.json && slimjson -u data.slim.json
+
+  # Keep a slimmed copy up to date while editing
+  slimjson -profile medium -watch -o data.slim.json data.json
+
+  # Review Kubernetes manifests, one JSON document per YAML document
+  slimjson -profile medium -block managedFields,status deploy.yaml
+
+  # Slim archived payloads, keeping them compressed
+  slimjson -profile medium -out-dir slim/ archive/*.json.gz
+
+  # Review what a profile removes
+  slimjson -profile aggressive -diff data.json
+  slimjson -profile aggressive -explain data.json > slim.json
+
+  # Get started: suggest options that make documents like this one 40%% smaller
+  slimjson -recommend -recommend-target 40 -pretty sample.json
+
+  # Find the fields that repeat values or take the most bytes
+  slimjson -analyze -pretty data.json
+
+  # Compare profiles without touching stdout
+  slimjson -profile aggressive -stats data.json > /dev/null
+
+  # Fail a CI job when the prompt payload is over budget
+  slimjson -profile ai-optimized -max-tokens 4000 -o prompt.json data.json
+
+Exit Codes:
+  0  Success
+  1  Invalid flags or config, unreadable or invalid input
+  2  Output written, but over -max-bytes or -max-tokens
+  3  Output written, but not smaller than the input (-fail-if-larger)
+
 Daemon API:
   POST /slim                 Compress JSON (use ?profile=name for profiles)
+                             Config keys in the query override the profile: ?depth=3&block=a,b
+                             With ?inline_config=1 or Content-Type application/vnd.slimjson+json,
+                             send {"config": {...}, "data": ...}
+  POST /slim/ndjson          Compress NDJSON, one document per line
+  POST /slim/batch           Compress a JSON array of documents, or NDJSON with
+                             Content-Type application/x-ndjson, in one request
   GET  /health               Health check
-  GET  /profiles             List available profiles
+  GET  /profiles             List available profiles with descriptions and settings
+  GET  /profiles/{name}      Resolved config of a profile
+  PUT  /profiles/{name}      Create or replace a custom profile, body {"max-depth": 3, ...}
+  DELETE /profiles/{name}    Delete a custom profile
+  POST /reload               Reload the profiles from the config file, as on SIGHUP
+                             PUT, DELETE and /reload require -auth-token
 
 For more information: https://github.com/tradik/slimjson
 `)
 }
 
-// runDaemon starts the HTTP server
-func runDaemon(port int, customProfiles map[string]slimjson.Config) {
-	// Combine built-in and custom profiles
-	allProfiles := slimjson.GetBuiltinProfiles()
-	for name, cfg := range customProfiles {
-		allProfiles[name] = cfg
-	}
-
-	// Health check endpoint
-	http.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, _ = fmt.Fprintf(w, `{"status":"ok","version":"1.0"}`)
-	})
+// fileModeFlag is an octal file mode, such as 0660
+type fileModeFlag os.FileMode
 
-	// List profiles endpoint
-	http.HandleFunc("/profiles", func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
+func (m *fileModeFlag) String() string {
+	if *m == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%#o", uint32(*m))
+}
 
-		profiles := make(map[string][]string)
-		profiles["builtin"] = []string{"light", "medium", "aggressive", "ai-optimized"}
-		profiles["custom"] = make([]string, 0)
+func (m *fileModeFlag) Set(value string) error {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o777 {
+		return fmt.Errorf("expected an octal file mode such as 0660, got %q", value)
+	}
+	*m = fileModeFlag(mode)
+	return nil
+}
 
-		for name := range customProfiles {
-			profiles["custom"] = append(profiles["custom"], name)
+// checkDaemon rejects invalid daemon listener flags and combinations of them
+func (o *options) checkDaemon(fs *flag.FlagSet) error {
+	portSet := false
+	fs.Visit(func(f *flag.Flag) { portSet = portSet || f.Name == "port" })
+	path, isUnix := strings.CutPrefix(o.listen, "unix://")
+	switch {
+	case o.listen != "" && portSet:
+		return errors.New("-listen cannot be combined with -port")
+	case isUnix && path == "":
+		return errors.New("-listen unix:// requires a socket path, e.g. unix:///var/run/slimjson.sock")
+	case (o.tlsCert == "") != (o.tlsKey == ""):
+		return errors.New("-tls-cert and -tls-key must be given together")
+	case o.socketMode != 0 && !isUnix:
+		return errors.New("-socket-mode requires -listen unix:///path")
+	case o.logFormat != "text" && o.logFormat != "json":
+		return fmt.Errorf("invalid -log-format %q: expected text or json", o.logFormat)
+	}
+	if o.listen != "" && !isUnix {
+		if _, _, err := net.SplitHostPort(o.listen); err != nil {
+			return fmt.Errorf("invalid -listen %q: expected host:port or unix:///path", o.listen)
 		}
+	}
+	return nil
+}
 
-		_ = json.NewEncoder(w).Encode(profiles)
-	})
-
-	// Slim endpoint
-	http.HandleFunc("/slim", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+// daemonAddr returns the address the daemon listens on: -listen, or -port
+func daemonAddr(o *options) string {
+	if o.listen != "" {
+		return o.listen
+	}
+	return fmt.Sprintf(":%d", o.port)
+}
 
-		// Get profile from query parameter
-		profileName := r.URL.Query().Get("profile")
+// daemonURL returns the base URL of a daemon listening on opts.Addr, for the log
+func daemonURL(opts server.Options) string {
+	if strings.HasPrefix(opts.Addr, "unix://") {
+		return opts.Addr
+	}
+	scheme := "http"
+	if opts.TLSCertFile != "" {
+		scheme = "https"
+	}
+	host, port, _ := net.SplitHostPort(opts.Addr)
+	if host == "" {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
 
-		var cfg slimjson.Config
-		if profileName != "" {
-			var ok bool
-			cfg, ok = allProfiles[strings.ToLower(profileName)]
-			if !ok {
-				http.Error(w, fmt.Sprintf("Unknown profile: %s", profileName), http.StatusBadRequest)
-				return
-			}
-		} else {
-			// Default config
-			cfg = slimjson.Config{
-				MaxDepth:      5,
-				MaxListLength: 10,
-				StripEmpty:    true,
-			}
+// daemonOptions returns the server options of the daemon flags of o
+func daemonOptions(o *options, customProfiles map[string]slimjson.Profile) server.Options {
+	authToken := o.authToken
+	if authToken == "" {
+		authToken = os.Getenv("SLIMJSON_AUTH_TOKEN")
+	}
+	var corsOrigins []string
+	for _, origin := range strings.Split(o.corsOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			corsOrigins = append(corsOrigins, origin)
 		}
+	}
 
-		// Parse JSON from request body
-		var data interface{}
-		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
-			return
-		}
+	return server.Options{
+		Addr:            daemonAddr(o),
+		SocketMode:      os.FileMode(o.socketMode),
+		TLSCertFile:     o.tlsCert,
+		TLSKeyFile:      o.tlsKey,
+		Profiles:        customProfiles,
+		MaxBodyBytes:    o.maxBody,
+		MaxBatchBytes:   o.maxBatchBody,
+		MaxBatchSize:    o.maxBatch,
+		BatchWorkers:    o.batchWorkers,
+		ReadTimeout:     o.readTimeout,
+		WriteTimeout:    o.writeTimeout,
+		IdleTimeout:     o.idleTimeout,
+		ShutdownTimeout: o.shutdownTimeout,
+		MaxHeaderBytes:  o.maxHeaderBytes,
+		MaxConcurrent:   o.maxConcurrent,
+		RequestTimeout:  o.requestTimeout,
+		AuthToken:       authToken,
+		CORSOrigins:     corsOrigins,
+		BuildInfo:       server.BuildInfo(getBuildInfo()),
+	}
+}
 
-		// Process
-		slimmer := slimjson.New(cfg)
-		result := slimmer.Slim(data)
+// logHandler returns the slog.Handler of the daemon log in format, text or
+// json, writing to w
+func logHandler(format string, w io.Writer) slog.Handler {
+	if format == "json" {
+		return slog.NewJSONHandler(w, nil)
+	}
+	return slog.NewTextHandler(w, nil)
+}
 
-		// Return result
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to encode result: %v", err), http.StatusInternalServerError)
-			return
-		}
-	})
+// runDaemon serves the HTTP API until SIGINT or SIGTERM, then waits up to
+// -shutdown-timeout for requests in flight
+func runDaemon(o *options, customProfiles map[string]slimjson.Profile, configFile, profilesFile string) {
+	opts := daemonOptions(o, customProfiles)
+	opts.ConfigFile, opts.ProfilesFile = configFile, profilesFile
+	opts.AccessLog = slog.New(logHandler(o.logFormat, os.Stderr))
+	if o.logFormat == "json" {
+		// The startup lines and warnings become JSON records too
+		slog.SetDefault(opts.AccessLog)
+	}
+	srv := server.New(opts)
 
-	addr := fmt.Sprintf(":%d", port)
-	log.Printf("SlimJSON daemon starting on http://localhost%s", addr)
+	log.Printf("SlimJSON %s daemon starting on %s with %d built-in and %d custom profiles",
+		getBuildInfo(), daemonURL(opts), len(slimjson.GetBuiltinProfiles()), len(customProfiles))
 	log.Printf("Endpoints:")
 	log.Printf("  POST /slim?profile=<name>  - Compress JSON")
+	log.Printf("  POST /slim/ndjson          - Compress NDJSON line by line")
+	log.Printf("  POST /slim/batch           - Compress a batch of documents")
+	log.Printf("  POST /unslim               - Restore compressed JSON")
 	log.Printf("  GET  /health               - Health check")
 	log.Printf("  GET  /profiles             - List profiles")
-	log.Printf("Available profiles: %d built-in, %d custom", len(slimjson.GetBuiltinProfiles()), len(customProfiles))
+	log.Printf("  GET, PUT, DELETE /profiles/{name} - Manage profiles")
+	log.Printf("  POST /reload               - Reload profiles from the config file (also on SIGHUP)")
+	if opts.AuthToken != "" {
+		log.Printf("Requests other than /health require a bearer token")
+	}
+	if len(opts.CORSOrigins) > 0 {
+		log.Printf("CORS origins: %s", strings.Join(opts.CORSOrigins, ", "))
+	}
+	if opts.AuthToken == "" {
+		log.Printf("Profiles cannot be changed through the API without -auth-token")
+	} else if opts.ProfilesFile != "" {
+		log.Printf("Profile changes are saved to %s", opts.ProfilesFile)
+	}
 
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		log.Printf("Shutting down, waiting for requests in flight")
+	}()
+
+	// Reload logs its outcome
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for range hup {
+			_, _ = srv.Reload()
+		}
+	}()
+	if err := srv.ListenAndServe(ctx); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
+	log.Printf("Stopped")
 }
 
 func main() {
-	var (
-		daemon                   bool
-		configFile               string
-		port                     int
-		profile                  string
-		maxDepth                 int
-		maxListLength            int
-		maxStringLength          int
-		stripEmpty               bool
-		blockList                string
-		pretty                   bool
-		decimalPlaces            int
-		deduplicateArrays        bool
-		sampleStrategy           string
-		sampleSize               int
-		nullCompression          bool
-		typeInference            bool
-		boolCompression          bool
-		timestampCompression     bool
-		stringPooling            bool
-		stringPoolMinOccurrences int
-		numberDeltaEncoding      bool
-		numberDeltaThreshold     int
-		enumDetection            bool
-		enumMaxValues            int
-		stripUTF8Emoji           bool
-	)
-
-	flag.BoolVar(&daemon, "d", false, "Run as HTTP daemon")
-	flag.BoolVar(&daemon, "daemon", false, "Run as HTTP daemon")
-	flag.StringVar(&configFile, "c", "", "Path to custom config file")
-	flag.StringVar(&configFile, "config", "", "Path to custom config file")
-	flag.IntVar(&port, "port", 8080, "Port for daemon mode")
-	flag.StringVar(&profile, "profile", "", "Use predefined profile: light, medium, aggressive, ai-optimized")
-	flag.IntVar(&maxDepth, "depth", 5, "Maximum nesting depth (0 for unlimited)")
-	flag.IntVar(&maxListLength, "list-len", 10, "Maximum list length (0 for unlimited)")
-	flag.IntVar(&maxStringLength, "string-len", 0, "Maximum string length in characters/runes (0 for unlimited)")
-	flag.BoolVar(&stripEmpty, "strip-empty", true, "Remove nulls, empty strings, empty arrays/objects")
-	flag.StringVar(&blockList, "block", "", "Comma-separated list of field names to remove")
-	flag.BoolVar(&pretty, "pretty", false, "Pretty print output")
-	flag.IntVar(&decimalPlaces, "decimal-places", -1, "Round floats to N decimal places (-1 for no rounding)")
-	flag.BoolVar(&deduplicateArrays, "deduplicate", false, "Remove duplicate values from arrays")
-	flag.StringVar(&sampleStrategy, "sample-strategy", "none", "Array sampling: none, first_last, random, representative")
-	flag.IntVar(&sampleSize, "sample-size", 0, "Number of items when sampling (0 = use list-len)")
-	flag.BoolVar(&nullCompression, "null-compression", false, "Track removed null fields in _nulls array")
-	flag.BoolVar(&typeInference, "type-inference", false, "Convert uniform arrays to schema+data format")
-	flag.BoolVar(&boolCompression, "bool-compression", false, "Convert booleans to bit flags")
-	flag.BoolVar(&timestampCompression, "timestamp-compression", false, "Convert ISO timestamps to unix timestamps")
-	flag.BoolVar(&stringPooling, "string-pooling", false, "Deduplicate repeated strings using string pool")
-	flag.IntVar(&stringPoolMinOccurrences, "string-pool-min", 2, "Minimum occurrences for string pooling")
-	flag.BoolVar(&numberDeltaEncoding, "number-delta", false, "Use delta encoding for sequential numbers")
-	flag.IntVar(&numberDeltaThreshold, "number-delta-threshold", 5, "Minimum array size for delta encoding")
-	flag.BoolVar(&enumDetection, "enum-detection", false, "Convert repeated categorical values to enums")
-	flag.IntVar(&enumMaxValues, "enum-max-values", 10, "Maximum unique values to consider as enum")
-	flag.BoolVar(&stripUTF8Emoji, "strip-emoji", false, "Remove emoji and non-ASCII characters from strings")
+	o := &options{}
+	defineFlags(flag.CommandLine, o)
 
 	// Custom usage message
 	flag.Usage = printUsage
@@ -260,39 +501,194 @@ func main() {
 	flag.Parse()
 
 	// Show help if no arguments and not daemon mode
-	if !daemon && len(os.Args) == 1 {
+	if !o.daemon && len(os.Args) == 1 {
 		printUsage()
 		os.Exit(0)
 	}
 
+	if o.version {
+		printVersion(os.Stdout)
+		return
+	}
+
+	// "slimjson init" is the same as -init, with its flags after the subcommand
+	if flag.NArg() > 0 && flag.Arg(0) == "init" {
+		if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil || flag.NArg() > 0 {
+			fmt.Fprintln(os.Stderr, "Error: init takes no arguments besides -force")
+			os.Exit(1)
+		}
+		o.initConfig = true
+	}
+	if o.initConfig {
+		path, err := initConfig(".", o.force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to write config file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+		return
+	}
+	if o.checkConfig {
+		if !checkConfig(os.Stdout, o.configFile) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load custom profiles from config file
-	var customProfiles map[string]slimjson.Config
+	var profiles map[string]slimjson.Profile
 	var err error
 
-	if configFile != "" {
+	if o.configFile != "" {
 		// Priority: use specified config file
-		customProfiles, err = slimjson.ParseConfigFile(configFile)
+		profiles, err = slimjson.ParseProfiles(o.configFile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to load config file %s: %v\n", configFile, err)
+			fmt.Fprintf(os.Stderr, "Error: Failed to load config file %s: %v\n", o.configFile, err)
 			os.Exit(1)
 		}
 	} else {
-		// Fallback: search for .slimjson in current dir and home dir
-		customProfiles, err = slimjson.LoadConfigFile()
+		// Fallback: search for .slimjson in current dir, its parents and home dir
+		profiles, err = slimjson.LoadProfiles()
 		if err != nil {
 			// Not an error if file doesn't exist
-			customProfiles = make(map[string]slimjson.Config)
+			profiles = make(map[string]slimjson.Profile)
 		}
 	}
+	if o.verbose {
+		printConfigSource(os.Stderr, o.configFile, err)
+	}
+	customProfiles := make(map[string]slimjson.Config, len(profiles))
+	for name, p := range profiles {
+		customProfiles[name] = p.Config
+	}
+
+	if o.list {
+		listProfiles(os.Stdout, profiles)
+		return
+	}
 
 	// Run daemon mode if requested
-	if daemon {
-		runDaemon(port, customProfiles)
+	if o.daemon {
+		if err := o.checkDaemon(flag.CommandLine); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := validateProfiles(customProfiles); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		var profilesFile string
+		if o.persistProfiles {
+			if profilesFile, err = persistedProfilesFile(o.configFile, err); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		configFile := o.configFile
+		if configFile == "" {
+			configFile = slimjson.ConfigSource()
+		}
+		runDaemon(o, profiles, configFile, profilesFile)
+		return
+	}
+
+	cfg, err := o.flagConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Lossless {
+		cfg = losslessConfig(cfg, flag.CommandLine)
+	}
+
+	// Apply profile if specified, overriding it with explicitly set flags
+	if o.profile != "" {
+		cfg = getProfile(o.profile, customProfiles).Merge(cfg, overrideKeys(flag.CommandLine))
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid config: %s\n", oneLine(err))
+		os.Exit(1)
+	}
+
+	if o.saveAs != "" {
+		if err := slimjson.SaveProfile(".slimjson", o.saveAs, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to save profile %s: %v\n", o.saveAs, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Saved profile %s to .slimjson\n", o.saveAs)
+		return
+	}
+
+	// Several files, or files written to disk, are processed in batch mode
+	args, err := expandArgs(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := o.checkURL(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// A URL is fetched and then read like stdin
+	var input io.Reader = os.Stdin
+	if len(args) == 1 && isURL(args[0]) {
+		body, mediaType, err := fetchURL(args[0], o.timeout, http.Header(o.headers))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = body.Close() }()
+		if o.inFormat == "" && isYAMLMediaType(mediaType) {
+			o.inFormat = "yaml"
+		}
+		input, args = body, nil
+	}
+	if o.inFormat == "" && len(args) == 1 && isYAMLName(args[0]) {
+		o.inFormat = "yaml"
+	}
+	if err := o.checkFormats(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if o.decompress || o.unslim {
+		if err := o.checkDecompress(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if o.watch {
+		if err := o.checkWatch(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		o.stats = true
+		o.watchFiles(ctx, args, cfg, os.Stdout, os.Stderr, watchInterval)
+		return
+	}
+	if len(args) > 1 || o.inPlace || o.outDir != "" || o.output != "" || o.suffix != "" {
+		if err := o.checkBatch(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(args) == 0 {
+			if err := slimToFile(input, o.output, "slimjson", o, cfg, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(max(budgetCode(err), 1))
+			}
+			return
+		}
+		if failed := processFiles(args, o, cfg, os.Stdout, os.Stderr); failed > 0 {
+			fmt.Fprintf(os.Stderr, "Error: %d of %d files failed\n", failed, len(args))
+			os.Exit(1)
+		}
+		if o.budgetExit != 0 {
+			os.Exit(o.budgetExit)
+		}
 		return
 	}
 
-	var input io.Reader
-	args := flag.Args()
 	if len(args) > 0 {
 		f, err := os.Open(args[0])
 		if err != nil {
@@ -301,100 +697,160 @@ func main() {
 		}
 		defer func() { _ = f.Close() }()
 		input = f
-	} else {
-		input = os.Stdin
 	}
 
-	decoder := json.NewDecoder(input)
-	var data interface{}
-	if err := decoder.Decode(&data); err != nil {
-		if err == io.EOF {
-			return
+	if o.recommend {
+		input, err := gunzip(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(1)
 		}
-		fmt.Fprintf(os.Stderr, "Error decoding JSON: %v\n", err)
-		os.Exit(1)
+		if err := recommendInput(input, os.Stdout, os.Stderr, o.recommendTarget, o.pretty); err != nil && err != io.EOF {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	// Apply profile if specified
-	var cfg slimjson.Config
-	if profile != "" {
-		cfg = getProfile(profile, customProfiles)
-		// Allow overriding profile settings with explicit flags
-		if decimalPlaces >= 0 {
-			cfg.DecimalPlaces = decimalPlaces
-		}
-		if deduplicateArrays {
-			cfg.DeduplicateArrays = deduplicateArrays
+	if o.analyze {
+		input, err := gunzip(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(1)
 		}
-		if sampleStrategy != "none" {
-			cfg.SampleStrategy = sampleStrategy
-			cfg.SampleSize = sampleSize
+		if err := analyzeInput(input, os.Stdout, o.pretty); err != nil && err != io.EOF {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(1)
 		}
-		// Apply advanced optimizations if specified
-		if nullCompression {
-			cfg.NullCompression = nullCompression
+		return
+	}
+
+	if o.diff && o.explain != "" {
+		fmt.Fprintf(os.Stderr, "Error: -diff cannot be combined with -explain\n")
+		os.Exit(1)
+	}
+	if o.diff {
+		input, err := gunzip(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(1)
 		}
-		if typeInference {
-			cfg.TypeInference = typeInference
+		if err := diffInput(input, os.Stdout, cfg, o.diffFormat, o.pretty); err != nil && err != io.EOF {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			os.Exit(1)
 		}
-		if boolCompression {
-			cfg.BoolCompression = boolCompression
+		return
+	}
+
+	if err := o.slimCompressed(input, os.Stdout, os.Stderr, "slimjson", cfg, o.gzipOut); err != nil {
+		if err == io.EOF {
+			return
 		}
-		if timestampCompression {
-			cfg.TimestampCompression = timestampCompression
+		if code := budgetCode(err); code != 0 {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(code)
 		}
-		if stringPooling {
-			cfg.StringPooling = stringPooling
-			cfg.StringPoolMinOccurrences = stringPoolMinOccurrences
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// slimInput slims the JSON documents read from in and writes them to out in
+// order. With stream, concatenated documents are slimmed one after another:
+// a document that fails to parse is skipped up to the next line starting with
+// { or [, and its error is returned once the rest were written. Without
+// stream, data after the first document is an error.
+// If st is non-nil, the input and output sizes are added to it.
+func slimInput(in io.Reader, out io.Writer, st *runStats, cfg slimjson.Config, pretty, stream bool) error {
+	// Keep a copy of the raw input only when it is needed for stats
+	var raw bytes.Buffer
+	if st != nil {
+		in = io.TeeReader(in, &raw)
+	}
+
+	slimmer := slimjson.New(cfg)
+	br := bufio.NewReader(in)
+	dec := json.NewDecoder(br)
+	var errs []error
+	var slimBytes, slimTokens int
+	for n := 1; ; n++ {
+		var buf bytes.Buffer
+		if err := slimmer.SlimNext(dec, &buf); err != nil {
+			if err == io.EOF {
+				if n == 1 {
+					return err
+				}
+				break
+			}
+			if !stream {
+				return fmt.Errorf("processing JSON: %w", err)
+			}
+			errs = append(errs, fmt.Errorf("processing JSON document %d: %w", n, err))
+			br = skipDocument(dec, br)
+			dec = json.NewDecoder(br)
+			continue
 		}
-		if numberDeltaEncoding {
-			cfg.NumberDeltaEncoding = numberDeltaEncoding
-			cfg.NumberDeltaThreshold = numberDeltaThreshold
+		if !stream {
+			if _, err := dec.Token(); err != io.EOF {
+				return fmt.Errorf("unexpected data after JSON document (use -stream to slim each document)")
+			}
 		}
-		if enumDetection {
-			cfg.EnumDetection = enumDetection
-			cfg.EnumMaxValues = enumMaxValues
+
+		if pretty {
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+				return fmt.Errorf("encoding JSON: %w", err)
+			}
+			buf = indented
 		}
-		if stripUTF8Emoji {
-			cfg.StripUTF8Emoji = stripUTF8Emoji
+		if _, err := out.Write(buf.Bytes()); err != nil {
+			return fmt.Errorf("writing output: %w", err)
 		}
-	} else {
-		// Use custom parameters
-		cfg = slimjson.Config{
-			MaxDepth:                 maxDepth,
-			MaxListLength:            maxListLength,
-			MaxStringLength:          maxStringLength,
-			StripEmpty:               stripEmpty,
-			DecimalPlaces:            decimalPlaces,
-			DeduplicateArrays:        deduplicateArrays,
-			SampleStrategy:           sampleStrategy,
-			SampleSize:               sampleSize,
-			NullCompression:          nullCompression,
-			TypeInference:            typeInference,
-			BoolCompression:          boolCompression,
-			TimestampCompression:     timestampCompression,
-			StringPooling:            stringPooling,
-			StringPoolMinOccurrences: stringPoolMinOccurrences,
-			NumberDeltaEncoding:      numberDeltaEncoding,
-			NumberDeltaThreshold:     numberDeltaThreshold,
-			EnumDetection:            enumDetection,
-			EnumMaxValues:            enumMaxValues,
-			StripUTF8Emoji:           stripUTF8Emoji,
-		}
-		if blockList != "" {
-			cfg.BlockList = strings.Split(blockList, ",")
+		slimBytes += buf.Len()
+		slimTokens += slimjson.EstimateTokens(buf.Bytes())
+		if !stream {
+			break
 		}
 	}
 
-	slimmer := slimjson.New(cfg)
-	result := slimmer.Slim(data)
+	if st != nil {
+		st.origBytes, st.origTokens = raw.Len(), slimjson.EstimateTokens(raw.Bytes())
+		st.slimBytes, st.slimTokens = slimBytes, slimTokens
+	}
+	return errors.Join(errs...)
+}
 
-	encoder := json.NewEncoder(os.Stdout)
-	if pretty {
-		encoder.SetIndent("", "  ")
+// skipDocument returns the input after a document dec failed to parse,
+// starting at the next line that begins with { or [
+func skipDocument(dec *json.Decoder, br *bufio.Reader) *bufio.Reader {
+	rest := bufio.NewReader(io.MultiReader(dec.Buffered(), br))
+	for {
+		_, err := rest.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return rest
+		}
+		if b, err := rest.Peek(1); err == nil && (b[0] == '{' || b[0] == '[') {
+			return rest
+		}
 	}
-	if err := encoder.Encode(result); err != nil {
-		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-		os.Exit(1)
+}
+
+// printConfigSource writes the config file in use: configFile if set, otherwise
+// the file found by LoadProfiles. loadErr is the error loading the found file.
+func printConfigSource(w io.Writer, configFile string, loadErr error) {
+	source := configFile
+	if source == "" {
+		source = slimjson.ConfigSource()
+	}
+	switch {
+	case source == "":
+		_, _ = fmt.Fprintln(w, "slimjson: no config file found")
+	case loadErr != nil:
+		_, _ = fmt.Fprintf(w, "slimjson: ignoring config file %s: %v\n", source, loadErr)
+	default:
+		_, _ = fmt.Fprintf(w, "slimjson: using config file %s\n", source)
 	}
 }
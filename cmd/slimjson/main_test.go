@@ -2,204 +2,41 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/tradik/slimjson"
+	"github.com/tradik/slimjson/server"
 )
 
-func TestHealthEndpoint(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/health", nil)
-	w := httptest.NewRecorder()
-
-	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status":"ok","version":"1.0"}`))
-	})
-
-	handler.ServeHTTP(w, req)
-
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
-	}
-
-	var response map[string]string
-	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
-		t.Fatalf("Failed to decode response: %v", err)
-	}
-
-	if response["status"] != "ok" {
-		t.Errorf("Expected status 'ok', got '%s'", response["status"])
-	}
-
-	if response["version"] != "1.0" {
-		t.Errorf("Expected version '1.0', got '%s'", response["version"])
-	}
-}
-
-func TestProfilesEndpoint(t *testing.T) {
-	customProfiles := map[string]slimjson.Config{
-		"test-profile": {
-			MaxDepth:      3,
-			MaxListLength: 5,
-			StripEmpty:    true,
-		},
+func TestValidateProfiles(t *testing.T) {
+	if err := validateProfiles(slimjson.GetBuiltinProfiles()); err != nil {
+		t.Errorf("Built-in profiles should be valid: %v", err)
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/profiles", nil)
-	w := httptest.NewRecorder()
-
-	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-
-		profiles := make(map[string][]string)
-		profiles["builtin"] = []string{"light", "medium", "aggressive", "ai-optimized"}
-		profiles["custom"] = make([]string, 0)
-
-		for name := range customProfiles {
-			profiles["custom"] = append(profiles["custom"], name)
-		}
-
-		_ = json.NewEncoder(w).Encode(profiles)
+	err := validateProfiles(map[string]slimjson.Config{
+		"ok":  {MaxDepth: 3},
+		"bad": {MaxDepth: -1, SampleStrategy: "frist_last"},
 	})
-
-	handler.ServeHTTP(w, req)
-
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
-	}
-
-	var response map[string][]string
-	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
-		t.Fatalf("Failed to decode response: %v", err)
-	}
-
-	if len(response["builtin"]) != 4 {
-		t.Errorf("Expected 4 built-in profiles, got %d", len(response["builtin"]))
-	}
-
-	if len(response["custom"]) != 1 {
-		t.Errorf("Expected 1 custom profile, got %d", len(response["custom"]))
+	if err == nil {
+		t.Fatal("Expected an error for an invalid profile")
 	}
-}
-
-func TestSlimEndpoint(t *testing.T) {
-	allProfiles := slimjson.GetBuiltinProfiles()
-
-	tests := []struct {
-		name           string
-		method         string
-		profile        string
-		input          string
-		expectedStatus int
-		checkResult    bool
-	}{
-		{
-			name:           "Valid request with medium profile",
-			method:         http.MethodPost,
-			profile:        "medium",
-			input:          `{"users":[{"id":1,"name":"Alice"},{"id":2,"name":"Bob"}],"prices":[19.999,29.123]}`,
-			expectedStatus: http.StatusOK,
-			checkResult:    true,
-		},
-		{
-			name:           "Valid request without profile",
-			method:         http.MethodPost,
-			profile:        "",
-			input:          `{"test":"data"}`,
-			expectedStatus: http.StatusOK,
-			checkResult:    true,
-		},
-		{
-			name:           "Invalid method GET",
-			method:         http.MethodGet,
-			profile:        "",
-			input:          `{}`,
-			expectedStatus: http.StatusMethodNotAllowed,
-			checkResult:    false,
-		},
-		{
-			name:           "Invalid JSON",
-			method:         http.MethodPost,
-			profile:        "",
-			input:          `{invalid json}`,
-			expectedStatus: http.StatusBadRequest,
-			checkResult:    false,
-		},
-		{
-			name:           "Unknown profile",
-			method:         http.MethodPost,
-			profile:        "nonexistent",
-			input:          `{"test":"data"}`,
-			expectedStatus: http.StatusBadRequest,
-			checkResult:    false,
-		},
+	for _, want := range []string{"profile bad:", "max-depth", "frist_last"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %q", want, err)
+		}
 	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			url := "/slim"
-			if tt.profile != "" {
-				url += "?profile=" + tt.profile
-			}
-
-			req := httptest.NewRequest(tt.method, url, bytes.NewBufferString(tt.input))
-			req.Header.Set("Content-Type", "application/json")
-			w := httptest.NewRecorder()
-
-			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodPost {
-					http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-					return
-				}
-
-				profileName := r.URL.Query().Get("profile")
-
-				var cfg slimjson.Config
-				if profileName != "" {
-					var ok bool
-					cfg, ok = allProfiles[profileName]
-					if !ok {
-						http.Error(w, "Unknown profile", http.StatusBadRequest)
-						return
-					}
-				} else {
-					cfg = slimjson.Config{
-						MaxDepth:      5,
-						MaxListLength: 10,
-						StripEmpty:    true,
-					}
-				}
-
-				var data interface{}
-				if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-					http.Error(w, "Invalid JSON", http.StatusBadRequest)
-					return
-				}
-
-				slimmer := slimjson.New(cfg)
-				result := slimmer.Slim(data)
-
-				w.Header().Set("Content-Type", "application/json")
-				_ = json.NewEncoder(w).Encode(result)
-			})
-
-			handler.ServeHTTP(w, req)
-
-			if w.Code != tt.expectedStatus {
-				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
-			}
-
-			if tt.checkResult && w.Code == http.StatusOK {
-				var result interface{}
-				if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
-					t.Errorf("Failed to decode result: %v", err)
-				}
-			}
-		})
+	if strings.Contains(err.Error(), "\n") {
+		t.Errorf("Expected a single line, got %q", err)
 	}
 }
 
@@ -291,3 +128,256 @@ func TestConfigFilePriority(t *testing.T) {
 		}
 	})
 }
+
+func TestSlimInputStats(t *testing.T) {
+	input := `{"name": "test", "empty": "", "tags": [], "nested": {"note": null}}`
+	cfg := slimjson.Config{MaxDepth: 5, StripEmpty: true, DecimalPlaces: -1}
+
+	t.Run("Stats on separate writer", func(t *testing.T) {
+		var out, stats bytes.Buffer
+		if err := (&options{stats: true}).slim(strings.NewReader(input), &out, &stats, "slimjson", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+
+		if got := out.String(); got != "{\"name\":\"test\"}\n" {
+			t.Errorf("Expected pure JSON output, got %q", got)
+		}
+
+		want := regexp.MustCompile(`^slimjson: (\d+) -> (\d+) bytes \((\d+\.\d)% reduction\), ~(\d+) -> ~(\d+) tokens \((\d+\.\d)% reduction\), (\d+) fields blocked, (\d+) arrays truncated, (\d+) subtrees cut by depth \(~(\d+) bytes\), \S+s\n$`)
+		m := want.FindStringSubmatch(stats.String())
+		if m == nil {
+			t.Fatalf("Unexpected stats line: %q", stats.String())
+		}
+		if m[1] != strconv.Itoa(len(input)) {
+			t.Errorf("Expected original size %d, got %s", len(input), m[1])
+		}
+		if m[2] != strconv.Itoa(out.Len()) {
+			t.Errorf("Expected compressed size %d, got %s", out.Len(), m[2])
+		}
+		if m[3] != fmt.Sprintf("%.1f", float64(len(input)-out.Len())/float64(len(input))*100) {
+			t.Errorf("Unexpected reduction percentage %s", m[3])
+		}
+	})
+
+	t.Run("Blocked fields and truncated arrays", func(t *testing.T) {
+		input := `{"token": "x", "user": {"password": "y", "ids": [1, 2, 3, 4]}, "tags": ["a", "b", "c"]}`
+		cfg := slimjson.Config{BlockList: []string{"token", "password"}, MaxListLength: 2, DecimalPlaces: -1}
+		var out, stats bytes.Buffer
+		if err := (&options{stats: true}).slim(strings.NewReader(input), &out, &stats, "slimjson", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+		if !strings.Contains(stats.String(), ", 2 fields blocked, 2 arrays truncated, ") {
+			t.Errorf("Unexpected stats line: %q", stats.String())
+		}
+	})
+
+	t.Run("Subtrees cut by depth", func(t *testing.T) {
+		input := `{"id": 1, "user": {"name": "a", "address": {"city": "x", "zip": "12345"}, "tags": ["a", "b"]}}`
+		cfg := slimjson.Config{MaxDepth: 2, ReportDepthElision: true, StripEmpty: true, DecimalPlaces: -1}
+		var out, stats bytes.Buffer
+		if err := (&options{stats: true}).slim(strings.NewReader(input), &out, &stats, "slimjson", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+		// name, address and tags are cut: "a" (3 bytes), the address (26) and the tags (9)
+		if !strings.Contains(stats.String(), ", 3 subtrees cut by depth (~38 bytes), ") {
+			t.Errorf("Unexpected stats line: %q", stats.String())
+		}
+		if want := `{"id":1,"user":{"address":{"_depth_elided":2},"tags":{"_depth_elided":2}}}` + "\n"; out.String() != want {
+			t.Errorf("Expected %q, got %q", want, out.String())
+		}
+	})
+
+	t.Run("No stats", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := slimInput(strings.NewReader(input), &out, nil, cfg, false, false); err != nil {
+			t.Fatalf("slimInput() error: %v", err)
+		}
+		if out.Len() == 0 {
+			t.Error("Expected output")
+		}
+	})
+}
+
+func TestSlimConcatenated(t *testing.T) {
+	cfg := slimjson.Config{StripEmpty: true, DecimalPlaces: -1}
+	docs := []string{"{\n  \"id\": 1,\n  \"tags\": []\n}\n", "{\n  \"id\": 2\n}\n", "[\n  3,\n  null\n]"}
+
+	t.Run("Three documents piped", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		go func() {
+			for _, doc := range docs {
+				_, _ = io.WriteString(pw, doc)
+			}
+			_ = pw.Close()
+		}()
+		var out bytes.Buffer
+		if err := (&options{stream: true}).slim(pr, &out, nil, "slimjson", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+		if expected := "{\"id\":1}\n{\"id\":2}\n[3]\n"; out.String() != expected {
+			t.Errorf("Output = %q, want %q", out.String(), expected)
+		}
+	})
+
+	t.Run("Pretty output", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := (&options{stream: true, pretty: true}).slim(strings.NewReader(`{"a":1}{"b":2}`), &out, nil, "slimjson", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+		if expected := "{\n  \"a\": 1\n}\n{\n  \"b\": 2\n}\n"; out.String() != expected {
+			t.Errorf("Output = %q, want %q", out.String(), expected)
+		}
+	})
+
+	t.Run("Invalid document is reported after the rest", func(t *testing.T) {
+		input := docs[0] + "{\n  \"id\": \n}\n" + docs[1]
+		var out bytes.Buffer
+		err := (&options{stream: true}).slim(strings.NewReader(input), &out, nil, "slimjson", cfg)
+		if err == nil || !strings.Contains(err.Error(), "document 2") {
+			t.Errorf("Expected an error for document 2, got %v", err)
+		}
+		if expected := "{\"id\":1}\n{\"id\":2}\n"; out.String() != expected {
+			t.Errorf("Output = %q, want %q", out.String(), expected)
+		}
+	})
+
+	t.Run("Trailing data without -stream", func(t *testing.T) {
+		var out bytes.Buffer
+		err := (&options{}).slim(strings.NewReader(docs[0]+docs[1]), &out, nil, "slimjson", cfg)
+		if err == nil || !strings.Contains(err.Error(), "unexpected data after JSON document") {
+			t.Errorf("Expected a trailing data error, got %v", err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("Expected no output, got %q", out.String())
+		}
+		if err := (&options{}).slim(strings.NewReader(docs[0]+"\n  \n"), &out, nil, "slimjson", cfg); err != nil {
+			t.Errorf("Expected trailing whitespace to be accepted, got %v", err)
+		}
+	})
+}
+
+func TestPrintConfigSource(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if _, err := slimjson.LoadProfiles(); err != nil {
+		t.Fatalf("LoadProfiles() error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		configFile string
+		loadErr    error
+		expected   string
+	}{
+		{name: "None found", expected: "slimjson: no config file found\n"},
+		{name: "Flag", configFile: "custom.slimjson", expected: "slimjson: using config file custom.slimjson\n"},
+		{name: "Load error", configFile: "bad.slimjson", loadErr: fmt.Errorf("invalid syntax"), expected: "slimjson: ignoring config file bad.slimjson: invalid syntax\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printConfigSource(&buf, tt.configFile, tt.loadErr)
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestDaemonOptions(t *testing.T) {
+	t.Setenv("SLIMJSON_AUTH_TOKEN", "")
+	fs := flag.NewFlagSet("slimjson", flag.ContinueOnError)
+	o := &options{}
+	defineFlags(fs, o)
+	if err := fs.Parse([]string{"-d", "-port", "9000", "-max-body-size", "64", "-read-timeout", "5s", "-shutdown-timeout", "2s", "-max-header-bytes", "4096", "-max-concurrent", "8", "-request-timeout", "10s"}); err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	opts := daemonOptions(o, nil)
+	if opts.Addr != ":9000" || opts.MaxBodyBytes != 64 || opts.MaxHeaderBytes != 4096 {
+		t.Errorf("Addr, MaxBodyBytes, MaxHeaderBytes = %q, %d, %d, want \":9000\", 64, 4096", opts.Addr, opts.MaxBodyBytes, opts.MaxHeaderBytes)
+	}
+	if opts.ReadTimeout != 5*time.Second || opts.WriteTimeout != time.Minute || opts.IdleTimeout != 2*time.Minute || opts.ShutdownTimeout != 2*time.Second {
+		t.Errorf("Timeouts = %v, %v, %v, %v, want 5s, 1m0s, 2m0s, 2s", opts.ReadTimeout, opts.WriteTimeout, opts.IdleTimeout, opts.ShutdownTimeout)
+	}
+
+	if opts.MaxConcurrent != 8 || opts.RequestTimeout != 10*time.Second {
+		t.Errorf("MaxConcurrent, RequestTimeout = %d, %v, want 8, 10s", opts.MaxConcurrent, opts.RequestTimeout)
+	}
+	if opts.AuthToken != "" || opts.CORSOrigins != nil {
+		t.Errorf("AuthToken, CORSOrigins = %q, %q, want none", opts.AuthToken, opts.CORSOrigins)
+	}
+
+	auth := &options{}
+	defineFlags(flag.NewFlagSet("slimjson", flag.ContinueOnError), auth)
+	t.Setenv("SLIMJSON_AUTH_TOKEN", "from-env")
+	if opts := daemonOptions(auth, nil); opts.AuthToken != "from-env" {
+		t.Errorf("AuthToken = %q, want it from SLIMJSON_AUTH_TOKEN", opts.AuthToken)
+	}
+	auth.authToken, auth.corsOrigins = "from-flag", "https://a.example.com, https://b.example.com,"
+	withAuth := daemonOptions(auth, nil)
+	if withAuth.AuthToken != "from-flag" || !slices.Equal(withAuth.CORSOrigins, []string{"https://a.example.com", "https://b.example.com"}) {
+		t.Errorf("AuthToken, CORSOrigins = %q, %q, want the flag token and two origins", withAuth.AuthToken, withAuth.CORSOrigins)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/slim", strings.NewReader(`{"data": "`+strings.Repeat("x", 100)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.New(opts).ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for a body over -max-body-size, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCheckDaemon(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+		url  string
+	}{
+		{"Port", []string{"-port", "9000"}, "", "http://localhost:9000"},
+		{"TCP address", []string{"-listen", "127.0.0.1:9000"}, "", "http://127.0.0.1:9000"},
+		{"Unix socket", []string{"-listen", "unix:///run/slimjson.sock", "-socket-mode", "0660"}, "", "unix:///run/slimjson.sock"},
+		{"TLS", []string{"-tls-cert", "cert.pem", "-tls-key", "key.pem"}, "", "https://localhost:8080"},
+		{"Listen with port", []string{"-listen", ":9000", "-port", "9000"}, "cannot be combined with -port", ""},
+		{"Invalid address", []string{"-listen", "localhost"}, "invalid -listen", ""},
+		{"Socket without path", []string{"-listen", "unix://"}, "requires a socket path", ""},
+		{"Certificate without key", []string{"-tls-cert", "cert.pem"}, "must be given together", ""},
+		{"Socket mode without socket", []string{"-socket-mode", "600"}, "requires -listen unix", ""},
+		{"JSON log", []string{"-log-format", "json"}, "", "http://localhost:8080"},
+		{"Invalid log format", []string{"-log-format", "xml"}, "invalid -log-format", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("slimjson", flag.ContinueOnError)
+			o := &options{}
+			defineFlags(fs, o)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			err := o.checkDaemon(fs)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("checkDaemon() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkDaemon() error: %v", err)
+			}
+			if got := daemonURL(daemonOptions(o, nil)); got != tt.url {
+				t.Errorf("daemonURL() = %q, want %q", got, tt.url)
+			}
+		})
+	}
+
+	fs := flag.NewFlagSet("slimjson", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	defineFlags(fs, &options{})
+	if err := fs.Parse([]string{"-socket-mode", "rw"}); err == nil {
+		t.Errorf("Expected -socket-mode rw to be rejected")
+	}
+}
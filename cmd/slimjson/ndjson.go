@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/tradik/slimjson"
+)
+
+// slimNDJSON slims newline-delimited JSON with Slimmer.SlimLines: each
+// non-blank line of in is slimmed independently and written to out as one line.
+// Decoding stops at the first invalid line, reporting its line number.
+// If st is non-nil, the sizes of the non-blank lines are added to it.
+func slimNDJSON(in io.Reader, out io.Writer, st *runStats, cfg slimjson.Config) error {
+	if st == nil {
+		return slimjson.New(cfg).SlimLines(in, out)
+	}
+
+	var orig, slim lineStats
+	err := slimjson.New(cfg).SlimLines(io.TeeReader(in, &orig), io.MultiWriter(out, &slim))
+	orig.flush()
+	st.origBytes, st.origTokens = orig.bytes, orig.tokens
+	st.slimBytes, st.slimTokens = slim.bytes, slim.tokens
+	return err
+}
+
+// lineStats counts the bytes and estimated tokens of the non-blank lines written to it
+type lineStats struct {
+	partial       []byte // Start of a line not terminated yet
+	bytes, tokens int
+}
+
+func (ls *lineStats) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			ls.partial = append(ls.partial, p...)
+			break
+		}
+		ls.partial = append(ls.partial, p[:i+1]...)
+		ls.flush()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// flush counts the pending line, if it is not blank
+func (ls *lineStats) flush() {
+	if trimmed := bytes.TrimSpace(ls.partial); len(trimmed) > 0 {
+		ls.bytes += len(ls.partial)
+		ls.tokens += slimjson.EstimateTokens(trimmed)
+	}
+	ls.partial = ls.partial[:0]
+}
+
+// looksLikeNDJSON reports whether the first two non-blank lines of br each
+// parse as a standalone JSON value. It only peeks at br, reading no further
+// than the end of the second line, so streamed input is not waited on.
+func looksLikeNDJSON(br *bufio.Reader) bool {
+	lines, start := 0, 0
+	for n := 1; ; n++ {
+		buf, err := br.Peek(n)
+		if err != nil {
+			// The second line may end the input without a newline
+			line := bytes.TrimSpace(buf[min(start, len(buf)):])
+			return err == io.EOF && lines == 1 && json.Valid(line)
+		}
+		if buf[n-1] != '\n' {
+			continue
+		}
+		line := bytes.TrimSpace(buf[start:n])
+		start = n
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return false
+		}
+		if lines++; lines == 2 {
+			return true
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestSlimNDJSON(t *testing.T) {
+	cfg := slimjson.Config{StripEmpty: true, DecimalPlaces: -1, BlockList: []string{"token"}}
+
+	t.Run("Three records with blank lines", func(t *testing.T) {
+		input := "{\"id\": 1, \"token\": \"x\"}\n\n{\"id\": 2, \"tags\": []}\r\n   \n{\"id\": 3, \"name\": \"c\"}"
+		var out bytes.Buffer
+		o := &options{ndjson: true}
+		if err := o.slim(strings.NewReader(input), &out, nil, "slimjson", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+
+		expected := "{\"id\":1}\n{\"id\":2}\n{\"id\":3,\"name\":\"c\"}\n"
+		if out.String() != expected {
+			t.Errorf("Output = %q, want %q", out.String(), expected)
+		}
+	})
+
+	t.Run("Decode error reports line number", func(t *testing.T) {
+		input := "{\"id\": 1}\n\n{\"id\": \n{\"id\": 3}\n"
+		var out bytes.Buffer
+		err := slimNDJSON(strings.NewReader(input), &out, nil, cfg)
+		if err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Errorf("Expected error for line 3, got %v", err)
+		}
+		if out.String() != "{\"id\":1}\n" {
+			t.Errorf("Expected records before the error to be written, got %q", out.String())
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		var out, stats bytes.Buffer
+		o := &options{ndjson: true, stats: true}
+		if err := o.slim(strings.NewReader("{\"a\": \"\"}\n{\"b\": 1}\n"), &out, &stats, "events.ndjson", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+		if !strings.HasPrefix(stats.String(), "events.ndjson: 19 -> 13 bytes") {
+			t.Errorf("Unexpected stats line: %q", stats.String())
+		}
+	})
+}
+
+func TestLooksLikeNDJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "Two objects", input: "{\"a\": 1}\n{\"a\": 2}\n", expected: true},
+		{name: "Second line without newline", input: "{\"a\": 1}\n[2]", expected: true},
+		{name: "Blank lines between", input: "\n{\"a\": 1}\r\n\n{\"a\": 2}", expected: true},
+		{name: "Single line", input: "{\"a\": 1}\n", expected: false},
+		{name: "Pretty-printed object", input: "{\n  \"a\": 1\n}\n", expected: false},
+		{name: "Pretty-printed array", input: "[\n  1,\n  2\n]", expected: false},
+		{name: "Empty", input: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := bufio.NewReader(strings.NewReader(tt.input))
+			if got := looksLikeNDJSON(br); got != tt.expected {
+				t.Errorf("looksLikeNDJSON(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+			if br.Buffered() != len(tt.input) {
+				t.Errorf("Expected the input to stay buffered, %d of %d bytes left", br.Buffered(), len(tt.input))
+			}
+		})
+	}
+
+	t.Run("Detected without -ndjson", func(t *testing.T) {
+		var out bytes.Buffer
+		o := &options{}
+		if err := o.slim(strings.NewReader("{\"a\": 1, \"b\": \"\"}\n{\"b\": 1}\n"), &out, nil, "slimjson", slimjson.Config{StripEmpty: true}); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+		if expected := "{\"a\":1}\n{\"b\":1}\n"; out.String() != expected {
+			t.Errorf("Output = %q, want %q", out.String(), expected)
+		}
+	})
+}
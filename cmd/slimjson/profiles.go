@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tradik/slimjson"
+	"github.com/tradik/slimjson/server"
+)
+
+// listProfiles writes the profiles with their descriptions and main settings, grouped by source
+func listProfiles(w io.Writer, custom map[string]slimjson.Profile) {
+	entries := server.ProfileEntries(custom)
+	groups := []struct{ source, title string }{
+		{"builtin", "Built-in profiles"},
+		{"registered", "Registered profiles"},
+		{"custom", "Custom profiles"},
+	}
+	for _, g := range groups {
+		var group []server.ProfileEntry
+		for _, e := range entries {
+			if e.Source == g.source {
+				group = append(group, e)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s:\n", g.title)
+		for _, e := range group {
+			_, _ = fmt.Fprintf(w, "  %s\n", e.Name)
+			if e.Description != "" {
+				_, _ = fmt.Fprintf(w, "      %s\n", e.Description)
+			}
+			_, _ = fmt.Fprintf(w, "      %s\n", profileSummary(e.Config))
+		}
+	}
+}
+
+// profileSummary describes the limits of cfg and the options it enables
+func profileSummary(cfg slimjson.Config) string {
+	limit := func(n int) string {
+		if n == 0 {
+			return "unlimited"
+		}
+		return strconv.Itoa(n)
+	}
+	summary := fmt.Sprintf("depth=%s list-len=%s string-len=%s",
+		limit(cfg.MaxDepth), limit(cfg.MaxListLength), limit(cfg.MaxStringLength))
+
+	// Enabled flags by config key, in field order
+	var flags []string
+	v, t := reflect.ValueOf(cfg), reflect.TypeOf(cfg)
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if v.Field(i).Kind() == reflect.Bool && v.Field(i).Bool() && key != "" && key != "-" {
+			flags = append(flags, key)
+		}
+	}
+	if len(flags) > 0 {
+		summary += " flags=" + strings.Join(flags, ",")
+	}
+	if len(cfg.BlockList) > 0 {
+		summary += fmt.Sprintf(" block=%d fields", len(cfg.BlockList))
+	}
+	if len(cfg.Rules) > 0 {
+		summary += fmt.Sprintf(" rules=%d", len(cfg.Rules))
+	}
+	return summary
+}
+
+// checkConfig writes the problems of the config file at path, or of the one
+// LoadProfiles would use if path is empty, to w. It reports whether the file
+// is free of errors; warnings do not count.
+func checkConfig(w io.Writer, path string) bool {
+	if path == "" {
+		path = slimjson.FindConfigFile()
+	}
+	if path == "" {
+		_, _ = fmt.Fprintln(w, "slimjson: no config file found")
+		return false
+	}
+
+	ok := true
+	problems := slimjson.CheckConfigFile(path)
+	for _, problem := range problems {
+		ok = ok && problem.Warning
+		_, _ = fmt.Fprintf(w, "%s: %s\n", path, problem)
+	}
+	if len(problems) == 0 {
+		_, _ = fmt.Fprintf(w, "%s: OK\n", path)
+	}
+	return ok
+}
+
+// persistedProfilesFile returns the file -persist-profiles writes the daemon
+// profiles to: configFile if set, otherwise the config file found by
+// LoadProfiles, or ./.slimjson without one. The file is rewritten in INI
+// format, so YAML and JSON config files are rejected, as is a file that
+// failed to load (loadErr), whose profiles the daemon does not serve.
+func persistedProfilesFile(configFile string, loadErr error) (string, error) {
+	path := configFile
+	if path == "" {
+		path = slimjson.ConfigSource()
+	}
+	if path == "" {
+		return ".slimjson", nil
+	}
+	if loadErr != nil {
+		return "", fmt.Errorf("-persist-profiles cannot replace %s, which failed to load: %v", path, loadErr)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return "", fmt.Errorf("-persist-profiles writes INI config files and cannot write %s", path)
+	}
+	return path, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestListProfiles(t *testing.T) {
+	var out bytes.Buffer
+	listProfiles(&out, map[string]slimjson.Profile{
+		"medium":  {Description: "Overridden medium", Config: slimjson.Config{MaxDepth: 2}},
+		"preview": {Config: slimjson.Config{MaxListLength: 3, DeduplicateArrays: true, Rules: []slimjson.PathRule{{Path: "logs"}}}},
+	})
+
+	for _, want := range []string{
+		"Built-in profiles:\n  aggressive\n",
+		"depth=3 list-len=5 string-len=unlimited flags=strip-empty block=6 fields\n",
+		"Custom profiles:\n  medium\n      Overridden medium\n      depth=2 list-len=unlimited string-len=unlimited\n",
+		"  preview\n      depth=unlimited list-len=3 string-len=unlimited flags=deduplicate-arrays rules=1\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Count(out.String(), "  medium\n") != 1 {
+		t.Errorf("Expected the custom medium profile to replace the built-in one, got:\n%s", out.String())
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("Broken file", func(t *testing.T) {
+		path := write("broken.slimjson", `[api]
+depth=3
+checksum=true
+
+[medium]
+override=true
+string-len=-5
+`)
+		var out bytes.Buffer
+		if checkConfig(&out, path) {
+			t.Error("Expected the check to fail")
+		}
+		expected := path + ": line 3: profile api: checksum requires lossless\n" +
+			path + ": line 5: warning: profile medium: replaces the built-in profile medium\n" +
+			path + ": line 7: profile medium: max-string-length must not be negative, got -5\n"
+		if out.String() != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+		}
+	})
+
+	t.Run("Syntax error", func(t *testing.T) {
+		path := write("syntax.slimjson", "[api]\ndepth=3\n[open\n")
+		var out bytes.Buffer
+		if checkConfig(&out, path) || !strings.Contains(out.String(), "line 3") {
+			t.Errorf("Expected a failure naming line 3, got %q", out.String())
+		}
+	})
+
+	t.Run("Warnings only", func(t *testing.T) {
+		path, err := initConfig(t.TempDir(), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if !checkConfig(&out, path) {
+			t.Errorf("Expected the generated file to pass, got:\n%s", out.String())
+		}
+		if n := strings.Count(out.String(), ": warning: "); n != 4 {
+			t.Errorf("Expected a warning per built-in profile, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("Valid file", func(t *testing.T) {
+		path := write("valid.slimjson", "[api]\ndepth=3\n")
+		var out bytes.Buffer
+		if !checkConfig(&out, path) || out.String() != path+": OK\n" {
+			t.Errorf("Expected OK, got %q", out.String())
+		}
+	})
+}
+
+func TestPersistedProfilesFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		configFile string
+		loadErr    error
+		expected   string
+		err        string
+	}{
+		{"INI config file", "conf/.slimjson", nil, "conf/.slimjson", ""},
+		{"YAML config file", "conf/.slimjson.yaml", nil, "", "cannot write conf/.slimjson.yaml"},
+		{"JSON config file", "profiles.JSON", nil, "", "cannot write profiles.JSON"},
+		{"Config file failed to load", "conf/.slimjson", os.ErrPermission, "", "failed to load"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := persistedProfilesFile(tt.configFile, tt.loadErr)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("persistedProfilesFile() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("persistedProfilesFile() = %q, %v, want %q", got, err, tt.expected)
+			}
+		})
+	}
+}
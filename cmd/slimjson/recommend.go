@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tradik/slimjson"
+)
+
+// recommendInput reads a sample JSON document from in and writes the config
+// RecommendConfig suggests for it to out, in the shape of the daemon envelope
+// config, and the reduction it achieves on the sample to errOut
+func recommendInput(in io.Reader, out, errOut io.Writer, target float64, pretty bool) error {
+	var sample interface{}
+	if err := json.NewDecoder(in).Decode(&sample); err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return fmt.Errorf("decoding JSON: %w", err)
+	}
+
+	cfg := slimjson.RecommendConfig(sample, target)
+	_, st := slimjson.New(cfg).SlimWithStats(sample)
+	_, _ = fmt.Fprintf(errOut, "Reduction on the sample: %.1f%% (target %.1f%%)\n", 100*(1-st.Ratio()), target)
+
+	encoder := json.NewEncoder(out)
+	if pretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(cfg)
+}
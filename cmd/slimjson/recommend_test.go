@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestRecommendInput(t *testing.T) {
+	input, err := os.ReadFile("../../testing/fixtures/resume.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	var out, errOut bytes.Buffer
+	if err := recommendInput(bytes.NewReader(input), &out, &errOut, 5, false); err != nil {
+		t.Fatalf("recommendInput() error: %v", err)
+	}
+	var cfg slimjson.Config
+	if err := json.Unmarshal(out.Bytes(), &cfg); err != nil {
+		t.Fatalf("Expected a JSON config, got %q: %v", out.String(), err)
+	}
+	if !cfg.StringPooling {
+		t.Errorf("Expected string pooling to be recommended, got %s", out.String())
+	}
+	if !strings.HasPrefix(errOut.String(), "Reduction on the sample: ") || !strings.Contains(errOut.String(), "(target 5.0%)") {
+		t.Errorf("Unexpected report %q", errOut.String())
+	}
+
+	if err := recommendInput(strings.NewReader("{"), &out, &errOut, 5, false); err == nil || !strings.Contains(err.Error(), "decoding JSON") {
+		t.Errorf("Expected a decoding error, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tradik/slimjson"
+)
+
+// runStats are the -stats figures of one input, or the total of several
+type runStats struct {
+	mu                    sync.Mutex
+	origBytes, origTokens int
+	slimBytes, slimTokens int
+	blocked               int             // Fields removed by the block list
+	arrays                map[string]bool // Paths of arrays shortened by -list-len or sampling
+	truncated             int             // Arrays shortened, for totals
+	depthCut              int             // Values cut by MaxDepth
+	depthBytes            int             // Approximate JSON size of the values cut by MaxDepth
+	elapsed               time.Duration
+}
+
+// count returns cfg with an OnRemove hook that counts blocked fields,
+// truncated arrays and values cut by MaxDepth into st, calling the existing
+// hook too
+func (st *runStats) count(cfg slimjson.Config) slimjson.Config {
+	next := cfg.OnRemove
+	cfg.OnRemove = func(path string, reason slimjson.RemoveReason, value interface{}) {
+		st.mu.Lock()
+		switch reason {
+		case slimjson.ReasonBlocked:
+			st.blocked++
+		case slimjson.ReasonListTruncated:
+			if st.arrays == nil {
+				st.arrays = make(map[string]bool)
+			}
+			st.arrays[parentPath(path)] = true
+		case slimjson.ReasonDepthExceeded:
+			st.depthCut++
+			if data, err := json.Marshal(value); err == nil {
+				st.depthBytes += len(data)
+			}
+		}
+		st.mu.Unlock()
+		if next != nil {
+			next(path, reason, value)
+		}
+	}
+	return cfg
+}
+
+// parentPath returns the path of the array or object holding the value at path
+func parentPath(path string) string {
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// truncatedArrays returns the number of arrays shortened
+func (st *runStats) truncatedArrays() int {
+	return st.truncated + len(st.arrays)
+}
+
+// add adds the figures of one input to a total. It is safe for concurrent use.
+func (st *runStats) add(other *runStats) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.origBytes += other.origBytes
+	st.origTokens += other.origTokens
+	st.slimBytes += other.slimBytes
+	st.slimTokens += other.slimTokens
+	st.blocked += other.blocked
+	st.truncated += other.truncatedArrays()
+	st.depthCut += other.depthCut
+	st.depthBytes += other.depthBytes
+}
+
+// print writes a one-line reduction summary prefixed with label
+func (st *runStats) print(w io.Writer, label string) {
+	_, _ = fmt.Fprintf(w, "%s: %d -> %d bytes (%.1f%% reduction), ~%d -> ~%d tokens (%.1f%% reduction), %d fields blocked, %d arrays truncated, %d subtrees cut by depth (~%d bytes), %s\n",
+		label, st.origBytes, st.slimBytes, reductionPct(st.origBytes, st.slimBytes),
+		st.origTokens, st.slimTokens, reductionPct(st.origTokens, st.slimTokens),
+		st.blocked, st.truncatedArrays(), st.depthCut, st.depthBytes, st.elapsed.Round(time.Microsecond))
+}
+
+func reductionPct(orig, slim int) float64 {
+	if orig == 0 {
+		return 0
+	}
+	return float64(orig-slim) / float64(orig) * 100
+}
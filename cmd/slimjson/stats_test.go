@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+// countInput slims input with cfg and returns the figures counted into a new
+// runStats
+func countInput(t *testing.T, input string, cfg slimjson.Config) *runStats {
+	t.Helper()
+	st := &runStats{}
+	if _, err := slimjson.New(st.count(cfg)).SlimBytes([]byte(input)); err != nil {
+		t.Fatalf("SlimBytes() error: %v", err)
+	}
+	return st
+}
+
+func TestRunStatsCount(t *testing.T) {
+	input := `{"token": "x", "user": {"password": "y", "address": {"geo": {"lat": 1}}}, "ids": [1, 2, 3, 4], "tags": ["a", "b", "c"]}`
+	var hooked int
+	cfg := slimjson.Config{
+		BlockList:     []string{"token", "password"},
+		MaxListLength: 2,
+		MaxDepth:      3,
+		DecimalPlaces: -1,
+		OnRemove:      func(string, slimjson.RemoveReason, interface{}) { hooked++ },
+	}
+	st := countInput(t, input, cfg)
+
+	if st.blocked != 2 {
+		t.Errorf("Expected 2 fields blocked, got %d", st.blocked)
+	}
+	// Two elements of ids and one of tags are removed, counted once per array
+	if got, want := st.truncatedArrays(), 2; got != want {
+		t.Errorf("Expected %d arrays truncated, got %d (%v)", want, got, st.arrays)
+	}
+	// user.address.geo, whose value {"lat":1} takes 9 bytes
+	if st.depthCut != 1 || st.depthBytes != 9 {
+		t.Errorf("Expected 1 value cut by depth, 9 bytes, got %d, %d bytes", st.depthCut, st.depthBytes)
+	}
+	if want := st.blocked + 3 + st.depthCut; hooked != want {
+		t.Errorf("Expected the existing OnRemove hook to be called %d times, got %d", want, hooked)
+	}
+}
+
+func TestRunStatsAdd(t *testing.T) {
+	cfg := slimjson.Config{BlockList: []string{"token"}, MaxListLength: 1, DecimalPlaces: -1}
+	first := countInput(t, `{"token": 1, "a": [1, 2], "b": {"c": [1, 2, 3]}}`, cfg)
+	second := countInput(t, `{"token": 1, "token2": 2, "a": [1, 2]}`, cfg)
+	if first.truncatedArrays() != 2 || second.truncatedArrays() != 1 {
+		t.Fatalf("Expected 2 and 1 arrays truncated, got %d and %d", first.truncatedArrays(), second.truncatedArrays())
+	}
+
+	total := &runStats{}
+	total.add(first)
+	total.add(second)
+	if total.truncatedArrays() != 3 || total.blocked != 2 {
+		t.Errorf("Expected 3 arrays truncated and 2 fields blocked in total, got %d and %d", total.truncatedArrays(), total.blocked)
+	}
+
+	// A total added to another keeps its count of arrays
+	grand := &runStats{}
+	grand.add(total)
+	grand.add(first)
+	if got := grand.truncatedArrays(); got != 5 {
+		t.Errorf("Expected 5 arrays truncated, got %d", got)
+	}
+
+	var out bytes.Buffer
+	grand.print(&out, "total")
+	if !strings.Contains(out.String(), ", 3 fields blocked, 5 arrays truncated, ") {
+		t.Errorf("Unexpected stats line: %q", out.String())
+	}
+}
+
+func TestParentPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"ids.3", "ids"},
+		{"user.ids.3", "user.ids"},
+		{"3", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parentPath(tt.path); got != tt.want {
+			t.Errorf("parentPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version is the slimjson release, set when building with
+// -ldflags "-X main.Version=v1.2.3". Without it the module version from the
+// build info is used, e.g. for go install, or "dev".
+var Version = ""
+
+// buildInfo describes the running binary, for -version and /health
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go"`
+}
+
+// getBuildInfo returns Version, the VCS revision the binary was built from, if
+// known, and the Go version it was built with
+func getBuildInfo() buildInfo {
+	info := buildInfo{Version: Version, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		var modified bool
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value[:min(len(setting.Value), 12)]
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if info.Commit != "" && modified {
+			info.Commit += "-dirty"
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+// String formats the build info as "v1.2.3 (commit 0123456789ab, go1.25.0)"
+func (b buildInfo) String() string {
+	if b.Commit == "" {
+		return fmt.Sprintf("%s (%s)", b.Version, b.GoVersion)
+	}
+	return fmt.Sprintf("%s (commit %s, %s)", b.Version, b.Commit, b.GoVersion)
+}
+
+// printVersion prints the -version output
+func printVersion(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "slimjson %s\n", getBuildInfo())
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson/server"
+)
+
+func TestPrintVersion(t *testing.T) {
+	var out bytes.Buffer
+	printVersion(&out)
+
+	version := strings.TrimPrefix(strings.TrimSpace(out.String()), "slimjson ")
+	if version == "" || version == out.String() {
+		t.Fatalf("Expected \"slimjson <version>\", got %q", out.String())
+	}
+	if !strings.Contains(version, "go1.") {
+		t.Errorf("Expected the Go version in %q", version)
+	}
+}
+
+func TestVersionLdflags(t *testing.T) {
+	defer func(v string) { Version = v }(Version)
+	Version = "v9.8.7"
+
+	var out bytes.Buffer
+	printVersion(&out)
+	if !strings.HasPrefix(out.String(), "slimjson v9.8.7 (") {
+		t.Errorf("Expected the -ldflags version, got %q", out.String())
+	}
+
+	w := httptest.NewRecorder()
+	server.New(server.Options{BuildInfo: server.BuildInfo(getBuildInfo())}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var response map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response["version"] != "v9.8.7" {
+		t.Errorf("Expected /health version v9.8.7, got %q", response["version"])
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tradik/slimjson"
+)
+
+// watchInterval is how often -watch polls the input files for changes
+const watchInterval = 250 * time.Millisecond
+
+// checkWatch validates the flags used with -watch
+func (o *options) checkWatch(files []string) error {
+	switch {
+	case len(files) == 0:
+		return errors.New("-watch requires file arguments")
+	case o.inPlace:
+		return errors.New("-watch cannot be combined with -in-place")
+	}
+	return o.checkBatch(files)
+}
+
+// fileStamp identifies a version of a file by its modification time and size
+type fileStamp struct {
+	mod  int64 // Modification time in Unix nanoseconds
+	size int64
+}
+
+// stampFile returns the stamp of file, or the zero stamp if it cannot be read
+func stampFile(file string) fileStamp {
+	info, err := os.Stat(file)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{info.ModTime().UnixNano(), info.Size()}
+}
+
+// watchFiles slims the files like processFiles, then polls them every interval
+// and slims each file again when it changes, until ctx is done. A change is
+// picked up once the file is unchanged for a whole interval, so a burst of
+// writes is slimmed once. Errors, such as a file saved with invalid JSON, are
+// reported to errOut and the files are still watched.
+func (o *options) watchFiles(ctx context.Context, files []string, cfg slimjson.Config, out, errOut io.Writer, interval time.Duration) {
+	slimmed := make([]fileStamp, len(files)) // Stamps of the versions slimmed
+	seen := make([]fileStamp, len(files))    // Stamps at the previous poll
+	for i, file := range files {
+		slimmed[i] = stampFile(file)
+		seen[i] = slimmed[i]
+		o.slimWatched(file, cfg, out, errOut)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for i, file := range files {
+			stamp := stampFile(file)
+			settled := stamp == seen[i]
+			seen[i] = stamp
+			if settled && stamp != slimmed[i] && stamp != (fileStamp{}) {
+				slimmed[i] = stamp
+				o.slimWatched(file, cfg, out, errOut)
+			}
+		}
+	}
+}
+
+// slimWatched slims one watched file, reporting an error to errOut
+func (o *options) slimWatched(file string, cfg slimjson.Config, out, errOut io.Writer) {
+	if err := processFile(file, o, cfg, out, errOut); err != nil {
+		_, _ = fmt.Fprintf(errOut, "Error: %s: %v\n", file, err)
+	}
+}
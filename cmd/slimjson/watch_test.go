@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestWatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "data.json")
+	output := filepath.Join(dir, "data.slim.json")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+	}
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			got, _ := os.ReadFile(output)
+			if string(got) == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected output %q, got %q", want, got)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	write(`{"name": "a", "secret": "x"}`)
+	o := &options{output: output, stats: true, explain: "text"}
+	cfg := slimjson.Config{DecimalPlaces: -1, BlockList: []string{"secret"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	var out, errOut bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		o.watchFiles(ctx, []string{input}, cfg, &out, &errOut, 10*time.Millisecond)
+		close(done)
+	}()
+
+	waitFor("{\"name\":\"a\"}\n")
+	write(`{"name": "bb", "secret": "y"}`)
+	waitFor("{\"name\":\"bb\"}\n")
+	write(`{"name": `)
+	time.Sleep(100 * time.Millisecond)
+	write(`{"name": "ccc"}`)
+	waitFor("{\"name\":\"ccc\"}\n")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected watchFiles to return once cancelled")
+	}
+
+	report := errOut.String()
+	if n := strings.Count(report, input+": "); n < 4 {
+		t.Errorf("Expected a stats line per run and the parse error, got:\n%s", report)
+	}
+	if !strings.Contains(report, "Error: "+input+": processing JSON document 1: unexpected EOF") {
+		t.Errorf("Expected the parse error to be reported, got:\n%s", report)
+	}
+	if !strings.Contains(report, "Blocked fields (1):\n  secret\n") {
+		t.Errorf("Expected the -explain report, got:\n%s", report)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing on stdout with -o, got %q", out.String())
+	}
+}
+
+func TestCheckWatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		o       options
+		files   []string
+		wantErr string
+	}{
+		{"File", options{}, []string{"a.json"}, ""},
+		{"Files to a directory", options{outDir: "slim"}, []string{"a.json", "b.json"}, ""},
+		{"Stdin", options{}, nil, "-watch requires file arguments"},
+		{"In place", options{inPlace: true}, []string{"a.json"}, "-watch cannot be combined with -in-place"},
+		{"Output of several files", options{output: "out.json"}, []string{"a.json", "b.json"}, "-o supports a single input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.o.checkWatch(tt.files)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
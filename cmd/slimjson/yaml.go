@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/tradik/slimjson"
+	"gopkg.in/yaml.v3"
+)
+
+// checkFormats validates -in-format and -out-format and the flags used with YAML
+func (o *options) checkFormats() error {
+	yamlUsed := o.inFormat == "yaml" || o.outFormat == "yaml"
+	switch {
+	case o.inFormat != "" && o.inFormat != "json" && o.inFormat != "yaml":
+		return fmt.Errorf("-in-format must be json or yaml, got %q", o.inFormat)
+	case o.outFormat != "" && o.outFormat != "json" && o.outFormat != "yaml":
+		return fmt.Errorf("-out-format must be json or yaml, got %q", o.outFormat)
+	case yamlUsed && o.ndjson:
+		return errors.New("-ndjson cannot be combined with YAML input or output")
+	case yamlUsed && o.diff:
+		return errors.New("-diff does not support YAML input or output")
+	case yamlUsed && (o.decompress || o.unslim):
+		return errors.New("-decompress and -unslim do not support YAML input or output")
+	}
+	return nil
+}
+
+// isYAMLName reports whether a file name has a .yaml or .yml extension,
+// before any .gz extension
+func isYAMLName(name string) bool {
+	switch strings.ToLower(filepath.Ext(trimGzipExt(name))) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// slimYAML slims each document of the YAML stream read from in and writes it
+// to out as JSON, or as a YAML stream with -out-format yaml. JSON input can be
+// read this way too, as YAML is a superset of it. With report, the -explain
+// report is written to it. If st is non-nil, the input and output sizes are
+// added to it.
+func (o *options) slimYAML(in io.Reader, out, report io.Writer, st *runStats, cfg slimjson.Config) error {
+	var raw bytes.Buffer
+	if st != nil {
+		in = io.TeeReader(in, &raw)
+	}
+
+	slimmer := slimjson.New(cfg)
+	dec := yaml.NewDecoder(in)
+	var buf bytes.Buffer
+	var yamlEnc *yaml.Encoder
+	if o.outFormat == "yaml" {
+		yamlEnc = yaml.NewEncoder(&buf)
+		yamlEnc.SetIndent(2)
+	}
+	jsonEnc := json.NewEncoder(&buf)
+	if o.pretty {
+		jsonEnc.SetIndent("", "  ")
+	}
+
+	var docs [][]slimjson.Change
+	for n := 1; ; n++ {
+		var node yaml.Node
+		if err := dec.Decode(&node); err == io.EOF {
+			if n == 1 {
+				return err
+			}
+			break
+		} else if err != nil {
+			return fmt.Errorf("processing YAML document %d: %w", n, err)
+		}
+		v, err := yamlValue(&node, cfg.PreserveKeyOrder)
+		if err != nil {
+			return fmt.Errorf("processing YAML document %d: %w", n, err)
+		}
+
+		var result interface{}
+		if report != nil {
+			var changes []slimjson.Change
+			result, changes = slimmer.Explain(v)
+			docs = append(docs, changes)
+		} else {
+			result = slimmer.Slim(v)
+		}
+
+		if yamlEnc == nil {
+			err = jsonEnc.Encode(result)
+		} else {
+			err = encodeYAML(yamlEnc, result)
+		}
+		if err != nil {
+			return fmt.Errorf("encoding document %d: %w", n, err)
+		}
+	}
+	if yamlEnc != nil {
+		if err := yamlEnc.Close(); err != nil {
+			return fmt.Errorf("encoding YAML: %w", err)
+		}
+	}
+
+	if _, err := out.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	if st != nil {
+		st.origBytes, st.origTokens = raw.Len(), slimjson.EstimateTokens(raw.Bytes())
+		st.slimBytes, st.slimTokens = buf.Len(), slimjson.EstimateTokens(buf.Bytes())
+	}
+	if report != nil {
+		return writeExplainReport(report, docs, string(o.explain), o.pretty)
+	}
+	return nil
+}
+
+// yamlValue converts a YAML node to the value model of encoding/json: mappings
+// become map[string]interface{}, or *slimjson.OrderedMap if ordered, sequences
+// []interface{} and numbers float64. Aliases are resolved, << merge keys are
+// expanded and keys that are not strings are converted to their text.
+// Timestamps and binary values are kept as strings.
+func yamlValue(node *yaml.Node, ordered bool) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0], ordered)
+
+	case yaml.AliasNode:
+		return yamlValue(node.Alias, ordered)
+
+	case yaml.SequenceNode:
+		arr := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := yamlValue(item, ordered)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+
+	case yaml.MappingNode:
+		m := slimjson.NewOrderedMap()
+		if err := addYAMLMapping(m, node, ordered, false); err != nil {
+			return nil, err
+		}
+		if ordered {
+			return m, nil
+		}
+		return m.Values, nil
+	}
+	return yamlScalar(node)
+}
+
+// addYAMLMapping adds the keys of the mapping node to m. Explicit keys take
+// precedence over merged ones, and with merged set, keys already in m are kept.
+func addYAMLMapping(m *slimjson.OrderedMap, node *yaml.Node, ordered, merged bool) error {
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valNode := node.Content[i], node.Content[i+1]
+		if keyNode.Kind == yaml.ScalarNode && keyNode.ShortTag() == "!!merge" {
+			merges = append(merges, valNode)
+			continue
+		}
+		key, err := yamlKey(keyNode, ordered)
+		if err != nil {
+			return err
+		}
+		if _, ok := m.Get(key); ok && merged {
+			continue
+		}
+		v, err := yamlValue(valNode, ordered)
+		if err != nil {
+			return err
+		}
+		m.Set(key, v)
+	}
+
+	// A merge value is a mapping or a sequence of mappings, the first taking precedence
+	for _, merge := range merges {
+		if merge.Kind == yaml.AliasNode {
+			merge = merge.Alias
+		}
+		sources := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+		for _, src := range sources {
+			if src.Kind == yaml.AliasNode {
+				src = src.Alias
+			}
+			if src.Kind != yaml.MappingNode {
+				return fmt.Errorf("line %d: << must merge a mapping", src.Line)
+			}
+			if err := addYAMLMapping(m, src, ordered, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// yamlKey returns a mapping key as a string: scalars by their text, and
+// collections as JSON
+func yamlKey(node *yaml.Node, ordered bool) (string, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+	v, err := yamlValue(node, ordered)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// yamlScalar converts a scalar node to a string, float64, bool or nil
+func yamlScalar(node *yaml.Node) (interface{}, error) {
+	switch node.ShortTag() {
+	case "!!str", "!!timestamp", "!!binary":
+		return node.Value, nil
+	}
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return nil, err
+	}
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case float64:
+		if math.IsInf(n, 0) || math.IsNaN(n) {
+			return node.Value, nil // Not representable in JSON
+		}
+	}
+	return v, nil
+}
+
+// encodeYAML writes v as a YAML document, keeping the key order of objects.
+// v is encoded as JSON and read back as YAML nodes, in block style.
+func encodeYAML(enc *yaml.Encoder, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	return enc.Encode(&node)
+}
+
+// blockStyle clears the flow and quoting styles of JSON read as YAML, so the
+// encoder writes block collections and quotes only strings that need it
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+// manifest is a two-document Kubernetes-style YAML stream with an anchor,
+// a merge key, an alias, integer and boolean keys and a block scalar
+const manifest = `# Deployment and its config
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels: &labels
+    app: web
+    tier: frontend
+  annotations: {}
+spec:
+  replicas: 3
+  selector:
+    matchLabels: *labels
+  template:
+    metadata:
+      labels:
+        <<: *labels
+        tier: edge
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+          ports:
+            - containerPort: 80
+          env: []
+---
+apiVersion: v1
+kind: ConfigMap
+data:
+  200: ok
+  true: enabled
+  script: |
+    echo "hello"
+  created: 2024-01-01T00:00:00Z
+`
+
+func TestSlimYAML(t *testing.T) {
+	cfg := slimjson.Config{StripEmpty: true, DecimalPlaces: -1, BlockList: []string{"apiVersion"}}
+
+	t.Run("JSON output", func(t *testing.T) {
+		o := &options{inFormat: "yaml"}
+		var out bytes.Buffer
+		if err := o.slim(strings.NewReader(manifest), &out, nil, "manifest", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+		expected := `{"kind":"Deployment","metadata":{"labels":{"app":"web","tier":"frontend"},"name":"web"},"spec":{"replicas":3,"selector":{"matchLabels":{"app":"web","tier":"frontend"}},"template":{"metadata":{"labels":{"app":"web","tier":"edge"}},"spec":{"containers":[{"image":"nginx:1.25","name":"web","ports":[{"containerPort":80}]}]}}}}
+{"data":{"200":"ok","created":"2024-01-01T00:00:00Z","script":"echo \"hello\"\n","true":"enabled"},"kind":"ConfigMap"}
+`
+		if out.String() != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+		}
+	})
+
+	t.Run("YAML output in key order", func(t *testing.T) {
+		o := &options{inFormat: "yaml", outFormat: "yaml"}
+		cfg := cfg
+		cfg.PreserveKeyOrder = true
+		var out bytes.Buffer
+		if err := o.slim(strings.NewReader(manifest), &out, nil, "manifest", cfg); err != nil {
+			t.Fatalf("slim() error: %v", err)
+		}
+		expected := `kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+    tier: frontend
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: web
+      tier: frontend
+  template:
+    metadata:
+      labels:
+        tier: edge
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+          ports:
+            - containerPort: 80
+---
+kind: ConfigMap
+data:
+  "200": ok
+  "true": enabled
+  script: |
+    echo "hello"
+  created: "2024-01-01T00:00:00Z"
+`
+		if out.String() != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+		}
+	})
+
+	t.Run("Detected by extension", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "manifest.yml")
+		if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+		var out, errOut bytes.Buffer
+		o := &options{stats: true, explain: "text"}
+		if err := processFile(path, o, cfg, &out, &errOut); err != nil {
+			t.Fatalf("processFile() error: %v", err)
+		}
+		if n := strings.Count(out.String(), "\n"); n != 2 {
+			t.Errorf("Expected a JSON document per YAML document, got %q", out.String())
+		}
+		for _, want := range []string{"Document 2:\nBlocked fields (1):\n  apiVersion\n", path + ": "} {
+			if !strings.Contains(errOut.String(), want) {
+				t.Errorf("Expected %q in the report, got:\n%s", want, errOut.String())
+			}
+		}
+	})
+
+	t.Run("Invalid YAML", func(t *testing.T) {
+		o := &options{inFormat: "yaml"}
+		var out bytes.Buffer
+		err := o.slim(strings.NewReader("a: 1\n---\nb: [1, 2\n"), &out, nil, "invalid", cfg)
+		if err == nil || !strings.Contains(err.Error(), "processing YAML document 2") {
+			t.Errorf("Expected an error in document 2, got %v", err)
+		}
+	})
+}
+
+func TestCheckFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		o       options
+		wantErr string
+	}{
+		{"JSON", options{inFormat: "json", outFormat: "json"}, ""},
+		{"YAML", options{inFormat: "yaml", outFormat: "yaml"}, ""},
+		{"Unknown input format", options{inFormat: "toml"}, `-in-format must be json or yaml, got "toml"`},
+		{"Unknown output format", options{outFormat: "xml"}, `-out-format must be json or yaml, got "xml"`},
+		{"YAML with NDJSON", options{inFormat: "yaml", ndjson: true}, "-ndjson cannot be combined with YAML"},
+		{"YAML output with diff", options{outFormat: "yaml", diff: true}, "-diff does not support YAML"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.o.checkFormats()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
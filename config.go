@@ -2,52 +2,409 @@ package slimjson
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 )
 
-// ProfileConfig represents a named configuration profile
+// configFields maps JSON tag names and Go field names to Config field indexes
+var configFields = func() map[string]int {
+	t := reflect.TypeOf(Config{})
+	fields := make(map[string]int, t.NumField()*2)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fields[f.Name] = i
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// Merge returns a copy of c with the named fields taken from override.
+// Fields are named by their JSON/config file key ("strip-empty") or Go name ("StripEmpty").
+// Unlike copying only non-zero values, this lets an override explicitly turn a feature off.
+// Unknown names are ignored.
+func (c Config) Merge(override Config, fields []string) Config {
+	result := c
+	dst := reflect.ValueOf(&result).Elem()
+	src := reflect.ValueOf(override)
+	for _, name := range fields {
+		if i, ok := configFields[name]; ok {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	return result
+}
+
+// sampleStrategies are the valid values of Config.SampleStrategy
+var sampleStrategies = []string{"none", "first_last", "random", "representative", "largest", "smallest", "frequency", "stratified"}
+
+// depthTruncationMarkers are the valid values of Config.DepthTruncationMarker
+var depthTruncationMarkers = []string{"null", "empty", "ellipsis"}
+
+// truncateBoundaries are the valid values of Config.TruncateBoundary
+var truncateBoundaries = []string{"rune", "grapheme", "word"}
+
+// stringLengthUnits are the valid values of Config.StringLengthUnit
+var stringLengthUnits = []string{"runes", "bytes", "tokens"}
+
+// unicodeForms are the valid values of Config.NormalizeUnicode, in any case
+var unicodeForms = []string{"none", "NFC", "NFD", "NFKC"}
+
+// Validate reports every option that is out of range, unknown or has no effect.
+// The error joins one error per problem, named by config file key.
+// New accepts any Config; use NewStrict to reject invalid ones.
+func (c Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	for _, f := range []struct {
+		key   string
+		value int
+	}{
+		{"max-depth", c.MaxDepth},
+		{"max-list-length", c.MaxListLength},
+		{"max-string-length", c.MaxStringLength},
+		{"max-output-bytes", c.MaxOutputBytes},
+		{"sample-size", c.SampleSize},
+		{"string-pool-min", c.StringPoolMinOccurrences},
+		{"number-delta-threshold", c.NumberDeltaThreshold},
+		{"enum-max-values", c.EnumMaxValues},
+		{"inline-enum-threshold", c.InlineEnumThreshold},
+		{"flatten-max-depth", c.FlattenMaxDepth},
+		{"min-savings-bytes", c.MinSavingsBytes},
+		{"parallelism", c.Parallelism},
+	} {
+		if f.value < 0 {
+			add("%s must not be negative, got %d", f.key, f.value)
+		}
+	}
+	if c.DecimalPlaces < -1 {
+		add("decimal-places must be -1 (no rounding) or more, got %d", c.DecimalPlaces)
+	}
+	for _, field := range slices.Sorted(maps.Keys(c.FieldDecimalPlaces)) {
+		if n := c.FieldDecimalPlaces[field]; n < -1 {
+			add("field-decimal-places %s must be -1 (no rounding) or more, got %d", field, n)
+		}
+	}
+
+	if c.SampleStrategy != "" && !slices.Contains(sampleStrategies, c.SampleStrategy) {
+		add("unknown sample-strategy %q (expected one of %s)", c.SampleStrategy, strings.Join(sampleStrategies, ", "))
+	}
+	if c.SampleSize > 0 && c.MaxListLength > 0 && c.SampleSize > c.MaxListLength {
+		add("sample-size %d exceeds max-list-length %d (lower sample-size or raise max-list-length)", c.SampleSize, c.MaxListLength)
+	}
+	if c.SampleSortKey != "" && c.SampleStrategy != "largest" && c.SampleStrategy != "smallest" {
+		add("sample-sort-key requires sample-strategy largest or smallest")
+	}
+	if c.SampleStratifyKey != "" && c.SampleStrategy != "stratified" {
+		add("sample-stratify-key requires sample-strategy stratified")
+	}
+	if c.SampleCounts && c.SampleStrategy != "frequency" {
+		add("sample-counts requires sample-strategy frequency")
+	}
+	if c.EnumDetection && c.EnumMaxValues == 1 {
+		add("enum-max-values must be at least 2, got 1")
+	}
+	if c.InlineEnumThreshold > 0 && !c.EnumDetection {
+		add("inline-enum-threshold requires enum-detection")
+	}
+	if c.DepthTruncationMarker != "" && !slices.Contains(depthTruncationMarkers, c.DepthTruncationMarker) {
+		add("unknown depth-truncation-marker %q (expected one of %s)", c.DepthTruncationMarker, strings.Join(depthTruncationMarkers, ", "))
+	} else if c.DepthTruncationMarker != "" && c.DepthTruncationMarker != "null" && c.MaxDepth == 0 {
+		add("depth-truncation-marker requires max-depth")
+	}
+	if c.ReportDepthElision && c.MaxDepth == 0 {
+		add("report-depth-elision requires max-depth")
+	}
+	if c.TruncateBoundary != "" && !slices.Contains(truncateBoundaries, c.TruncateBoundary) {
+		add("unknown truncate-boundary %q (expected one of %s)", c.TruncateBoundary, strings.Join(truncateBoundaries, ", "))
+	} else if c.TruncateBoundary != "" && c.TruncateBoundary != "rune" && c.MaxStringLength == 0 {
+		add("truncate-boundary requires max-string-length")
+	}
+	if c.StringLengthUnit != "" && !slices.Contains(stringLengthUnits, c.StringLengthUnit) {
+		add("unknown string-length-unit %q (expected one of %s)", c.StringLengthUnit, strings.Join(stringLengthUnits, ", "))
+	} else if c.StringLengthUnit != "" && c.StringLengthUnit != "runes" && c.MaxStringLength == 0 {
+		add("string-length-unit requires max-string-length")
+	}
+	if c.NormalizeUnicode != "" && !slices.ContainsFunc(unicodeForms, func(f string) bool { return strings.EqualFold(f, c.NormalizeUnicode) }) {
+		add("unknown normalize-unicode %q (expected one of %s)", c.NormalizeUnicode, strings.Join(unicodeForms, ", "))
+	}
+	if c.TypeInferenceColumnar && !c.TypeInference {
+		add("type-inference-columnar requires type-inference")
+	}
+	if c.FlattenMaxDepth > 0 && !c.Flatten && !c.FlattenSingleKeyChains {
+		add("flatten-max-depth requires flatten or flatten-single-key-chains")
+	}
+	if len(c.EmptyValues) > 0 && !c.StripEmpty {
+		add("empty-values requires strip-empty")
+	}
+	if c.KeepNull && !c.StripEmpty {
+		add("keep-null requires strip-empty")
+	}
+	if c.DropIfEqualsIgnoreCase && len(c.DropIfEquals) == 0 {
+		add("drop-if-ignore-case requires drop-if")
+	}
+	if c.CollapseWhitespaceKeepNewlines && !c.CollapseWhitespace {
+		add("keep-newlines requires collapse-whitespace")
+	}
+	if c.Checksum && !c.Lossless {
+		add("checksum requires lossless")
+	}
+	if c.Lossless {
+		for _, problem := range c.lossyOptions() {
+			add("lossless: %s", problem)
+		}
+	}
+
+	for i, r := range c.Rules {
+		if _, err := parseSelector(r.Path); err != nil {
+			add("rules[%d]: %w", i, err)
+			continue
+		}
+		if err := r.Config.Validate(); err != nil {
+			for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+				add("rules[%d] %s: %w", i, r.Path, e)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// lossyOptions describes each option of c that loses data or that Unslim cannot reverse
+func (c Config) lossyOptions() []string {
+	var problems []string
+	check := func(lossy bool, problem string) {
+		if lossy {
+			problems = append(problems, problem)
+		}
+	}
+	check(c.MaxDepth > 0, "max-depth removes nested values")
+	check(c.MaxListLength > 0, "max-list-length shortens arrays")
+	check(c.MaxStringLength > 0, "max-string-length truncates strings")
+	check(c.MaxOutputBytes > 0, "max-output-bytes shortens arrays and strings")
+	check(c.StripEmpty, "strip-empty removes empty values")
+	check(len(c.BlockList) > 0, "block-list removes fields")
+	check(len(c.DropIfEquals) > 0, "drop-if removes fields")
+	check(c.DecimalPlaces >= 0, "decimal-places rounds numbers (use -1)")
+	for _, field := range slices.Sorted(maps.Keys(c.FieldDecimalPlaces)) {
+		check(c.FieldDecimalPlaces[field] >= 0, "field-decimal-places rounds numbers of "+field+" (use -1)")
+	}
+	check(c.DeduplicateArrays, "deduplicate-arrays removes array elements")
+	check((c.SampleStrategy != "" && c.SampleStrategy != "none") || c.SampleSize > 0, "sampling removes array elements")
+	check(c.NormalizeUnicode != "" && !strings.EqualFold(c.NormalizeUnicode, "none"), "normalize-unicode changes characters")
+	check(c.StripHTML, "strip-html removes markup")
+	check(c.StripMarkdown, "strip-markdown removes markup")
+	check(c.DecodeHTMLEntities, "decode-html-entities changes characters")
+	check(c.CleanWhitespace, "clean-whitespace changes characters")
+	check(c.NormalizeQuotes, "normalize-quotes changes characters")
+	check(c.CollapseWhitespace, "collapse-whitespace removes whitespace")
+	check(c.StripUTF8Emoji, "strip-emoji removes characters")
+	check(c.TimestampCompression, "timestamp-compression changes timestamp formats")
+	check(c.NullCompression, "null-compression adds _nulls, which Unslim does not remove")
+	check(c.ValueTransform != nil, "ValueTransform rewrites values")
+
+	for i, r := range c.Rules {
+		// Document-wide options of a rule are taken from the top-level Config
+		rc := r.Config
+		rc.EnumDetection, rc.NullCompression, rc.ValueTransform = false, false, nil
+		for _, problem := range rc.lossyOptions() {
+			problems = append(problems, fmt.Sprintf("rules[%d] %s: %s", i, r.Path, problem))
+		}
+	}
+	return problems
+}
+
+// ProfileConfig represents a named configuration profile.
+//
+// Deprecated: use Profile, which also has a description.
 type ProfileConfig struct {
 	Name   string
 	Config Config
 }
 
-// LoadConfigFile loads configuration from .slimjson file
-// Searches in: current directory, user home directory
+// configFileNames are the config file names LoadConfigFile looks for, in order
+var configFileNames = []string{".slimjson", ".slimjson.yaml", ".slimjson.yml", ".slimjson.json"}
+
+// configSource is the file loaded by the last LoadConfigFile or LoadProfiles call
+var configSource struct {
+	sync.Mutex
+	path string
+}
+
+// LoadConfigFile loads configuration from .slimjson, .slimjson.yaml, .slimjson.yml
+// or .slimjson.json, as found by FindConfigFile. ConfigSource reports the file loaded.
 func LoadConfigFile() (map[string]Config, error) {
-	// Try current directory first
-	configPath := ".slimjson"
-	if _, err := os.Stat(configPath); err != nil {
-		// Try home directory
-		home, err := os.UserHomeDir()
-		if err == nil {
-			configPath = filepath.Join(home, ".slimjson")
-			if _, err := os.Stat(configPath); err != nil {
-				// No config file found - return empty map (not an error)
-				return make(map[string]Config), nil
+	return profileConfigs(LoadProfiles())
+}
+
+// LoadProfiles is like LoadConfigFile, but returns the profiles with their descriptions.
+func LoadProfiles() (map[string]Profile, error) {
+	configPath := FindConfigFile()
+	configSource.Lock()
+	configSource.path = configPath
+	configSource.Unlock()
+
+	if configPath == "" {
+		// No config file found - return empty map (not an error)
+		return make(map[string]Profile), nil
+	}
+	return ParseProfiles(configPath)
+}
+
+// ConfigSource returns the path of the config file loaded by the last
+// LoadConfigFile or LoadProfiles call, or "" if none was found.
+func ConfigSource() string {
+	configSource.Lock()
+	defer configSource.Unlock()
+	return configSource.path
+}
+
+// FindConfigFile returns the path of the config file LoadConfigFile uses, or ""
+// if there is none. It searches the current directory and its parents, up to
+// the filesystem root or the first directory containing .git, then the user
+// home directory. Within a directory, names are tried in the order .slimjson,
+// .slimjson.yaml, .slimjson.yml, .slimjson.json.
+func FindConfigFile() string {
+	if dir, err := os.Getwd(); err == nil {
+		for {
+			if configPath := configFileIn(dir); configPath != "" {
+				return configPath
 			}
-		} else {
-			return make(map[string]Config), nil
+			if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return configFileIn(home)
+	}
+	return ""
+}
+
+// configFileIn returns the first config file in dir, or ""
+func configFileIn(dir string) string {
+	for _, name := range configFileNames {
+		configPath := filepath.Join(dir, name)
+		if info, err := os.Stat(configPath); err == nil && !info.IsDir() {
+			return configPath
 		}
 	}
+	return ""
+}
 
-	return ParseConfigFile(configPath)
+// profileConfigs returns the Config of each profile
+func profileConfigs(profiles map[string]Profile, err error) (map[string]Config, error) {
+	if err != nil {
+		return nil, err
+	}
+	configs := make(map[string]Config, len(profiles))
+	for name, p := range profiles {
+		configs[name] = p.Config
+	}
+	return configs, nil
 }
 
-// ParseConfigFile parses a .slimjson configuration file
+// ConfigParser parses profile configuration files. All formats share the
+// config file keys, so a key works the same in INI, YAML and JSON.
+type ConfigParser struct {
+	// Strict makes unknown keys an error. Otherwise they are skipped and reported to Warn.
+	Strict bool
+	// Warn receives a message for each skipped key (nil = ignore)
+	Warn func(msg string)
+}
+
+// ParseConfig parses a config file in the format given by its extension:
+// .yaml/.yml for YAML, .json for JSON and INI (.slimjson) otherwise.
+// Unknown keys are an error.
+func ParseConfig(path string) (map[string]Config, error) {
+	return ConfigParser{Strict: true}.Parse(path)
+}
+
+// ParseProfiles is like ParseConfig, but returns the profiles with their
+// descriptions, set by the description key of each profile.
+func ParseProfiles(path string) (map[string]Profile, error) {
+	return ConfigParser{Strict: true}.ParseProfiles(path)
+}
+
+// ParseConfigFile parses a .slimjson configuration file. Profile names and the
+// keys of a profile must be unique; a built-in profile name needs override=true.
+// A profile with extends=<name> starts from the named profile, from the same file
+// (declared before or after it), built-in or registered, and overrides only the keys it sets.
+// description=<text> describes a profile (see ParseProfiles) and is not inherited.
 func ParseConfigFile(path string) (map[string]Config, error) {
+	return ConfigParser{Strict: true}.ParseINI(path)
+}
+
+// Parse parses a config file in the format given by its extension (see ParseConfig)
+func (p ConfigParser) Parse(path string) (map[string]Config, error) {
+	return profileConfigs(p.ParseProfiles(path))
+}
+
+// ParseProfiles is like Parse, but returns the profiles with their descriptions
+func (p ConfigParser) ParseProfiles(path string) (map[string]Profile, error) {
+	sections, err := p.readSections(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.resolveProfiles(sections)
+}
+
+// readSections reads the profile sections of a config file in the format given
+// by its extension
+func (p ConfigParser) readSections(path string) ([]*profileSection, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return p.readYAMLSections(path)
+	case ".json":
+		return p.readJSONSections(path)
+	default:
+		return p.readINISections(path)
+	}
+}
+
+// ParseINI parses a config file in INI (.slimjson) format
+func (p ConfigParser) ParseINI(path string) (map[string]Config, error) {
+	return profileConfigs(p.parseINIProfiles(path))
+}
+
+func (p ConfigParser) parseINIProfiles(path string) (map[string]Profile, error) {
+	sections, err := p.readINISections(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.resolveProfiles(sections)
+}
+
+// readINISections reads the [name] sections of an INI config file
+func (p ConfigParser) readINISections(path string) ([]*profileSection, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
-	profiles := make(map[string]Config)
-	var currentProfile string
-	var currentConfig Config
+	var sections []*profileSection
+	var current *profileSection
 
 	scanner := bufio.NewScanner(file)
 	lineNum := 0
@@ -62,17 +419,9 @@ func ParseConfigFile(path string) (map[string]Config, error) {
 		}
 
 		// Check for profile section [name]
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			// Save previous profile if exists
-			if currentProfile != "" {
-				profiles[currentProfile] = currentConfig
-			}
-
-			// Start new profile
-			currentProfile = strings.TrimSpace(line[1 : len(line)-1])
-			currentConfig = Config{
-				DecimalPlaces: -1, // Default: no rounding
-			}
+		if name, ok := sectionName(line); ok {
+			current = &profileSection{name: name, pos: fmt.Sprintf("line %d", lineNum)}
+			sections = append(sections, current)
 			continue
 		}
 
@@ -82,27 +431,206 @@ func ParseConfigFile(path string) (map[string]Config, error) {
 			return nil, fmt.Errorf("invalid syntax at line %d: %s", lineNum, line)
 		}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Apply parameter to current config
-		if err := applyConfigParameter(&currentConfig, key, value); err != nil {
-			return nil, fmt.Errorf("error at line %d: %w", lineNum, err)
+		param := configParam{
+			key:   strings.TrimSpace(parts[0]),
+			value: strings.TrimSpace(parts[1]),
+			pos:   fmt.Sprintf("line %d", lineNum),
 		}
-	}
 
-	// Save last profile
-	if currentProfile != "" {
-		profiles[currentProfile] = currentConfig
+		if current == nil {
+			// Parameters outside a profile are checked but not used
+			if err := p.apply(&Config{}, param); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		current.add(param)
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
+	return sections, nil
+}
+
+// configParam is a single key=value setting from a config file
+type configParam struct {
+	key   string
+	value string
+	pos   string // Where the parameter was set, for error messages
+}
+
+// profileSection holds the settings of a profile as written in a config file
+type profileSection struct {
+	name        string
+	pos         string      // Where the section starts, for error messages
+	description string      // Not inherited through extends
+	extends     configParam // Parent profile, if value is set
+	override    configParam // Allows replacing a built-in profile, if value is true
+	params      []configParam
+}
+
+// add adds a parameter set in the section
+func (sec *profileSection) add(param configParam) {
+	switch strings.ToLower(param.key) {
+	case "extends":
+		sec.extends = param
+	case "description":
+		sec.description = param.value
+	case "override":
+		sec.override = param
+	default:
+		sec.params = append(sec.params, param)
+	}
+}
+
+// check rejects a section that replaces a built-in profile without override=true,
+// and reports keys set more than once (see checkDuplicateKeys)
+func (p ConfigParser) check(sec *profileSection) error {
+	override := false
+	if sec.override.value != "" {
+		v, err := strconv.ParseBool(sec.override.value)
+		if err != nil {
+			return fmt.Errorf("error at %s: invalid override value: %s", sec.override.pos, sec.override.value)
+		}
+		override = v
+	}
+	if _, builtin := GetBuiltinProfiles()[strings.ToLower(sec.name)]; builtin && !override {
+		return fmt.Errorf("error at %s: profile %s replaces a built-in profile (set override=true to replace it)", sec.pos, sec.name)
+	}
+	return p.checkDuplicateKeys(sec)
+}
+
+// checkDuplicateKeys reports each key set twice in a section, where the later
+// value would silently win. rules may be repeated. Duplicates are errors with
+// p.Strict and are reported to p.Warn otherwise.
+func (p ConfigParser) checkDuplicateKeys(sec *profileSection) error {
+	seen := make(map[string]configParam, len(sec.params))
+	for _, param := range sec.params {
+		if lower := strings.ToLower(param.key); lower == "rules" || lower == "rule" {
+			continue
+		}
+		key := paramField(param)
+		first, ok := seen[key]
+		if !ok {
+			seen[key] = param
+			continue
+		}
+		msg := fmt.Sprintf("error at %s: duplicate key %s in profile %s (first set at %s)", param.pos, param.key, sec.name, first.pos)
+		if p.Strict {
+			return errors.New(msg)
+		}
+		if p.Warn != nil {
+			p.Warn(msg + " (later value used)")
+		}
+	}
+	return nil
+}
 
+// paramField returns the Config field param sets, so aliases such as depth and
+// max-depth compare equal, or the lowercase key when no field changes (unknown
+// keys, invalid or zero values) or the key sets a single decimal.FIELD
+func paramField(param configParam) string {
+	if strings.HasPrefix(strings.ToLower(param.key), "decimal.") {
+		return strings.ToLower(param.key)
+	}
+	var cfg Config
+	if applyConfigParameter(&cfg, param.key, param.value) == nil {
+		v := reflect.ValueOf(cfg)
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).IsZero() {
+				return v.Type().Field(i).Name
+			}
+		}
+	}
+	return strings.ToLower(param.key)
+}
+
+// resolveProfiles builds the Config of each section after all sections were read,
+// so a profile can extend one declared later. Section names must be unique.
+func (p ConfigParser) resolveProfiles(sections []*profileSection) (map[string]Profile, error) {
+	byName := make(map[string]*profileSection, len(sections))
+	for _, sec := range sections {
+		if first, ok := byName[sec.name]; ok {
+			return nil, fmt.Errorf("error at %s: duplicate profile %s (first defined at %s)", sec.pos, sec.name, first.pos)
+		}
+		if err := p.check(sec); err != nil {
+			return nil, err
+		}
+		byName[sec.name] = sec
+	}
+
+	profiles := make(map[string]Profile, len(byName))
+	var resolve func(sec *profileSection, chain []string) (Config, error)
+	resolve = func(sec *profileSection, chain []string) (Config, error) {
+		if profile, ok := profiles[sec.name]; ok {
+			return profile.Config, nil
+		}
+		if slices.Contains(chain, sec.name) {
+			return Config{}, fmt.Errorf("error at %s: profile inheritance cycle: %s -> %s",
+				sec.extends.pos, strings.Join(chain, " -> "), sec.name)
+		}
+
+		cfg := Config{
+			DecimalPlaces: -1, // Default: no rounding
+		}
+		if parent := sec.extends.value; parent != "" {
+			if parentSec, ok := byName[parent]; ok {
+				var err error
+				if cfg, err = resolve(parentSec, append(chain, sec.name)); err != nil {
+					return Config{}, err
+				}
+				// Rules added by the child must not share the parent's backing array
+				cfg.Rules = slices.Clip(cfg.Rules)
+			} else if profile, ok := GetProfile(parent); ok {
+				cfg = profile
+				cfg.Rules = slices.Clip(cfg.Rules)
+			} else {
+				return Config{}, fmt.Errorf("error at %s: profile %s extends unknown profile %s",
+					sec.extends.pos, sec.name, parent)
+			}
+		}
+
+		for _, param := range sec.params {
+			if err := p.apply(&cfg, param); err != nil {
+				return Config{}, err
+			}
+		}
+		profiles[sec.name] = Profile{Name: sec.name, Description: sec.description, Config: cfg}
+		return cfg, nil
+	}
+
+	for _, sec := range sections {
+		if _, err := resolve(sec, nil); err != nil {
+			return nil, err
+		}
+	}
 	return profiles, nil
 }
 
+// apply applies param to cfg, skipping unknown keys unless p.Strict is set
+func (p ConfigParser) apply(cfg *Config, param configParam) error {
+	err := applyConfigParameter(cfg, param.key, param.value)
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("error at %s: %w", param.pos, err)
+	if !p.Strict && errors.Is(err, errUnknownParameter) {
+		if p.Warn != nil {
+			p.Warn(err.Error() + " (ignored)")
+		}
+		return nil
+	}
+	return err
+}
+
+// Set applies a single config file parameter, such as depth=3 or block=a,b, to
+// c. Keys are case-insensitive and accept the aliases of config files, which
+// include the names of the CLI flags. It does not validate c as a whole.
+func (c *Config) Set(key, value string) error {
+	return applyConfigParameter(c, key, value)
+}
+
 // applyConfigParameter applies a single parameter to config
 func applyConfigParameter(cfg *Config, key, value string) error {
 	key = strings.ToLower(key)
@@ -121,7 +649,7 @@ func applyConfigParameter(cfg *Config, key, value string) error {
 		return err
 	}
 
-	return fmt.Errorf("unknown parameter: %s", key)
+	return fmt.Errorf("%w: %s", errUnknownParameter, key)
 }
 
 var errUnknownParameter = fmt.Errorf("unknown parameter")
@@ -135,6 +663,15 @@ func applyBasicParameter(cfg *Config, key, value string) error {
 		}
 		cfg.MaxDepth = v
 
+	case "depth-truncation-marker", "depthtruncationmarker", "depth-marker":
+		cfg.DepthTruncationMarker = value
+	case "report-depth-elision", "reportdepthelision", "depth-elision":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid report-depth-elision value: %s", value)
+		}
+		cfg.ReportDepthElision = v
+
 	case "list-len", "list-length", "max-list-length", "maxlistlength":
 		v, err := strconv.Atoi(value)
 		if err != nil {
@@ -149,6 +686,20 @@ func applyBasicParameter(cfg *Config, key, value string) error {
 		}
 		cfg.MaxStringLength = v
 
+	case "truncate-boundary", "truncateboundary", "string-boundary":
+		cfg.TruncateBoundary = value
+	case "string-length-unit", "stringlengthunit", "string-unit":
+		cfg.StringLengthUnit = value
+	case "truncation-suffix", "truncationsuffix", "string-suffix":
+		cfg.TruncationSuffix = &value
+
+	case "max-output-bytes", "maxoutputbytes", "max-bytes":
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid max-output-bytes value: %s", value)
+		}
+		cfg.MaxOutputBytes = v
+
 	case "strip-empty", "stripempty":
 		v, err := strconv.ParseBool(value)
 		if err != nil {
@@ -156,6 +707,16 @@ func applyBasicParameter(cfg *Config, key, value string) error {
 		}
 		cfg.StripEmpty = v
 
+	case "empty-values", "emptyvalues":
+		cfg.EmptyValues = ParseEmptyValues(value)
+
+	case "keep-null", "keepnull":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid keep-null value: %s", value)
+		}
+		cfg.KeepNull = v
+
 	case "block", "block-list", "blocklist":
 		if value != "" {
 			cfg.BlockList = strings.Split(value, ",")
@@ -164,6 +725,14 @@ func applyBasicParameter(cfg *Config, key, value string) error {
 			}
 		}
 
+	case "preserve", "preserve-fields", "preservefields":
+		if value != "" {
+			cfg.PreserveFields = strings.Split(value, ",")
+			for i := range cfg.PreserveFields {
+				cfg.PreserveFields[i] = strings.TrimSpace(cfg.PreserveFields[i])
+			}
+		}
+
 	case "decimal-places", "decimalplaces":
 		v, err := strconv.Atoi(value)
 		if err != nil {
@@ -171,6 +740,13 @@ func applyBasicParameter(cfg *Config, key, value string) error {
 		}
 		cfg.DecimalPlaces = v
 
+	case "field-decimal-places", "fielddecimalplaces", "field-decimals":
+		v, err := ParseFieldDecimalPlaces(value)
+		if err != nil {
+			return fmt.Errorf("invalid field-decimal-places value: %w", err)
+		}
+		cfg.FieldDecimalPlaces = v
+
 	case "deduplicate", "deduplicate-arrays", "deduplicatearrays":
 		v, err := strconv.ParseBool(value)
 		if err != nil {
@@ -181,6 +757,26 @@ func applyBasicParameter(cfg *Config, key, value string) error {
 	case "sample-strategy", "samplestrategy":
 		cfg.SampleStrategy = value
 
+	case "sample-sort-key", "samplesortkey":
+		cfg.SampleSortKey = value
+
+	case "sample-counts", "samplecounts":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid sample-counts value: %s", value)
+		}
+		cfg.SampleCounts = v
+
+	case "sample-stratify-key", "samplestratifykey":
+		cfg.SampleStratifyKey = value
+
+	case "random-seed", "randomseed":
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid random-seed value: %s", value)
+		}
+		cfg.RandomSeed = v
+
 	case "sample-size", "samplesize":
 		v, err := strconv.Atoi(value)
 		if err != nil {
@@ -189,7 +785,22 @@ func applyBasicParameter(cfg *Config, key, value string) error {
 		cfg.SampleSize = v
 
 	default:
-		return errUnknownParameter
+		// decimal.FIELD=N sets the precision of a single field
+		field, ok := strings.CutPrefix(key, "decimal.")
+		if !ok {
+			return errUnknownParameter
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil || field == "" {
+			return fmt.Errorf("invalid %s value: %s", key, value)
+		}
+		// Copy the map, which a profile may share with the one it extends
+		places := maps.Clone(cfg.FieldDecimalPlaces)
+		if places == nil {
+			places = make(map[string]int)
+		}
+		places[field] = v
+		cfg.FieldDecimalPlaces = places
 	}
 	return nil
 }
@@ -210,6 +821,34 @@ func applyAdvancedParameter(cfg *Config, key, value string) error {
 		}
 		cfg.TypeInference = v
 
+	case "type-inference-columnar", "typeinferencecolumnar":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid type-inference-columnar value: %s", value)
+		}
+		cfg.TypeInferenceColumnar = v
+
+	case "matrix-columnar", "matrixcolumnar":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid matrix-columnar value: %s", value)
+		}
+		cfg.MatrixColumnar = v
+
+	case "run-length-encode", "runlengthencode", "rle":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid run-length-encode value: %s", value)
+		}
+		cfg.RunLengthEncode = v
+
+	case "bool-array-packing", "boolarraypacking", "bit-packing":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool-array-packing value: %s", value)
+		}
+		cfg.BoolArrayPacking = v
+
 	case "bool-compression", "boolcompression":
 		v, err := strconv.ParseBool(value)
 		if err != nil {
@@ -238,6 +877,48 @@ func applyAdvancedParameter(cfg *Config, key, value string) error {
 		}
 		cfg.StringPoolMinOccurrences = v
 
+	case "lossless":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid lossless value: %s", value)
+		}
+		cfg.Lossless = v
+
+	case "emit-version", "emitversion":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid emit-version value: %s", value)
+		}
+		cfg.EmitVersion = v
+
+	case "warn-on-expansion", "warnonexpansion":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid warn-on-expansion value: %s", value)
+		}
+		cfg.WarnOnExpansion = v
+
+	case "checksum":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid checksum value: %s", value)
+		}
+		cfg.Checksum = v
+
+	case "shorten-keys", "shortenkeys":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid shorten-keys value: %s", value)
+		}
+		cfg.ShortenKeys = v
+
+	case "min-savings-bytes", "minsavingsbytes", "min-savings":
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid min-savings-bytes value: %s", value)
+		}
+		cfg.MinSavingsBytes = v
+
 	case "number-delta", "numberdelta":
 		v, err := strconv.ParseBool(value)
 		if err != nil {
@@ -266,6 +947,65 @@ func applyAdvancedParameter(cfg *Config, key, value string) error {
 		}
 		cfg.EnumMaxValues = v
 
+	case "inline-enum-threshold", "inlineenumthreshold", "enum-inline-threshold":
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid inline-enum-threshold value: %s", value)
+		}
+		cfg.InlineEnumThreshold = v
+
+	case "strip-html", "striphtml":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid strip-html value: %s", value)
+		}
+		cfg.StripHTML = v
+
+	case "strip-markdown", "stripmarkdown":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid strip-markdown value: %s", value)
+		}
+		cfg.StripMarkdown = v
+
+	case "decode-html-entities", "decodehtmlentities", "html-entities":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid decode-html-entities value: %s", value)
+		}
+		cfg.DecodeHTMLEntities = v
+
+	case "normalize-unicode", "normalizeunicode", "unicode-normalization":
+		cfg.NormalizeUnicode = value
+
+	case "clean-whitespace", "cleanwhitespace":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid clean-whitespace value: %s", value)
+		}
+		cfg.CleanWhitespace = v
+
+	case "normalize-quotes", "normalizequotes":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid normalize-quotes value: %s", value)
+		}
+		cfg.NormalizeQuotes = v
+
+	case "collapse-whitespace", "collapsewhitespace":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid collapse-whitespace value: %s", value)
+		}
+		cfg.CollapseWhitespace = v
+
+	case "keep-newlines", "keepnewlines", "collapse-whitespace-keep-newlines":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid keep-newlines value: %s", value)
+		}
+		cfg.CollapseWhitespaceKeepNewlines = v
+
 	case "strip-emoji", "stripemoji", "strip-utf8-emoji", "striputf8emoji":
 		v, err := strconv.ParseBool(value)
 		if err != nil {
@@ -273,12 +1013,471 @@ func applyAdvancedParameter(cfg *Config, key, value string) error {
 		}
 		cfg.StripUTF8Emoji = v
 
+	case "defaults":
+		v, err := parseFieldValues(value)
+		if err != nil {
+			return fmt.Errorf("invalid defaults value: %w", err)
+		}
+		cfg.Defaults = v
+
+	case "drop-if", "dropif":
+		v, err := ParseDropIf(value)
+		if err != nil {
+			return fmt.Errorf("invalid drop-if value: %w", err)
+		}
+		cfg.DropIfEquals = v
+
+	case "drop-if-ignore-case", "dropifignorecase":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid drop-if-ignore-case value: %s", value)
+		}
+		cfg.DropIfEqualsIgnoreCase = v
+
+	case "flatten":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid flatten value: %s", value)
+		}
+		cfg.Flatten = v
+
+	case "parallelism":
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid parallelism value: %s", value)
+		}
+		cfg.Parallelism = v
+
+	case "flatten-max-depth", "flattenmaxdepth":
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid flatten-max-depth value: %s", value)
+		}
+		cfg.FlattenMaxDepth = v
+
+	case "flatten-single-key-chains", "flattensinglekeychains", "flatten-chains":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid flatten-single-key-chains value: %s", value)
+		}
+		cfg.FlattenSingleKeyChains = v
+
+	case "sort-keys", "sortkeys":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid sort-keys value: %s", value)
+		}
+		cfg.SortKeys = v
+
+	case "preserve-key-order", "preservekeyorder":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid preserve-key-order value: %s", value)
+		}
+		cfg.PreserveKeyOrder = v
+
+	case "truncation-summaries", "truncationsummaries":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid truncation-summaries value: %s", value)
+		}
+		cfg.TruncationSummaries = v
+
+	case "rules", "rule":
+		rule, err := parsePathRule(value)
+		if err != nil {
+			return fmt.Errorf("invalid rules value: %w", err)
+		}
+		cfg.Rules = append(slices.Clip(cfg.Rules), rule)
+
+	case "detect-defaults", "detectdefaults":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid detect-defaults value: %s", value)
+		}
+		cfg.DetectDefaults = v
+
 	default:
 		return errUnknownParameter
 	}
 	return nil
 }
 
+// fieldValue is a single field:value pair from the config file
+type fieldValue struct {
+	field string
+	value interface{}
+}
+
+// parseFieldValuePairs parses "field:value;field:value" pairs.
+// Values are decoded as JSON when possible (numbers, booleans, null) and kept as strings otherwise.
+func parseFieldValuePairs(value string) ([]fieldValue, error) {
+	var pairs []fieldValue
+	for _, pair := range strings.Split(value, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected field:value, got %q", pair)
+		}
+		raw := strings.TrimSpace(parts[1])
+
+		var v interface{}
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			v = raw
+		}
+		pairs = append(pairs, fieldValue{field: strings.TrimSpace(parts[0]), value: v})
+	}
+	return pairs, nil
+}
+
+// parseFieldValues parses "field:value;field:value" into a map, later pairs winning
+func parseFieldValues(value string) (map[string]interface{}, error) {
+	pairs, err := parseFieldValuePairs(value)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(pairs))
+	for _, p := range pairs {
+		result[p.field] = p.value
+	}
+	return result, nil
+}
+
+// ParseDropIf parses DropIfEquals rules in "field:value;field:value" form,
+// e.g. "status:ok;error:none". Repeating a field adds another value for it.
+func ParseDropIf(value string) (map[string][]interface{}, error) {
+	pairs, err := parseFieldValuePairs(value)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]interface{}, len(pairs))
+	for _, p := range pairs {
+		result[p.field] = append(result[p.field], p.value)
+	}
+	return result, nil
+}
+
+// ParseEmptyValues parses EmptyValues in "value;value" form, e.g.
+// "N/A;-;0". Each value is read as JSON, or as a string if it is not valid
+// JSON, so "0" is the number 0 and "\"0\"" the string "0".
+func ParseEmptyValues(value string) []interface{} {
+	var result []interface{}
+	for _, raw := range strings.Split(value, ";") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			v = raw
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+// ParseFieldDecimalPlaces parses FieldDecimalPlaces in "field:N;field:N"
+// form, e.g. "price:2;lat:6;lng:6".
+func ParseFieldDecimalPlaces(value string) (map[string]int, error) {
+	pairs, err := parseFieldValuePairs(value)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]int, len(pairs))
+	for _, p := range pairs {
+		n, ok := p.value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return nil, fmt.Errorf("%s: expected an integer number of decimal places, got %v", p.field, p.value)
+		}
+		result[p.field] = int(n)
+	}
+	return result, nil
+}
+
+// parsePathRule parses a rule in "selector key=value key=value" form,
+// e.g. "$.logs[*] max-depth=2 list-len=3". Keys are config file keys.
+// Repeating the rules key in a profile adds another rule.
+func parsePathRule(value string) (PathRule, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return PathRule{}, fmt.Errorf("missing path")
+	}
+	if _, err := parseSelector(fields[0]); err != nil {
+		return PathRule{}, err
+	}
+
+	rule := PathRule{Path: fields[0], Config: Config{DecimalPlaces: -1}}
+	for _, param := range fields[1:] {
+		key, val, ok := strings.Cut(param, "=")
+		if !ok {
+			return PathRule{}, fmt.Errorf("expected key=value, got %q", param)
+		}
+		if err := applyConfigParameter(&rule.Config, key, val); err != nil {
+			return PathRule{}, err
+		}
+	}
+	return rule, nil
+}
+
+// WriteConfigFile writes profiles to path in .slimjson format, sorted by name.
+// Only options that differ from their default are written, so ParseConfigFile
+// returns equal Configs. OnRemove and ValueTransform cannot be written and are skipped.
+func WriteConfigFile(path string, profiles map[string]Config) error {
+	var buf bytes.Buffer
+	for i, name := range slices.Sorted(maps.Keys(profiles)) {
+		section, err := formatProfile(name, profiles[name])
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(section)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// SaveProfile writes a profile to the .slimjson file at path, replacing the
+// section of the same name and keeping other sections and comments as they are.
+// The file is created if it does not exist.
+func SaveProfile(path, name string, cfg Config) error {
+	section, err := formatProfile(name, cfg)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	isHeader := func(line string) bool {
+		_, ok := sectionName(line)
+		return ok
+	}
+	isComment := func(line string) bool {
+		line = strings.TrimSpace(line)
+		return line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+	}
+
+	var out strings.Builder
+	replaced := false
+	for i := 0; i < len(lines); {
+		if header, ok := sectionName(lines[i]); !ok || header != name {
+			out.WriteString(lines[i])
+			i++
+			continue
+		}
+
+		// Skip the section, but keep comments that introduce the next section
+		end := i + 1
+		for end < len(lines) && !isHeader(lines[end]) {
+			end++
+		}
+		keep := end
+		for keep > i+1 && isComment(lines[keep-1]) {
+			keep--
+		}
+		if !replaced {
+			out.WriteString(section)
+			if keep < end && strings.TrimSpace(lines[keep]) != "" {
+				out.WriteByte('\n')
+			}
+			replaced = true
+		}
+		out.WriteString(strings.Join(lines[keep:end], ""))
+		i = end
+	}
+
+	if !replaced {
+		if out.Len() > 0 {
+			if !strings.HasSuffix(out.String(), "\n") {
+				out.WriteByte('\n')
+			}
+			out.WriteByte('\n')
+		}
+		out.WriteString(section)
+	}
+
+	if err := os.WriteFile(path, []byte(out.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// sectionName returns the profile name of a [name] section header line
+func sectionName(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	return strings.TrimSpace(line[1 : len(line)-1]), true
+}
+
+// formatProfile returns the .slimjson section for a profile, with override=true
+// for a built-in profile name
+func formatProfile(name string, cfg Config) (string, error) {
+	if name == "" || name != strings.TrimSpace(name) || strings.ContainsAny(name, "[]\n") {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+	params, err := configParams(cfg, false)
+	if err != nil {
+		return "", fmt.Errorf("profile %s: %w", name, err)
+	}
+	var b strings.Builder
+	b.WriteString("[" + name + "]\n")
+	if _, builtin := GetBuiltinProfiles()[strings.ToLower(name)]; builtin {
+		b.WriteString("override=true\n")
+	}
+	for _, param := range params {
+		b.WriteString(param + "\n")
+	}
+	return b.String(), nil
+}
+
+// configParams returns the key=value parameters for the options of cfg that
+// differ from their default, in field order. Parameters of a path rule
+// (inRule) must not contain whitespace.
+func configParams(cfg Config, inRule bool) ([]string, error) {
+	var params []string
+	add := func(key, value string) error {
+		if value != strings.TrimSpace(value) || strings.Contains(value, "\n") ||
+			(inRule && strings.ContainsAny(value, " \t")) {
+			return fmt.Errorf("%s value %q cannot be written to a config file", key, value)
+		}
+		params = append(params, key+"="+value)
+		return nil
+	}
+
+	v, t := reflect.ValueOf(cfg), reflect.TypeOf(cfg)
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		field := v.Field(i)
+		if key == "" || key == "-" {
+			continue
+		}
+		if key == "decimal-places" {
+			// Profiles default to -1 (no rounding), and 0 rounds to integers
+			if cfg.DecimalPlaces != -1 {
+				params = append(params, key+"="+strconv.Itoa(cfg.DecimalPlaces))
+			}
+			continue
+		}
+		if field.IsZero() {
+			continue
+		}
+
+		var err error
+		switch val := field.Interface().(type) {
+		case int, int64:
+			err = add(key, fmt.Sprint(val))
+		case bool:
+			err = add(key, strconv.FormatBool(val))
+		case string:
+			err = add(key, val)
+		case *string:
+			err = add(key, *val)
+		case []string:
+			for _, item := range val {
+				if item == "" || item != strings.TrimSpace(item) || strings.Contains(item, ",") {
+					return nil, fmt.Errorf("%s value %q cannot be written to a config file", key, item)
+				}
+			}
+			err = add(key, strings.Join(val, ","))
+		case []interface{}:
+			var values []string
+			for _, item := range val {
+				raw, ferr := formatValue(item)
+				if ferr != nil || raw == "" || strings.ContainsAny(raw, ";\n") {
+					return nil, fmt.Errorf("%s value %v cannot be written to a config file", key, item)
+				}
+				values = append(values, raw)
+			}
+			err = add(key, strings.Join(values, ";"))
+		case map[string]interface{}:
+			var pairs []string
+			for _, f := range slices.Sorted(maps.Keys(val)) {
+				pair, perr := formatFieldValue(f, val[f])
+				if perr != nil {
+					return nil, fmt.Errorf("%s: %w", key, perr)
+				}
+				pairs = append(pairs, pair)
+			}
+			err = add(key, strings.Join(pairs, ";"))
+		case map[string]int:
+			var pairs []string
+			for _, f := range slices.Sorted(maps.Keys(val)) {
+				pair, perr := formatFieldValue(f, val[f])
+				if perr != nil {
+					return nil, fmt.Errorf("%s: %w", key, perr)
+				}
+				pairs = append(pairs, pair)
+			}
+			err = add(key, strings.Join(pairs, ";"))
+		case map[string][]interface{}:
+			var pairs []string
+			for _, f := range slices.Sorted(maps.Keys(val)) {
+				for _, item := range val[f] {
+					pair, perr := formatFieldValue(f, item)
+					if perr != nil {
+						return nil, fmt.Errorf("%s: %w", key, perr)
+					}
+					pairs = append(pairs, pair)
+				}
+			}
+			err = add(key, strings.Join(pairs, ";"))
+		case []PathRule:
+			if inRule {
+				return nil, fmt.Errorf("path rules cannot contain rules")
+			}
+			for _, rule := range val {
+				ruleParams, rerr := configParams(rule.Config, true)
+				if rerr != nil {
+					return nil, fmt.Errorf("rule %s: %w", rule.Path, rerr)
+				}
+				if err = add(key, strings.Join(append([]string{rule.Path}, ruleParams...), " ")); err != nil {
+					break
+				}
+			}
+		default:
+			err = fmt.Errorf("%s cannot be written to a config file", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return params, nil
+}
+
+// formatFieldValue formats a field:value pair as parseFieldValuePairs reads it.
+// Strings are written as JSON if they would otherwise be read as another type.
+func formatFieldValue(field string, value interface{}) (string, error) {
+	raw, err := formatValue(value)
+	if err != nil {
+		return "", err
+	}
+	if field == "" || field != strings.TrimSpace(field) || strings.ContainsAny(field, ":;") || strings.ContainsAny(raw, ";\n") {
+		return "", fmt.Errorf("%s:%s cannot be written to a config file", field, raw)
+	}
+	return field + ":" + raw, nil
+}
+
+// formatValue formats a value as JSON, or as the plain string if it would be
+// read back as the same string
+func formatValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok && s == strings.TrimSpace(s) && !json.Valid([]byte(s)) {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 // GetBuiltinProfiles returns the built-in profiles (light, medium, aggressive, ai-optimized)
 func GetBuiltinProfiles() map[string]Config {
 	return map[string]Config{
@@ -1,8 +1,11 @@
 package slimjson
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -160,6 +163,194 @@ depth=not-a-number
 	}
 }
 
+func TestParseConfigFileExtends(t *testing.T) {
+	configContent := `[child]
+extends=parent
+block=token
+string-pooling=true
+decimal.price=2
+
+[parent]
+extends=base
+depth=4
+list-len=8
+
+[base]
+depth=2
+strip-empty=true
+block=password,secret
+rules=logs depth=1
+decimal.lat=6
+decimal.lng=6
+
+[ai]
+extends=ai-optimized
+depth=6
+rules=logs depth=1
+
+[sibling]
+extends=parent
+rules=meta depth=2
+`
+	configPath := filepath.Join(t.TempDir(), ".slimjson")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	profiles, err := ParseConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to parse config file: %v", err)
+	}
+
+	logsRule := PathRule{Path: "logs", Config: Config{MaxDepth: 1, DecimalPlaces: -1}}
+	coords := map[string]int{"lat": 6, "lng": 6}
+	builtin := GetBuiltinProfiles()["ai-optimized"]
+	expected := map[string]Config{
+		"base": {MaxDepth: 2, StripEmpty: true, DecimalPlaces: -1, FieldDecimalPlaces: coords, BlockList: []string{"password", "secret"}, Rules: []PathRule{logsRule}},
+		"parent": {MaxDepth: 4, MaxListLength: 8, StripEmpty: true, DecimalPlaces: -1, FieldDecimalPlaces: coords, BlockList: []string{"password", "secret"},
+			Rules: []PathRule{logsRule}},
+		"child": {MaxDepth: 4, MaxListLength: 8, StripEmpty: true, DecimalPlaces: -1, FieldDecimalPlaces: map[string]int{"lat": 6, "lng": 6, "price": 2},
+			BlockList: []string{"token"}, StringPooling: true, Rules: []PathRule{logsRule}},
+		"ai": {MaxDepth: 6, MaxListLength: builtin.MaxListLength, StripEmpty: builtin.StripEmpty, BlockList: builtin.BlockList,
+			Rules: []PathRule{logsRule}},
+		"sibling": {MaxDepth: 4, MaxListLength: 8, StripEmpty: true, DecimalPlaces: -1, FieldDecimalPlaces: coords, BlockList: []string{"password", "secret"},
+			Rules: []PathRule{logsRule, {Path: "meta", Config: Config{MaxDepth: 2, DecimalPlaces: -1}}}},
+	}
+	for name, want := range expected {
+		if got := profiles[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Profile %s = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestParseConfigFileExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Unknown parent",
+			content:  "[a]\nextends=missing\n",
+			expected: "error at line 2: profile a extends unknown profile missing",
+		},
+		{
+			name:     "Self reference",
+			content:  "[a]\nextends=a\n",
+			expected: "profile inheritance cycle: a -> a",
+		},
+		{
+			name:     "Cycle",
+			content:  "[a]\nextends=b\n[b]\nextends=c\n[c]\nextends=a\n",
+			expected: "profile inheritance cycle: a -> b -> c -> a",
+		},
+		{
+			name:     "Invalid value in a child",
+			content:  "[a]\ndepth=2\n[b]\nextends=a\ndepth=x\n",
+			expected: "error at line 5: invalid depth value: x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".slimjson")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test config file: %v", err)
+			}
+			_, err := ParseConfigFile(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ParseConfigFile() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseConfigFileDuplicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expected  string // Error in strict mode
+		warning   string // Warning in non-strict mode, "" if it is an error in both
+		wantDepth int    // Depth of the first profile in non-strict mode
+	}{
+		{
+			name:     "Duplicate profile",
+			content:  "[a]\ndepth=2\n\n[b]\n[a]\ndepth=3\n",
+			expected: "error at line 5: duplicate profile a (first defined at line 1)",
+		},
+		{
+			name:     "Built-in profile",
+			content:  "[medium]\ndepth=2\n",
+			expected: "error at line 1: profile medium replaces a built-in profile (set override=true to replace it)",
+		},
+		{
+			name:     "Invalid override",
+			content:  "[light]\noverride=maybe\n",
+			expected: "error at line 2: invalid override value: maybe",
+		},
+		{
+			name:      "Duplicate key",
+			content:   "[a]\ndepth=2\nlist-len=4\ndepth=3\n",
+			expected:  "error at line 4: duplicate key depth in profile a (first set at line 2)",
+			warning:   "error at line 4: duplicate key depth in profile a (first set at line 2) (later value used)",
+			wantDepth: 3,
+		},
+		{
+			name:      "Duplicate key through an alias",
+			content:   "[a]\nmax-depth=2\nMaxDepth=3\n",
+			expected:  "error at line 3: duplicate key MaxDepth in profile a (first set at line 2)",
+			warning:   "error at line 3: duplicate key MaxDepth in profile a (first set at line 2) (later value used)",
+			wantDepth: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".slimjson")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test config file: %v", err)
+			}
+			_, err := ParseConfigFile(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ParseConfigFile() error = %v, want %q", err, tt.expected)
+			}
+
+			var warnings []string
+			p := ConfigParser{Warn: func(msg string) { warnings = append(warnings, msg) }}
+			profiles, err := p.ParseINI(configPath)
+			if tt.warning == "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expected) {
+					t.Errorf("Non-strict ParseINI() error = %v, want %q", err, tt.expected)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Non-strict ParseINI() error: %v", err)
+			}
+			if !reflect.DeepEqual(warnings, []string{tt.warning}) {
+				t.Errorf("Warnings = %q, want %q", warnings, tt.warning)
+			}
+			if profiles["a"].MaxDepth != tt.wantDepth {
+				t.Errorf("Expected depth %d, got %d", tt.wantDepth, profiles["a"].MaxDepth)
+			}
+		})
+	}
+
+	// override=true replaces a built-in profile; rules may be repeated
+	configPath := filepath.Join(t.TempDir(), ".slimjson")
+	content := "[medium]\noverride=true\ndepth=2\nrule=a max-depth=1\nrule=b max-depth=1\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+	profiles, err := ParseConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() error: %v", err)
+	}
+	if profiles["medium"].MaxDepth != 2 || len(profiles["medium"].Rules) != 2 {
+		t.Errorf("Expected overridden medium profile with 2 rules, got %+v", profiles["medium"])
+	}
+}
+
 func TestApplyConfigParameter(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -199,6 +390,22 @@ func TestApplyConfigParameter(t *testing.T) {
 				return c.DecimalPlaces == 2
 			},
 		},
+		{
+			name:  "field-decimal-places",
+			key:   "field-decimal-places",
+			value: "price:2; lat:6",
+			checkFunc: func(c *Config) bool {
+				return c.FieldDecimalPlaces["price"] == 2 && c.FieldDecimalPlaces["lat"] == 6
+			},
+		},
+		{
+			name:  "decimal.field",
+			key:   "decimal.lat",
+			value: "6",
+			checkFunc: func(c *Config) bool {
+				return c.FieldDecimalPlaces["lat"] == 6
+			},
+		},
 		{
 			name:  "string-pooling",
 			key:   "string-pooling",
@@ -207,6 +414,22 @@ func TestApplyConfigParameter(t *testing.T) {
 				return c.StringPooling == true
 			},
 		},
+		{
+			name:  "defaults",
+			key:   "defaults",
+			value: "enabled:true; weight:1; mode:fast",
+			checkFunc: func(c *Config) bool {
+				return c.Defaults["enabled"] == true && c.Defaults["weight"] == 1.0 && c.Defaults["mode"] == "fast"
+			},
+		},
+		{
+			name:  "drop-if",
+			key:   "drop-if",
+			value: "status:ok;error:none;status:success;retries:0",
+			checkFunc: func(c *Config) bool {
+				return len(c.DropIfEquals["status"]) == 2 && c.DropIfEquals["error"][0] == "none" && c.DropIfEquals["retries"][0] == 0.0
+			},
+		},
 		{
 			name:  "block-list",
 			key:   "block",
@@ -231,6 +454,243 @@ func TestApplyConfigParameter(t *testing.T) {
 	}
 }
 
+func TestConfigSet(t *testing.T) {
+	rules := make([]PathRule, 1, 2)
+	base := Config{Rules: rules}
+
+	cfg := base
+	for key, value := range map[string]string{"Depth": "3", "min-savings": "8", "rule": "logs list-len=1"} {
+		if err := cfg.Set(key, value); err != nil {
+			t.Fatalf("Set(%q) error: %v", key, err)
+		}
+	}
+	if cfg.MaxDepth != 3 || cfg.MinSavingsBytes != 8 || len(cfg.Rules) != 2 || cfg.Rules[1].Path != "logs" {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if rules[:2][1].Path != "" {
+		t.Error("Set modified the rules of the config it was copied from")
+	}
+
+	if err := cfg.Set("colour", "red"); err == nil || !strings.Contains(err.Error(), "unknown parameter: colour") {
+		t.Errorf("Set() error = %v, want an unknown parameter error", err)
+	}
+}
+
+func TestConfigJSONTags(t *testing.T) {
+	cfg := Config{
+		MaxDepth:      3,
+		MaxListLength: 5,
+		StripEmpty:    true,
+		BlockList:     []string{"secret"},
+		DecimalPlaces: 2,
+		StringPooling: true,
+		DropIfEquals:  map[string][]interface{}{"status": {"ok"}},
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+
+	var keys map[string]interface{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		t.Fatalf("Failed to unmarshal config keys: %v", err)
+	}
+	for _, key := range []string{"max-depth", "max-list-length", "strip-empty", "block-list", "decimal-places", "string-pooling", "drop-if"} {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("Expected key %q in %s", key, data)
+		}
+	}
+	if _, ok := keys["MaxDepth"]; ok {
+		t.Errorf("Unexpected Go-cased key in %s", data)
+	}
+	if len(keys) != 7 {
+		t.Errorf("Expected zero-valued fields to be omitted, got %s", data)
+	}
+
+	// Every JSON key must also be accepted by the config file parser
+	full, err := json.Marshal(Config{
+		MaxDepth: 1, DepthTruncationMarker: "empty", ReportDepthElision: true, MaxListLength: 1, MaxStringLength: 1, TruncateBoundary: "word", StringLengthUnit: "bytes", TruncationSuffix: stringPtr(""), MaxOutputBytes: 1, StripEmpty: true, EmptyValues: []interface{}{"a"}, KeepNull: true, BlockList: []string{"a"}, PreserveFields: []string{"a"},
+		DecimalPlaces: 1, FieldDecimalPlaces: map[string]int{"a": 1}, DeduplicateArrays: true, SampleStrategy: "random", SampleSize: 1, SampleSortKey: "a", SampleCounts: true, SampleStratifyKey: "a", RandomSeed: 1,
+		NullCompression: true, TypeInference: true, TypeInferenceColumnar: true, MatrixColumnar: true, RunLengthEncode: true, BoolCompression: true, BoolArrayPacking: true,
+		TimestampCompression: true, StringPooling: true, StringPoolMinOccurrences: 1, NumberDeltaEncoding: true,
+		NumberDeltaThreshold: 1, EnumDetection: true, EnumMaxValues: 1, InlineEnumThreshold: 1, ShortenKeys: true, MinSavingsBytes: 1, Lossless: true, Checksum: true, EmitVersion: true, WarnOnExpansion: true, Parallelism: 1, NormalizeUnicode: "NFKC", CleanWhitespace: true, NormalizeQuotes: true, StripUTF8Emoji: true, StripHTML: true, StripMarkdown: true, DecodeHTMLEntities: true, CollapseWhitespace: true, CollapseWhitespaceKeepNewlines: true,
+		Defaults: map[string]interface{}{"a": 1}, DetectDefaults: true,
+		DropIfEquals: map[string][]interface{}{"a": {1}}, DropIfEqualsIgnoreCase: true,
+		Flatten: true, FlattenMaxDepth: 1, FlattenSingleKeyChains: true, SortKeys: true, PreserveKeyOrder: true, TruncationSummaries: true,
+		Rules: []PathRule{{Path: "a"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	var fullKeys map[string]interface{}
+	if err := json.Unmarshal(full, &fullKeys); err != nil {
+		t.Fatalf("Failed to unmarshal config keys: %v", err)
+	}
+	n := 0
+	for _, f := range reflect.VisibleFields(reflect.TypeOf(Config{})) {
+		if f.Tag.Get("json") != "-" {
+			n++
+		}
+	}
+	if len(fullKeys) != n {
+		t.Errorf("Expected %d keys for a fully populated config, got %d", n, len(fullKeys))
+	}
+	for key := range fullKeys {
+		if err := applyConfigParameter(&Config{}, key, "1"); err != nil && strings.Contains(err.Error(), "unknown parameter") {
+			t.Errorf("JSON key %q is not a config file key", key)
+		}
+	}
+}
+
+func TestConfigUnmarshalKebabCase(t *testing.T) {
+	input := `{"max-depth": 4, "max-list-length": 8, "strip-empty": true, "block-list": ["url", "avatar_url"], "decimal-places": 2, "string-pool-min": 3}`
+
+	var cfg Config
+	if err := json.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+
+	expected := Config{
+		MaxDepth:                 4,
+		MaxListLength:            8,
+		StripEmpty:               true,
+		BlockList:                []string{"url", "avatar_url"},
+		DecimalPlaces:            2,
+		StringPoolMinOccurrences: 3,
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, expected)
+	}
+}
+
+func TestConfigMerge(t *testing.T) {
+	base := Config{
+		MaxDepth:      5,
+		MaxListLength: 10,
+		StripEmpty:    true,
+		StringPooling: true,
+		BlockList:     []string{"a"},
+	}
+
+	t.Run("Override on", func(t *testing.T) {
+		got := base.Merge(Config{MaxDepth: 2, BoolCompression: true, MaxListLength: 99}, []string{"max-depth", "BoolCompression"})
+		if got.MaxDepth != 2 {
+			t.Errorf("MaxDepth: expected 2, got %d", got.MaxDepth)
+		}
+		if !got.BoolCompression {
+			t.Error("BoolCompression: expected true")
+		}
+		if got.MaxListLength != 10 {
+			t.Errorf("MaxListLength: expected unlisted field to keep 10, got %d", got.MaxListLength)
+		}
+	})
+
+	t.Run("Explicit override off", func(t *testing.T) {
+		got := base.Merge(Config{}, []string{"strip-empty", "string-pooling", "block-list"})
+		if got.StripEmpty {
+			t.Error("StripEmpty: expected override to false")
+		}
+		if got.StringPooling {
+			t.Error("StringPooling: expected override to false")
+		}
+		if got.BlockList != nil {
+			t.Errorf("BlockList: expected override to nil, got %v", got.BlockList)
+		}
+		if got.MaxDepth != 5 {
+			t.Errorf("MaxDepth: expected 5, got %d", got.MaxDepth)
+		}
+	})
+
+	t.Run("Base is not modified", func(t *testing.T) {
+		_ = base.Merge(Config{}, []string{"max-depth"})
+		if base.MaxDepth != 5 {
+			t.Errorf("Merge modified the receiver: MaxDepth = %d", base.MaxDepth)
+		}
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected []string // Substrings of each expected problem
+	}{
+		{name: "Zero config", config: Config{}},
+		{name: "Valid config", config: Config{MaxDepth: 3, MaxListLength: 10, SampleStrategy: "largest", SampleSortKey: "score", SampleSize: 5, DecimalPlaces: -1}},
+		{name: "Negative limits", config: Config{MaxDepth: -1, MaxListLength: -2, MaxStringLength: -3}, expected: []string{"max-depth must not be negative", "max-list-length must not be negative", "max-string-length must not be negative"}},
+		{name: "Negative thresholds", config: Config{SampleSize: -1, StringPoolMinOccurrences: -1, NumberDeltaThreshold: -1, EnumMaxValues: -1, InlineEnumThreshold: -1, FlattenMaxDepth: -1, Parallelism: -1}, expected: []string{"sample-size", "string-pool-min", "number-delta-threshold", "enum-max-values", "inline-enum-threshold", "flatten-max-depth", "parallelism"}},
+		{name: "Decimal places below -1", config: Config{DecimalPlaces: -2}, expected: []string{"decimal-places must be -1"}},
+		{name: "Field decimal places below -1", config: Config{FieldDecimalPlaces: map[string]int{"lat": 6, "price": -2}}, expected: []string{"field-decimal-places price must be -1"}},
+		{name: "Lossless with field decimal places", config: Config{Lossless: true, DecimalPlaces: -1, FieldDecimalPlaces: map[string]int{"lat": 6, "raw": -1}}, expected: []string{"lossless: field-decimal-places rounds numbers of lat"}},
+		{name: "Unknown sample strategy", config: Config{SampleStrategy: "frist_last"}, expected: []string{`unknown sample-strategy "frist_last" (expected one of none, first_last`}},
+		{name: "Sample size exceeds list length", config: Config{SampleSize: 20, MaxListLength: 10}, expected: []string{"sample-size 20 exceeds max-list-length 10"}},
+		{name: "Sort key without value sampling", config: Config{SampleStrategy: "random", SampleSortKey: "score"}, expected: []string{"sample-sort-key requires sample-strategy largest or smallest"}},
+		{name: "Stratify key without stratified sampling", config: Config{SampleStrategy: "representative", SampleStratifyKey: "level"}, expected: []string{"sample-stratify-key requires sample-strategy stratified"}},
+		{name: "Counts without frequency sampling", config: Config{SampleCounts: true}, expected: []string{"sample-counts requires sample-strategy frequency"}},
+		{name: "Enum max values below 2", config: Config{EnumDetection: true, EnumMaxValues: 1}, expected: []string{"enum-max-values must be at least 2"}},
+		{name: "Inline enum threshold without enum detection", config: Config{InlineEnumThreshold: 3}, expected: []string{"inline-enum-threshold requires enum-detection"}},
+		{name: "Unknown depth marker", config: Config{MaxDepth: 2, DepthTruncationMarker: "dots"}, expected: []string{`unknown depth-truncation-marker "dots" (expected one of null, empty, ellipsis)`}},
+		{name: "Depth marker without max depth", config: Config{DepthTruncationMarker: "empty"}, expected: []string{"depth-truncation-marker requires max-depth"}},
+		{name: "Depth elision without max depth", config: Config{ReportDepthElision: true}, expected: []string{"report-depth-elision requires max-depth"}},
+		{name: "Unknown truncate boundary", config: Config{MaxStringLength: 10, TruncateBoundary: "sentence"}, expected: []string{`unknown truncate-boundary "sentence" (expected one of rune, grapheme, word)`}},
+		{name: "Truncate boundary without string length", config: Config{TruncateBoundary: "word"}, expected: []string{"truncate-boundary requires max-string-length"}},
+		{name: "Unknown string length unit", config: Config{MaxStringLength: 10, StringLengthUnit: "words"}, expected: []string{`unknown string-length-unit "words" (expected one of runes, bytes, tokens)`}},
+		{name: "String length unit without string length", config: Config{StringLengthUnit: "tokens"}, expected: []string{"string-length-unit requires max-string-length"}},
+		{name: "Unknown Unicode form", config: Config{NormalizeUnicode: "NFKD"}, expected: []string{`unknown normalize-unicode "NFKD" (expected one of none, NFC, NFD, NFKC)`}},
+		{name: "Columnar without type inference", config: Config{TypeInferenceColumnar: true}, expected: []string{"type-inference-columnar requires type-inference"}},
+		{name: "Flatten depth without flatten", config: Config{FlattenMaxDepth: 2}, expected: []string{"flatten-max-depth requires flatten or flatten-single-key-chains"}},
+		{name: "Ignore case without drop-if", config: Config{DropIfEqualsIgnoreCase: true}, expected: []string{"drop-if-ignore-case requires drop-if"}},
+		{name: "Keep newlines without collapsing", config: Config{CollapseWhitespaceKeepNewlines: true}, expected: []string{"keep-newlines requires collapse-whitespace"}},
+		{name: "Lossless config", config: Config{Lossless: true, DecimalPlaces: -1, StringPooling: true, ShortenKeys: true, TypeInference: true, NumberDeltaEncoding: true, Flatten: true, DetectDefaults: true, SortKeys: true}},
+		{name: "Checksum without lossless", config: Config{Checksum: true}, expected: []string{"checksum requires lossless"}},
+		{name: "Lossless with truncation", config: Config{Lossless: true, DecimalPlaces: -1, MaxStringLength: 100}, expected: []string{"lossless: max-string-length truncates strings"}},
+		{name: "Lossless with lossy options", config: Config{Lossless: true, StripEmpty: true, SampleStrategy: "random", NullCompression: true, Rules: []PathRule{{Path: "logs", Config: Config{DecimalPlaces: -1, MaxDepth: 2, EnumDetection: true}}}},
+			expected: []string{"lossless: strip-empty", "lossless: decimal-places rounds numbers (use -1)", "lossless: sampling", "lossless: null-compression", "lossless: rules[0] logs: max-depth"}},
+		{name: "Invalid rules", config: Config{Rules: []PathRule{{Path: "logs[", Config: Config{}}, {Path: "$.logs[*]", Config: Config{MaxDepth: -1, SampleStrategy: "x"}}}}, expected: []string{"rules[0]: invalid path rule", "rules[1] $.logs[*]: max-depth", "rules[1] $.logs[*]: unknown sample-strategy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if len(tt.expected) == 0 {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want %d problems", len(tt.expected))
+			}
+			problems := strings.Split(err.Error(), "\n")
+			if len(problems) != len(tt.expected) {
+				t.Errorf("Validate() reported %d problems, want %d: %v", len(problems), len(tt.expected), err)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %v, want a problem containing %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewStrict(t *testing.T) {
+	if _, err := NewStrict(Config{SampleStrategy: "frist_last"}); err == nil || !strings.Contains(err.Error(), "invalid config") {
+		t.Errorf("NewStrict() error = %v, want invalid config", err)
+	}
+	if _, err := NewStrict(Config{Lossless: true, DecimalPlaces: -1, MaxStringLength: 10}); err == nil || !strings.Contains(err.Error(), "lossless: max-string-length") {
+		t.Errorf("NewStrict() error = %v, want a lossless max-string-length problem", err)
+	}
+	s, err := NewStrict(Config{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("NewStrict() error = %v", err)
+	}
+	if s.Config.EnumMaxValues != 10 {
+		t.Errorf("NewStrict() should apply defaults like New, got EnumMaxValues %d", s.Config.EnumMaxValues)
+	}
+}
+
 func TestGetBuiltinProfiles(t *testing.T) {
 	profiles := GetBuiltinProfiles()
 
@@ -269,6 +729,105 @@ func TestLoadConfigFileNotFound(t *testing.T) {
 	}
 }
 
+func TestFindConfigFile(t *testing.T) {
+	writeConfig := func(t *testing.T, path string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := map[string]string{".yaml": "p:\n  depth: 2\n", ".json": `{"p": {"depth": 2}}`}[filepath.Ext(path)]
+		if content == "" {
+			content = "[p]\ndepth=2\n"
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		files    []string // Config files and .git markers, relative to the temp root
+		wd       string
+		expected string // Relative to the temp root, "~" for the home directory
+	}{
+		{
+			name:     "Current directory",
+			files:    []string{"repo/.git/HEAD", "repo/a/.slimjson.yaml", "repo/.slimjson"},
+			wd:       "repo/a",
+			expected: "repo/a/.slimjson.yaml",
+		},
+		{
+			name:     "Nearest ancestor wins over home",
+			files:    []string{"repo/.git/HEAD", "repo/.slimjson", "repo/a/.slimjson", "home/.slimjson"},
+			wd:       "repo/a/b/c",
+			expected: "repo/a/.slimjson",
+		},
+		{
+			name:     "Repository root",
+			files:    []string{"repo/.git/HEAD", "repo/.slimjson.json", "home/.slimjson"},
+			wd:       "repo/a/b",
+			expected: "repo/.slimjson.json",
+		},
+		{
+			name:     "Search stops at .git",
+			files:    []string{".slimjson", "repo/.git/HEAD", "home/.slimjson"},
+			wd:       "repo/a",
+			expected: "~",
+		},
+		{
+			name:     "Git worktree file",
+			files:    []string{".slimjson", "repo/.git"},
+			wd:       "repo",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := filepath.EvalSymlinks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			home := filepath.Join(root, "home")
+			if err := os.MkdirAll(home, 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("HOME", home)
+			for _, f := range tt.files {
+				writeConfig(t, filepath.Join(root, f))
+			}
+			wd := filepath.Join(root, tt.wd)
+			if err := os.MkdirAll(wd, 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Chdir(wd)
+
+			expected := tt.expected
+			switch expected {
+			case "":
+			case "~":
+				expected = filepath.Join(home, ".slimjson")
+			default:
+				expected = filepath.Join(root, expected)
+			}
+			if got := FindConfigFile(); got != expected {
+				t.Errorf("FindConfigFile() = %q, want %q", got, expected)
+			}
+
+			profiles, err := LoadConfigFile()
+			if err != nil {
+				t.Fatalf("LoadConfigFile() error: %v", err)
+			}
+			if got := ConfigSource(); got != expected {
+				t.Errorf("ConfigSource() = %q, want %q", got, expected)
+			}
+			if _, ok := profiles["p"]; ok != (expected != "") {
+				t.Errorf("LoadConfigFile() = %+v, want profile p loaded from %q", profiles, expected)
+			}
+		})
+	}
+}
+
 func TestConfigFileAllParameters(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, ".slimjson")
@@ -368,3 +927,151 @@ enum-max-values=5
 
 	t.Log("All parameters parsed correctly")
 }
+
+func TestWriteConfigFile(t *testing.T) {
+	full := Config{
+		MaxDepth: 3, DepthTruncationMarker: "ellipsis", ReportDepthElision: true, MaxListLength: 20, MaxStringLength: 80, TruncateBoundary: "grapheme", StringLengthUnit: "tokens", TruncationSuffix: stringPtr("[cut]"), MaxOutputBytes: 4096, StripEmpty: true, EmptyValues: []interface{}{"N/A", "-", 0.0, "0", nil}, KeepNull: true, BlockList: []string{"password", "token"},
+		PreserveFields: []string{"id", "user.signature"}, DecimalPlaces: 0, FieldDecimalPlaces: map[string]int{"price": 2, "geo.lat": 6, "raw": -1}, DeduplicateArrays: true, SampleStrategy: "largest", SampleSize: 5,
+		SampleSortKey: "score", SampleCounts: true, SampleStratifyKey: "kind", RandomSeed: 9007199254740993,
+		NullCompression: true, TypeInference: true, TypeInferenceColumnar: true, MatrixColumnar: true, RunLengthEncode: true, BoolCompression: true, BoolArrayPacking: true,
+		TimestampCompression: true, StringPooling: true, StringPoolMinOccurrences: 3, NumberDeltaEncoding: true,
+		NumberDeltaThreshold: 7, EnumDetection: true, EnumMaxValues: 4, InlineEnumThreshold: 3, ShortenKeys: true, MinSavingsBytes: 16, Lossless: true, Checksum: true, EmitVersion: true, WarnOnExpansion: true, Parallelism: 4, NormalizeUnicode: "NFD", CleanWhitespace: true, NormalizeQuotes: true, StripUTF8Emoji: true, StripHTML: true, StripMarkdown: true, DecodeHTMLEntities: true, CollapseWhitespace: true, CollapseWhitespaceKeepNewlines: true,
+		Defaults:       map[string]interface{}{"level": "info", "count": 0.0, "text": "1", "note": "a: b", "obj": map[string]interface{}{"x": true}},
+		DetectDefaults: true,
+		DropIfEquals:   map[string][]interface{}{"status": {"ok", "none", nil}, "code": {200.0}}, DropIfEqualsIgnoreCase: true,
+		Flatten: true, FlattenMaxDepth: 2, FlattenSingleKeyChains: true, SortKeys: true, PreserveKeyOrder: true, TruncationSummaries: true,
+		Rules: []PathRule{
+			{Path: "$.logs[*]", Config: Config{MaxDepth: 2, DecimalPlaces: -1, BlockList: []string{"trace", "span"}}},
+			{Path: "summary", Config: Config{MaxListLength: 3, DecimalPlaces: 2, DropIfEquals: map[string][]interface{}{"x": {"y"}}}},
+		},
+	}
+
+	// Every option must be set, so new options are covered
+	v := reflect.ValueOf(full)
+	for i := 0; i < v.NumField(); i++ {
+		if tag := v.Type().Field(i).Tag.Get("json"); tag != "-" && v.Field(i).IsZero() && v.Type().Field(i).Name != "DecimalPlaces" {
+			t.Fatalf("Expected %s to be set in the fully populated config", v.Type().Field(i).Name)
+		}
+	}
+
+	profiles := map[string]Config{
+		"full":    full,
+		"minimal": {DecimalPlaces: -1},
+		"light":   GetBuiltinProfiles()["light"],
+	}
+	path := filepath.Join(t.TempDir(), ".slimjson")
+	if err := WriteConfigFile(path, profiles); err != nil {
+		t.Fatalf("WriteConfigFile() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "[full]\n") || !strings.HasSuffix(string(data), "\n\n[minimal]\n") {
+		t.Errorf("Expected sections sorted by name and no parameters for default options, got:\n%s", data)
+	}
+
+	got, err := ParseConfigFile(path)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() error: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(got, profiles) {
+		t.Errorf("Round trip changed the profiles:\n%s\ngot  %+v\nwant %+v", data, got, profiles)
+	}
+}
+
+func TestWriteConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles map[string]Config
+		expected string
+	}{
+		{name: "Invalid name", profiles: map[string]Config{"a]": {}}, expected: `invalid profile name "a]"`},
+		{name: "Comma in block list", profiles: map[string]Config{"a": {BlockList: []string{"x,y"}}}, expected: `block-list value "x,y"`},
+		{name: "Semicolon in a value", profiles: map[string]Config{"a": {Defaults: map[string]interface{}{"x": "a;b"}}}, expected: "x:a;b cannot be written"},
+		{name: "Space in a rule", profiles: map[string]Config{"a": {Rules: []PathRule{{Path: "x", Config: Config{SampleStrategy: "a b"}}}}},
+			expected: `profile a: rule x: sample-strategy value "a b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WriteConfigFile(filepath.Join(t.TempDir(), ".slimjson"), tt.profiles)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("WriteConfigFile() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSaveProfile(t *testing.T) {
+	existing := `# Team profiles
+[api]
+depth=2
+list-len=4
+
+# Used by the nightly job
+[nightly]
+depth=6
+extends=medium
+`
+	tests := []struct {
+		name     string
+		existing string
+		profile  string
+		expected string
+	}{
+		{
+			name:     "Create file",
+			profile:  "api",
+			expected: "[api]\nmax-depth=3\nstrip-empty=true\n",
+		},
+		{
+			name:     "Replace section",
+			existing: existing,
+			profile:  "api",
+			expected: "# Team profiles\n[api]\nmax-depth=3\nstrip-empty=true\n\n# Used by the nightly job\n[nightly]\ndepth=6\nextends=medium\n",
+		},
+		{
+			name:     "Replace last section",
+			existing: existing,
+			profile:  "nightly",
+			expected: "# Team profiles\n[api]\ndepth=2\nlist-len=4\n\n# Used by the nightly job\n[nightly]\nmax-depth=3\nstrip-empty=true\n",
+		},
+		{
+			name:     "Append section",
+			existing: strings.TrimSuffix(existing, "\n"),
+			profile:  "new",
+			expected: existing + "\n[new]\nmax-depth=3\nstrip-empty=true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".slimjson")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cfg := Config{MaxDepth: 3, StripEmpty: true, DecimalPlaces: -1}
+			if err := SaveProfile(path, tt.profile, cfg); err != nil {
+				t.Fatalf("SaveProfile() error: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("SaveProfile() wrote:\n%s\nwant:\n%s", data, tt.expected)
+			}
+
+			profiles, err := ParseConfigFile(path)
+			if err != nil {
+				t.Fatalf("ParseConfigFile() error: %v", err)
+			}
+			if !reflect.DeepEqual(profiles[tt.profile], cfg) {
+				t.Errorf("Saved profile = %+v, want %+v", profiles[tt.profile], cfg)
+			}
+		})
+	}
+}
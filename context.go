@@ -0,0 +1,51 @@
+package slimjson
+
+import "context"
+
+// contextCheckInterval is the number of values slimmed between checks of the
+// context of SlimContext
+const contextCheckInterval = 1024
+
+// contextDone carries the error of a done context up from checkContext
+type contextDone struct{ err error }
+
+// SlimContext is like Slim, but stops and returns ctx.Err() when ctx is done
+// before slimming finishes, so a deadline bounds the time spent on a large
+// document. The context is checked every few thousand values, including by
+// the statistics pass of StringPooling and EnumDetection.
+func (s *Slimmer) SlimContext(ctx context.Context, data interface{}) (result interface{}, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Passes that swap the config restore it themselves only when they finish
+	saved, changes := s.Config, s.changes
+	s.ctx, s.visited = ctx, 0
+	defer func() {
+		s.ctx = nil
+		if r := recover(); r != nil {
+			done, ok := r.(contextDone)
+			if !ok {
+				panic(r)
+			}
+			s.Config, s.changes = saved, changes
+			result, err = nil, done.err
+		}
+	}()
+	return s.Slim(data), nil
+}
+
+// checkContext aborts SlimContext, by panicking with contextDone, once its
+// context is done. Only every contextCheckInterval-th call checks it.
+func (s *Slimmer) checkContext() {
+	if s.ctx == nil {
+		return
+	}
+	s.visited++
+	if s.visited%contextCheckInterval != 0 {
+		return
+	}
+	if err := s.ctx.Err(); err != nil {
+		panic(contextDone{err})
+	}
+}
@@ -0,0 +1,62 @@
+package slimjson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSlimContext(t *testing.T) {
+	items := make([]interface{}, 5000)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": float64(i), "status": "active", "note": ""}
+	}
+	input := map[string]interface{}{"items": items}
+	cfg := Config{StripEmpty: true, StringPooling: true, MinSavingsBytes: 1, DecimalPlaces: -1}
+
+	// Finished in time: the same result as Slim
+	got, err := New(cfg).SlimContext(context.Background(), input)
+	if err != nil {
+		t.Fatalf("SlimContext() error: %v", err)
+	}
+	if want := New(cfg).Slim(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("SlimContext() differs from Slim()")
+	}
+
+	// Done before starting
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	if _, err := New(cfg).SlimContext(ctx, input); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// Cancelled midway, during the plain pass of MinSavingsBytes, which swaps the config
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	cancelling := cfg
+	cancelling.ValueTransform = func(_ string, v interface{}) (interface{}, bool) {
+		if calls++; calls == 20000 {
+			cancel()
+		}
+		return v, false
+	}
+	slimmer := New(cancelling)
+	if _, err := slimmer.SlimContext(ctx, input); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if calls >= 2*15000 {
+		t.Errorf("Expected slimming to stop soon after the cancellation, got %d values", calls)
+	}
+	if !slimmer.Config.StringPooling {
+		t.Errorf("Expected the config to be restored after the cancellation")
+	}
+
+	// The Slimmer can be reused
+	slimmer.Config.ValueTransform = nil
+	if got, want := slimmer.Slim(input), New(cfg).Slim(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("Slim() after a cancelled SlimContext differs from a new Slimmer's")
+	}
+}
@@ -0,0 +1,285 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// SlimDiff returns a compact patch that turns the slimmed old document into
+// the slimmed new document. Both documents are slimmed with cfg first, so
+// blocklists, truncation and other options apply to the values in the patch,
+// and the receiver applies the patch to the slimmed document it already has.
+//
+// Patch format:
+//   - Objects list changed and added keys with their new values (nested objects
+//     as nested patches) and removed keys under "_removed"
+//   - Arrays are compared index-wise: {"_at": {"3": patch}, "_len": 5}. A reordered
+//     array becomes {"_order": [old indices]}; an array where most elements changed
+//     is sent whole
+//   - Any other value, or {"_replace": value}, replaces the old value
+//   - An empty object means no change
+//
+// Options with random output (random sampling) make consecutive diffs noisy.
+func SlimDiff(old, new interface{}, cfg Config) interface{} {
+	oldSlim := normalizeJSON(New(cfg).Slim(old))
+	newSlim := normalizeJSON(New(cfg).Slim(new))
+
+	patch, changed := diffValue(oldSlim, newSlim)
+	if !changed {
+		return map[string]interface{}{}
+	}
+	return patch
+}
+
+// ApplyDiff applies a patch produced by SlimDiff to base and returns the result.
+// base is not modified.
+func ApplyDiff(base, diff interface{}) (interface{}, error) {
+	return applyPatch(normalizeJSON(base), normalizeJSON(diff))
+}
+
+// patchKeys are the reserved keys of object and array patches
+var patchKeys = map[string]bool{"_replace": true, "_removed": true, "_at": true, "_len": true, "_order": true}
+
+// normalizeJSON converts v to the generic form produced by encoding/json
+// (map[string]interface{}, []interface{}, float64, ...)
+func normalizeJSON(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return plainMaps(v)
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return plainMaps(v)
+	}
+	return result
+}
+
+// diffValue returns the patch from old to new and whether they differ
+func diffValue(old, new interface{}) (interface{}, bool) {
+	if reflect.DeepEqual(old, new) {
+		return nil, false
+	}
+	switch n := new.(type) {
+	case map[string]interface{}:
+		if o, ok := old.(map[string]interface{}); ok {
+			return diffObject(o, n), true
+		}
+		return literal(n), true
+	case []interface{}:
+		if o, ok := old.([]interface{}); ok {
+			return diffArray(o, n), true
+		}
+		return n, true
+	default:
+		return n, true
+	}
+}
+
+// literal returns a patch that sets v. Objects are sent as-is when applying
+// them as a patch to nothing rebuilds them exactly, and wrapped in _replace otherwise.
+func literal(v interface{}) interface{} {
+	if m, ok := v.(map[string]interface{}); ok && !selfPatching(m) {
+		return map[string]interface{}{"_replace": m}
+	}
+	return v
+}
+
+// selfPatching reports whether m and its nested objects are non-empty and free of patch keys
+func selfPatching(m map[string]interface{}) bool {
+	if len(m) == 0 {
+		return false
+	}
+	for k, v := range m {
+		if patchKeys[k] {
+			return false
+		}
+		if child, ok := v.(map[string]interface{}); ok && !selfPatching(child) {
+			return false
+		}
+	}
+	return true
+}
+
+func diffObject(old, new map[string]interface{}) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for k, nv := range new {
+		ov, exists := old[k]
+		if !exists {
+			patch[k] = literal(nv)
+			continue
+		}
+		if p, changed := diffValue(ov, nv); changed {
+			patch[k] = p
+		}
+	}
+
+	var removed []string
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	if len(removed) > 0 {
+		slices.Sort(removed)
+		patch["_removed"] = removed
+	}
+	return patch
+}
+
+func diffArray(old, new []interface{}) interface{} {
+	if order := permutation(old, new); order != nil {
+		return map[string]interface{}{"_order": order}
+	}
+
+	at := make(map[string]interface{})
+	for i, nv := range new {
+		if i >= len(old) {
+			at[strconv.Itoa(i)] = literal(nv)
+			continue
+		}
+		if p, changed := diffValue(old[i], nv); changed {
+			at[strconv.Itoa(i)] = p
+		}
+	}
+
+	// Sending the whole array is simpler when most elements changed
+	if len(at) > len(new)/2 {
+		return new
+	}
+
+	patch := map[string]interface{}{}
+	if len(at) > 0 {
+		patch["_at"] = at
+	}
+	if len(new) != len(old) {
+		patch["_len"] = len(new)
+	}
+	return patch
+}
+
+// permutation returns order such that new[i] == old[order[i]], or nil if new
+// is not a reordering of old
+func permutation(old, new []interface{}) []int {
+	if len(old) != len(new) || len(old) < 2 {
+		return nil
+	}
+	positions := make(map[string][]int, len(old))
+	for i, v := range old {
+		key := canonicalJSON(v)
+		positions[key] = append(positions[key], i)
+	}
+	order := make([]int, len(new))
+	for i, v := range new {
+		key := canonicalJSON(v)
+		if len(positions[key]) == 0 {
+			return nil
+		}
+		order[i] = positions[key][0]
+		positions[key] = positions[key][1:]
+	}
+	return order
+}
+
+func applyPatch(base, patch interface{}) (interface{}, error) {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch, nil
+	}
+	if len(p) == 0 {
+		return base, nil
+	}
+	if v, ok := p["_replace"]; ok {
+		return v, nil
+	}
+	if arr, ok := base.([]interface{}); ok && isArrayPatch(p) {
+		return applyArrayPatch(arr, p)
+	}
+
+	obj, _ := base.(map[string]interface{})
+	result := make(map[string]interface{}, len(obj)+len(p))
+	for k, v := range obj {
+		result[k] = v
+	}
+	for k, v := range p {
+		if k == "_removed" {
+			continue
+		}
+		applied, err := applyPatch(result[k], v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		result[k] = applied
+	}
+	if r, ok := p["_removed"]; ok {
+		removed, err := toSlice(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid _removed: %w", err)
+		}
+		for _, k := range removed {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid _removed key: %v", k)
+			}
+			delete(result, key)
+		}
+	}
+	return result, nil
+}
+
+func isArrayPatch(p map[string]interface{}) bool {
+	for k := range p {
+		if k != "_at" && k != "_len" && k != "_order" {
+			return false
+		}
+	}
+	return true
+}
+
+func applyArrayPatch(base []interface{}, p map[string]interface{}) (interface{}, error) {
+	if o, ok := p["_order"]; ok {
+		order, err := toFloatSlice(o)
+		if err != nil || len(order) != len(base) {
+			return nil, fmt.Errorf("invalid _order: %v", o)
+		}
+		result := make([]interface{}, len(base))
+		for i, idx := range order {
+			if idx < 0 || int(idx) >= len(base) {
+				return nil, fmt.Errorf("invalid _order index: %v", idx)
+			}
+			result[i] = base[int(idx)]
+		}
+		return result, nil
+	}
+
+	length := len(base)
+	if l, ok := p["_len"].(float64); ok {
+		if l < 0 {
+			return nil, fmt.Errorf("invalid _len: %v", l)
+		}
+		length = int(l)
+	}
+	result := make([]interface{}, length)
+	copy(result, base)
+
+	if a, ok := p["_at"]; ok {
+		at, ok := a.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid _at: expected object, got %T", a)
+		}
+		for k, v := range at {
+			idx, err := strconv.Atoi(k)
+			if err != nil || idx < 0 || idx >= length {
+				return nil, fmt.Errorf("invalid _at index: %s", k)
+			}
+			applied, err := applyPatch(result[idx], v)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", idx, err)
+			}
+			result[idx] = applied
+		}
+	}
+	return result, nil
+}
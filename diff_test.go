@@ -0,0 +1,133 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSlimDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "No changes",
+			config:   Config{DecimalPlaces: -1},
+			old:      `{"a": 1, "b": [1, 2]}`,
+			new:      `{"b": [1, 2], "a": 1}`,
+			expected: `{}`,
+		},
+		{
+			name:     "Nested change",
+			config:   Config{DecimalPlaces: -1},
+			old:      `{"user": {"name": "a", "meta": {"visits": 1, "plan": "free"}}}`,
+			new:      `{"user": {"name": "a", "meta": {"visits": 2, "plan": "free"}}}`,
+			expected: `{"user": {"meta": {"visits": 2}}}`,
+		},
+		{
+			name:     "Added and removed keys",
+			config:   Config{DecimalPlaces: -1},
+			old:      `{"a": 1, "b": 2, "c": 3}`,
+			new:      `{"a": 1, "d": {"x": 1}}`,
+			expected: `{"d": {"x": 1}, "_removed": ["b", "c"]}`,
+		},
+		{
+			name:     "Type changes",
+			config:   Config{DecimalPlaces: -1},
+			old:      `{"a": "text", "b": {"x": 1}, "c": [1]}`,
+			new:      `{"a": {}, "b": [1], "c": {"_len": 2}}`,
+			expected: `{"a": {"_replace": {}}, "b": [1], "c": {"_replace": {"_len": 2}}}`,
+		},
+		{
+			name:     "Array elements index-wise",
+			config:   Config{DecimalPlaces: -1},
+			old:      `{"items": [{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}]}`,
+			new:      `{"items": [{"id": 1}, {"id": 2, "done": true}, {"id": 3}, {"id": 4}, {"id": 5}]}`,
+			expected: `{"items": {"_at": {"1": {"done": true}, "4": {"id": 5}}, "_len": 5}}`,
+		},
+		{
+			name:     "Reordered array",
+			config:   Config{DecimalPlaces: -1},
+			old:      `{"tags": ["a", "b", "c"]}`,
+			new:      `{"tags": ["c", "a", "b"]}`,
+			expected: `{"tags": {"_order": [2, 0, 1]}}`,
+		},
+		{
+			name:     "Mostly changed array is sent whole",
+			config:   Config{DecimalPlaces: -1},
+			old:      `{"n": [1, 2, 3]}`,
+			new:      `{"n": [4, 5, 3]}`,
+			expected: `{"n": [4, 5, 3]}`,
+		},
+		{
+			name:     "Slimming applies to values",
+			config:   Config{DecimalPlaces: -1, BlockList: []string{"password"}, MaxStringLength: 5},
+			old:      `{"name": "a", "password": "x"}`,
+			new:      `{"name": "abcdefgh", "password": "y"}`,
+			expected: `{"name": "ab..."}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var oldDoc, newDoc, expected interface{}
+			for _, in := range []struct {
+				src string
+				dst *interface{}
+			}{{tt.old, &oldDoc}, {tt.new, &newDoc}, {tt.expected, &expected}} {
+				if err := json.Unmarshal([]byte(in.src), in.dst); err != nil {
+					t.Fatalf("Failed to unmarshal %s: %v", in.src, err)
+				}
+			}
+
+			diff := SlimDiff(oldDoc, newDoc, tt.config)
+			if got := normalizeJSON(diff); !reflect.DeepEqual(got, expected) {
+				gotJSON, _ := json.Marshal(diff)
+				t.Errorf("SlimDiff() = %s, want %s", gotJSON, tt.expected)
+			}
+
+			// The receiver rebuilds the new slimmed document from the old one
+			base := New(tt.config).Slim(oldDoc)
+			restored, err := ApplyDiff(base, diff)
+			if err != nil {
+				t.Fatalf("ApplyDiff() error: %v", err)
+			}
+			want := normalizeJSON(New(tt.config).Slim(newDoc))
+			if !reflect.DeepEqual(restored, want) {
+				t.Errorf("ApplyDiff() = %v, want %v", restored, want)
+			}
+		})
+	}
+}
+
+func TestApplyDiffInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		diff string
+	}{
+		{name: "Order length mismatch", base: `[1, 2]`, diff: `{"_order": [0]}`},
+		{name: "Order index out of range", base: `[1, 2]`, diff: `{"_order": [0, 5]}`},
+		{name: "Index beyond length", base: `[1, 2]`, diff: `{"_at": {"3": 1}}`},
+		{name: "Invalid removed key", base: `{"a": 1}`, diff: `{"_removed": [1]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var base, diff interface{}
+			if err := json.Unmarshal([]byte(tt.base), &base); err != nil {
+				t.Fatalf("Failed to unmarshal base: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.diff), &diff); err != nil {
+				t.Fatalf("Failed to unmarshal diff: %v", err)
+			}
+			if _, err := ApplyDiff(base, diff); err == nil {
+				t.Error("Expected error for invalid diff")
+			}
+		})
+	}
+}
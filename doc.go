@@ -84,19 +84,30 @@
 //	    MaxDepth        int      // Maximum nesting depth (0 = unlimited)
 //	    MaxListLength   int      // Maximum array length (0 = unlimited)
 //	    MaxStringLength int      // Maximum string length (0 = unlimited)
+//	    TruncateBoundary string  // rune, grapheme or word: where strings are cut
+//	    StringLengthUnit string  // runes, bytes or tokens: unit of MaxStringLength
+//	    TruncationSuffix *string // Appended to truncated strings ("..." if nil)
 //	    StripEmpty      bool     // Remove nulls, empty strings, arrays, objects
+//	    EmptyValues []interface{} // More values StripEmpty removes ("N/A")
+//	    KeepNull        bool     // Keep explicit nulls under StripEmpty
 //	    BlockList       []string // Field names to remove
 //
 //	    // Optimization options
 //	    DecimalPlaces     int    // Round floats to N decimal places
+//	    FieldDecimalPlaces map[string]int // Per-field precision, e.g. {"price": 2, "lat": 6}
 //	    DeduplicateArrays bool   // Remove duplicate array values
 //	    SampleStrategy    string // Array sampling strategy
 //	    SampleSize        int    // Number of items when sampling
+//	    SampleSortKey     string // Field ranking objects for largest/smallest
+//	    SampleStratifyKey string // Field grouping objects for stratified
 //
 //	    // Advanced compression
 //	    NullCompression          bool // Track removed nulls
 //	    TypeInference            bool // Convert arrays to schema+data
+//	    MatrixColumnar           bool // Numeric 2D arrays to column-major _matrix
+//	    RunLengthEncode          bool // Runs of repeated values to _rle pairs
 //	    BoolCompression          bool // Convert booleans to bit flags
+//	    BoolArrayPacking         bool // Boolean arrays to a _bits bitmap
 //	    TimestampCompression     bool // Convert ISO to unix timestamps
 //	    StringPooling            bool // Deduplicate repeated strings
 //	    StringPoolMinOccurrences int  // Min occurrences for pooling
@@ -104,7 +115,18 @@
 //	    NumberDeltaThreshold     int  // Min array size for delta
 //	    EnumDetection            bool // Convert categorical values to enums
 //	    EnumMaxValues            int  // Max unique values for enum
-//	    StripUTF8Emoji           bool // Remove emoji and non-ASCII characters
+//	    InlineEnumThreshold      int  // Min occurrences before a field is extracted
+//	    StripHTML                bool   // Remove HTML tags, keeping the visible text
+//	    StripMarkdown            bool   // Remove Markdown syntax, keeping text and code
+//	    DecodeHTMLEntities       bool   // &amp; and other entities to characters
+//	    NormalizeUnicode         string // NFC, NFD or NFKC before stripping and truncation
+//	    CleanWhitespace          bool   // Unicode spaces to ASCII, drop zero-width characters
+//	    NormalizeQuotes          bool   // Curly quotes, dashes and ellipses to ASCII
+//	    StripUTF8Emoji           bool   // Remove emoji and non-ASCII characters
+//	    CollapseWhitespace       bool   // Runs of whitespace to one space, trimmed
+//
+//	    // Performance
+//	    Parallelism              int    // Goroutines pruning arrays of 512+ elements
 //	}
 //
 // # Advanced Compression
@@ -130,6 +152,21 @@
 //	// - _strings: String pool (if StringPooling enabled)
 //	// - _enums: Enum mappings (if EnumDetection enabled)
 //	// - _nulls: Tracked null fields (if NullCompression enabled)
+//	// - _keys: Key aliases (if ShortenKeys enabled)
+//
+// # Chaining Slimmers
+//
+// A Chain runs several configs in order, each on the result of the one before:
+//
+//	chain := slimjson.NewChain(
+//	    slimjson.Config{BlockList: []string{"debug", "trace_id"}},
+//	    slimjson.Config{TypeInference: true, StringPooling: true},
+//	)
+//	result := chain.Slim(data)
+//
+// Later passes keep the metadata of earlier ones as it is and skip options whose
+// table (_strings, _enums, _nulls, _keys, _checksum) an earlier pass already
+// wrote, so put the passes that need the original field names and values first.
 //
 // # Emoji and Non-ASCII Character Removal
 //
@@ -165,7 +202,7 @@
 //
 // Or parse a specific config file:
 //
-//	profiles, err := slimjson.ParseConfigFile("/path/to/.slimjson")
+//	profiles, err := slimjson.ParseConfig("/path/to/.slimjson")
 //	if err != nil {
 //	    // Handle error
 //	}
@@ -235,6 +272,12 @@
 //	  -H "Content-Type: application/json" \
 //	  -d '{"users":[{"id":1,"name":"Alice"}]}'
 //
+// The same endpoints can be embedded in other programs as an http.Handler with
+// the server package (github.com/tradik/slimjson/server), and the middleware
+// package (github.com/tradik/slimjson/middleware) slims the JSON responses of
+// an existing http.Handler. NewTransport does the same for the responses a
+// client receives, as an http.RoundTripper.
+//
 // # Performance
 //
 // SlimJSON is highly optimized for performance:
@@ -270,10 +313,20 @@
 //	type-inference=true
 //	bool-compression=true
 //
+// The same profiles can be written in YAML (.slimjson.yaml, .slimjson.yml) or
+// JSON (.slimjson.json) as a mapping of profile names to settings:
+//
+//	api-response:
+//	  depth: 5
+//	  block: [metadata, debug, trace]
+//
+// ParseConfig picks the format from the file extension. ConfigParser with Strict
+// unset skips unknown keys with a warning instead of failing.
+//
 // The file is searched in:
 //  1. Path specified by -c/--config flag (highest priority)
-//  2. Current directory (./.slimjson)
-//  3. User home directory (~/.slimjson)
+//  2. Current directory (./.slimjson, .slimjson.yaml, .slimjson.yml, .slimjson.json)
+//  3. User home directory (same names)
 //
 // # Thread Safety
 //
@@ -0,0 +1,222 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RemoveReason tells why a value was passed to Config.OnRemove
+type RemoveReason int
+
+// Reasons passed to Config.OnRemove
+const (
+	ReasonBlocked         RemoveReason = iota // Field name or path is in BlockList
+	ReasonDropIf                              // Field matched a DropIfEquals value
+	ReasonEmpty                               // Value was empty after pruning and StripEmpty is set
+	ReasonDepthExceeded                       // Value is nested deeper than MaxDepth
+	ReasonListTruncated                       // Array element was cut by MaxListLength or sampling
+	ReasonStringTruncated                     // String was shortened to MaxStringLength
+)
+
+// String returns the reason name, matching Change.Reason where they overlap
+func (r RemoveReason) String() string {
+	switch r {
+	case ReasonBlocked:
+		return "blocked"
+	case ReasonDropIf:
+		return "drop-if"
+	case ReasonEmpty:
+		return "empty"
+	case ReasonDepthExceeded:
+		return "max-depth"
+	case ReasonListTruncated:
+		return "list-truncated"
+	case ReasonStringTruncated:
+		return "max-string-length"
+	default:
+		return "unknown"
+	}
+}
+
+// removed reports a dropped value to the OnRemove hook
+func (s *Slimmer) removed(path string, reason RemoveReason, value interface{}) {
+	if s.Config.OnRemove != nil {
+		s.Config.OnRemove(path, reason, value)
+	}
+}
+
+// Change kinds reported by Explain
+const (
+	ChangeRemoved         = "removed"          // A field or array element was dropped
+	ChangeTruncatedArray  = "truncated-array"  // An array was shortened
+	ChangeTruncatedString = "truncated-string" // A string was shortened
+)
+
+// Change describes one lossy edit made while slimming.
+type Change struct {
+	// Path is the dot-separated location in the original document ("users.3.password")
+	Path string `json:"path"`
+
+	// Kind is one of ChangeRemoved, ChangeTruncatedArray or ChangeTruncatedString
+	Kind string `json:"kind"`
+
+	// Reason names the option responsible: "blocked", "drop-if", "empty", "max-depth",
+	// "deduplicate", "max-list-length", a sample strategy, or "max-string-length"
+	Reason string `json:"reason"`
+
+	// From and To are the original and resulting lengths of truncated arrays
+	// (elements) and strings (characters, whatever the StringLengthUnit)
+	From int `json:"from,omitempty"`
+	To   int `json:"to,omitempty"`
+}
+
+// Explain slims data like Slim and also returns the lossy changes that were made,
+// ordered by path. Reversible encodings such as type inference or string pooling
+// are not reported.
+func (s *Slimmer) Explain(data interface{}) (interface{}, []Change) {
+	s.changes = []Change{}
+	defer func() { s.changes = nil }()
+
+	result := s.Slim(data)
+
+	changes := s.changes
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		return comparePaths(a.Path, b.Path)
+	})
+	return result, changes
+}
+
+// ExplainNext is like SlimNext, but also returns the lossy changes made to
+// the document, as Explain does. A top-level array is read whole.
+func (s *Slimmer) ExplainNext(dec *json.Decoder, w io.Writer) ([]Change, error) {
+	v, err := s.decode(dec)
+	if err != nil {
+		return nil, err
+	}
+	result, changes := s.Explain(v)
+	return changes, json.NewEncoder(w).Encode(result)
+}
+
+// record adds a change when Explain is running
+func (s *Slimmer) record(c Change) {
+	if s.changes != nil {
+		s.changes = append(s.changes, c)
+	}
+}
+
+// depthExceeded reports whether values at depth are cut by MaxDepth
+func (s *Slimmer) depthExceeded(depth int) bool {
+	return s.Config.MaxDepth > 0 && depth >= s.Config.MaxDepth
+}
+
+// sampleReason names the option that shortens arrays during sampling
+func (s *Slimmer) sampleReason() string {
+	switch s.Config.SampleStrategy {
+	case "", "none":
+		return "max-list-length"
+	default:
+		return s.Config.SampleStrategy
+	}
+}
+
+// sampleTracked samples arr like sampleArray, reports the elements that were
+// sampled out to OnRemove and forgets the changes Explain recorded inside them.
+// indices holds the index of each element in the original array orig at path.
+func (s *Slimmer) sampleTracked(arr []interface{}, indices []int, path string, orig []interface{}) []interface{} {
+	sampled := s.sampleIndices(arr)
+	if sampled == nil {
+		return arr
+	}
+
+	result := make([]interface{}, len(sampled))
+	kept := make([]bool, len(arr))
+	for i, p := range sampled {
+		result[i] = arr[p]
+		kept[p] = true
+	}
+	dropped := make(map[string]bool, len(arr)-len(sampled))
+	for i, k := range kept {
+		if !k {
+			elemPath := joinPath(path, strconv.Itoa(indices[i]))
+			dropped[elemPath] = true
+			s.removed(elemPath, ReasonListTruncated, orig[indices[i]])
+		}
+	}
+	if s.changes == nil {
+		return result
+	}
+
+	changes := s.changes[:0]
+	for _, c := range s.changes {
+		if !dropped[elementPath(path, c.Path)] {
+			changes = append(changes, c)
+		}
+	}
+	s.changes = changes
+	return result
+}
+
+// elementPath returns the path of the element of the array at path that
+// contains p, or "" if p is not inside the array
+func elementPath(path, p string) string {
+	if path != "" {
+		if !strings.HasPrefix(p, path+".") {
+			return ""
+		}
+		p = p[len(path)+1:]
+	}
+	seg, _, _ := strings.Cut(p, ".")
+	return joinPath(path, seg)
+}
+
+// comparePaths orders paths segment by segment, comparing array indices numerically
+func comparePaths(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		ai, aErr := strconv.Atoi(as[i])
+		bi, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			return ai - bi
+		}
+		return strings.Compare(as[i], bs[i])
+	}
+	return len(as) - len(bs)
+}
+
+// recordDepthCut records a non-empty object or array at depth whose children
+// are all cut by MaxDepth
+func (s *Slimmer) recordDepthCut(path string, depth int) {
+	if s.depthExceeded(depth + 1) {
+		s.record(Change{Path: path, Kind: ChangeRemoved, Reason: "max-depth"})
+	}
+}
+
+// recordEmpty records a value at depth stripped as empty, unless it was
+// already reported as cut by MaxDepth itself. Explain also skips containers
+// whose contents were reported by recordDepthCut.
+func (s *Slimmer) recordEmpty(path string, original interface{}, depth int) {
+	if s.depthExceeded(depth) {
+		return
+	}
+	s.removed(path, ReasonEmpty, original)
+	if s.changes == nil {
+		return
+	}
+	if om, ok := original.(*OrderedMap); ok {
+		original = om.Values
+	}
+	if s.depthExceeded(depth+1) && !isEmpty(original) {
+		switch reflect.ValueOf(original).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array:
+			return
+		}
+	}
+	s.record(Change{Path: path, Kind: ChangeRemoved, Reason: "empty"})
+}
@@ -0,0 +1,173 @@
+package slimjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected []Change
+	}{
+		{
+			name:   "Removed fields",
+			config: Config{StripEmpty: true, BlockList: []string{"password"}, DropIfEquals: map[string][]interface{}{"status": {"ok"}}},
+			input:  `{"users": [{"name": "a", "password": "x", "status": "ok", "bio": ""}]}`,
+			expected: []Change{
+				{Path: "users.0.bio", Kind: ChangeRemoved, Reason: "empty"},
+				{Path: "users.0.password", Kind: ChangeRemoved, Reason: "blocked"},
+				{Path: "users.0.status", Kind: ChangeRemoved, Reason: "drop-if"},
+			},
+		},
+		{
+			name:   "Depth cut reported once",
+			config: Config{MaxDepth: 3, StripEmpty: true},
+			input:  `{"a": {"b": {"c": 1, "d": 2}, "e": 3}}`,
+			expected: []Change{
+				{Path: "a.b", Kind: ChangeRemoved, Reason: "max-depth"},
+			},
+		},
+		{
+			name:   "Truncated array forgets dropped elements",
+			config: Config{MaxListLength: 2, MaxStringLength: 4},
+			input:  `{"items": ["one", "three", "three", "seventeen"]}`,
+			expected: []Change{
+				{Path: "items", Kind: ChangeTruncatedArray, Reason: "max-list-length", From: 4, To: 2},
+				{Path: "items.1", Kind: ChangeTruncatedString, Reason: "max-string-length", From: 5, To: 4},
+			},
+		},
+		{
+			name:   "Deduplicated and sampled array",
+			config: Config{DeduplicateArrays: true, SampleStrategy: "first_last", SampleSize: 2},
+			input:  `[1, 1, 2, 3, 4]`,
+			expected: []Change{
+				{Path: "", Kind: ChangeTruncatedArray, Reason: "deduplicate", From: 5, To: 4},
+				{Path: "", Kind: ChangeTruncatedArray, Reason: "first_last", From: 4, To: 2},
+			},
+		},
+		{
+			name:     "No changes",
+			config:   Config{DecimalPlaces: -1},
+			input:    `{"a": [1, 2], "b": "text"}`,
+			expected: []Change{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+
+			s := New(tt.config)
+			result, changes := s.Explain(data)
+			if !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("Explain() changes = %+v, want %+v", changes, tt.expected)
+			}
+
+			// The slimmed result must match a plain Slim run
+			if !reflect.DeepEqual(result, New(tt.config).Slim(data)) {
+				t.Errorf("Explain() result = %v differs from Slim()", result)
+			}
+			if s.changes != nil {
+				t.Error("Expected change recording to stop after Explain")
+			}
+		})
+	}
+}
+
+func TestOnRemove(t *testing.T) {
+	input := `{
+		"users": [
+			{"name": "a"}, {"name": "b"}, {"name": "c"},
+			{"name": "d", "password": "hunter2", "bio": "", "prefs": {"theme": {"color": "red"}}, "about": "a very long text"}
+		],
+		"items": [1, 2, 3, 4, 5]
+	}`
+	var data interface{}
+	if err := json.Unmarshal([]byte(input), &data); err != nil {
+		t.Fatalf("Failed to unmarshal input: %v", err)
+	}
+
+	type removal struct {
+		path   string
+		reason RemoveReason
+		value  interface{}
+	}
+	var removals []removal
+
+	cfg := Config{
+		MaxDepth:        4,
+		MaxListLength:   4,
+		MaxStringLength: 10,
+		StripEmpty:      true,
+		DecimalPlaces:   -1,
+		BlockList:       []string{"password"},
+	}
+	withHook := cfg
+	withHook.OnRemove = func(path string, reason RemoveReason, value interface{}) {
+		removals = append(removals, removal{path, reason, value})
+	}
+
+	result := New(withHook).Slim(data)
+	if !reflect.DeepEqual(result, New(cfg).Slim(data)) {
+		t.Errorf("OnRemove changed the output: %v", result)
+	}
+
+	expected := map[string]removal{
+		"users.3.password":    {"users.3.password", ReasonBlocked, "hunter2"},
+		"users.3.bio":         {"users.3.bio", ReasonEmpty, ""},
+		"users.3.prefs.theme": {"users.3.prefs.theme", ReasonDepthExceeded, map[string]interface{}{"color": "red"}},
+		"users.3.about":       {"users.3.about", ReasonStringTruncated, "a very long text"},
+		"items.4":             {"items.4", ReasonListTruncated, float64(5)},
+	}
+	got := make(map[string]removal)
+	for _, r := range removals {
+		got[r.path] = r
+	}
+	for path, want := range expected {
+		if !reflect.DeepEqual(got[path], want) {
+			t.Errorf("Removal at %s = %+v, want %+v", path, got[path], want)
+		}
+	}
+
+	// A nil hook must not allocate
+	s := New(cfg)
+	if allocs := testing.AllocsPerRun(100, func() { s.removed("users.3.password", ReasonBlocked, data) }); allocs != 0 {
+		t.Errorf("Expected no allocations without OnRemove, got %v", allocs)
+	}
+}
+
+func TestExplainNext(t *testing.T) {
+	cfg := Config{MaxListLength: 1, PreserveKeyOrder: true, DecimalPlaces: -1}
+	dec := json.NewDecoder(strings.NewReader(`{"b": [1, 2], "a": 1} {"z": 1}`))
+	s := New(cfg)
+
+	var out bytes.Buffer
+	changes, err := s.ExplainNext(dec, &out)
+	if err != nil {
+		t.Fatalf("ExplainNext() error: %v", err)
+	}
+	if expected := []Change{{Path: "b", Kind: ChangeTruncatedArray, Reason: "max-list-length", From: 2, To: 1}}; !reflect.DeepEqual(changes, expected) {
+		t.Errorf("ExplainNext() changes = %+v, want %+v", changes, expected)
+	}
+
+	changes, err = s.ExplainNext(dec, &out)
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("ExplainNext() = %+v, %v, want no changes", changes, err)
+	}
+	if expected := "{\"b\":[1],\"a\":1}\n{\"z\":1}\n"; out.String() != expected {
+		t.Errorf("Output = %q, want %q", out.String(), expected)
+	}
+	if _, err := s.ExplainNext(dec, &out); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end of the input, got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseConfigYAML parses a config file in YAML format. Unknown keys are an error.
+//
+//	api-response:
+//	  description: REST responses without debugging fields
+//	  extends: medium
+//	  block: [debug, trace]
+//	  drop-if: {status: [ok, none]}
+//	  rules:
+//	    - path: $.logs[*]
+//	      max-depth: 2
+func ParseConfigYAML(path string) (map[string]Config, error) {
+	return ConfigParser{Strict: true}.ParseYAML(path)
+}
+
+// ParseConfigJSON parses a config file in JSON format, with the same structure
+// as YAML. Unknown keys are an error.
+func ParseConfigJSON(path string) (map[string]Config, error) {
+	return ConfigParser{Strict: true}.ParseJSON(path)
+}
+
+// ParseYAML parses a config file in YAML format
+func (p ConfigParser) ParseYAML(path string) (map[string]Config, error) {
+	return profileConfigs(p.parseYAMLProfiles(path))
+}
+
+func (p ConfigParser) parseYAMLProfiles(path string) (map[string]Profile, error) {
+	sections, err := p.readYAMLSections(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.resolveProfiles(sections)
+}
+
+// readYAMLSections reads the profiles of a YAML config file
+func (p ConfigParser) readYAMLSections(path string) ([]*profileSection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	doc, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	return documentSections(doc)
+}
+
+// ParseJSON parses a config file in JSON format
+func (p ConfigParser) ParseJSON(path string) (map[string]Config, error) {
+	return profileConfigs(p.parseJSONProfiles(path))
+}
+
+func (p ConfigParser) parseJSONProfiles(path string) (map[string]Profile, error) {
+	sections, err := p.readJSONSections(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.resolveProfiles(sections)
+}
+
+// readJSONSections reads the profiles of a JSON config file
+func (p ConfigParser) readJSONSections(path string) ([]*profileSection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	dec := json.NewDecoder(file)
+	dec.UseNumber()
+	doc, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON config file: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON config file: unexpected data after the top-level object")
+	}
+	return documentSections(doc)
+}
+
+// documentSections converts a decoded YAML or JSON document, a mapping of profile
+// names to settings, into profile sections with config file key=value parameters
+func documentSections(doc interface{}) ([]*profileSection, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	root, ok := doc.(*OrderedMap)
+	if !ok {
+		return nil, fmt.Errorf("config file must map profile names to settings")
+	}
+
+	sections := make([]*profileSection, 0, root.Len())
+	for _, name := range root.Keys {
+		sec := &profileSection{name: name, pos: "profile " + name}
+		sections = append(sections, sec)
+
+		settings, ok := root.Values[name].(*OrderedMap)
+		if !ok {
+			if root.Values[name] == nil {
+				continue
+			}
+			return nil, fmt.Errorf("error at profile %s: settings must be a mapping", name)
+		}
+
+		for _, key := range settings.Keys {
+			pos := fmt.Sprintf("profile %s, key %s", name, key)
+			params, err := documentParams(key, settings.Values[key])
+			if err != nil {
+				return nil, fmt.Errorf("error at %s: %w", pos, err)
+			}
+			for _, param := range params {
+				param.pos = pos
+				sec.add(param)
+			}
+		}
+	}
+	return sections, nil
+}
+
+// documentParams converts a document setting to config file parameters.
+// Lists become comma-separated values, mappings become "field:value;field:value"
+// (a list value repeats the field) and each rule becomes its own rules parameter.
+func documentParams(key string, value interface{}) ([]configParam, error) {
+	lower := strings.ToLower(key)
+	if rules, ok := value.([]interface{}); ok && (lower == "rules" || lower == "rule") {
+		params := make([]configParam, 0, len(rules))
+		for i, rule := range rules {
+			v, err := documentRule(rule)
+			if err != nil {
+				return nil, fmt.Errorf("rules[%d]: %w", i, err)
+			}
+			params = append(params, configParam{key: key, value: v})
+		}
+		return params, nil
+	}
+
+	v, err := documentValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return []configParam{{key: key, value: v}}, nil
+}
+
+// documentRule converts a rule mapping with a path and config keys to "selector key=value ..."
+func documentRule(rule interface{}) (string, error) {
+	m, ok := rule.(*OrderedMap)
+	if !ok {
+		return documentScalar(rule, "")
+	}
+	path, ok := m.Get("path")
+	if !ok {
+		return "", fmt.Errorf("missing path")
+	}
+	parts := []string{fmt.Sprint(path)}
+	for _, key := range m.Keys {
+		if key == "path" {
+			continue
+		}
+		v, err := documentValue(m.Values[key])
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", key, err)
+		}
+		parts = append(parts, key+"="+v)
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// documentValue converts a setting value to its config file form
+func documentValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := documentScalar(item, "")
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+
+	case *OrderedMap:
+		var pairs []string
+		for _, field := range v.Keys {
+			values, ok := v.Values[field].([]interface{})
+			if !ok {
+				values = []interface{}{v.Values[field]}
+			}
+			for _, item := range values {
+				s, err := documentScalar(item, "null")
+				if err != nil {
+					return "", err
+				}
+				pairs = append(pairs, field+":"+s)
+			}
+		}
+		return strings.Join(pairs, ";"), nil
+
+	default:
+		return documentScalar(value, "")
+	}
+}
+
+// documentScalar formats a scalar, using null for a missing value
+func documentScalar(value interface{}, null string) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return null, nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("expected a single value, got %s", documentType(value))
+	}
+}
+
+func documentType(value interface{}) string {
+	switch value.(type) {
+	case []interface{}:
+		return "a list"
+	case *OrderedMap:
+		return "a mapping"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
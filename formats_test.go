@@ -0,0 +1,249 @@
+package slimjson
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes content to a temporary file with the given name
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+	return path
+}
+
+func TestParseConfigFormatsRoundTrip(t *testing.T) {
+	ini := `[base]
+depth=4
+strip-empty=true
+block=password,token
+decimal-places=2
+random-seed=9007199254740993
+
+[api]
+extends=base
+list-len=20
+sample-strategy=first_last
+sample-size=6
+drop-if=status:ok;status:none;count:0
+defaults=level:info
+rules=$.logs[*] depth=2 block=trace,span
+rules=$.summary list-len=3
+
+[empty]
+`
+	yaml := `# Same profiles in YAML
+base:
+  depth: 4
+  strip-empty: true
+  block:
+    - password
+    - token
+  decimal-places: 2
+  random-seed: 9007199254740993
+
+api:
+  extends: base
+  list-len: 20
+  sample-strategy: "first_last"
+  sample-size: 6
+  drop-if:
+    status: [ok, none]
+    count: 0
+  defaults: {level: info}
+  rules:
+    - path: $.logs[*]
+      depth: 2
+      block: [trace, span]
+    - path: '$.summary'
+      list-len: 3
+
+empty:
+`
+	json := `{
+  "base": {"depth": 4, "strip-empty": true, "block": ["password", "token"], "decimal-places": 2,
+           "random-seed": 9007199254740993},
+  "api": {
+    "extends": "base",
+    "list-len": 20,
+    "sample-strategy": "first_last",
+    "sample-size": 6,
+    "drop-if": {"status": ["ok", "none"], "count": 0},
+    "defaults": {"level": "info"},
+    "rules": [
+      {"path": "$.logs[*]", "depth": 2, "block": ["trace", "span"]},
+      {"path": "$.summary", "list-len": 3}
+    ]
+  },
+  "empty": {}
+}`
+
+	expected, err := ParseConfigFile(writeConfig(t, ".slimjson", ini))
+	if err != nil {
+		t.Fatalf("ParseConfigFile() error: %v", err)
+	}
+	if len(expected) != 3 || expected["api"].DropIfEquals == nil || len(expected["api"].Rules) != 2 ||
+		expected["base"].RandomSeed != 9007199254740993 {
+		t.Fatalf("Unexpected INI profiles: %+v", expected)
+	}
+
+	for name, content := range map[string]string{"profiles.yaml": yaml, "profiles.yml": yaml, "profiles.json": json} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseConfig(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatalf("ParseConfig() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("ParseConfig() = %+v, want %+v", got, expected)
+			}
+		})
+	}
+}
+
+func TestParseProfilesDescription(t *testing.T) {
+	files := map[string]string{
+		".slimjson": `[base]
+description=Keeps the first 5 items = enough for a preview
+list-len=5
+
+[api]
+extends=base
+depth=2
+`,
+		"profiles.yaml": `base:
+  description: "Keeps the first 5 items = enough for a preview"
+  list-len: 5
+api:
+  extends: base
+  depth: 2
+`,
+		"profiles.json": `{"base": {"description": "Keeps the first 5 items = enough for a preview", "list-len": 5},
+ "api": {"extends": "base", "depth": 2}}`,
+	}
+
+	expected := map[string]Profile{
+		"base": {Name: "base", Description: "Keeps the first 5 items = enough for a preview",
+			Config: Config{MaxListLength: 5, DecimalPlaces: -1}},
+		// Descriptions are not inherited
+		"api": {Name: "api", Config: Config{MaxDepth: 2, MaxListLength: 5, DecimalPlaces: -1}},
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfig(t, name, content)
+			got, err := ParseProfiles(path)
+			if err != nil {
+				t.Fatalf("ParseProfiles() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("ParseProfiles() = %+v, want %+v", got, expected)
+			}
+
+			// description is not a Config parameter, but ParseConfig accepts it
+			configs, err := ParseConfig(path)
+			if err != nil || !reflect.DeepEqual(configs["base"], expected["base"].Config) {
+				t.Errorf("ParseConfig() = %+v, %v", configs, err)
+			}
+		})
+	}
+}
+
+func TestParseConfigFormatsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{name: "YAML invalid value", file: "c.yaml", content: "a:\n  depth: x\n", expected: "error at profile a, key depth: invalid depth value: x"},
+		{name: "YAML unknown key", file: "c.yaml", content: "a:\n  colour: red\n", expected: "unknown parameter: colour"},
+		{name: "YAML bad indentation", file: "c.yaml", content: "a:\n  depth: 1\n    list-len: 2\n", expected: "line 3: unexpected indentation"},
+		{name: "YAML tabs", file: "c.yaml", content: "a:\n\tdepth: 1\n", expected: "line 2: tabs are not allowed"},
+		{name: "YAML not a mapping", file: "c.yaml", content: "- a\n- b\n", expected: "must map profile names to settings"},
+		{name: "YAML nested list", file: "c.yaml", content: "a:\n  block: [[x]]\n", expected: "expected a single value, got a list"},
+		{name: "YAML unknown parent", file: "c.yaml", content: "a:\n  extends: nope\n", expected: "error at profile a, key extends: profile a extends unknown profile nope"},
+		{name: "YAML rule without path", file: "c.yaml", content: "a:\n  rules:\n    - depth: 1\n", expected: "rules[0]: missing path"},
+		{name: "YAML built-in profile", file: "c.yaml", content: "light:\n  depth: 1\n", expected: "error at profile light: profile light replaces a built-in profile"},
+		{name: "JSON duplicate key", file: "c.json", content: `{"a": {"depth": 1, "max-depth": 2}}`, expected: "duplicate key max-depth in profile a (first set at profile a, key depth)"},
+		{name: "JSON syntax", file: "c.json", content: `{"a": {"depth": 1}`, expected: "invalid JSON config file"},
+		{name: "JSON trailing data", file: "c.json", content: `{"a": {}} {}`, expected: "unexpected data"},
+		{name: "JSON settings not a mapping", file: "c.json", content: `{"a": [1]}`, expected: "error at profile a: settings must be a mapping"},
+		{name: "JSON cycle", file: "c.json", content: `{"a": {"extends": "b"}, "b": {"extends": "a"}}`, expected: "profile inheritance cycle: a -> b -> a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig(writeConfig(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ParseConfig() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfigParserNonStrict(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+		warning string
+	}{
+		{file: ".slimjson", content: "colour=red\n[a]\ndepth=3\ncolour=red\n", warning: "error at line 4: unknown parameter: colour (ignored)"},
+		{file: "c.yaml", content: "a:\n  depth: 3\n  colour: red\n", warning: "error at profile a, key colour: unknown parameter: colour (ignored)"},
+		{file: "c.json", content: `{"a": {"depth": 3, "colour": "red"}}`, warning: "error at profile a, key colour: unknown parameter: colour (ignored)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var warnings []string
+			p := ConfigParser{Warn: func(msg string) { warnings = append(warnings, msg) }}
+			profiles, err := p.Parse(writeConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if profiles["a"].MaxDepth != 3 {
+				t.Errorf("Expected known keys to apply, got %+v", profiles["a"])
+			}
+			if len(warnings) == 0 || warnings[len(warnings)-1] != tt.warning {
+				t.Errorf("Warnings = %q, want last %q", warnings, tt.warning)
+			}
+
+			// Invalid values are still errors
+			if _, err := p.Parse(writeConfig(t, tt.file, strings.Replace(tt.content, "3", "x", 1))); err == nil {
+				t.Error("Expected error for invalid value, got nil")
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", t.TempDir())
+
+	if err := os.WriteFile(".slimjson.json", []byte(`{"j": {"depth": 2}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := LoadConfigFile()
+	if err != nil || profiles["j"].MaxDepth != 2 {
+		t.Fatalf("LoadConfigFile() = %+v, %v, want profile j", profiles, err)
+	}
+
+	// INI takes priority over YAML and JSON
+	if err := os.WriteFile(".slimjson.yaml", []byte("y:\n  depth: 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if profiles, _ = LoadConfigFile(); profiles["y"].MaxDepth != 3 {
+		t.Errorf("Expected .slimjson.yaml before .slimjson.json, got %+v", profiles)
+	}
+	if err := os.WriteFile(".slimjson", []byte("[i]\ndepth=4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if profiles, _ = LoadConfigFile(); profiles["i"].MaxDepth != 4 {
+		t.Errorf("Expected .slimjson first, got %+v", profiles)
+	}
+}
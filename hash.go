@@ -0,0 +1,97 @@
+package slimjson
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// structuralHash returns a 64-bit FNV-1a hash of v's canonical JSON form, with
+// object keys sorted and numbers written by value, so structurally equal values
+// (objects with reordered keys, *OrderedMap and plain maps, 1 and 1.0) hash
+// alike. The canonical form is streamed into the hash without being built.
+func structuralHash(v interface{}) uint64 {
+	h := fnv.New64a()
+	writeCanonical(h, v)
+	return h.Sum64()
+}
+
+// checksum returns the SHA-256 hash of v's canonical JSON form, as stored in _checksum
+func checksum(v interface{}) string {
+	h := sha256.New()
+	writeCanonical(h, v)
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// writeCanonical writes the canonical JSON form of v to h
+func writeCanonical(h io.Writer, v interface{}) {
+	switch val := v.(type) {
+	case nil:
+		_, _ = h.Write([]byte("null"))
+	case bool:
+		_, _ = h.Write([]byte(strconv.FormatBool(val)))
+	case string:
+		_, _ = h.Write(strconv.AppendQuote(nil, val))
+	case []interface{}:
+		_, _ = h.Write([]byte{'['})
+		for i, item := range val {
+			if i > 0 {
+				_, _ = h.Write([]byte{','})
+			}
+			writeCanonical(h, item)
+		}
+		_, _ = h.Write([]byte{']'})
+	case map[string]interface{}:
+		writeCanonicalObject(h, val)
+	case *OrderedMap:
+		writeCanonicalObject(h, val.Values)
+	default:
+		if f, ok := toFloat(v); ok {
+			_, _ = h.Write([]byte(strconv.FormatFloat(f, 'g', -1, 64)))
+			return
+		}
+		b, err := json.Marshal(plainMaps(v))
+		if err != nil {
+			b = []byte(valueToString(v))
+		}
+		_, _ = h.Write(b)
+	}
+}
+
+func writeCanonicalObject(h io.Writer, m map[string]interface{}) {
+	_, _ = h.Write([]byte{'{'})
+	for i, k := range slices.Sorted(maps.Keys(m)) {
+		if i > 0 {
+			_, _ = h.Write([]byte{','})
+		}
+		_, _ = h.Write(strconv.AppendQuote(nil, k))
+		_, _ = h.Write([]byte{':'})
+		writeCanonical(h, m[k])
+	}
+	_, _ = h.Write([]byte{'}'})
+}
+
+// structuralSet tracks structurally distinct values by structuralHash.
+// Values with the same hash are compared by valueToString, so a hash
+// collision never merges different values.
+type structuralSet map[uint64][]interface{}
+
+// add adds v and reports whether it was not in the set yet
+func (set structuralSet) add(v interface{}) bool {
+	h := structuralHash(v)
+	if bucket := set[h]; len(bucket) > 0 {
+		key := valueToString(v)
+		for _, prev := range bucket {
+			if valueToString(prev) == key {
+				return false
+			}
+		}
+	}
+	set[h] = append(set[h], v)
+	return true
+}
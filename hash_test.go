@@ -0,0 +1,45 @@
+package slimjson
+
+import "testing"
+
+func TestStructuralHash(t *testing.T) {
+	ordered := NewOrderedMap()
+	ordered.Set("b", []interface{}{1, "x"})
+	ordered.Set("a", nil)
+
+	tests := []struct {
+		name  string
+		a, b  interface{}
+		equal bool
+	}{
+		{name: "Reordered keys", a: ordered, b: map[string]interface{}{"a": nil, "b": []interface{}{1.0, "x"}}, equal: true},
+		{name: "Integer and float", a: []interface{}{int64(3)}, b: []interface{}{3.0}, equal: true},
+		{name: "Array order", a: []interface{}{1, 2}, b: []interface{}{2, 1}},
+		{name: "Number and string", a: 1, b: "1"},
+		{name: "Null and string", a: nil, b: "null"},
+		{name: "Nested values", a: map[string]interface{}{"a": map[string]interface{}{"b": 1}}, b: map[string]interface{}{"a": map[string]interface{}{"b": 2}}},
+		{name: "Key and value boundary", a: map[string]interface{}{"a": "b,c"}, b: map[string]interface{}{"a": "b", "c": nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if equal := structuralHash(tt.a) == structuralHash(tt.b); equal != tt.equal {
+				t.Errorf("structuralHash(%v) == structuralHash(%v) is %v, want %v", tt.a, tt.b, equal, tt.equal)
+			}
+		})
+	}
+}
+
+func TestStructuralSetCollision(t *testing.T) {
+	set := make(structuralSet)
+	a, b := "a", "b"
+
+	// Force a collision: b is stored under a's hash
+	set[structuralHash(a)] = []interface{}{b}
+	if !set.add(a) {
+		t.Error("Expected a value colliding with a different value to be added")
+	}
+	if set.add(a) {
+		t.Error("Expected a repeated value not to be added")
+	}
+}
@@ -0,0 +1,152 @@
+package slimjson
+
+import "strings"
+
+// blockTags are the HTML elements whose tags separate words, replaced with a
+// space rather than removed
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true,
+	"div": true, "dl": true, "dt": true, "figcaption": true, "figure": true, "footer": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+}
+
+// hiddenTags are the HTML elements whose content is not visible text
+var hiddenTags = map[string]bool{"script": true, "style": true, "template": true}
+
+// stripHTML removes the HTML tags and comments of s, and the content of
+// script, style and template elements, keeping the visible text. Tags of
+// block elements such as <p> and <br> become a space so words stay apart.
+// Malformed markup never hides text: a "<" that does not start a tag, such as
+// in "a < b", or whose tag is never closed by ">" is kept as text, and so are
+// unterminated comments and the content of unclosed script elements.
+// Entities are left as they are.
+func stripHTML(s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	stripped := false
+	space := func() {
+		if b.Len() > 0 && !isSpaceByte(b.String()[b.Len()-1]) {
+			b.WriteByte(' ')
+		}
+	}
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		rest := s[i:]
+		if strings.HasPrefix(rest, "<!--") {
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				b.WriteString(rest) // Unterminated comment
+				break
+			}
+			i += 4 + end + 3
+			stripped = true
+			continue
+		}
+
+		end := tagEnd(rest)
+		if end < 0 {
+			b.WriteByte('<')
+			i++
+			continue
+		}
+		name, closing := tagName(rest[:end])
+		i += end + 1
+		stripped = true
+
+		if hiddenTags[name] && !closing && !strings.HasSuffix(rest[:end], "/") {
+			// Skip the content up to the closing tag; without one it is kept
+			if closeAt := indexFold(s[i:], "</"+name); closeAt >= 0 {
+				i += closeAt
+			}
+			continue
+		}
+		if blockTags[name] {
+			space()
+		}
+	}
+
+	if !stripped {
+		return s
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// tagEnd returns the index of the ">" closing the tag at the start of s, or -1
+// if s does not start with a tag: "<" followed by a letter, "/" and a letter,
+// "!" or "?". A ">" inside a quoted attribute value does not close the tag,
+// unless the quote is never closed.
+func tagEnd(s string) int {
+	if len(s) < 2 {
+		return -1
+	}
+	switch c := s[1]; {
+	case isASCIILetter(c), c == '!', c == '?':
+	case c == '/' && len(s) > 2 && isASCIILetter(s[2]):
+	default:
+		return -1
+	}
+
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		case c == '<':
+			return -1 // A new tag starts before this one ends
+		}
+	}
+	if quote != 0 {
+		return strings.IndexByte(s, '>') // Unbalanced quote
+	}
+	return -1
+}
+
+// tagName returns the lowercase element name of tag, "<name ...", and whether
+// it is a closing tag, "</name"
+func tagName(tag string) (name string, closing bool) {
+	tag = tag[1:]
+	if closing = strings.HasPrefix(tag, "/"); closing {
+		tag = tag[1:]
+	}
+	end := 0
+	for end < len(tag) && (isASCIILetter(tag[end]) || (end > 0 && tag[end] >= '0' && tag[end] <= '9')) {
+		end++
+	}
+	return strings.ToLower(tag[:end]), closing
+}
+
+// indexFold returns the index of the first ASCII case-insensitive match of
+// the ASCII substr in s, or -1
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,70 @@
+package slimjson
+
+import "testing"
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "No markup", input: "plain & simple", expected: "plain & simple"},
+		{name: "Inline tags", input: "Fast <b>and</b> <i>light</i>", expected: "Fast and light"},
+		{name: "Nested tags", input: "<div><p>One <a href=\"/x\"><strong>two</strong></a></p><ul><li>three</li><li>four</li></ul></div>", expected: "One two three four"},
+		{name: "Line breaks separate words", input: "first<br>second<br/>third", expected: "first second third"},
+		{name: "Uppercase tags", input: "<P>Hello</P><BR>World", expected: "Hello World"},
+		{name: "Comments", input: "a<!-- hidden <b>note</b> -->b", expected: "ab"},
+		{name: "Script and style content", input: "<style>p{color:red}</style>Text<script type=\"text/javascript\">alert('<b>')</SCRIPT>", expected: "Text"},
+		{name: "Quoted greater-than in attributes", input: `<img alt="a > b" src="x.png">caption`, expected: "caption"},
+		{name: "Entities are kept", input: "<p>Tom &amp; Jerry</p>", expected: "Tom &amp; Jerry"},
+		{name: "Less-than sign", input: "a < b and 3<4", expected: "a < b and 3<4"},
+		{name: "Unclosed tag", input: "Hello <b world", expected: "Hello <b world"},
+		{name: "Tag cut off at the end", input: "Hello <b>world</b> <a href=\"", expected: "Hello world <a href=\""},
+		{name: "Stray closing tags", input: "</p>text</div></span>", expected: "text"},
+		{name: "Unterminated comment", input: "a <!-- b", expected: "a <!-- b"},
+		{name: "Unclosed script keeps its content", input: "<script>var x", expected: "var x"},
+		{name: "Non-ASCII text", input: "<p>Café</p><script>ünïcode</script><em>naïve</em>", expected: "Café naïve"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripHTML(tt.input); got != tt.expected {
+				t.Errorf("stripHTML(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStripHTMLConfig(t *testing.T) {
+	const input = "<p>Caf&eacute; &amp; bar&nbsp;&#8212; <b>open</b></p>"
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{name: "Off", config: Config{}, expected: input},
+		{name: "Tags", config: Config{StripHTML: true}, expected: "Caf&eacute; &amp; bar&nbsp;&#8212; open"},
+		{name: "Entities", config: Config{DecodeHTMLEntities: true}, expected: "<p>Café & bar — <b>open</b></p>"},
+		{name: "Tags and entities", config: Config{StripHTML: true, DecodeHTMLEntities: true}, expected: "Café & bar — open"},
+		{name: "Entities before whitespace and quote cleanup", config: Config{StripHTML: true, DecodeHTMLEntities: true, CleanWhitespace: true, NormalizeQuotes: true}, expected: "Café & bar - open"},
+		{name: "Truncation counts the cleaned text", config: Config{StripHTML: true, DecodeHTMLEntities: true, MaxStringLength: 10, TruncationSuffix: stringPtr("")}, expected: "Café & bar"},
+		{name: "Encoded tags stay text", config: Config{StripHTML: true, DecodeHTMLEntities: true}, expected: "Café & bar — open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DecimalPlaces = -1
+			result := New(tt.config).Slim(map[string]interface{}{"description": input})
+			if got := result.(map[string]interface{})["description"]; got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	// Entities decoded after stripping are not mistaken for tags
+	got := New(Config{StripHTML: true, DecodeHTMLEntities: true, DecimalPlaces: -1}).Slim("<p>Use &lt;b&gt; for bold</p>")
+	if got != "Use <b> for bold" {
+		t.Errorf("Expected %q, got %q", "Use <b> for bold", got)
+	}
+}
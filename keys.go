@@ -0,0 +1,166 @@
+package slimjson
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// keyDataFields are metadata fields whose values hold document objects, so
+// their keys are shortened too. Other metadata (_bools, _truncated, _enums...)
+// is left as is.
+var keyDataFields = map[string]bool{
+	"_data": true, "_cols": true, "_defaults": true, "_items": true, "_value": true,
+}
+
+// shortenKeys replaces object keys in result that repeat often enough to pay
+// for a dictionary entry with aliases k0, k1, ..., most frequent first, and
+// adds the alias -> key dictionary as _keys. Keys are counted on the slimmed
+// result, so sampled, truncated and flattened data is accounted for. Keys
+// starting with "_" are never shortened, and aliases never reuse a key that
+// already occurs. Results that are not objects have nowhere to keep _keys and
+// are returned unchanged.
+func shortenKeys(result interface{}) interface{} {
+	if !isObject(result) {
+		return result
+	}
+
+	counts := make(map[string]int)
+	countKeys(result, counts)
+
+	// Most frequent keys get the shortest aliases; sorted for deterministic output
+	keys := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(counts[b]-counts[a], strings.Compare(a, b))
+	})
+	aliases := make(map[string]string)
+	dict := make(map[string]string)
+	next := 0
+	for _, key := range keys {
+		if strings.HasPrefix(key, "_") {
+			continue
+		}
+		alias := "k" + strconv.Itoa(next)
+		for counts[alias] > 0 {
+			next++
+			alias = "k" + strconv.Itoa(next)
+		}
+		// Each use saves the length difference; the entry costs "alias":"key",
+		saved := counts[key] * (len(key) - len(alias))
+		if saved <= len(alias)+len(key)+6 {
+			continue
+		}
+		aliases[key] = alias
+		dict[alias] = key
+		next++
+	}
+	if len(aliases) == 0 {
+		return result
+	}
+
+	result = renameKeys(result, aliases)
+	switch r := result.(type) {
+	case map[string]interface{}:
+		r["_keys"] = dict
+	case *OrderedMap:
+		r.Set("_keys", dict)
+	}
+	return result
+}
+
+// countKeys counts the occurrences of each object key in data
+func countKeys(data interface{}, counts map[string]int) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			counts[k]++
+			countKeyData(k, val, counts)
+		}
+	case *OrderedMap:
+		for _, k := range v.Keys {
+			counts[k]++
+			countKeyData(k, v.Values[k], counts)
+		}
+	case []interface{}:
+		for _, item := range v {
+			countKeys(item, counts)
+		}
+	case [][]interface{}:
+		for _, row := range v {
+			countKeys(row, counts)
+		}
+	}
+}
+
+// countKeyData counts the keys in the value of field k, and the field names of a _schema
+func countKeyData(k string, val interface{}, counts map[string]int) {
+	if k == "_schema" {
+		if names, ok := val.([]string); ok {
+			for _, name := range names {
+				counts[name]++
+			}
+		}
+		return
+	}
+	if !strings.HasPrefix(k, "_") || keyDataFields[k] {
+		countKeys(val, counts)
+	}
+}
+
+// renameKeys returns data with object keys and _schema names replaced by their aliases
+func renameKeys(data interface{}, aliases map[string]string) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			result[alias(k, aliases)] = renameKeyData(k, val, aliases)
+		}
+		return result
+	case *OrderedMap:
+		result := &OrderedMap{Keys: make([]string, len(v.Keys)), Values: make(map[string]interface{}, len(v.Keys))}
+		for i, k := range v.Keys {
+			result.Keys[i] = alias(k, aliases)
+			result.Values[result.Keys[i]] = renameKeyData(k, v.Values[k], aliases)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = renameKeys(item, aliases)
+		}
+		return result
+	case [][]interface{}:
+		result := make([][]interface{}, len(v))
+		for i, row := range v {
+			result[i] = renameKeys(row, aliases).([]interface{})
+		}
+		return result
+	default:
+		return data
+	}
+}
+
+func renameKeyData(k string, val interface{}, aliases map[string]string) interface{} {
+	if k == "_schema" {
+		if names, ok := val.([]string); ok {
+			renamed := make([]string, len(names))
+			for i, name := range names {
+				renamed[i] = alias(name, aliases)
+			}
+			return renamed
+		}
+		return val
+	}
+	if !strings.HasPrefix(k, "_") || keyDataFields[k] {
+		return renameKeys(val, aliases)
+	}
+	return val
+}
+
+func alias(key string, aliases map[string]string) string {
+	if a, ok := aliases[key]; ok {
+		return a
+	}
+	return key
+}
@@ -0,0 +1,178 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// uniformRecords builds an array of n objects with the same long keys
+func uniformRecords(n int) []interface{} {
+	records := make([]interface{}, n)
+	for i := range records {
+		records[i] = map[string]interface{}{
+			"id":                      float64(i),
+			"created_at":              fmt.Sprintf("2024-01-%02d", i%28+1),
+			"organization_identifier": fmt.Sprintf("org-%d", i%7),
+			"is_active":               i%2 == 0,
+		}
+	}
+	return records
+}
+
+// roundTrip marshals v and decodes it like a client reading Slim output would
+func roundTrip(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	return decoded
+}
+
+func TestShortenKeysSavings(t *testing.T) {
+	doc := map[string]interface{}{"records": uniformRecords(100)}
+
+	plain, err := json.Marshal(New(Config{DecimalPlaces: -1}).Slim(doc))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	result := New(Config{DecimalPlaces: -1, ShortenKeys: true}).Slim(doc)
+	short, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	saved := 1 - float64(len(short))/float64(len(plain))
+	t.Logf("Key dictionary: %d -> %d bytes (%.1f%% saved)", len(plain), len(short), saved*100)
+	if saved < 0.3 {
+		t.Errorf("Expected at least 30%% savings on uniform objects, got %.1f%%", saved*100)
+	}
+
+	keys := result.(map[string]interface{})["_keys"].(map[string]string)
+	expected := map[string]string{"k0": "created_at", "k1": "is_active", "k2": "organization_identifier"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected _keys %v, got %v", expected, keys)
+	}
+	if !strings.Contains(string(short), `"records"`) || !strings.Contains(string(short), `"id"`) {
+		t.Errorf("Expected keys used once or shorter than an alias to be kept, got %s", short)
+	}
+
+	restored, err := Unslim(roundTrip(t, result))
+	if err != nil {
+		t.Fatalf("Unslim() error: %v", err)
+	}
+	if want := roundTrip(t, doc); !reflect.DeepEqual(restored, want) {
+		t.Errorf("Unslim() did not restore the original keys:\ngot  %v\nwant %v", restored, want)
+	}
+}
+
+func TestShortenKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		input    interface{}
+		expected string
+	}{
+		{
+			name:     "Keys used once are kept",
+			config:   Config{DecimalPlaces: -1},
+			input:    map[string]interface{}{"organization_identifier": "a"},
+			expected: `{"organization_identifier":"a"}`,
+		},
+		{
+			name:   "Aliases skip existing keys",
+			config: Config{DecimalPlaces: -1, SortKeys: true},
+			input: map[string]interface{}{"k0": true, "items": []interface{}{
+				map[string]interface{}{"description": 1.0}, map[string]interface{}{"description": 2.0},
+				map[string]interface{}{"description": 3.0}, map[string]interface{}{"description": 4.0},
+			}},
+			expected: `{"items":[{"k1":1},{"k1":2},{"k1":3},{"k1":4}],"k0":true,"_keys":{"k1":"description"}}`,
+		},
+		{
+			name:   "Metadata keys are kept",
+			config: Config{DecimalPlaces: -1, MaxDepth: 3, TruncationSummaries: true},
+			input: map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"meta": map[string]interface{}{"a": 1.0}}, map[string]interface{}{"meta": map[string]interface{}{"a": 1.0}},
+				map[string]interface{}{"meta": map[string]interface{}{"a": 1.0}}, map[string]interface{}{"meta": map[string]interface{}{"a": 1.0}},
+			}},
+			expected: `{"items":[{"_truncated":{"depth":2,"keys":["meta"]}},{"_truncated":{"depth":2,"keys":["meta"]}},{"_truncated":{"depth":2,"keys":["meta"]}},{"_truncated":{"depth":2,"keys":["meta"]}}]}`,
+		},
+		{
+			name:   "Top-level arrays are unchanged",
+			config: Config{DecimalPlaces: -1},
+			input: []interface{}{
+				map[string]interface{}{"description": 1.0}, map[string]interface{}{"description": 2.0},
+				map[string]interface{}{"description": 3.0}, map[string]interface{}{"description": 4.0},
+			},
+			expected: `[{"description":1},{"description":2},{"description":3},{"description":4}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ShortenKeys = true
+			data, err := json.Marshal(New(tt.config).Slim(tt.input))
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestShortenKeysUnslim(t *testing.T) {
+	records := uniformRecords(20)
+	nested := map[string]interface{}{"profile": map[string]interface{}{"display_name": "x", "description": "y"}}
+	for i := 0; i < 5; i++ {
+		records = append(records, nested)
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "Objects", config: Config{}},
+		{name: "Row type inference", config: Config{TypeInference: true}},
+		{name: "Columnar type inference", config: Config{TypeInference: true, TypeInferenceColumnar: true}},
+		{name: "Detected defaults", config: Config{DetectDefaults: true}},
+		{name: "Flattened", config: Config{Flatten: true}},
+		{name: "Preserved key order", config: Config{PreserveKeyOrder: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := map[string]interface{}{"users": records[:20], "profiles": records[20:]}
+			tt.config.DecimalPlaces = -1
+
+			want, err := Unslim(roundTrip(t, New(tt.config).Slim(doc)))
+			if err != nil {
+				t.Fatalf("Unslim() error without ShortenKeys: %v", err)
+			}
+			tt.config.ShortenKeys = true
+			result := New(tt.config).Slim(doc)
+			if !strings.Contains(fmt.Sprint(roundTrip(t, result)), "_keys") {
+				t.Fatalf("Expected keys to be shortened, got %v", result)
+			}
+			got, err := Unslim(roundTrip(t, result))
+			if err != nil {
+				t.Fatalf("Unslim() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Unslim() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	if _, err := Unslim(map[string]interface{}{"_keys": map[string]interface{}{"k0": 1.0}}); err == nil {
+		t.Error("Expected an error for an invalid _keys dictionary")
+	}
+}
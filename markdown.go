@@ -0,0 +1,171 @@
+package slimjson
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownHeading matches the opening hashes of an ATX heading, "## Title"
+var markdownHeading = regexp.MustCompile(`^ {0,3}#{1,6}(?:[ \t]+|$)`)
+
+// markdownClosingHashes matches the optional closing hashes of a heading, "## Title ##"
+var markdownClosingHashes = regexp.MustCompile(`[ \t]+#+[ \t]*$`)
+
+// markdownLink matches inline links and images, [text](url) and
+// ![alt](url "title"), capturing the text
+var markdownLink = regexp.MustCompile(`!?\[([^\[\]]*)\]\([^()\s]*(?:\s+"[^"]*")?\)`)
+
+// markdownEmphasis are the emphasis delimiters stripMarkdown removes, longest
+// first so ** is not taken for two *; *** is bold italic
+var markdownEmphasis = []string{"***", "**", "__", "~~", "*", "_"}
+
+// stripMarkdown removes common Markdown syntax from s: heading hashes,
+// emphasis and strikethrough markers, the URLs of links and images, keeping
+// their text, and code fence lines. It is conservative so code is not
+// corrupted: fenced and indented code blocks and `inline code` are kept
+// verbatim, and a marker is only removed with a matching one on the same
+// line at word boundaries, so snake_case names and 2*3*4 are left alone.
+func stripMarkdown(s string) string {
+	if !strings.ContainsAny(s, "#*_~[`") {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	fence := "" // Marker of the open code fence
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent <= 3 {
+			if marker := fenceMarker(trimmed); marker != "" && (fence == "" || (strings.HasPrefix(marker, fence) && strings.TrimSpace(trimmed[len(marker):]) == "")) {
+				if fence == "" {
+					fence = marker
+				} else {
+					fence = ""
+				}
+				continue
+			}
+		}
+		if fence != "" || strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+			out = append(out, line) // Code
+			continue
+		}
+
+		if loc := markdownHeading.FindStringIndex(line); loc != nil {
+			line = markdownClosingHashes.ReplaceAllString(line[loc[1]:], "")
+		}
+		out = append(out, stripInlineMarkdown(line))
+	}
+	return strings.Join(out, "\n")
+}
+
+// fenceMarker returns the run of three or more backticks or tildes opening
+// line, or "" if it does not start a code fence
+func fenceMarker(line string) string {
+	if !strings.HasPrefix(line, "```") && !strings.HasPrefix(line, "~~~") {
+		return ""
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if line[0] == '`' && strings.Contains(line[n:], "`") {
+		return "" // Inline code, not a fence
+	}
+	return line[:n]
+}
+
+// stripInlineMarkdown removes links and emphasis from line, leaving code
+// spans as they are
+func stripInlineMarkdown(line string) string {
+	var b strings.Builder
+	for line != "" {
+		start := strings.IndexByte(line, '`')
+		if start < 0 {
+			b.WriteString(stripSpanMarkdown(line))
+			break
+		}
+		ticks := start
+		for ticks < len(line) && line[ticks] == '`' {
+			ticks++
+		}
+		run := line[start:ticks]
+		end := strings.Index(line[ticks:], run)
+		if end < 0 {
+			b.WriteString(stripSpanMarkdown(line))
+			break
+		}
+		end += ticks + len(run)
+		b.WriteString(stripSpanMarkdown(line[:start]))
+		b.WriteString(line[start:end])
+		line = line[end:]
+	}
+	return b.String()
+}
+
+// stripSpanMarkdown removes links and emphasis from text without code
+func stripSpanMarkdown(text string) string {
+	text = markdownLink.ReplaceAllString(text, "$1")
+	for _, marker := range markdownEmphasis {
+		text = stripDelimiters(text, marker)
+	}
+	return text
+}
+
+// stripDelimiters removes pairs of marker around text. An opening marker
+// follows the start or a character other than a letter, digit, backslash or
+// marker character and precedes a non-space; a closing marker follows a
+// non-space and precedes the end or a character other than a letter, digit or
+// marker character.
+func stripDelimiters(s, marker string) string {
+	if !strings.Contains(s, marker) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if !isOpeningDelimiter(s, i, marker) {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		end := -1
+		for j := i + len(marker) + 1; j+len(marker) <= len(s); j++ {
+			if isClosingDelimiter(s, j, marker) {
+				end = j
+				break
+			}
+		}
+		if end < 0 {
+			b.WriteString(s[i : i+len(marker)])
+			i += len(marker)
+			continue
+		}
+		b.WriteString(s[i+len(marker) : end])
+		i = end + len(marker)
+	}
+	return b.String()
+}
+
+func isOpeningDelimiter(s string, i int, marker string) bool {
+	if !strings.HasPrefix(s[i:], marker) || i+len(marker) >= len(s) {
+		return false
+	}
+	if i > 0 && (isWordByte(s[i-1]) || s[i-1] == '\\' || s[i-1] == marker[0]) {
+		return false
+	}
+	next := s[i+len(marker)]
+	return !isSpaceByte(next) && next != marker[0]
+}
+
+func isClosingDelimiter(s string, j int, marker string) bool {
+	if !strings.HasPrefix(s[j:], marker) || isSpaceByte(s[j-1]) || s[j-1] == marker[0] || s[j-1] == '\\' {
+		return false
+	}
+	end := j + len(marker)
+	return end == len(s) || (!isWordByte(s[end]) && s[end] != marker[0])
+}
+
+// isWordByte reports whether c is an ASCII letter or digit, or part of a
+// multi-byte UTF-8 character
+func isWordByte(c byte) bool {
+	return isASCIILetter(c) || (c >= '0' && c <= '9') || c >= 0x80
+}
@@ -0,0 +1,53 @@
+package slimjson
+
+import "testing"
+
+func TestStripMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Plain text", input: "nothing to strip here", expected: "nothing to strip here"},
+		{name: "Headings", input: "# Title\n## Section ##\n####### Not a heading\n#hashtag", expected: "Title\nSection\n####### Not a heading\n#hashtag"},
+		{name: "Emphasis", input: "**bold**, __strong__, *italic*, _em_ and ~~gone~~", expected: "bold, strong, italic, em and gone"},
+		{name: "Nested emphasis", input: "***very*** important", expected: "very important"},
+		{name: "Links and images", input: "See [the docs](https://example.com/docs \"Docs\") and ![logo](logo.png)", expected: "See the docs and logo"},
+		{name: "Identifiers and arithmetic", input: "set max_list_length and compute 2*3*4 or a * b", expected: "set max_list_length and compute 2*3*4 or a * b"},
+		{name: "Unmatched markers", input: "**open and *half", expected: "**open and *half"},
+		{name: "Escaped markers", input: `\*not italic\*`, expected: `\*not italic\*`},
+		{name: "Inline code", input: "Call `fn(**kwargs)` with **care**", expected: "Call `fn(**kwargs)` with care"},
+		{name: "Code fence", input: "Example:\n```go\nx := a*b*c // **not bold**\n# not a heading\n```\nDone **now**", expected: "Example:\nx := a*b*c // **not bold**\n# not a heading\nDone now"},
+		{name: "Indented code", input: "Run:\n    make **all**\nthen _stop_", expected: "Run:\n    make **all**\nthen stop"},
+		{name: "Horizontal rule and list", input: "* item one\n***\n- item *two*", expected: "* item one\n***\n- item two"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripMarkdown(tt.input); got != tt.expected {
+				t.Errorf("stripMarkdown(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStripMarkdownConfig(t *testing.T) {
+	input := map[string]interface{}{
+		"id":          "release_notes",
+		"description": "## Release 2.0\n\nThe **fastest** release yet. Read the [upgrade guide](https://example.com/upgrade) first.",
+	}
+
+	result := New(Config{StripMarkdown: true, DecimalPlaces: -1}).Slim(input).(map[string]interface{})
+	if want := "Release 2.0\n\nThe fastest release yet. Read the upgrade guide first."; result["description"] != want {
+		t.Errorf("description = %q, want %q", result["description"], want)
+	}
+	if result["id"] != "release_notes" {
+		t.Errorf("id = %q, want it unchanged", result["id"])
+	}
+
+	// Markdown is stripped before truncation
+	result = New(Config{StripMarkdown: true, MaxStringLength: 11, TruncationSuffix: stringPtr(""), DecimalPlaces: -1}).Slim(input).(map[string]interface{})
+	if result["description"] != "Release 2.0" {
+		t.Errorf("truncated description = %q, want %q", result["description"], "Release 2.0")
+	}
+}
@@ -0,0 +1,228 @@
+// Package middleware slims the JSON responses of an existing HTTP service
+// without changing its handlers:
+//
+//	api := http.NewServeMux()
+//	// ... register the service's handlers on api
+//	handler := middleware.Handler(api, slimjson.Config{StripEmpty: true, MaxListLength: 20},
+//		middleware.WithPaths("/api/"), middleware.WithProfileHeader(middleware.ProfileHeader))
+//	log.Fatal(http.ListenAndServe(":8080", handler))
+//
+// Responses with a JSON Content-Type are buffered, slimmed and sent with a
+// new Content-Length; everything else is passed through untouched.
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tradik/slimjson"
+)
+
+// DefaultMaxBuffer is the size of the largest response Handler buffers
+// unless WithMaxBuffer says otherwise
+const DefaultMaxBuffer = 10 << 20
+
+// ProfileHeader is the conventional request header naming the profile to
+// slim a response with, see WithProfileHeader
+const ProfileHeader = "X-SlimJSON-Profile"
+
+// Option changes how Handler selects and slims responses
+type Option func(*options)
+
+// options are the settings of a Handler
+type options struct {
+	maxBuffer     int64
+	paths         []string
+	profileHeader string
+}
+
+// WithMaxBuffer sets the size of the largest response body buffered for
+// slimming, DefaultMaxBuffer by default. Larger responses are passed through
+// as they are; 0 or less buffers responses of any size.
+func WithMaxBuffer(n int64) Option {
+	return func(o *options) { o.maxBuffer = n }
+}
+
+// WithPaths only slims the responses to requests whose path starts with one
+// of prefixes, such as "/api/". Without it, every response may be slimmed.
+func WithPaths(prefixes ...string) Option {
+	return func(o *options) { o.paths = append(o.paths, prefixes...) }
+}
+
+// WithProfileHeader lets clients pick the profile their response is slimmed
+// with by naming a built-in or registered profile in the request header name,
+// such as ProfileHeader. Requests without it, or with an unknown profile, use
+// the Config given to Handler.
+func WithProfileHeader(name string) Option {
+	return func(o *options) { o.profileHeader = name }
+}
+
+// Handler returns a handler serving requests with next and slimming its
+// responses with cfg. A response is slimmed when its Content-Type is JSON
+// (application/json or +json), it has no Content-Encoding and it fits the
+// buffer, see WithMaxBuffer. Other responses, responses to HEAD requests and
+// streamed responses, whose handler flushes them, are passed through as
+// they are, as are bodies that are not valid JSON or fail to slim. Slimmed
+// responses get a new Content-Length.
+func Handler(next http.Handler, cfg slimjson.Config, opts ...Option) http.Handler {
+	o := options{maxBuffer: DefaultMaxBuffer}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead || !o.matches(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		reqCfg := cfg
+		if o.profileHeader != "" {
+			if profile, ok := slimjson.GetProfile(r.Header.Get(o.profileHeader)); ok {
+				reqCfg = profile
+			}
+		}
+
+		bw := &bufferingWriter{ResponseWriter: w, maxBuffer: o.maxBuffer}
+		next.ServeHTTP(bw, r)
+		bw.finish(r, reqCfg)
+	})
+}
+
+// matches reports whether responses to path may be slimmed, see WithPaths
+func (o *options) matches(path string) bool {
+	if len(o.paths) == 0 {
+		return true
+	}
+	for _, prefix := range o.paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferingWriter buffers a response that may be slimmed until the handler
+// returns, and switches to writing it through as soon as it turns out it
+// cannot be: by its headers, its size or a Flush
+type bufferingWriter struct {
+	http.ResponseWriter
+	maxBuffer   int64
+	status      int  // Status of the buffered response
+	wroteHeader bool // The handler called WriteHeader or Write
+	passthrough bool // Everything is written through
+	buf         bytes.Buffer
+}
+
+// WriteHeader decides from the headers whether the response is buffered.
+// Informational responses are always written through.
+func (w *bufferingWriter) WriteHeader(status int) {
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if !w.slimmable() {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// slimmable reports whether the headers of the response allow slimming it
+func (w *bufferingWriter) slimmable() bool {
+	h := w.Header()
+	switch w.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusSwitchingProtocols:
+		return false
+	}
+	if encoding := h.Get("Content-Encoding"); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		return false
+	}
+	if n, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err == nil && w.maxBuffer > 0 && n > w.maxBuffer {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// Write buffers p, or writes it through once the buffer would outgrow the
+// limit
+func (w *bufferingWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.passthrough && w.maxBuffer > 0 && int64(w.buf.Len()+len(p)) > w.maxBuffer {
+		if err := w.writeThrough(); err != nil {
+			return 0, err
+		}
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.buf.Write(p)
+}
+
+// Flush writes the response through from now on, since a handler that
+// flushes is streaming it, and flushes it
+func (w *bufferingWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.passthrough {
+		_ = w.writeThrough()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (w *bufferingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writeThrough writes the header and what was buffered, and switches to
+// writing through
+func (w *bufferingWriter) writeThrough() error {
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// finish writes the buffered response, slimmed with cfg if it is valid JSON
+func (w *bufferingWriter) finish(r *http.Request, cfg slimjson.Config) {
+	if !w.wroteHeader || w.passthrough {
+		return
+	}
+	body := w.buf.Bytes()
+	if slimmed, err := slim(r, cfg, body); err == nil {
+		body = slimmed
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+}
+
+// slim returns the JSON document body slimmed with cfg until the context of
+// r is done
+func slim(r *http.Request, cfg slimjson.Config, body []byte) ([]byte, error) {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	result, err := slimjson.New(cfg).SlimContext(r.Context(), data)
+	if err != nil {
+		return nil, err
+	}
+	slimmed, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return append(slimmed, '\n'), nil
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+// largeJSON is a list of records with empty fields, as an API might return
+func largeJSON(n int) string {
+	records := make([]interface{}, n)
+	for i := range records {
+		records[i] = map[string]interface{}{"id": i, "name": fmt.Sprintf("user-%d", i), "email": nil, "tags": []string{}}
+	}
+	data, _ := json.Marshal(map[string]interface{}{"users": records})
+	return string(data)
+}
+
+// service returns a server whose handlers, wrapped in Handler with cfg and
+// opts, answer /api/users with largeJSON, /api/text with text, /api/events
+// with flushed JSON lines and /public/users with largeJSON
+func service(t *testing.T, cfg slimjson.Config, opts ...Option) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	users := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = io.WriteString(w, largeJSON(100))
+	}
+	mux.HandleFunc("/api/users", users)
+	mux.HandleFunc("/public/users", users)
+	mux.HandleFunc("/api/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, `{"email": null, "tags": []}`)
+	})
+	mux.HandleFunc("/api/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		for i := range 3 {
+			_, _ = fmt.Fprintf(w, "{\"n\": %d, \"empty\": null}\n", i)
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/api/invalid", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"email": null,`)
+	})
+	srv := httptest.NewServer(Handler(mux, cfg, opts...))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// get requests path from srv with headers, returning the response and its body
+func get(t *testing.T, srv *httptest.Server, path string, headers map[string]string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return resp, string(body)
+}
+
+func TestHandler(t *testing.T) {
+	cfg := slimjson.Config{StripEmpty: true, MaxListLength: 2}
+	srv := service(t, cfg)
+
+	resp, body := get(t, srv, "/api/users", nil)
+	var data interface{}
+	if err := json.Unmarshal([]byte(largeJSON(100)), &data); err != nil {
+		t.Fatalf("Failed to unmarshal input: %v", err)
+	}
+	want, _ := json.Marshal(slimjson.New(cfg).Slim(data))
+	if got := strings.TrimSpace(body); got != string(want) {
+		t.Errorf("Expected the slimmed body %s, got %s", want, got)
+	}
+	if got, want := resp.Header.Get("Content-Length"), strconv.Itoa(len(body)); got != want {
+		t.Errorf("Expected Content-Length %s, got %s", want, got)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		want   string
+	}{
+		{"Text", "/api/text", http.StatusOK, `{"email": null, "tags": []}`},
+		{"Invalid JSON", "/api/invalid", http.StatusCreated, `{"email": null,`},
+		{"Streamed", "/api/events", http.StatusOK, "{\"n\": 0, \"empty\": null}\n{\"n\": 1, \"empty\": null}\n{\"n\": 2, \"empty\": null}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, srv, tt.path, nil)
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			if body != tt.want {
+				t.Errorf("Expected the body passed through, %q, got %q", tt.want, body)
+			}
+		})
+	}
+}
+
+func TestHandlerOptions(t *testing.T) {
+	slimmed := len(largeJSON(2)) // At most two users, without empty fields
+	tests := []struct {
+		name    string
+		opts    []Option
+		path    string
+		headers map[string]string
+		slim    bool
+	}{
+		{name: "Within max buffer", opts: []Option{WithMaxBuffer(int64(len(largeJSON(100))))}, path: "/api/users", slim: true},
+		{name: "Over max buffer", opts: []Option{WithMaxBuffer(1024)}, path: "/api/users"},
+		{name: "Unlimited buffer", opts: []Option{WithMaxBuffer(0)}, path: "/api/users", slim: true},
+		{name: "Allowed path", opts: []Option{WithPaths("/public/", "/api/")}, path: "/api/users", slim: true},
+		{name: "Other path", opts: []Option{WithPaths("/api/")}, path: "/public/users"},
+		{name: "Unknown profile", opts: []Option{WithProfileHeader(ProfileHeader)}, path: "/api/users", headers: map[string]string{ProfileHeader: "nonexistent"}, slim: true},
+		{name: "Profile header not honored", path: "/api/users", headers: map[string]string{ProfileHeader: "light"}, slim: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := service(t, slimjson.Config{StripEmpty: true, MaxListLength: 2}, tt.opts...)
+			_, body := get(t, srv, tt.path, tt.headers)
+			switch {
+			case tt.slim && len(body) > slimmed:
+				t.Errorf("Expected a slimmed body of at most %d bytes, got %d", slimmed, len(body))
+			case !tt.slim && body != largeJSON(100):
+				t.Errorf("Expected the body passed through, got %d bytes", len(body))
+			}
+		})
+	}
+
+	// The light profile keeps more users than the Config
+	srv := service(t, slimjson.Config{StripEmpty: true, MaxListLength: 2}, WithProfileHeader(ProfileHeader))
+	_, light := get(t, srv, "/api/users", map[string]string{ProfileHeader: "light"})
+	if len(light) <= slimmed || len(light) >= len(largeJSON(100)) {
+		t.Errorf("Expected the light profile to slim less than the Config, got %d bytes", len(light))
+	}
+}
+
+func TestHandlerHeadersPassThrough(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{"Content-Encoding", "Content-Encoding", "gzip", http.StatusOK},
+		{"Large Content-Length", "Content-Length", "100000", http.StatusOK},
+		{"No content", "", "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"a": null}`
+			if tt.status == http.StatusNoContent {
+				body = ""
+			}
+			handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tt.header != "" {
+					w.Header().Set(tt.header, tt.value)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, body)
+			}), slimjson.Config{StripEmpty: true}, WithMaxBuffer(1024))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.status || w.Body.String() != body {
+				t.Errorf("Expected %d %q passed through, got %d %q", tt.status, body, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package slimjson
+
+import (
+	"reflect"
+	"slices"
+)
+
+// DefaultConfig returns a Config with every option off except the defaults
+// that New would otherwise fill in: no float rounding, string pooling from
+// 2 occurrences, delta encoding from 5 numbers and enums of up to 10 values.
+func DefaultConfig() Config {
+	return Config{
+		DecimalPlaces:            -1,
+		StringPoolMinOccurrences: 2,
+		NumberDeltaThreshold:     5,
+		EnumMaxValues:            10,
+	}
+}
+
+// Option changes one aspect of a Config built by NewWithOptions.
+type Option func(*Config)
+
+// NewWithOptions creates a new Slimmer from DefaultConfig with opts applied in order,
+// so later options override earlier ones.
+func NewWithOptions(opts ...Option) *Slimmer {
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return New(cfg)
+}
+
+// WithConfig replaces all settings with cfg.
+func WithConfig(cfg Config) Option {
+	return func(c *Config) { *c = cfg }
+}
+
+// WithProfile replaces all settings with a built-in (light, medium, aggressive,
+// ai-optimized) or registered profile. Options the profile leaves unset keep
+// their DefaultConfig values. Unknown names are ignored.
+func WithProfile(name string) Option {
+	return func(c *Config) {
+		profile, ok := GetProfile(name)
+		if !ok {
+			return
+		}
+		cfg := DefaultConfig()
+		dst, src := reflect.ValueOf(&cfg).Elem(), reflect.ValueOf(profile)
+		for i := 0; i < src.NumField(); i++ {
+			if !src.Field(i).IsZero() {
+				dst.Field(i).Set(src.Field(i))
+			}
+		}
+		*c = cfg
+	}
+}
+
+// WithMaxDepth sets MaxDepth (0 = unlimited).
+func WithMaxDepth(n int) Option {
+	return func(c *Config) { c.MaxDepth = n }
+}
+
+// WithMaxListLength sets MaxListLength (0 = unlimited).
+func WithMaxListLength(n int) Option {
+	return func(c *Config) { c.MaxListLength = n }
+}
+
+// WithMaxStringLength sets MaxStringLength (0 = unlimited).
+func WithMaxStringLength(n int) Option {
+	return func(c *Config) { c.MaxStringLength = n }
+}
+
+// WithStripEmpty sets StripEmpty.
+func WithStripEmpty(enabled bool) Option {
+	return func(c *Config) { c.StripEmpty = enabled }
+}
+
+// WithBlockList replaces the BlockList with fields.
+func WithBlockList(fields ...string) Option {
+	return func(c *Config) { c.BlockList = slices.Clone(fields) }
+}
+
+// WithDecimalPlaces rounds floats to n decimal places (-1 = no rounding).
+func WithDecimalPlaces(n int) Option {
+	return func(c *Config) { c.DecimalPlaces = n }
+}
+
+// WithDeduplication sets DeduplicateArrays.
+func WithDeduplication(enabled bool) Option {
+	return func(c *Config) { c.DeduplicateArrays = enabled }
+}
+
+// WithSampling sets the SampleStrategy and SampleSize (0 = use MaxListLength).
+func WithSampling(strategy string, size int) Option {
+	return func(c *Config) {
+		c.SampleStrategy = strategy
+		c.SampleSize = size
+	}
+}
+
+// WithStringPooling enables StringPooling for strings occurring at least minOccurrences times.
+func WithStringPooling(minOccurrences int) Option {
+	return func(c *Config) {
+		c.StringPooling = true
+		c.StringPoolMinOccurrences = minOccurrences
+	}
+}
+
+// WithTypeInference enables TypeInference, column-major if columnar is set.
+func WithTypeInference(columnar bool) Option {
+	return func(c *Config) {
+		c.TypeInference = true
+		c.TypeInferenceColumnar = columnar
+	}
+}
+
+// WithRules appends path rules.
+func WithRules(rules ...PathRule) Option {
+	return func(c *Config) { c.Rules = append(slices.Clip(c.Rules), rules...) }
+}
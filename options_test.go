@@ -0,0 +1,123 @@
+package slimjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("DefaultConfig() is invalid: %v", err)
+	}
+	if !reflect.DeepEqual(New(cfg).Config, cfg) {
+		t.Errorf("New should not change DefaultConfig(), got %+v", New(cfg).Config)
+	}
+
+	// Floats are not rounded by default
+	result := NewWithOptions().Slim(map[string]interface{}{"pi": 3.14159})
+	if got := result.(map[string]interface{})["pi"]; got != 3.14159 {
+		t.Errorf("Expected unrounded float, got %v", got)
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	medium := GetBuiltinProfiles()["medium"]
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected func(*Config)
+	}{
+		{
+			name:     "No options",
+			expected: func(*Config) {},
+		},
+		{
+			name: "Options compose",
+			opts: []Option{WithMaxDepth(4), WithBlockList("password", "token"), WithStringPooling(3), WithTypeInference(false)},
+			expected: func(c *Config) {
+				c.MaxDepth = 4
+				c.BlockList = []string{"password", "token"}
+				c.StringPooling = true
+				c.StringPoolMinOccurrences = 3
+				c.TypeInference = true
+			},
+		},
+		{
+			name: "Later options override earlier ones",
+			opts: []Option{WithMaxDepth(4), WithBlockList("a"), WithMaxDepth(2), WithBlockList("b"), WithStripEmpty(true), WithStripEmpty(false)},
+			expected: func(c *Config) {
+				c.MaxDepth = 2
+				c.BlockList = []string{"b"}
+			},
+		},
+		{
+			name: "Profile keeps defaults for unset options",
+			opts: []Option{WithProfile("Medium")},
+			expected: func(c *Config) {
+				c.MaxDepth = medium.MaxDepth
+				c.MaxListLength = medium.MaxListLength
+				c.StripEmpty = medium.StripEmpty
+			},
+		},
+		{
+			name: "Options after a profile override it",
+			opts: []Option{WithProfile("medium"), WithMaxDepth(8), WithSampling("first_last", 4)},
+			expected: func(c *Config) {
+				c.MaxDepth = 8
+				c.MaxListLength = medium.MaxListLength
+				c.StripEmpty = medium.StripEmpty
+				c.SampleStrategy = "first_last"
+				c.SampleSize = 4
+			},
+		},
+		{
+			name: "Profile replaces earlier options",
+			opts: []Option{WithDeduplication(true), WithProfile("medium")},
+			expected: func(c *Config) {
+				c.MaxDepth = medium.MaxDepth
+				c.MaxListLength = medium.MaxListLength
+				c.StripEmpty = medium.StripEmpty
+			},
+		},
+		{
+			name:     "Unknown profile is ignored",
+			opts:     []Option{WithMaxDepth(3), WithProfile("nope")},
+			expected: func(c *Config) { c.MaxDepth = 3 },
+		},
+		{
+			name: "Rules append",
+			opts: []Option{WithRules(PathRule{Path: "a"}), WithRules(PathRule{Path: "b"})},
+			expected: func(c *Config) {
+				c.Rules = []PathRule{{Path: "a"}, {Path: "b"}}
+			},
+		},
+		{
+			name:     "Config replaces everything",
+			opts:     []Option{WithMaxDepth(3), WithConfig(Config{MaxListLength: 2}), WithDecimalPlaces(1)},
+			expected: func(c *Config) { *c = New(Config{MaxListLength: 2, DecimalPlaces: 1}).Config },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := DefaultConfig()
+			tt.expected(&expected)
+
+			got := NewWithOptions(tt.opts...).Config
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("NewWithOptions() config = %+v, want %+v", got, expected)
+			}
+		})
+	}
+}
+
+func TestWithBlockListCopies(t *testing.T) {
+	fields := []string{"a"}
+	s := NewWithOptions(WithBlockList(fields...))
+	fields[0] = "b"
+	if s.Config.BlockList[0] != "a" {
+		t.Errorf("WithBlockList should copy its fields, got %v", s.Config.BlockList)
+	}
+}
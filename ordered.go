@@ -0,0 +1,310 @@
+package slimjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// OrderedMap is a JSON object that keeps its keys in a fixed order when marshaled.
+type OrderedMap struct {
+	Keys   []string
+	Values map[string]interface{}
+}
+
+// NewOrderedMap creates an empty OrderedMap.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{Values: make(map[string]interface{})}
+}
+
+// Set assigns a value, appending the key if it is new.
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if _, ok := m.Values[key]; !ok {
+		m.Keys = append(m.Keys, key)
+	}
+	m.Values[key] = value
+}
+
+// Get returns the value stored under key.
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	v, ok := m.Values[key]
+	return v, ok
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap) Len() int {
+	return len(m.Keys)
+}
+
+// MarshalJSON encodes the object with keys in order.
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.Values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered reads the next JSON value from dec, decoding objects as *OrderedMap
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrderedValue(dec, tok)
+}
+
+func decodeOrderedValue(dec *json.Decoder, tok json.Token) (interface{}, error) {
+	switch tok {
+	case json.Delim('{'):
+		m := NewOrderedMap()
+		for dec.More() {
+			keyTok, err := nextToken(dec)
+			if err != nil {
+				return nil, err
+			}
+			valTok, err := nextToken(dec)
+			if err != nil {
+				return nil, err
+			}
+			val, err := decodeOrderedValue(dec, valTok)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(keyTok.(string), val)
+		}
+		if _, err := nextToken(dec); err != nil { // Closing brace
+			return nil, err
+		}
+		return m, nil
+
+	case json.Delim('['):
+		arr := make([]interface{}, 0)
+		for dec.More() {
+			itemTok, err := nextToken(dec)
+			if err != nil {
+				return nil, err
+			}
+			item, err := decodeOrderedValue(dec, itemTok)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, item)
+		}
+		if _, err := nextToken(dec); err != nil { // Closing bracket
+			return nil, err
+		}
+		return arr, nil
+
+	default:
+		return tok, nil
+	}
+}
+
+// nextToken reads a token inside a value, where EOF means truncated input
+func nextToken(dec *json.Decoder) (json.Token, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return tok, err
+}
+
+// recordKeyOrder appends keys not yet seen at path to its recorded order
+func (s *Slimmer) recordKeyOrder(path string, keys []string) {
+	if s.keyOrder == nil {
+		s.keyOrder = make(map[string]map[string]int)
+	}
+	order := s.keyOrder[path]
+	if order == nil {
+		order = make(map[string]int, len(keys))
+		s.keyOrder[path] = order
+	}
+	for _, k := range keys {
+		if _, ok := order[k]; !ok {
+			order[k] = len(order)
+		}
+	}
+}
+
+// keyRank returns the recorded position of key at path. Flattened keys rank
+// by the position of each segment. It returns nil for unknown keys.
+func (s *Slimmer) keyRank(path, key string) []int {
+	if i, ok := s.keyOrder[path][key]; ok {
+		return []int{i}
+	}
+	segments := strings.Split(key, ".")
+	if len(segments) == 1 {
+		return nil
+	}
+	rank := make([]int, 0, len(segments))
+	for _, seg := range segments {
+		i, ok := s.keyOrder[path][seg]
+		if !ok {
+			return nil
+		}
+		rank = append(rank, i)
+		path = joinPath(path, seg)
+	}
+	return rank
+}
+
+// compareKeys orders keys at path by their recorded position.
+// Unknown keys, such as generated metadata, sort alphabetically after known keys.
+func (s *Slimmer) compareKeys(path, a, b string) int {
+	ra, rb := s.keyRank(path, a), s.keyRank(path, b)
+	switch {
+	case ra == nil && rb != nil:
+		return 1
+	case ra != nil && rb == nil:
+		return -1
+	}
+	if c := slices.Compare(ra, rb); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// orderKeys recursively converts objects into OrderedMaps in their recorded key order.
+// path is the field path of data, without array indices.
+func (s *Slimmer) orderKeys(data interface{}, path string) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		keys := slices.SortedFunc(maps.Keys(v), func(a, b string) int {
+			return s.compareKeys(path, a, b)
+		})
+		result := &OrderedMap{Keys: keys, Values: make(map[string]interface{}, len(v))}
+		for k, val := range v {
+			switch k {
+			case "_data":
+				// Row values belong to the fields named by _schema
+				if schema, ok := v["_schema"].([]string); ok {
+					result.Values[k] = s.orderRows(val, path, schema)
+					continue
+				}
+				result.Values[k] = s.orderKeys(val, path)
+			case "_cols", "_defaults", "_items":
+				// Metadata describing the objects at this path
+				result.Values[k] = s.orderKeys(val, path)
+			default:
+				result.Values[k] = s.orderKeys(val, joinPath(path, k))
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = s.orderKeys(item, path)
+		}
+		return result
+	case [][]interface{}:
+		result := make([][]interface{}, len(v))
+		for i, row := range v {
+			result[i] = s.orderKeys(row, path).([]interface{})
+		}
+		return result
+	default:
+		return data
+	}
+}
+
+// orderRows orders objects nested in type-inferred _data rows
+func (s *Slimmer) orderRows(data interface{}, path string, schema []string) interface{} {
+	rows, ok := data.([][]interface{})
+	if !ok {
+		return data
+	}
+	result := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		result[i] = make([]interface{}, len(row))
+		for j, val := range row {
+			if j < len(schema) {
+				val = s.orderKeys(val, joinPath(path, schema[j]))
+			}
+			result[i][j] = val
+		}
+	}
+	return result
+}
+
+// sortKeys recursively converts objects into OrderedMaps with sorted keys
+func sortKeys(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		result := &OrderedMap{Keys: slices.Sorted(maps.Keys(v)), Values: make(map[string]interface{}, len(v))}
+		for k, val := range v {
+			result.Values[k] = sortKeys(val)
+		}
+		return result
+	case map[string][]string:
+		result := &OrderedMap{Keys: slices.Sorted(maps.Keys(v)), Values: make(map[string]interface{}, len(v))}
+		for k, val := range v {
+			result.Values[k] = val
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = sortKeys(item)
+		}
+		return result
+	case [][]interface{}:
+		result := make([][]interface{}, len(v))
+		for i, row := range v {
+			result[i] = sortKeys(row).([]interface{})
+		}
+		return result
+	default:
+		return data
+	}
+}
+
+// plainMaps recursively converts OrderedMaps back into regular maps
+func plainMaps(data interface{}) interface{} {
+	switch v := data.(type) {
+	case *OrderedMap:
+		result := make(map[string]interface{}, len(v.Values))
+		for k, val := range v.Values {
+			result[k] = plainMaps(val)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			result[k] = plainMaps(val)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = plainMaps(item)
+		}
+		return result
+	case [][]interface{}:
+		result := make([]interface{}, len(v))
+		for i, row := range v {
+			result[i] = plainMaps(row)
+		}
+		return result
+	default:
+		return data
+	}
+}
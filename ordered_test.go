@@ -0,0 +1,26 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrderedMapMarshal(t *testing.T) {
+	m := NewOrderedMap()
+	m.Set("zeta", 1)
+	m.Set("alpha", []interface{}{"x", map[string]interface{}{"b": 2, "a": 1}})
+	m.Set("zeta", 3) // Existing key keeps its position
+
+	got, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	expected := `{"zeta":3,"alpha":["x",{"a":1,"b":2}]}`
+	if string(got) != expected {
+		t.Errorf("MarshalJSON() = %s, want %s", got, expected)
+	}
+	if m.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", m.Len())
+	}
+}
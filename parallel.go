@@ -0,0 +1,101 @@
+package slimjson
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// parallelMinElements is the length from which Parallelism fans out the
+// pruning of an array; shorter arrays are not worth the goroutines
+const parallelMinElements = 512
+
+// parallelWorkers returns the number of goroutines pruning an array of n
+// elements, 1 to prune it serially
+func (s *Slimmer) parallelWorkers(n int) int {
+	if s.Config.Parallelism < 2 || n < parallelMinElements || s.forked || s.samplesRandomly() {
+		return 1
+	}
+	return min(s.Config.Parallelism, n)
+}
+
+// samplesRandomly reports whether the config or a rule uses "random"
+// sampling, whose draws from the shared rng depend on the pruning order
+func (s *Slimmer) samplesRandomly() bool {
+	if s.Config.SampleStrategy == "random" {
+		return true
+	}
+	for _, r := range s.rules {
+		if r.config.SampleStrategy == "random" {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneElementsParallel prunes the elements of the array arr like
+// pruneElement on workers goroutines, each with a fork of s pruning a
+// contiguous share, and joins the forks in element order, so the state they
+// collect is the same as if s had pruned the elements itself. A panic in a
+// worker, such as the contextDone of checkContext, is raised again here.
+func (s *Slimmer) pruneElementsParallel(arr []interface{}, depth int, path string, workers int) ([]interface{}, []bool) {
+	n := len(arr)
+	pruned, kept := make([]interface{}, n), make([]bool, n)
+	forks := make([]*Slimmer, workers)
+	panics := make([]interface{}, workers)
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		forks[w] = s.fork()
+		lo, hi := w*n/workers, (w+1)*n/workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { panics[w] = recover() }()
+			for i := lo; i < hi; i++ {
+				pruned[i], kept[i] = forks[w].pruneElement(arr, i, depth, path)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for w, f := range forks {
+		if panics[w] != nil {
+			panic(panics[w])
+		}
+		s.join(f)
+	}
+	return pruned, kept
+}
+
+// fork returns a copy of s for a worker of pruneElementsParallel. It shares
+// the config and the string and enum pools, which pruning only reads, and
+// starts the state pruning collects afresh.
+func (s *Slimmer) fork() *Slimmer {
+	f := *s
+	f.forked = true
+	f.nullFields = nil
+	f.flattened, f.literalDots = false, false
+	f.keyOrder = nil
+	f.visited = 0
+	if s.changes != nil {
+		f.changes = []Change{}
+	}
+	return &f
+}
+
+// join adds the state collected by the fork f to s
+func (s *Slimmer) join(f *Slimmer) {
+	s.nullFields = append(s.nullFields, f.nullFields...)
+	s.flattened = s.flattened || f.flattened
+	s.literalDots = s.literalDots || f.literalDots
+	for path, order := range f.keyOrder {
+		s.recordKeyOrder(path, slices.SortedFunc(maps.Keys(order), func(a, b string) int {
+			return cmp.Compare(order[a], order[b])
+		}))
+	}
+	if s.changes != nil {
+		s.changes = append(s.changes, f.changes...)
+	}
+}
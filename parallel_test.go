@@ -0,0 +1,144 @@
+package slimjson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syntheticRecords builds n log-like records with repeated strings, nulls,
+// empty values, nested objects and floats
+func syntheticRecords(n int) []interface{} {
+	records := make([]interface{}, n)
+	levels := []string{"info", "warning", "error"}
+	for i := range records {
+		record := map[string]interface{}{
+			"id":      float64(i),
+			"level":   levels[i%len(levels)],
+			"message": fmt.Sprintf("request %d served from the primary region", i),
+			"latency": float64(i%100) / 7,
+			"user":    map[string]interface{}{"name": fmt.Sprintf("user-%d", i%40), "roles": []interface{}{"reader", "writer"}},
+			"trace":   nil,
+			"tags":    []interface{}{},
+			"at":      "2024-05-01T10:00:00Z",
+		}
+		if i%5 == 0 {
+			record["note"] = strings.Repeat("long note ", 20)
+		}
+		if i%7 == 0 {
+			record["ctx"] = map[string]interface{}{"a.b": map[string]interface{}{"c": true}}
+		}
+		records[i] = record
+	}
+	return records
+}
+
+func TestParallelismMatchesSerial(t *testing.T) {
+	records := syntheticRecords(2000)
+	docs := map[string]interface{}{
+		"Root array":   records,
+		"Nested array": map[string]interface{}{"meta": map[string]interface{}{"count": 2000.0}, "records": records},
+	}
+	configs := map[string]Config{
+		"Limits": {MaxDepth: 3, MaxListLength: 1500, MaxStringLength: 40, StripEmpty: true, DecimalPlaces: 2, BlockList: []string{"trace"}},
+		"Encodings": {
+			StripEmpty: true, NullCompression: true, StringPooling: true, EnumDetection: true, TypeInference: true,
+			BoolCompression: true, TimestampCompression: true, ShortenKeys: true, DecimalPlaces: -1,
+		},
+		"Flatten":        {Flatten: true, DecimalPlaces: -1},
+		"Rules":          {DecimalPlaces: -1, Rules: []PathRule{{Path: "$.records[*].user", Config: Config{BlockList: []string{"roles"}, DecimalPlaces: -1}}}},
+		"Sampling":       {SampleStrategy: "representative", MaxListLength: 100, DeduplicateArrays: true, DecimalPlaces: -1},
+		"Empty elements": {StripEmpty: true, MaxDepth: 1, TruncationSummaries: true, DecimalPlaces: -1},
+	}
+
+	for docName, doc := range docs {
+		for cfgName, cfg := range configs {
+			t.Run(docName+"/"+cfgName, func(t *testing.T) {
+				serialResult, serialChanges := New(cfg).Explain(doc)
+				cfg.Parallelism = 4
+				parallelResult, parallelChanges := New(cfg).Explain(doc)
+
+				want, _ := json.Marshal(serialResult)
+				got, _ := json.Marshal(parallelResult)
+				if string(got) != string(want) {
+					t.Errorf("Expected the serial output\n%.300s\ngot\n%.300s", want, got)
+				}
+				if !reflect.DeepEqual(parallelChanges, serialChanges) {
+					t.Errorf("Expected the serial changes (%d), got %d", len(serialChanges), len(parallelChanges))
+				}
+			})
+		}
+	}
+
+	// Key order of ordered input, recorded by every worker
+	input, err := json.Marshal(records)
+	if err != nil {
+		t.Fatalf("Failed to marshal records: %v", err)
+	}
+	cfg := Config{PreserveKeyOrder: true, StripEmpty: true, DecimalPlaces: -1}
+	serial, err := New(cfg).SlimBytes(input)
+	if err != nil {
+		t.Fatalf("SlimBytes() error: %v", err)
+	}
+	cfg.Parallelism = 3
+	parallel, err := New(cfg).SlimBytes(input)
+	if err != nil {
+		t.Fatalf("SlimBytes() error: %v", err)
+	}
+	want, _ := json.Marshal(serial)
+	got, _ := json.Marshal(parallel)
+	if string(got) != string(want) {
+		t.Errorf("Expected the serial key order\n%.300s\ngot\n%.300s", want, got)
+	}
+}
+
+func TestParallelismWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		n        int
+		expected int
+	}{
+		{"Serial", Config{}, 1000, 1},
+		{"One worker", Config{Parallelism: 1}, 1000, 1},
+		{"Small array", Config{Parallelism: 4}, parallelMinElements - 1, 1},
+		{"Large array", Config{Parallelism: 4}, parallelMinElements, 4},
+		{"Random sampling", Config{Parallelism: 4, SampleStrategy: "random"}, 1000, 1},
+		{"Random sampling in a rule", Config{Parallelism: 4, Rules: []PathRule{{Path: "a", Config: Config{SampleStrategy: "random"}}}}, 1000, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.cfg)
+			s.compileRules()
+			if got := s.parallelWorkers(tt.n); got != tt.expected {
+				t.Errorf("Expected %d workers, got %d", tt.expected, got)
+			}
+			if got := s.fork().parallelWorkers(tt.n); got != 1 {
+				t.Errorf("Expected workers not to fan out again, got %d", got)
+			}
+		})
+	}
+}
+
+func TestParallelismContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var once sync.Once
+	cfg := Config{Parallelism: 4, DecimalPlaces: -1, ValueTransform: func(path string, value interface{}) (interface{}, bool) {
+		if strings.HasPrefix(path, "1500.") {
+			once.Do(cancel)
+		}
+		return nil, false
+	}}
+
+	_, err := New(cfg).SlimContext(ctx, syntheticRecords(20000))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from a worker, got %v", err)
+	}
+}
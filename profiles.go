@@ -0,0 +1,119 @@
+package slimjson
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Profile is a named Config with a description of what it is for
+type Profile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Config      Config `json:"config"`
+}
+
+// builtinDescriptions describe the profiles returned by GetBuiltinProfiles
+var builtinDescriptions = map[string]string{
+	"light":        "Light compression that keeps most data: 10 levels of nesting, 20 items per list",
+	"medium":       "Balanced reduction: 5 levels of nesting, 10 items per list",
+	"aggressive":   "Maximum reduction: 3 levels of nesting, 5 items per list, drops free-text fields (description, notes, bio...)",
+	"ai-optimized": "Token reduction for LLM context: 4 levels of nesting, 8 items per list, drops GitHub API URL fields",
+}
+
+func builtinProfiles() map[string]Profile {
+	profiles := make(map[string]Profile)
+	for name, cfg := range GetBuiltinProfiles() {
+		profiles[name] = Profile{Name: name, Description: builtinDescriptions[name], Config: cfg}
+	}
+	return profiles
+}
+
+// registry holds the named profiles available to GetProfile, seeded with the built-ins
+var registry = struct {
+	sync.RWMutex
+	profiles map[string]Profile
+}{profiles: builtinProfiles()}
+
+// RegisterProfile adds a named profile that can be referenced like a built-in
+// one: by GetProfile, WithProfile, the CLI and daemon, and extends= in config
+// files. Names are case-insensitive. Registering a name again replaces the
+// profile, but built-in names are rejected; use ForceRegisterProfile to replace
+// a built-in profile. cfg must not be modified after it is registered.
+// It is safe for concurrent use.
+func RegisterProfile(name string, cfg Config) error {
+	return registerProfile(Profile{Name: name, Config: cfg}, false)
+}
+
+// RegisterProfileWithDescription is like RegisterProfile, and sets the description
+// listed by ListProfiles, the CLI and the daemon.
+func RegisterProfileWithDescription(name, description string, cfg Config) error {
+	return registerProfile(Profile{Name: name, Description: description, Config: cfg}, false)
+}
+
+// ForceRegisterProfile is like RegisterProfile, but also replaces built-in profiles.
+func ForceRegisterProfile(name string, cfg Config) error {
+	return registerProfile(Profile{Name: name, Config: cfg}, true)
+}
+
+func registerProfile(p Profile, force bool) error {
+	key := strings.ToLower(strings.TrimSpace(p.Name))
+	if key == "" {
+		return fmt.Errorf("profile name must not be empty")
+	}
+	if _, builtin := GetBuiltinProfiles()[key]; builtin && !force {
+		return fmt.Errorf("profile %s is built-in and cannot be replaced without force", key)
+	}
+	if err := p.Config.Validate(); err != nil {
+		return fmt.Errorf("profile %s: invalid config: %w", key, err)
+	}
+	p.Name = key
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.profiles[key] = p
+	return nil
+}
+
+// GetProfile returns the built-in or registered profile with the given name (case-insensitive).
+func GetProfile(name string) (Config, bool) {
+	p, ok := LookupProfile(name)
+	return p.Config, ok
+}
+
+// LookupProfile is like GetProfile, but returns the profile with its description.
+func LookupProfile(name string) (Profile, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	p, ok := registry.profiles[strings.ToLower(name)]
+	return p, ok
+}
+
+// AllProfiles returns the built-in profiles together with the registered ones,
+// which replace built-ins of the same name.
+func AllProfiles() map[string]Config {
+	registry.RLock()
+	defer registry.RUnlock()
+	configs := make(map[string]Config, len(registry.profiles))
+	for name, p := range registry.profiles {
+		configs[name] = p.Config
+	}
+	return configs
+}
+
+// ListProfiles returns the built-in and registered profiles with their
+// descriptions, sorted by name.
+func ListProfiles() []Profile {
+	registry.RLock()
+	defer registry.RUnlock()
+	return sortedProfiles(registry.profiles)
+}
+
+func sortedProfiles(profiles map[string]Profile) []Profile {
+	return slices.SortedFunc(maps.Values(profiles), func(a, b Profile) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
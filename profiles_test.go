@@ -0,0 +1,141 @@
+package slimjson
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// unregisterProfiles restores the registry after a test
+func unregisterProfiles(t *testing.T) {
+	t.Cleanup(func() {
+		registry.Lock()
+		defer registry.Unlock()
+		registry.profiles = builtinProfiles()
+	})
+}
+
+func TestRegisterProfile(t *testing.T) {
+	unregisterProfiles(t)
+
+	webhook := Config{MaxDepth: 2, BlockList: []string{"signature"}}
+	if err := RegisterProfile("Webhook-Slim", webhook); err != nil {
+		t.Fatalf("RegisterProfile() error: %v", err)
+	}
+	if got, ok := GetProfile("webhook-slim"); !ok || !reflect.DeepEqual(got, webhook) {
+		t.Errorf("GetProfile() = %+v, %v, want %+v", got, ok, webhook)
+	}
+
+	// Registered profiles can be replaced, built-ins only with force
+	if err := RegisterProfile("webhook-slim", Config{MaxDepth: 3}); err != nil {
+		t.Errorf("Expected a registered profile to be replaceable, got %v", err)
+	}
+	if err := RegisterProfile("Medium", Config{MaxDepth: 1}); err == nil || !strings.Contains(err.Error(), "built-in") {
+		t.Errorf("Expected an error replacing a built-in profile, got %v", err)
+	}
+	if got, _ := GetProfile("medium"); got.MaxDepth != GetBuiltinProfiles()["medium"].MaxDepth {
+		t.Errorf("Expected the built-in medium profile to be kept, got %+v", got)
+	}
+	if err := ForceRegisterProfile("medium", Config{MaxDepth: 1}); err != nil {
+		t.Errorf("ForceRegisterProfile() error: %v", err)
+	}
+	if got, _ := GetProfile("medium"); got.MaxDepth != 1 {
+		t.Errorf("Expected the forced medium profile, got %+v", got)
+	}
+
+	for name, cfg := range map[string]Config{" ": {}, "bad": {MaxDepth: -1}} {
+		if err := RegisterProfile(name, cfg); err == nil {
+			t.Errorf("RegisterProfile(%q) expected an error", name)
+		}
+	}
+
+	// GetBuiltinProfiles is unaffected; AllProfiles merges
+	if got := GetBuiltinProfiles()["medium"].MaxDepth; got != 5 {
+		t.Errorf("GetBuiltinProfiles() medium MaxDepth = %d, want 5", got)
+	}
+	all := AllProfiles()
+	if len(all) != 5 || all["medium"].MaxDepth != 1 || all["webhook-slim"].MaxDepth != 3 {
+		t.Errorf("AllProfiles() = %+v", all)
+	}
+	all["light"] = Config{}
+	if got, _ := GetProfile("light"); got.MaxDepth == 0 {
+		t.Error("Modifying the AllProfiles() map should not change the registry")
+	}
+
+	// Registered profiles are usable by name like the built-ins
+	if got := NewWithOptions(WithProfile("WEBHOOK-SLIM")).Config.MaxDepth; got != 3 {
+		t.Errorf("WithProfile() MaxDepth = %d, want 3", got)
+	}
+	path := writeConfig(t, ".slimjson", "[hooks]\nextends=webhook-slim\nlist-len=4\n")
+	profiles, err := ParseConfigFile(path)
+	if err != nil || profiles["hooks"].MaxDepth != 3 || profiles["hooks"].MaxListLength != 4 {
+		t.Errorf("ParseConfigFile() = %+v, %v, want a profile extending webhook-slim", profiles, err)
+	}
+}
+
+func TestRegisterProfileConcurrent(t *testing.T) {
+	unregisterProfiles(t)
+
+	const workers, perWorker = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				name := fmt.Sprintf("profile-%d-%d", w, i)
+				if err := RegisterProfile(name, Config{MaxDepth: i + 1}); err != nil {
+					t.Errorf("RegisterProfile(%s) error: %v", name, err)
+					return
+				}
+				if _, ok := GetProfile(name); !ok {
+					t.Errorf("GetProfile(%s) not found after registration", name)
+				}
+				_ = AllProfiles()
+				_ = RegisterProfile("light", Config{}) // Rejected concurrently with reads
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	all := AllProfiles()
+	if len(all) != workers*perWorker+len(GetBuiltinProfiles()) {
+		t.Errorf("Expected %d profiles, got %d", workers*perWorker+len(GetBuiltinProfiles()), len(all))
+	}
+	if cfg, _ := GetProfile("profile-3-9"); cfg.MaxDepth != 10 {
+		t.Errorf("Expected profile-3-9 MaxDepth 10, got %d", cfg.MaxDepth)
+	}
+}
+
+func TestProfileDescriptions(t *testing.T) {
+	unregisterProfiles(t)
+
+	for _, p := range ListProfiles() {
+		if p.Description == "" {
+			t.Errorf("Built-in profile %s has no description", p.Name)
+		}
+	}
+
+	if err := RegisterProfileWithDescription("Webhook", "Drops signatures from webhook payloads", Config{MaxDepth: 2}); err != nil {
+		t.Fatalf("RegisterProfileWithDescription() error: %v", err)
+	}
+	if err := RegisterProfileWithDescription("light", "Replaced", Config{}); err == nil {
+		t.Error("Expected an error replacing a built-in profile")
+	}
+
+	p, ok := LookupProfile("WEBHOOK")
+	expected := Profile{Name: "webhook", Description: "Drops signatures from webhook payloads", Config: Config{MaxDepth: 2}}
+	if !ok || !reflect.DeepEqual(p, expected) {
+		t.Errorf("LookupProfile() = %+v, %v, want %+v", p, ok, expected)
+	}
+
+	var names []string
+	for _, p := range ListProfiles() {
+		names = append(names, p.Name)
+	}
+	if want := []string{"aggressive", "ai-optimized", "light", "medium", "webhook"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ListProfiles() names = %v, want %v", names, want)
+	}
+}
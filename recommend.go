@@ -0,0 +1,189 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Floors of the limits RecommendConfig tightens to reach its target
+const (
+	minRecommendedStringLength = 32
+	minRecommendedListLength   = 3
+	minRecommendedDepth        = 2
+)
+
+// sampleProfile describes a sample document for RecommendConfig
+type sampleProfile struct {
+	depth         int            // Deepest nesting of arrays and objects
+	empties       int            // Nulls, empty strings, arrays and objects
+	longestList   int            // Elements of the longest array
+	longestString int            // Characters of the longest string
+	strings       map[string]int // Occurrences of each string value
+	keys          map[string]int // Occurrences of each object key
+	uniformArrays int            // Arrays of two or more objects with the same keys
+	preciseFloats int            // Numbers with more than two decimals
+}
+
+// analyzeSample walks data and collects its sampleProfile
+func analyzeSample(data interface{}) sampleProfile {
+	p := sampleProfile{strings: make(map[string]int), keys: make(map[string]int)}
+	p.visit(data, 0)
+	return p
+}
+
+func (p *sampleProfile) visit(v interface{}, depth int) {
+	switch val := v.(type) {
+	case nil:
+		p.empties++
+	case string:
+		if val == "" {
+			p.empties++
+		}
+		p.strings[val]++
+		p.longestString = max(p.longestString, utf8.RuneCountInString(val))
+	case float64:
+		if s := strconv.FormatFloat(val, 'f', -1, 64); strings.Contains(s, ".") && len(s)-strings.IndexByte(s, '.') > 3 {
+			p.preciseFloats++
+		}
+	case map[string]interface{}:
+		p.depth = max(p.depth, depth+1)
+		if len(val) == 0 {
+			p.empties++
+		}
+		for k, child := range val {
+			p.keys[k]++
+			p.visit(child, depth+1)
+		}
+	case []interface{}:
+		p.depth = max(p.depth, depth+1)
+		if len(val) == 0 {
+			p.empties++
+		}
+		p.longestList = max(p.longestList, len(val))
+		if uniformObjects(val) {
+			p.uniformArrays++
+		}
+		for _, child := range val {
+			p.visit(child, depth+1)
+		}
+	}
+}
+
+// uniformObjects reports whether arr holds two or more objects with the same keys
+func uniformObjects(arr []interface{}) bool {
+	if len(arr) < 2 {
+		return false
+	}
+	first, ok := arr[0].(map[string]interface{})
+	if !ok || len(first) == 0 {
+		return false
+	}
+	for _, v := range arr[1:] {
+		m, ok := v.(map[string]interface{})
+		if !ok || len(m) != len(first) {
+			return false
+		}
+		for k := range first {
+			if _, ok := m[k]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// repeatedBytes returns the bytes taken by repetitions of the values of
+// counts beyond their first occurrence
+func repeatedBytes(counts map[string]int) int {
+	n := 0
+	for s, c := range counts {
+		if c > 1 {
+			n += (c - 1) * (len(s) + 2)
+		}
+	}
+	return n
+}
+
+// RecommendConfig suggests a Config for documents like sample that makes them
+// at least targetReductionPct percent smaller as compact JSON, for users who
+// do not know which options to set. It is a heuristic advisor: the sample is
+// analyzed for empty values, repeated strings and keys, uniform arrays of
+// objects, nesting depth and long values, and each option this suggests is
+// kept only if it shrinks the sample. Reversible encodings are tried first.
+// If they fall short of the target, floats are rounded to two decimals, the
+// string and list limits are halved in turn and then the depth lowered level
+// by level until the target is reached or the limits hit their floors (32
+// characters, 3 elements and 2 levels), so the target is not always reached. A target of 0 or less keeps
+// the sample intact apart from dropping empty values.
+func RecommendConfig(sample interface{}, targetReductionPct float64) Config {
+	p := analyzeSample(sample)
+	cfg := Config{DecimalPlaces: -1}
+
+	original, err := json.Marshal(sample)
+	if err != nil || len(original) == 0 {
+		return cfg
+	}
+	size := len(original)
+	measure := func(c Config) int {
+		if n := encodedSize(New(c).Slim(sample)); n > 0 {
+			return n
+		}
+		return math.MaxInt
+	}
+	reached := func() bool {
+		return 100*(1-float64(size)/float64(len(original))) >= targetReductionPct
+	}
+	// try keeps the change made by apply to a copy of cfg if it shrinks the sample
+	try := func(apply func(c *Config)) {
+		c := cfg
+		apply(&c)
+		if n := measure(c); n < size {
+			cfg, size = c, n
+		}
+	}
+
+	// Reversible encodings and empty values
+	if p.empties > 0 {
+		try(func(c *Config) { c.StripEmpty = true })
+	}
+	if repeatedBytes(p.strings) > 0 {
+		try(func(c *Config) { c.StringPooling = true })
+	}
+	if p.uniformArrays > 0 {
+		try(func(c *Config) { c.TypeInference = true })
+	}
+	if repeatedBytes(p.keys) > 0 {
+		try(func(c *Config) { c.ShortenKeys = true })
+	}
+	if targetReductionPct <= 0 || reached() {
+		return cfg
+	}
+
+	// Lossy options, tightened until the target is reached
+	if p.preciseFloats > 0 {
+		try(func(c *Config) { c.DecimalPlaces = 2 })
+	}
+	// Depth goes last, as each level cut loses the most
+	strLen, listLen := p.longestString, p.longestList
+	for !reached() {
+		tightened := false
+		if next := max(strLen/2, minRecommendedStringLength); next < strLen {
+			strLen, tightened = next, true
+			try(func(c *Config) { c.MaxStringLength = next })
+		}
+		if next := max(listLen/2, minRecommendedListLength); next < listLen && !reached() {
+			listLen, tightened = next, true
+			try(func(c *Config) { c.MaxListLength = next })
+		}
+		if !tightened {
+			break
+		}
+	}
+	for depth := p.depth - 1; depth >= minRecommendedDepth && !reached(); depth-- {
+		try(func(c *Config) { c.MaxDepth = depth })
+	}
+	return cfg
+}
@@ -0,0 +1,46 @@
+package slimjson
+
+import "testing"
+
+func TestRecommendConfig(t *testing.T) {
+	resume := loadTestData(t, "testing/fixtures/resume.json")
+	reduction := func(cfg Config) float64 {
+		_, st := New(cfg).SlimWithStats(resume)
+		return 100 * (1 - st.Ratio())
+	}
+
+	// Reversible encodings reach a low target
+	cfg := RecommendConfig(resume, 5)
+	if !cfg.StringPooling {
+		t.Errorf("Expected string pooling for the repeated strings of the resume, got %+v", cfg)
+	}
+	if cfg.MaxStringLength != 0 || cfg.MaxListLength != 0 || cfg.MaxDepth != 0 {
+		t.Errorf("Expected no lossy limits for a 5%% target, got %+v", cfg)
+	}
+	if got := reduction(cfg); got < 5 {
+		t.Errorf("Expected at least 5%% reduction, got %.1f%%", got)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Recommended config is invalid: %v", err)
+	}
+
+	// Limits are tightened for a higher one
+	cfg = RecommendConfig(resume, 50)
+	if !cfg.StringPooling || cfg.MaxStringLength == 0 {
+		t.Errorf("Expected string pooling and a string limit for a 50%% target, got %+v", cfg)
+	}
+	if got := reduction(cfg); got < 50 {
+		t.Errorf("Expected at least 50%% reduction, got %.1f%%", got)
+	}
+
+	// Uniform arrays of objects
+	users := loadTestData(t, "testing/fixtures/users.json")
+	if cfg := RecommendConfig(users, 10); !cfg.TypeInference {
+		t.Errorf("Expected type inference for the users, got %+v", cfg)
+	}
+
+	// Nothing to gain
+	if cfg := RecommendConfig(map[string]interface{}{"id": 1.0}, 10); cfg.StringPooling || cfg.StripEmpty || cfg.DecimalPlaces != -1 {
+		t.Errorf("Expected no options for a tiny document, got %+v", cfg)
+	}
+}
@@ -0,0 +1,47 @@
+package slimjson
+
+// applyRunLength returns arr as {"_rle": [[value, count], ...]}, one pair per
+// run of equal consecutive values, or nil to leave arr as it is: when it holds
+// values other than strings, numbers and bools, has no repeated values, has
+// more runs than sampling keeps elements, or the encoding is not smaller in
+// compact JSON (by at least MinSavingsBytes, if set). Numbers compare by value.
+func (s *Slimmer) applyRunLength(arr []interface{}) interface{} {
+	if len(arr) < 2 {
+		return nil
+	}
+
+	var runs []interface{}
+	count, prev := 0, ""
+	for i, v := range arr {
+		switch v.(type) {
+		case string, bool:
+		default:
+			if _, ok := toFloat(v); !ok {
+				return nil // Not a scalar
+			}
+		}
+		key := valueToString(v)
+		if i > 0 && key == prev {
+			count++
+			continue
+		}
+		if i > 0 {
+			runs[len(runs)-1].([]interface{})[1] = count
+		}
+		runs = append(runs, []interface{}{v, 1})
+		count, prev = 1, key
+	}
+	runs[len(runs)-1].([]interface{})[1] = count
+
+	if len(runs) == len(arr) {
+		return nil // No repeats
+	}
+	if target := s.sampleTarget(); target > 0 && len(runs) > target {
+		return nil // Leave the array to sampling
+	}
+	rle := map[string]interface{}{"_rle": runs}
+	if encodedSize(arr)-encodedSize(rle) < max(s.Config.MinSavingsBytes, 1) {
+		return nil
+	}
+	return rle
+}
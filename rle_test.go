@@ -0,0 +1,117 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRunLengthEncode(t *testing.T) {
+	// 50 readings in three runs
+	states := make([]interface{}, 0, 50)
+	for i := 0; i < 50; i++ {
+		switch {
+		case i < 30:
+			states = append(states, "ok")
+		case i < 35:
+			states = append(states, "err")
+		default:
+			states = append(states, "ok")
+		}
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		input    interface{}
+		expected string
+	}{
+		{
+			name:     "Three runs kept whole despite the list limit",
+			config:   Config{RunLengthEncode: true, MaxListLength: 10, DeduplicateArrays: true},
+			input:    map[string]interface{}{"states": states},
+			expected: `{"states":{"_rle":[["ok",30],["err",5],["ok",15]]}}`,
+		},
+		{
+			name:     "Numbers compare by value and bools",
+			config:   Config{RunLengthEncode: true, DecimalPlaces: -1},
+			input:    []interface{}{1, 1.0, 1, 1, 2.5, 2.5, 2.5, 2.5, true, true, true, true, true},
+			expected: `{"_rle":[[1,4],[2.5,4],[true,5]]}`,
+		},
+		{
+			name:     "No repeats",
+			config:   Config{RunLengthEncode: true},
+			input:    []interface{}{"a", "b", "a", "b"},
+			expected: `["a","b","a","b"]`,
+		},
+		{
+			name:     "Not smaller",
+			config:   Config{RunLengthEncode: true},
+			input:    []interface{}{"a", "a", "b"},
+			expected: `["a","a","b"]`,
+		},
+		{
+			name:     "Objects are left as they are",
+			config:   Config{RunLengthEncode: true},
+			input:    []interface{}{map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1}},
+			expected: `[{"a":1},{"a":1},{"a":1},{"a":1}]`,
+		},
+		{
+			name:     "More runs than the list limit are sampled",
+			config:   Config{RunLengthEncode: true, MaxListLength: 2},
+			input:    []interface{}{"a", "a", "a", "b", "b", "b", "c", "c", "c"},
+			expected: `["a","a"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := New(tt.config).Slim(tt.input)
+			got, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Slim() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnslimRunLength(t *testing.T) {
+	input := `{"states": ["ok", "ok", "ok", "ok", "err", "err", "ok", "ok", "ok", "ok", "ok"], "codes": [200, 200, 200, 200, 500, 500, 500, 500]}`
+	var original interface{}
+	if err := json.Unmarshal([]byte(input), &original); err != nil {
+		t.Fatalf("Failed to unmarshal input: %v", err)
+	}
+
+	slimmed := New(Config{RunLengthEncode: true, StringPooling: true, Lossless: true}).Slim(original)
+	encoded, err := json.Marshal(slimmed)
+	if err != nil {
+		t.Fatalf("Failed to marshal slimmed: %v", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal slimmed: %v", err)
+	}
+
+	for _, slim := range []interface{}{slimmed, decoded} {
+		restored, err := Unslim(slim)
+		if err != nil {
+			t.Fatalf("Unslim() error: %v", err)
+		}
+		if !reflect.DeepEqual(restored, original) {
+			t.Errorf("Unslim() = %v, want %v", restored, original)
+		}
+	}
+
+	for _, bad := range []string{`{"_rle": [["a"]]}`, `{"_rle": [["a", 0]]}`, `{"_rle": [["a", 1.5]]}`, `{"_rle": "a"}`} {
+		var data interface{}
+		if err := json.Unmarshal([]byte(bad), &data); err != nil {
+			t.Fatalf("Failed to unmarshal %s: %v", bad, err)
+		}
+		if _, err := Unslim(data); err == nil {
+			t.Errorf("Expected an error for %s", bad)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package slimjson
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PathRule applies its own Config to the values matched by Path.
+type PathRule struct {
+	// Path selects values with a JSONPath-like expression: dot-separated keys,
+	// with "*" or "[*]" matching any key or array index, and "[N]" a single index.
+	// The leading "$" is optional ("$.logs[*]", "logs[*]" and "logs.*" are equivalent).
+	Path string `json:"path"`
+
+	// Config is used to slim the matched value and everything below it.
+	// MaxDepth counts from the matched value. Options that produce root metadata
+	// (StringPooling, EnumDetection, NullCompression), output ordering (SortKeys,
+	// PreserveKeyOrder), PreserveFields and hooks are always taken from the
+	// top-level Config.
+	Config Config `json:"config"`
+}
+
+// compiledRule is a PathRule with its selector split into segments
+type compiledRule struct {
+	segments []string // "*" matches any segment
+	literals int      // Number of non-wildcard segments, higher is more specific
+	config   Config
+}
+
+// parseSelector splits a PathRule selector into path segments
+func parseSelector(selector string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(selector), "$")
+	var segments []string
+	for _, seg := range strings.Split(strings.ReplaceAll(trimmed, "[", ".["), ".") {
+		if seg == "" {
+			continue
+		}
+		if strings.HasPrefix(seg, "[") {
+			if !strings.HasSuffix(seg, "]") || len(seg) < 3 {
+				return nil, fmt.Errorf("invalid path rule %q: bad index %s", selector, seg)
+			}
+			seg = seg[1 : len(seg)-1]
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// compileRules parses the selectors of Config.Rules. Rules with invalid
+// selectors never match.
+func (s *Slimmer) compileRules() {
+	s.rules = nil
+	for _, r := range s.Config.Rules {
+		segments, err := parseSelector(r.Path)
+		if err != nil {
+			continue
+		}
+		literals := 0
+		for _, seg := range segments {
+			if seg != "*" {
+				literals++
+			}
+		}
+		s.rules = append(s.rules, &compiledRule{segments: segments, literals: literals, config: s.ruleConfig(r.Config)})
+	}
+}
+
+// ruleConfig returns the Config used below a rule, with the options that
+// apply to the whole document taken from the top-level Config
+func (s *Slimmer) ruleConfig(cfg Config) Config {
+	root := s.Config
+	cfg.StringPooling = root.StringPooling
+	cfg.StringPoolMinOccurrences = root.StringPoolMinOccurrences
+	cfg.EnumDetection = root.EnumDetection
+	cfg.EnumMaxValues = root.EnumMaxValues
+	cfg.InlineEnumThreshold = root.InlineEnumThreshold
+	cfg.NullCompression = root.NullCompression
+	cfg.SortKeys = root.SortKeys
+	cfg.PreserveKeyOrder = root.PreserveKeyOrder
+	cfg.OnRemove = root.OnRemove
+	cfg.ValueTransform = root.ValueTransform
+	cfg.Lossless = root.Lossless
+	cfg.PreserveFields = root.PreserveFields
+	cfg.Rules = nil
+	return New(cfg).Config
+}
+
+// matchRule returns the most specific rule whose selector matches path.
+// Ties go to the rule listed first.
+func (s *Slimmer) matchRule(path string) *compiledRule {
+	if len(s.rules) == 0 {
+		return nil
+	}
+	var segments []string
+	if path != "" {
+		segments = strings.Split(path, ".")
+	}
+	var best *compiledRule
+	for _, r := range s.rules {
+		if r.matches(segments) && (best == nil || r.literals > best.literals) {
+			best = r
+		}
+	}
+	return best
+}
+
+func (r *compiledRule) matches(segments []string) bool {
+	if len(segments) != len(r.segments) {
+		return false
+	}
+	for i, seg := range r.segments {
+		if seg != "*" && seg != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneRule slims a value matched by rule with the rule's Config
+func (s *Slimmer) pruneRule(rule *compiledRule, data interface{}, path string) interface{} {
+	saved := s.Config
+	s.Config = rule.config
+	defer func() { s.Config = saved }()
+	return s.pruneValue(data, 0, path)
+}
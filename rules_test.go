@@ -0,0 +1,133 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	input := `{
+		"logs": [
+			{"level": "info", "ctx": {"req": {"id": 1}}},
+			{"level": "warn", "ctx": {"req": {"id": 2}}}
+		],
+		"summary": {"stats": {"by_level": {"info": 1, "warn": 1}}},
+		"meta": {"a": {"b": {"c": 1}}}
+	}`
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{
+			name: "Distinct depth limits for two subtrees",
+			config: Config{
+				MaxDepth:      2,
+				DecimalPlaces: -1,
+				StripEmpty:    true,
+				Rules: []PathRule{
+					{Path: "$.logs[*]", Config: Config{MaxDepth: 2, DecimalPlaces: -1, StripEmpty: true}},
+					{Path: "$.summary", Config: Config{DecimalPlaces: -1}},
+				},
+			},
+			expected: `{
+				"logs": [{"level": "info"}, {"level": "warn"}],
+				"summary": {"stats": {"by_level": {"info": 1, "warn": 1}}}
+			}`,
+		},
+		{
+			name: "Most specific rule wins",
+			config: Config{
+				DecimalPlaces: -1,
+				Rules: []PathRule{
+					{Path: "logs[*]", Config: Config{MaxDepth: 2, DecimalPlaces: -1, BlockList: []string{"ctx"}}},
+					{Path: "logs[1]", Config: Config{MaxDepth: 3, DecimalPlaces: -1}},
+				},
+			},
+			expected: `{
+				"logs": [{"level": "info"}, {"level": "warn", "ctx": {"req": {"id": null}}}],
+				"summary": {"stats": {"by_level": {"info": 1, "warn": 1}}},
+				"meta": {"a": {"b": {"c": 1}}}
+			}`,
+		},
+		{
+			name: "Nested rule applies inside a rule",
+			config: Config{
+				DecimalPlaces: -1,
+				Rules: []PathRule{
+					{Path: "meta", Config: Config{MaxDepth: 2, DecimalPlaces: -1}},
+					{Path: "meta.a.*", Config: Config{MaxDepth: 1, DecimalPlaces: -1}},
+				},
+			},
+			expected: `{
+				"logs": [{"level": "info", "ctx": {"req": {"id": 1}}}, {"level": "warn", "ctx": {"req": {"id": 2}}}],
+				"summary": {"stats": {"by_level": {"info": 1, "warn": 1}}},
+				"meta": {"a": {"b": {"c": null}}}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data, expected interface{}
+			if err := json.Unmarshal([]byte(input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			result := normalizeJSON(New(tt.config).Slim(data))
+			if !reflect.DeepEqual(result, expected) {
+				got, _ := json.Marshal(result)
+				t.Errorf("Slim() = %s", got)
+			}
+		})
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		expected []string
+		wantErr  bool
+	}{
+		{selector: "$", expected: nil},
+		{selector: "$.logs[*]", expected: []string{"logs", "*"}},
+		{selector: "logs.*.ctx", expected: []string{"logs", "*", "ctx"}},
+		{selector: "$.items[2].name", expected: []string{"items", "2", "name"}},
+		{selector: "$.items[", wantErr: true},
+		{selector: "items[]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			segments, err := parseSelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(segments, tt.expected) {
+				t.Errorf("parseSelector() = %q, want %q", segments, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePathRule(t *testing.T) {
+	rule, err := parsePathRule("$.logs[*] max-depth=2 list-len=3 strip-empty=true")
+	if err != nil {
+		t.Fatalf("parsePathRule() error = %v", err)
+	}
+	expected := PathRule{Path: "$.logs[*]", Config: Config{MaxDepth: 2, MaxListLength: 3, StripEmpty: true, DecimalPlaces: -1}}
+	if !reflect.DeepEqual(rule, expected) {
+		t.Errorf("parsePathRule() = %+v, want %+v", rule, expected)
+	}
+
+	for _, value := range []string{"", "logs max-depth", "logs max-depth=x", "logs[ max-depth=1"} {
+		if _, err := parsePathRule(value); err == nil {
+			t.Errorf("parsePathRule(%q) expected error", value)
+		}
+	}
+}
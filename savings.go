@@ -0,0 +1,60 @@
+package slimjson
+
+import "maps"
+
+// Encodings that add metadata can grow small inputs: a _schema for a
+// two-element array, a _bools object for three flags or a _strings table for
+// a string used twice cost more than they save. With MinSavingsBytes set, each
+// is kept only when the compact JSON encoding shrinks by at least that much.
+
+// slim runs Slim with the current Config. The _strings and _enums tables are
+// dropped, by slimming again without them, when they do not save MinSavingsBytes.
+func (s *Slimmer) slim(data interface{}) interface{} {
+	result := s.slimPass(data)
+	if s.Config.MinSavingsBytes <= 0 || !s.usedTables() {
+		return result
+	}
+
+	// The second pass removes the same values, so it does not report them again
+	saved, changes := s.Config, s.changes
+	s.Config.StringPooling, s.Config.EnumDetection = false, false
+	s.Config.OnRemove, s.changes = nil, nil
+	plain := s.slimPass(data)
+	s.Config, s.changes = saved, changes
+
+	if encodedSize(plain)-encodedSize(result) < s.Config.MinSavingsBytes {
+		return plain
+	}
+	return result
+}
+
+// usedTables reports whether the last pass replaced values with _strings or _enums entries
+func (s *Slimmer) usedTables() bool {
+	return (s.Config.StringPooling && len(s.stringList) > 0) || (s.Config.EnumDetection && len(s.enumPools) > 0)
+}
+
+// smaller returns the encoded form of an array, after, if it saves at least
+// MinSavingsBytes over the plain array, before. Arrays a transform left as
+// they were, and any result when MinSavingsBytes is 0, are returned as is.
+func (s *Slimmer) smaller(before []interface{}, after interface{}) interface{} {
+	if _, unchanged := after.([]interface{}); unchanged || s.Config.MinSavingsBytes <= 0 {
+		return after
+	}
+	if encodedSize(before)-encodedSize(after) < s.Config.MinSavingsBytes {
+		return before
+	}
+	return after
+}
+
+// compressBools applies BoolCompression to m, unless the _bools object
+// saves less than MinSavingsBytes
+func (s *Slimmer) compressBools(m map[string]interface{}) map[string]interface{} {
+	if s.Config.MinSavingsBytes <= 0 {
+		return s.applyBoolCompression(m)
+	}
+	compressed := s.applyBoolCompression(maps.Clone(m))
+	if _, ok := compressed["_bools"]; !ok || encodedSize(m)-encodedSize(compressed) < s.Config.MinSavingsBytes {
+		return m
+	}
+	return compressed
+}
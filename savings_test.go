@@ -0,0 +1,106 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMinSavingsBytes(t *testing.T) {
+	records := func(n int, key string) []interface{} {
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = map[string]interface{}{"id": float64(i), key: "user"}
+		}
+		return items
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		input   interface{}
+		key     string // Metadata key of the encoding
+		applied bool   // Whether it is used without MinSavingsBytes
+		kept    bool   // Whether it is used with MinSavingsBytes
+	}{
+		{
+			name:   "Type inference on two small elements",
+			config: Config{TypeInference: true},
+			input:  map[string]interface{}{"users": records(2, "name")},
+			key:    "_schema",
+		},
+		{
+			name:   "Type inference on two elements with long keys",
+			config: Config{TypeInference: true},
+			input:  map[string]interface{}{"users": records(2, "organization_display_name")},
+			key:    "_schema",
+			kept:   true,
+		},
+		{
+			name:    "Type inference on many elements",
+			config:  Config{TypeInference: true},
+			input:   map[string]interface{}{"users": records(20, "name")},
+			key:     "_schema",
+			applied: true,
+			kept:    true,
+		},
+		{
+			name:    "String pool for two occurrences",
+			config:  Config{StringPooling: true},
+			input:   map[string]interface{}{"a": "abcd", "b": "abcd"},
+			key:     "_strings",
+			applied: true,
+		},
+		{
+			name:    "String pool for long repeated strings",
+			config:  Config{StringPooling: true},
+			input:   map[string]interface{}{"a": strings.Repeat("x", 40), "b": strings.Repeat("x", 40), "c": strings.Repeat("x", 40)},
+			key:     "_strings",
+			applied: true,
+			kept:    true,
+		},
+		{
+			name:    "Bool compression of short keys",
+			config:  Config{BoolCompression: true},
+			input:   map[string]interface{}{"a": true, "b": false, "c": true},
+			key:     "_bools",
+			applied: true,
+		},
+		{
+			name:    "Range of a short sequence",
+			config:  Config{NumberDeltaEncoding: true},
+			input:   map[string]interface{}{"ids": []interface{}{1.0, 2.0, 3.0, 4.0, 5.0}},
+			key:     "_range",
+			applied: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DecimalPlaces = -1
+			always, err := json.Marshal(New(tt.config).Slim(tt.input))
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if got := strings.Contains(string(always), tt.key); got != tt.applied {
+				t.Errorf("Expected %s used = %v without MinSavingsBytes, got %s", tt.key, tt.applied, always)
+			}
+
+			tt.config.MinSavingsBytes = 1
+			checked, err := json.Marshal(New(tt.config).Slim(tt.input))
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if got := strings.Contains(string(checked), tt.key); got != tt.kept {
+				t.Errorf("Expected %s used = %v with MinSavingsBytes, got %s", tt.key, tt.kept, checked)
+			}
+			plain, err := json.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if len(checked) > len(plain) {
+				t.Errorf("Expected output no larger than the input (%d bytes), got %d: %s", len(plain), len(checked), checked)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Type names of the skeleton returned by ExtractSchema
+const (
+	schemaNull    = "null"
+	schemaBoolean = "boolean"
+	schemaNumber  = "number"
+	schemaString  = "string"
+	schemaObject  = "object"
+	schemaArray   = "array"
+)
+
+// ExtractSchema returns the structure of data with every value replaced by
+// its JSON type, without slimming it. Objects keep their keys, scalars become
+// "string", "number", "boolean" or "null", and arrays become a one-element
+// array with the merged schema of their elements, or [] when empty:
+//
+//	{"name": "Ada", "age": 36, "tags": ["a", "b"], "jobs": [{"id": 1}, {"id": 2, "end": null}]}
+//
+// gives
+//
+//	{"name": "string", "age": "number", "tags": ["string"], "jobs": [{"id": "number", "end": "null"}]}
+//
+// Merging unites the keys of objects and the elements of arrays. Values of
+// different types become a union of their type names sorted and joined with
+// "|", such as "null|string" for an optional string; an object or array in a
+// union is only named, "object" or "array". The result can be marshaled as
+// JSON. Like Slim, ExtractSchema accepts any maps and slices, and OrderedMap;
+// other values are described by their JSON encoding.
+func ExtractSchema(data interface{}) interface{} {
+	if om, ok := data.(*OrderedMap); ok {
+		data = om.Values
+	}
+	switch v := data.(type) {
+	case nil:
+		return schemaNull
+	case bool:
+		return schemaBoolean
+	case string:
+		return schemaString
+	case json.Number:
+		return schemaNumber
+	case map[string]interface{}:
+		schema := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			schema[key] = ExtractSchema(child)
+		}
+		return schema
+	case []interface{}:
+		var elem interface{}
+		for i, child := range v {
+			if i == 0 {
+				elem = ExtractSchema(child)
+			} else {
+				elem = mergeSchemas(elem, ExtractSchema(child))
+			}
+		}
+		if elem == nil {
+			return []interface{}{}
+		}
+		return []interface{}{elem}
+	}
+
+	val := reflect.ValueOf(data)
+	switch val.Kind() {
+	case reflect.Map:
+		schema := make(map[string]interface{}, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			schema[fmt.Sprint(iter.Key().Interface())] = ExtractSchema(iter.Value().Interface())
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		elems := make([]interface{}, val.Len())
+		for i := range elems {
+			elems[i] = val.Index(i).Interface()
+		}
+		return ExtractSchema(elems)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return schemaNumber
+	case reflect.Bool:
+		return schemaBoolean
+	case reflect.String:
+		return schemaString
+	case reflect.Pointer, reflect.Interface:
+		if val.IsNil() {
+			return schemaNull
+		}
+		return ExtractSchema(val.Elem().Interface())
+	}
+
+	// Structs and other values, such as time.Time, by their JSON encoding
+	if encoded, err := json.Marshal(data); err == nil {
+		var decoded interface{}
+		if err := json.Unmarshal(encoded, &decoded); err == nil {
+			return ExtractSchema(decoded)
+		}
+	}
+	return schemaNull
+}
+
+// mergeSchemas returns the schema of values having schema a or b
+func mergeSchemas(a, b interface{}) interface{} {
+	if ma, ok := a.(map[string]interface{}); ok {
+		if mb, ok := b.(map[string]interface{}); ok {
+			merged := make(map[string]interface{}, max(len(ma), len(mb)))
+			for key, s := range ma {
+				merged[key] = s
+			}
+			for key, s := range mb {
+				if prev, ok := merged[key]; ok {
+					merged[key] = mergeSchemas(prev, s)
+				} else {
+					merged[key] = s
+				}
+			}
+			return merged
+		}
+	}
+	if aa, ok := a.([]interface{}); ok {
+		if ab, ok := b.([]interface{}); ok {
+			switch {
+			case len(aa) == 0:
+				return ab
+			case len(ab) == 0:
+				return aa
+			}
+			return []interface{}{mergeSchemas(aa[0], ab[0])}
+		}
+	}
+
+	names := append(schemaTypeNames(a), schemaTypeNames(b)...)
+	slices.Sort(names)
+	return strings.Join(slices.Compact(names), "|")
+}
+
+// schemaTypeNames returns the type names a schema stands for
+func schemaTypeNames(schema interface{}) []string {
+	switch s := schema.(type) {
+	case map[string]interface{}:
+		return []string{schemaObject}
+	case []interface{}:
+		return []string{schemaArray}
+	case string:
+		return strings.Split(s, "|")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtractSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Nested object",
+			input:    `{"name": "Ada", "age": 36, "active": true, "manager": null, "tags": ["a", "b"], "address": {"city": "London", "geo": {"lat": 51.5, "lng": -0.1}}}`,
+			expected: `{"name": "string", "age": "number", "active": "boolean", "manager": "null", "tags": ["string"], "address": {"city": "string", "geo": {"lat": "number", "lng": "number"}}}`,
+		},
+		{
+			name:     "Array elements merged",
+			input:    `{"jobs": [{"id": 1, "skills": []}, {"id": 2, "end": null, "skills": ["go"]}, {"id": 3, "end": "2024"}]}`,
+			expected: `{"jobs": [{"id": "number", "end": "null|string", "skills": ["string"]}]}`,
+		},
+		{
+			name:     "Mixed types",
+			input:    `[1, "two", {"three": 3}, [4], null, 5]`,
+			expected: `["array|null|number|object|string"]`,
+		},
+		{
+			name:     "Nested arrays",
+			input:    `[[1, 2], [], [3, null]]`,
+			expected: `[["null|number"]]`,
+		},
+		{
+			name:     "Empty containers",
+			input:    `{"list": [], "obj": {}}`,
+			expected: `{"list": [], "obj": {}}`,
+		},
+		{
+			name:     "Scalar",
+			input:    `"text"`,
+			expected: `"string"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input, expected interface{}
+			if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			got := ExtractSchema(input)
+			if !reflect.DeepEqual(got, expected) {
+				gotBytes, _ := json.Marshal(got)
+				t.Errorf("ExtractSchema() = %s, want %s", gotBytes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractSchemaGoValues(t *testing.T) {
+	type event struct {
+		Name string    `json:"name"`
+		At   time.Time `json:"at"`
+	}
+	om := NewOrderedMap()
+	om.Set("id", 7)
+	om.Set("counts", map[string]int{"a": 1})
+	om.Set("events", []event{{Name: "start"}})
+	om.Set("ptr", (*int)(nil))
+
+	got, _ := json.Marshal(ExtractSchema(om))
+	want := `{"counts":{"a":"number"},"events":[{"at":"string","name":"string"}],"id":"number","ptr":"null"}`
+	if string(got) != want {
+		t.Errorf("ExtractSchema() = %s, want %s", got, want)
+	}
+}
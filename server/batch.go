@@ -0,0 +1,210 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+
+	"github.com/tradik/slimjson"
+)
+
+// errBatchTooLarge is returned when a batch has more than Options.MaxBatchSize
+// documents
+var errBatchTooLarge = errors.New("batch too large")
+
+// batchItem is a document of a /slim/batch request, or the error that kept it
+// from being decoded
+type batchItem struct {
+	data interface{}
+	err  error
+}
+
+// batchError is the result of a document that could not be slimmed
+type batchError struct {
+	Error string `json:"_error"`
+}
+
+// batchHandler returns the handler for the /slim/batch endpoint, which slims
+// every document of a JSON array, or every line of an NDJSON body, like /slim
+// and responds with the results in the same order and framing. A line that is
+// not valid JSON gets a {"_error": "..."} result instead of failing the batch.
+func (s *Server) batchHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		ndjson := !isJSONContentType(contentType) && isNDJSONContentType(contentType)
+		if !ndjson && !isJSONContentType(contentType) {
+			http.Error(w, "Unsupported Content-Type: expected application/json or application/x-ndjson", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if limit := s.maxBatchBytes(); limit > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+
+		cfg, ok := s.requestConfig(w, r)
+		if !ok {
+			return
+		}
+
+		var items []batchItem
+		var err error
+		if ndjson {
+			items, err = s.readBatchLines(r.Body)
+		} else {
+			items, err = s.readBatchArray(r.Body)
+		}
+		if errors.Is(err, errBatchTooLarge) {
+			http.Error(w, fmt.Sprintf("Batch too large: limit is %d documents", s.opts.MaxBatchSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			writeBodyError(w, "Invalid batch", err)
+			return
+		}
+
+		results, err := s.slimBatch(cfg, items, r)
+		if err != nil {
+			s.writeSlimError(w, r, err)
+			return
+		}
+
+		if !ndjson {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(results); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to encode result: %v", err), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		var out bytes.Buffer
+		enc := json.NewEncoder(&out)
+		for _, result := range results {
+			if err := enc.Encode(result); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to encode result: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write(out.Bytes())
+	}
+}
+
+// maxBatchBytes returns the /slim/batch body size limit: Options.MaxBatchBytes,
+// or Options.MaxBodyBytes if it is 0
+func (s *Server) maxBatchBytes() int64 {
+	if s.opts.MaxBatchBytes > 0 {
+		return s.opts.MaxBatchBytes
+	}
+	return s.opts.MaxBodyBytes
+}
+
+// readBatchArray reads the documents of a JSON array. A syntax error fails the
+// whole batch, as the following documents cannot be told apart.
+func (s *Server) readBatchArray(r io.Reader) ([]batchItem, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("expected a JSON array of documents")
+	}
+
+	var items []batchItem
+	for dec.More() {
+		if s.opts.MaxBatchSize > 0 && len(items) == s.opts.MaxBatchSize {
+			return nil, errBatchTooLarge
+		}
+		var data interface{}
+		if err := dec.Decode(&data); err != nil {
+			return nil, fmt.Errorf("document %d: %w", len(items), err)
+		}
+		items = append(items, batchItem{data: data})
+	}
+	if _, err := dec.Token(); err != nil { // Closing bracket
+		return nil, err
+	}
+	return items, nil
+}
+
+// readBatchLines reads the documents of an NDJSON body, skipping blank lines.
+// A line that is not valid JSON becomes an item with an error.
+func (s *Server) readBatchLines(r io.Reader) ([]batchItem, error) {
+	reader := bufio.NewReader(r)
+	var items []batchItem
+	for lineNum := 1; ; lineNum++ {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			if s.opts.MaxBatchSize > 0 && len(items) == s.opts.MaxBatchSize {
+				return nil, errBatchTooLarge
+			}
+			var item batchItem
+			if err := json.Unmarshal(trimmed, &item.data); err != nil {
+				item.err = fmt.Errorf("line %d: invalid JSON: %w", lineNum, err)
+			}
+			items = append(items, item)
+		}
+
+		if readErr == io.EOF {
+			return items, nil
+		}
+	}
+}
+
+// slimBatch slims items on up to Options.BatchWorkers goroutines, each with
+// its own Slimmer, and returns the results in the order of items. It stops
+// with the error of the context of r once it is done.
+func (s *Server) slimBatch(cfg slimjson.Config, items []batchItem, r *http.Request) ([]interface{}, error) {
+	results := make([]interface{}, len(items))
+	workers := s.opts.BatchWorkers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(items))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			slimmer := slimjson.New(cfg)
+			for i := range next {
+				if items[i].err != nil {
+					results[i] = batchError{items[i].err.Error()}
+					continue
+				}
+				result, err := s.slim(slimmer, items[i].data, r, fmt.Sprintf("%s document %d", r.URL.Path, i))
+				if err != nil {
+					continue // Only the context fails, so the other documents are skipped too
+				}
+				results[i] = result
+			}
+		})
+	}
+	for i := range items {
+		if r.Context().Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
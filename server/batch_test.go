@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBatchHandler(t *testing.T) {
+	handler := New(Options{MaxBatchSize: 3})
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		contentType string
+		body        string
+		status      int
+		resultType  string
+		expected    string
+	}{
+		{
+			name: "JSON array slimmed with the default config", method: http.MethodPost, url: "/slim/batch",
+			contentType: "application/json", body: `[{"id": 1, "tags": []}, {"id": 2}, [1, 2]]`,
+			status: http.StatusOK, resultType: "application/json", expected: "[{\"id\":1},{\"id\":2},[1,2]]\n",
+		},
+		{
+			name: "Empty array", method: http.MethodPost, url: "/slim/batch",
+			contentType: "application/json", body: `[]`,
+			status: http.StatusOK, resultType: "application/json", expected: "[]\n",
+		},
+		{
+			name: "NDJSON lines with an invalid one", method: http.MethodPost, url: "/slim/batch?list-len=1",
+			contentType: "application/x-ndjson", body: "[1, 2]\n\n{\n[3, 4]\n",
+			status: http.StatusOK, resultType: "application/x-ndjson",
+			expected: "[1]\n{\"_error\":\"line 3: invalid JSON: unexpected end of JSON input\"}\n[3]\n",
+		},
+		{
+			name: "Profile and query parameters", method: http.MethodPost, url: "/slim/batch?profile=light&list-len=2",
+			contentType: "application/json", body: `[[1, 2, 3], [4]]`,
+			status: http.StatusOK, resultType: "application/json", expected: "[[1,2],[4]]\n",
+		},
+		{
+			name: "Too many documents", method: http.MethodPost, url: "/slim/batch",
+			contentType: "application/json", body: `[1, 2, 3, 4]`,
+			status: http.StatusRequestEntityTooLarge, expected: "limit is 3 documents",
+		},
+		{
+			name: "Too many lines", method: http.MethodPost, url: "/slim/batch",
+			contentType: "application/x-ndjson", body: "1\n2\n3\n4\n",
+			status: http.StatusRequestEntityTooLarge, expected: "limit is 3 documents",
+		},
+		{
+			name: "Not an array", method: http.MethodPost, url: "/slim/batch",
+			contentType: "application/json", body: `{"id": 1}`,
+			status: http.StatusBadRequest, expected: "expected a JSON array",
+		},
+		{
+			name: "Invalid array", method: http.MethodPost, url: "/slim/batch",
+			contentType: "application/json", body: `[{"id": 1}, {`,
+			status: http.StatusBadRequest, expected: "document 1",
+		},
+		{
+			name: "Unknown profile", method: http.MethodPost, url: "/slim/batch?profile=nope",
+			contentType: "application/json", body: `[]`,
+			status: http.StatusBadRequest, expected: "Unknown profile: nope",
+		},
+		{
+			name: "Missing content type", method: http.MethodPost, url: "/slim/batch",
+			body:   `[[1, 2, 3], [4]]`,
+			status: http.StatusOK, resultType: "application/json", expected: "[[1,2,3],[4]]\n",
+		},
+		{
+			name: "Unsupported content type", method: http.MethodPost, url: "/slim/batch",
+			contentType: "text/plain", body: `[]`,
+			status: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "Wrong method", method: http.MethodGet, url: "/slim/batch",
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusOK {
+				if ct := w.Header().Get("Content-Type"); ct != tt.resultType {
+					t.Errorf("Expected Content-Type %s, got %q", tt.resultType, ct)
+				}
+				if w.Body.String() != tt.expected {
+					t.Errorf("Body = %q, want %q", w.Body.String(), tt.expected)
+				}
+			} else if !strings.Contains(w.Body.String(), tt.expected) {
+				t.Errorf("Expected body to contain %q, got %q", tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Order kept with concurrent workers", func(t *testing.T) {
+		var body, expected strings.Builder
+		body.WriteString("[")
+		expected.WriteString("[")
+		for i := range 500 {
+			if i > 0 {
+				body.WriteString(",")
+				expected.WriteString(",")
+			}
+			fmt.Fprintf(&body, `{"id": %d, "empty": ""}`, i)
+			fmt.Fprintf(&expected, `{"id":%d}`, i)
+		}
+		body.WriteString("]")
+		expected.WriteString("]\n")
+
+		req := httptest.NewRequest(http.MethodPost, "/slim/batch", strings.NewReader(body.String()))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		New(Options{BatchWorkers: 8}).ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if w.Body.String() != expected.String() {
+			t.Errorf("Results out of order: %.200s", w.Body.String())
+		}
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		tests := []struct {
+			name string
+			opts Options
+		}{
+			{"MaxBodyBytes", Options{MaxBodyBytes: 32}},
+			{"MaxBatchBytes", Options{MaxBodyBytes: 1 << 20, MaxBatchBytes: 32}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, "/slim/batch", strings.NewReader("["+strings.Repeat("{},", 100)+"{}]"))
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				New(tt.opts).ServeHTTP(w, req)
+				if w.Code != http.StatusRequestEntityTooLarge {
+					t.Errorf("Expected status 413, got %d: %s", w.Code, w.Body.String())
+				}
+			})
+		}
+	})
+}
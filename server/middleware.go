@@ -0,0 +1,293 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Middleware wraps an http.Handler. Middleware composes by nesting,
+// CORS(origins)(RequireToken(token)(handler)), the outermost running first.
+// Each returns the handler unchanged when it is not configured.
+type Middleware func(http.Handler) http.Handler
+
+// RequireToken returns middleware answering requests without the header
+// "Authorization: Bearer <token>" with 401, except for the paths in open,
+// such as "/health". With an empty token every request is let through.
+func RequireToken(token string, open ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if token == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(open, r.URL.Path) && !validToken(r.Header.Get("Authorization"), token) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="slimjson"`)
+				http.Error(w, "Unauthorized: missing or invalid bearer token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validToken reports whether the Authorization header is "Bearer <token>",
+// comparing the token in constant time
+func validToken(header, token string) bool {
+	scheme, credentials, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(credentials)), []byte(token)) == 1
+}
+
+// corsExposedHeaders are the response headers browsers may read cross-origin
+const corsExposedHeaders = "X-SlimJSON-Original-Bytes, X-SlimJSON-Slimmed-Bytes, X-SlimJSON-Reduction-Percent, X-SlimJSON-Tokens-Estimated, X-SlimJSON-Profile, X-SlimJSON-Applied-Transforms, X-SlimJSON-Kept-Transforms, X-Request-ID"
+
+// CORS returns middleware allowing browsers on the listed origins, e.g.
+// "https://tools.example.com", or on any origin with "*", to call the API.
+// Responses to allowed origins carry Access-Control-Allow-Origin, and
+// preflight OPTIONS requests are answered with 204 and the allowed methods
+// and headers without reaching the handler, or with 403 for other origins.
+// Requests from other origins are served without CORS headers, so browsers
+// do not expose the response. With no origins every request is let through.
+func CORS(origins []string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if len(origins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			allowed := allowedOrigin(origins, origin)
+			if allowed == "" {
+				if preflight {
+					http.Error(w, "Origin not allowed", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Origin", allowed)
+			if !preflight {
+				h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+				next.ServeHTTP(w, r)
+				return
+			}
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for a request
+// from origin, or "" if origin is not allowed. Origins compare
+// case-insensitively, ignoring a trailing slash in the allowed ones.
+func allowedOrigin(origins []string, origin string) string {
+	for _, allowed := range origins {
+		allowed = strings.TrimSuffix(strings.TrimSpace(allowed), "/")
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// LimitConcurrency returns middleware serving at most n requests at a time,
+// except for the paths in open, such as "/health". Requests over the limit
+// are answered at once with 429 and "Retry-After: 1" rather than queued, so
+// a few large documents cannot exhaust memory. With n <= 0 every request is
+// let through.
+func LimitConcurrency(n int, open ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		slots := make(chan struct{}, n)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(open, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				next.ServeHTTP(w, r)
+			default:
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "Too many requests: the server is busy", http.StatusTooManyRequests)
+			}
+		})
+	}
+}
+
+// Timeout returns middleware giving each request a context that is done
+// after d. The slimming endpoints stop when it is and answer with 504. With
+// d <= 0 every request is let through as it is.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestIDHeader is the header carrying the ID of a request, see LogRequests
+const requestIDHeader = "X-Request-ID"
+
+// maxLoggedError is the length of the error response logged by LogRequests
+const maxLoggedError = 200
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// RequestID returns the ID LogRequests gave the request of ctx, or "" if the
+// request was not logged
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// LogRequests returns middleware logging one line per request to logger once
+// it is served, with the request ID, method, path, profile, status, the bytes
+// read from the body and written in the response, the duration in
+// milliseconds and the remote address. Responses with an error status are
+// logged as warnings (4xx) or errors (5xx), with the start of their body as
+// "error". The ID is taken from the X-Request-ID request header if it holds
+// 1 to 128 printable ASCII characters, or generated otherwise, and returned
+// in the X-Request-ID response header so clients can quote it; handlers get
+// it with RequestID. With a nil logger every request is let through.
+func LogRequests(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		if logger == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			id := r.Header.Get(requestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(requestIDHeader, id)
+
+			body := &countingReader{ReadCloser: r.Body}
+			r.Body = body
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+
+			attrs := []slog.Attr{
+				slog.String("id", id),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("profile", strings.ToLower(r.URL.Query().Get("profile"))),
+				slog.Int("status", rec.status),
+				slog.Int64("in_bytes", body.n),
+				slog.Int64("out_bytes", rec.n),
+				slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.String("remote", r.RemoteAddr),
+			}
+			level := slog.LevelInfo
+			switch {
+			case rec.status >= 500:
+				level = slog.LevelError
+			case rec.status >= 400:
+				level = slog.LevelWarn
+			}
+			if level != slog.LevelInfo {
+				attrs = append(attrs, slog.String("error", strings.TrimSpace(rec.errBody.String())))
+			}
+			logger.LogAttrs(r.Context(), level, "request", attrs...)
+		})
+	}
+}
+
+// validRequestID reports whether an incoming request ID can be used as it is
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 16 character hexadecimal request ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// responseRecorder records the status and size of a response, and the start
+// of its body if the status is an error
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	n           int64
+	wroteHeader bool
+	errBody     bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = status, true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	if rec.status >= 400 && rec.errBody.Len() < maxLoggedError {
+		rec.errBody.Write(p[:min(len(p), maxLoggedError-rec.errBody.Len())])
+	}
+	n, err := rec.ResponseWriter.Write(p)
+	rec.n += int64(n)
+	return n, err
+}
+
+// Unwrap returns the wrapped ResponseWriter, for http.ResponseController
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
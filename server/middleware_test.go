@@ -0,0 +1,425 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthToken(t *testing.T) {
+	srv := New(Options{AuthToken: "s3cret"})
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		expected      int
+	}{
+		{name: "Valid token", method: http.MethodGet, path: "/profiles", authorization: "Bearer s3cret", expected: http.StatusOK},
+		{name: "Scheme is case-insensitive", method: http.MethodPost, path: "/slim", authorization: "bearer s3cret", expected: http.StatusOK},
+		{name: "Missing header", method: http.MethodPost, path: "/slim", expected: http.StatusUnauthorized},
+		{name: "Wrong token", method: http.MethodPost, path: "/slim", authorization: "Bearer s3cre", expected: http.StatusUnauthorized},
+		{name: "Wrong scheme", method: http.MethodGet, path: "/profiles", authorization: "Basic s3cret", expected: http.StatusUnauthorized},
+		{name: "Token without scheme", method: http.MethodGet, path: "/profiles", authorization: "s3cret", expected: http.StatusUnauthorized},
+		{name: "Other endpoints", method: http.MethodPost, path: "/unslim", expected: http.StatusUnauthorized},
+		{name: "Health stays open", method: http.MethodGet, path: "/health", expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"a": 1}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusUnauthorized && !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Bearer") {
+				t.Errorf("Expected a Bearer WWW-Authenticate challenge, got %q", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		method      string
+		path        string
+		headers     map[string]string
+		expected    int
+		allowOrigin string
+		preflight   bool // Expect the preflight response headers
+	}{
+		{
+			name:        "Allowed origin",
+			origins:     []string{"https://tools.example.com"},
+			method:      http.MethodPost,
+			path:        "/slim",
+			headers:     map[string]string{"Origin": "https://tools.example.com"},
+			expected:    http.StatusOK,
+			allowOrigin: "https://tools.example.com",
+		},
+		{
+			name:     "Denied origin is served without CORS headers",
+			origins:  []string{"https://tools.example.com"},
+			method:   http.MethodPost,
+			path:     "/slim",
+			headers:  map[string]string{"Origin": "https://evil.example.com"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "Same-origin request",
+			origins:  []string{"https://tools.example.com"},
+			method:   http.MethodGet,
+			path:     "/profiles",
+			expected: http.StatusOK,
+		},
+		{
+			name:        "Any origin",
+			origins:     []string{"*"},
+			method:      http.MethodGet,
+			path:        "/profiles",
+			headers:     map[string]string{"Origin": "http://localhost:3000"},
+			expected:    http.StatusOK,
+			allowOrigin: "*",
+		},
+		{
+			name:        "Preflight of /slim",
+			origins:     []string{"https://a.example.com", "https://tools.example.com/"},
+			method:      http.MethodOptions,
+			path:        "/slim",
+			headers:     map[string]string{"Origin": "https://tools.example.com", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "authorization, content-type"},
+			expected:    http.StatusNoContent,
+			allowOrigin: "https://tools.example.com",
+			preflight:   true,
+		},
+		{
+			name:        "Preflight of /profiles",
+			origins:     []string{"https://tools.example.com"},
+			method:      http.MethodOptions,
+			path:        "/profiles",
+			headers:     map[string]string{"Origin": "https://tools.example.com", "Access-Control-Request-Method": "GET"},
+			expected:    http.StatusNoContent,
+			allowOrigin: "https://tools.example.com",
+			preflight:   true,
+		},
+		{
+			name:     "Preflight from a denied origin",
+			origins:  []string{"https://tools.example.com"},
+			method:   http.MethodOptions,
+			path:     "/slim",
+			headers:  map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "POST"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "No origins configured",
+			method:   http.MethodOptions,
+			path:     "/slim",
+			headers:  map[string]string{"Origin": "https://tools.example.com", "Access-Control-Request-Method": "POST"},
+			expected: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"a": 1}`))
+			req.Header.Set("Content-Type", "application/json")
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			New(Options{CORSOrigins: tt.origins}).ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.allowOrigin, got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); (got != "") != tt.preflight {
+				t.Errorf("Expected preflight headers %v, got Access-Control-Allow-Methods %q", tt.preflight, got)
+			}
+			if tt.preflight && !strings.Contains(w.Header().Get("Access-Control-Allow-Headers"), "Authorization") {
+				t.Errorf("Expected Authorization in Access-Control-Allow-Headers, got %q", w.Header().Get("Access-Control-Allow-Headers"))
+			}
+			if tt.allowOrigin != "" && !tt.preflight && !strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), "X-SlimJSON-Profile") {
+				t.Errorf("Expected the X-SlimJSON-* headers to be exposed, got %q", w.Header().Get("Access-Control-Expose-Headers"))
+			}
+		})
+	}
+}
+
+func TestAuthWithCORS(t *testing.T) {
+	srv := New(Options{AuthToken: "s3cret", CORSOrigins: []string{"https://tools.example.com"}})
+
+	// Preflight requests carry no credentials
+	req := httptest.NewRequest(http.MethodOptions, "/slim", nil)
+	req.Header.Set("Origin", "https://tools.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 for a preflight without a token, got %d", w.Code)
+	}
+
+	// A 401 is readable by the allowed origin
+	req = httptest.NewRequest(http.MethodPost, "/slim", strings.NewReader(`{}`))
+	req.Header.Set("Origin", "https://tools.example.com")
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || w.Header().Get("Access-Control-Allow-Origin") != "https://tools.example.com" {
+		t.Errorf("Expected 401 with CORS headers, got %d and %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestMiddlewareComposes(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	// Unconfigured middleware returns the handler as it is
+	if w := serve(CORS(nil)(RequireToken("")(ok)), "/", ""); w.Code != http.StatusTeapot {
+		t.Errorf("Expected the wrapped handler's status 418, got %d", w.Code)
+	}
+
+	h := CORS([]string{"*"})(RequireToken("t", "/open")(ok))
+	if w := serve(h, "/open", ""); w.Code != http.StatusTeapot {
+		t.Errorf("Expected 418 for an open path, got %d", w.Code)
+	}
+	if w := serve(h, "/closed", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", w.Code)
+	}
+	if w := serve(h, "/closed", "Bearer t"); w.Code != http.StatusTeapot {
+		t.Errorf("Expected 418 with the token, got %d", w.Code)
+	}
+}
+
+func TestLimitConcurrency(t *testing.T) {
+	srv := New(Options{MaxConcurrent: 1})
+
+	// A slow client sending a large document holds the only slot
+	pr, pw := io.Pipe()
+	slow := httptest.NewRequest(http.MethodPost, "/slim", pr)
+	slow.Header.Set("Content-Type", "application/json")
+	slowW := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.ServeHTTP(slowW, slow)
+	}()
+	// The write returns once the handler reads the body, in its slot
+	if _, err := io.WriteString(pw, `{"items": [`); err != nil {
+		t.Fatalf("Failed to write the body: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/slim", strings.NewReader(`{"a": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After while saturated, got %d and %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve(srv, "/health", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected /health to stay available, got %d", w.Code)
+	}
+
+	if _, err := io.WriteString(pw, strings.TrimPrefix(largeDocument(20000), "[")+"}"); err != nil {
+		t.Fatalf("Failed to write the body: %v", err)
+	}
+	_ = pw.Close()
+	<-done
+	if slowW.Code != http.StatusOK {
+		t.Errorf("Expected the slow request to succeed, got %d: %s", slowW.Code, slowW.Body.String())
+	}
+
+	// The slot is released
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/slim", strings.NewReader(`{"a": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 once the slot is free, got %d", w.Code)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	doc := largeDocument(20000)
+	var logs strings.Builder
+	srv := New(Options{RequestTimeout: time.Nanosecond, Logger: log.New(&logs, "", 0)})
+	for _, tt := range []struct{ path, contentType, body string }{
+		{"/slim", "application/json", doc},
+		{"/slim/batch", "application/json", "[" + doc + "]"},
+		{"/slim/ndjson", "application/x-ndjson", doc + "\n" + doc},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("%s: expected status 504, got %d: %.100s", tt.path, w.Code, w.Body.String())
+		}
+	}
+	if !strings.Contains(logs.String(), "/slim/batch timed out") {
+		t.Errorf("Expected timeouts to be logged, got %q", logs.String())
+	}
+
+	// Health checks are not slimmed
+	if w := serve(srv, "/health", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected /health to answer, got %d", w.Code)
+	}
+}
+
+// largeDocument returns a JSON array of n generated objects
+func largeDocument(n int) string {
+	var doc strings.Builder
+	doc.WriteString("[")
+	for i := range n {
+		fmt.Fprintf(&doc, `{"id": %d, "name": "item %d", "tags": ["a", "b"]},`, i, i)
+	}
+	doc.WriteString(`{"id": -1}]`)
+	return doc.String()
+}
+
+// serve sends a GET request for path with the Authorization header to h
+func serve(h http.Handler, path, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// logLines decodes the JSON lines written by a slog.JSONHandler
+func logLines(t *testing.T, logs string) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestLogRequests(t *testing.T) {
+	var access bytes.Buffer
+	srv := New(Options{AccessLog: slog.New(slog.NewJSONHandler(&access, nil))})
+
+	tests := []struct {
+		name      string
+		path      string
+		body      string
+		requestID string
+		status    int
+		level     string
+		error     string
+	}{
+		{name: "Slimmed", path: "/slim?profile=Light", body: `{"a": 1, "b": ""}`, status: http.StatusOK, level: "INFO"},
+		{name: "Incoming ID", path: "/slim", body: `{}`, requestID: "client-42", status: http.StatusOK, level: "INFO"},
+		{name: "Invalid incoming ID", path: "/slim", body: `{}`, requestID: "bad id", status: http.StatusOK, level: "INFO"},
+		{name: "Invalid JSON", path: "/slim", body: `{`, status: http.StatusBadRequest, level: "WARN", error: "Invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			access.Reset()
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+
+			lines := logLines(t, access.String())
+			if len(lines) != 1 {
+				t.Fatalf("Expected one line per request, got %d: %s", len(lines), access.String())
+			}
+			entry := lines[0]
+			for _, field := range []string{"time", "level", "msg", "id", "method", "path", "profile", "status", "in_bytes", "out_bytes", "duration_ms", "remote"} {
+				if _, ok := entry[field]; !ok {
+					t.Errorf("Expected field %q, got %v", field, entry)
+				}
+			}
+
+			id := w.Header().Get("X-Request-ID")
+			switch {
+			case id == "":
+				t.Errorf("Expected an X-Request-ID response header")
+			case entry["id"] != id:
+				t.Errorf("Expected the logged ID %v to be the header %q", entry["id"], id)
+			case tt.requestID == "client-42" && id != tt.requestID:
+				t.Errorf("Expected the incoming ID to be kept, got %q", id)
+			case tt.requestID == "bad id" && id == tt.requestID:
+				t.Errorf("Expected an invalid incoming ID to be replaced")
+			}
+			if entry["status"] != float64(tt.status) || entry["level"] != tt.level {
+				t.Errorf("Expected status %d at level %s, got %v at %v", tt.status, tt.level, entry["status"], entry["level"])
+			}
+			if entry["in_bytes"] != float64(len(tt.body)) || entry["out_bytes"] != float64(w.Body.Len()) {
+				t.Errorf("Expected %d bytes in and %d out, got %v and %v", len(tt.body), w.Body.Len(), entry["in_bytes"], entry["out_bytes"])
+			}
+			if errMsg, _ := entry["error"].(string); !strings.HasPrefix(errMsg, tt.error) || (tt.error == "") != (entry["error"] == nil) {
+				t.Errorf("Expected error %q, got %v", tt.error, entry["error"])
+			}
+		})
+	}
+
+	// The profile is logged lowercased, as reported in X-SlimJSON-Profile
+	access.Reset()
+	req := httptest.NewRequest(http.MethodPost, "/slim?profile=Light", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+	if entry := logLines(t, access.String())[0]; entry["profile"] != "light" || entry["path"] != "/slim" {
+		t.Errorf("Expected path /slim and profile light, got %v", entry)
+	}
+}
+
+func TestLogRequestsSlimmingErrors(t *testing.T) {
+	var access bytes.Buffer
+	var logs strings.Builder
+	srv := New(Options{
+		RequestTimeout: time.Nanosecond,
+		Logger:         log.New(&logs, "", 0),
+		AccessLog:      slog.New(slog.NewJSONHandler(&access, nil)),
+	})
+	req := httptest.NewRequest(http.MethodPost, "/slim", strings.NewReader(largeDocument(20000)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	id := w.Header().Get("X-Request-ID")
+	if w.Code != http.StatusGatewayTimeout || id == "" {
+		t.Fatalf("Expected 504 with a request ID, got %d and %q", w.Code, id)
+	}
+	if !strings.Contains(logs.String(), "/slim (request "+id+") timed out") {
+		t.Errorf("Expected the timeout to be logged with the request ID, got %q", logs.String())
+	}
+	if entry := logLines(t, access.String())[0]; entry["level"] != "ERROR" || entry["error"] != "Request timed out" {
+		t.Errorf("Expected an error line, got %v", entry)
+	}
+
+	// Without an access log, requests get no ID
+	if w := serve(New(Options{}), "/health", ""); w.Header().Get("X-Request-ID") != "" {
+		t.Errorf("Expected no X-Request-ID without an access log")
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/tradik/slimjson"
+)
+
+// isNDJSONContentType reports whether the Content-Type header denotes
+// newline-delimited JSON. Plain JSON is accepted too.
+func isNDJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return true
+	}
+	return isJSONContentType(contentType)
+}
+
+// contextReader is an io.Reader that fails with the error of ctx once it is
+// done, so /slim/ndjson stops between lines when the request times out
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// ndjsonHandler returns the handler for the /slim/ndjson endpoint, which slims
+// each line of the body like /slim and responds with one line per document.
+// The output is buffered so that an invalid line is answered with 400 and its
+// line number.
+func (s *Server) ndjsonHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !isNDJSONContentType(r.Header.Get("Content-Type")) {
+			http.Error(w, "Unsupported Content-Type: expected application/x-ndjson", http.StatusUnsupportedMediaType)
+			return
+		}
+		s.limitBody(w, r)
+		cfg, ok := s.requestConfig(w, r)
+		if !ok {
+			return
+		}
+
+		var out bytes.Buffer
+		if err := slimjson.New(cfg).SlimLines(contextReader{r.Context(), r.Body}, &out); err != nil {
+			if r.Context().Err() != nil {
+				s.writeSlimError(w, r, r.Context().Err())
+				return
+			}
+			writeBodyError(w, "Invalid NDJSON", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write(out.Bytes())
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONHandler(t *testing.T) {
+	handler := New(Options{})
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		contentType string
+		body        string
+		status      int
+		expected    string
+	}{
+		{
+			name: "Lines slimmed with the default config", method: http.MethodPost, url: "/slim/ndjson",
+			contentType: "application/x-ndjson", body: "{\"id\": 1, \"tags\": []}\n\n{\"id\": 2}\n",
+			status: http.StatusOK, expected: "{\"id\":1}\n{\"id\":2}\n",
+		},
+		{
+			name: "JSON Lines content type", method: http.MethodPost, url: "/slim/ndjson?profile=light",
+			contentType: "application/jsonl", body: "[1]\n[2]",
+			status: http.StatusOK, expected: "[1]\n[2]\n",
+		},
+		{
+			name: "Invalid line", method: http.MethodPost, url: "/slim/ndjson",
+			contentType: "application/x-ndjson", body: "{}\n{\n",
+			status: http.StatusBadRequest, expected: "line 2",
+		},
+		{
+			name: "Query parameters over the profile", method: http.MethodPost, url: "/slim/ndjson?profile=light&list-len=1",
+			contentType: "application/x-ndjson", body: "[1, 2, 3]\n[4, 5]\n",
+			status: http.StatusOK, expected: "[1]\n[4]\n",
+		},
+		{
+			name: "Invalid query parameter", method: http.MethodPost, url: "/slim/ndjson?list-len=many",
+			contentType: "application/x-ndjson", body: "{}\n",
+			status: http.StatusBadRequest, expected: "Invalid query parameter list-len",
+		},
+		{
+			name: "Unknown profile", method: http.MethodPost, url: "/slim/ndjson?profile=nope",
+			contentType: "application/x-ndjson", body: "{}\n",
+			status: http.StatusBadRequest, expected: "Unknown profile: nope",
+		},
+		{
+			name: "Unsupported content type", method: http.MethodPost, url: "/slim/ndjson",
+			contentType: "text/plain", body: "{}\n",
+			status: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "Wrong method", method: http.MethodGet, url: "/slim/ndjson",
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusOK {
+				if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+					t.Errorf("Expected Content-Type application/x-ndjson, got %q", ct)
+				}
+				if w.Body.String() != tt.expected {
+					t.Errorf("Body = %q, want %q", w.Body.String(), tt.expected)
+				}
+			} else if !strings.Contains(w.Body.String(), tt.expected) {
+				t.Errorf("Expected body to contain %q, got %q", tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Body too large", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/slim/ndjson", strings.NewReader(strings.Repeat("{}\n", 100)))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		w := httptest.NewRecorder()
+		New(Options{MaxBodyBytes: 32}).ServeHTTP(w, req)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
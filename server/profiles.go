@@ -0,0 +1,192 @@
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/tradik/slimjson"
+)
+
+// BuiltinProfileNames lists the built-in profiles from lightest to most aggressive
+var BuiltinProfileNames = []string{"light", "medium", "aggressive", "ai-optimized"}
+
+// ProfileEntry is a profile listed by GET /profiles
+type ProfileEntry struct {
+	slimjson.Profile
+	Source string `json:"source"` // builtin, registered or custom
+}
+
+// ProfileEntries returns the built-in, registered and custom profiles sorted by
+// name. Custom profiles replace the others of the same name.
+func ProfileEntries(custom map[string]slimjson.Profile) []ProfileEntry {
+	builtin := slimjson.GetBuiltinProfiles()
+	byName := make(map[string]ProfileEntry)
+	for _, p := range slimjson.ListProfiles() {
+		source := "registered"
+		if _, ok := builtin[p.Name]; ok {
+			source = "builtin"
+		}
+		byName[p.Name] = ProfileEntry{Profile: p, Source: source}
+	}
+	for name, p := range custom {
+		p.Name = name
+		byName[name] = ProfileEntry{Profile: p, Source: "custom"}
+	}
+	return slices.SortedFunc(maps.Values(byName), func(a, b ProfileEntry) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// profilesResponse is the GET /profiles response. The builtin and custom name
+// lists are kept for existing clients; profiles describes every profile.
+type profilesResponse struct {
+	Builtin  []string       `json:"builtin"`
+	Custom   []string       `json:"custom"`
+	Profiles []ProfileEntry `json:"profiles"`
+}
+
+// profilesHandler returns the handler for the /profiles endpoint
+func (s *Server) profilesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		s.mu.RLock()
+		response := profilesResponse{
+			Builtin:  BuiltinProfileNames,
+			Custom:   slices.Sorted(maps.Keys(s.custom)),
+			Profiles: ProfileEntries(s.custom),
+		}
+		s.mu.RUnlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
+
+// profileHandler returns the handler for the /profiles/{name} endpoint.
+// GET returns the resolved Config of a profile. PUT creates or replaces a
+// custom profile from a config in the shape of the /slim envelope config,
+// layered on the defaults of a config file profile; a custom profile may
+// shadow a built-in or registered one. DELETE removes a custom profile,
+// bringing back the one it shadowed; others cannot be deleted. PUT and DELETE
+// are only allowed when Options.AuthToken is set, so they are authenticated.
+func (s *Server) profileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.ToLower(r.PathValue("name"))
+		switch r.Method {
+		case http.MethodGet:
+			s.mu.RLock()
+			cfg, ok := s.profiles[name]
+			s.mu.RUnlock()
+			if !ok {
+				http.Error(w, fmt.Sprintf("Unknown profile: %s", name), http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(cfg)
+
+		case http.MethodPut, http.MethodDelete:
+			if s.opts.AuthToken == "" {
+				http.Error(w, "Forbidden: changing profiles requires an auth token", http.StatusForbidden)
+				return
+			}
+			if r.Method == http.MethodPut {
+				s.putProfile(w, r, name)
+			} else {
+				s.deleteProfile(w, name)
+			}
+
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// putProfile creates or replaces the custom profile name with the config of
+// the request body, answering 201 for a new profile name and 200 otherwise
+func (s *Server) putProfile(w http.ResponseWriter, r *http.Request, name string) {
+	if name == "" || strings.ContainsAny(name, "[]/ \t\r\n") {
+		http.Error(w, fmt.Sprintf("Invalid profile name %q", name), http.StatusBadRequest)
+		return
+	}
+	if !isJSONContentType(r.Header.Get("Content-Type")) {
+		http.Error(w, "Unsupported Content-Type: expected application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	s.limitBody(w, r)
+
+	cfg := slimjson.Config{DecimalPlaces: -1} // As in config files
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		writeBodyError(w, "Invalid profile config", err)
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid profile config: %s", oneLine(err)), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, replaced := s.profiles[name]
+	p := s.custom[name]
+	p.Name, p.Config = name, cfg // A replaced custom profile keeps its description
+	custom := maps.Clone(s.custom)
+	custom[name] = p
+	if err := s.persistProfiles(custom); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save profiles: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.custom = custom
+	s.profiles[name] = cfg
+
+	w.Header().Set("Content-Type", "application/json")
+	if !replaced {
+		w.WriteHeader(http.StatusCreated)
+	}
+	_ = json.NewEncoder(w).Encode(cfg)
+}
+
+// deleteProfile removes the custom profile name, answering 204
+func (s *Server) deleteProfile(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.custom[name]; !ok {
+		if _, ok := s.profiles[name]; ok {
+			http.Error(w, fmt.Sprintf("Profile %s is not a custom profile and cannot be deleted", name), http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Unknown profile: %s", name), http.StatusNotFound)
+		return
+	}
+
+	custom := maps.Clone(s.custom)
+	delete(custom, name)
+	if err := s.persistProfiles(custom); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save profiles: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.custom = custom
+	if shadowed, ok := slimjson.GetProfile(name); ok {
+		s.profiles[name] = shadowed
+	} else {
+		delete(s.profiles, name)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// persistProfiles writes custom to Options.ProfilesFile, if set
+func (s *Server) persistProfiles(custom map[string]slimjson.Profile) error {
+	if s.opts.ProfilesFile == "" {
+		return nil
+	}
+	configs := make(map[string]slimjson.Config, len(custom))
+	for name, p := range custom {
+		configs[name] = p.Config
+	}
+	return slimjson.WriteConfigFile(s.opts.ProfilesFile, configs)
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+// profileRequest sends an authenticated request with body to /profiles/{name}
+func profileRequest(h http.Handler, method, name, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/profiles/"+name, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// slimWithProfile returns the /slim response body for input with ?profile=name
+func slimWithProfile(h http.Handler, name, input string) string {
+	req := httptest.NewRequest(http.MethodPost, "/slim?profile="+name, strings.NewReader(input))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Body.String()
+}
+
+func TestProfileManagement(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".slimjson")
+	srv := New(Options{
+		AuthToken:    "secret",
+		ProfilesFile: path,
+		Profiles: map[string]slimjson.Profile{
+			"short": {Description: "Short lists", Config: slimjson.Config{MaxListLength: 3}},
+		},
+	})
+
+	// Create
+	w := profileRequest(srv, http.MethodPut, "tiny", `{"max-list-length": 1, "strip-empty": true}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Create: expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if got, want := slimWithProfile(srv, "tiny", `{"list": [1, 2, 3], "empty": ""}`), "{\"list\":[1]}\n"; got != want {
+		t.Errorf("Create: expected %q, got %q", want, got)
+	}
+
+	// Get returns the resolved config
+	w = profileRequest(srv, http.MethodGet, "TINY", "")
+	var cfg slimjson.Config
+	if err := json.NewDecoder(w.Body).Decode(&cfg); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Get: status %d, error %v", w.Code, err)
+	}
+	if cfg.MaxListLength != 1 || !cfg.StripEmpty || cfg.DecimalPlaces != -1 {
+		t.Errorf("Get: unexpected config %+v", cfg)
+	}
+
+	// Overwrite keeps the description
+	w = profileRequest(srv, http.MethodPut, "short", `{"max-list-length": 2}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Overwrite: expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got, want := slimWithProfile(srv, "short", `[1, 2, 3]`), "[1,2]\n"; got != want {
+		t.Errorf("Overwrite: expected %q, got %q", want, got)
+	}
+	if p := srv.custom["short"]; p.Description != "Short lists" {
+		t.Errorf("Overwrite: expected the description to be kept, got %q", p.Description)
+	}
+
+	// Shadow a built-in profile, then delete the shadow
+	if w = profileRequest(srv, http.MethodPut, "light", `{"max-list-length": 1}`); w.Code != http.StatusOK {
+		t.Fatalf("Shadow: expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got, want := slimWithProfile(srv, "light", `[1, 2, 3]`), "[1]\n"; got != want {
+		t.Errorf("Shadow: expected %q, got %q", want, got)
+	}
+	if w = profileRequest(srv, http.MethodDelete, "light", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Delete shadow: expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if got, want := slimWithProfile(srv, "light", `[1, 2, 3]`), "[1,2,3]\n"; got != want {
+		t.Errorf("Delete shadow: expected the built-in profile back, %q, got %q", want, got)
+	}
+
+	// Delete
+	if w = profileRequest(srv, http.MethodDelete, "tiny", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Delete: expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := slimWithProfile(srv, "tiny", `{}`); !strings.Contains(got, "Unknown profile") {
+		t.Errorf("Delete: expected the profile to be gone, got %q", got)
+	}
+
+	// Changes are persisted
+	profiles, err := slimjson.ParseConfigFile(path)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() error: %v", err)
+	}
+	if len(profiles) != 1 || profiles["short"].MaxListLength != 2 {
+		t.Errorf("Expected only the short profile to be persisted, got %+v", profiles)
+	}
+}
+
+func TestProfileUpdateConcurrentReads(t *testing.T) {
+	srv := New(Options{AuthToken: "secret"})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				slimWithProfile(srv, "live", `[1, 2, 3]`)
+				profileRequest(srv, http.MethodGet, "live", "")
+			}
+		}()
+	}
+	for j := 0; j < 50; j++ {
+		profileRequest(srv, http.MethodPut, "live", `{"max-list-length": 1}`)
+		profileRequest(srv, http.MethodDelete, "live", "")
+	}
+	wg.Wait()
+}
+
+func TestProfileManagementErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"Invalid config", Options{AuthToken: "secret"}, http.MethodPut, "x", `{"keep-null": true}`, http.StatusBadRequest},
+		{"Unknown field", Options{AuthToken: "secret"}, http.MethodPut, "x", `{"depth": 3}`, http.StatusBadRequest},
+		{"Invalid JSON", Options{AuthToken: "secret"}, http.MethodPut, "x", `{`, http.StatusBadRequest},
+		{"Invalid name", Options{AuthToken: "secret"}, http.MethodPut, "a%20b", `{}`, http.StatusBadRequest},
+		{"Delete built-in", Options{AuthToken: "secret"}, http.MethodDelete, "light", "", http.StatusConflict},
+		{"Delete unknown", Options{AuthToken: "secret"}, http.MethodDelete, "nope", "", http.StatusNotFound},
+		{"Get unknown", Options{AuthToken: "secret"}, http.MethodGet, "nope", "", http.StatusNotFound},
+		{"Without auth token", Options{}, http.MethodPut, "x", `{}`, http.StatusForbidden},
+		{"Wrong method", Options{AuthToken: "secret"}, http.MethodPost, "x", `{}`, http.StatusMethodNotAllowed},
+		{"Unwritable file", Options{AuthToken: "secret", ProfilesFile: t.TempDir()}, http.MethodPut, "x", `{}`, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := New(tt.opts)
+			w := profileRequest(srv, tt.method, tt.path, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.method == http.MethodPut {
+				if _, ok := srv.profiles[tt.path]; ok {
+					t.Errorf("Expected the failed change not to be applied")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/tradik/slimjson"
+)
+
+// ErrNoConfigFile is returned by Reload when Options.ConfigFile is not set
+var ErrNoConfigFile = errors.New("no config file to reload")
+
+// ProfileChanges lists the custom profiles a Reload added, removed and
+// modified, by name
+type ProfileChanges struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// String describes the changes for the log, e.g. "added a; removed b"
+func (c ProfileChanges) String() string {
+	var parts []string
+	for _, group := range []struct {
+		verb  string
+		names []string
+	}{{"added", c.Added}, {"removed", c.Removed}, {"modified", c.Modified}} {
+		if len(group.names) > 0 {
+			parts = append(parts, group.verb+" "+strings.Join(group.names, ", "))
+		}
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Reload parses Options.ConfigFile again and, if it parses and every profile
+// is valid, replaces the custom profiles with its own in one step. Otherwise
+// the current profiles are kept. Either way the outcome is logged. Requests
+// already being served keep the config they started with. Profiles changed
+// through PUT or DELETE /profiles/{name} and not written to the file are lost.
+func (s *Server) Reload() (ProfileChanges, error) {
+	changes, err := s.reload()
+	if err != nil {
+		s.logger.Printf("Warning: reload failed, keeping the current profiles: %v", err)
+		return changes, err
+	}
+	s.logger.Printf("Reloaded profiles from %s: %s", s.opts.ConfigFile, changes)
+	return changes, nil
+}
+
+// reload does the work of Reload without logging
+func (s *Server) reload() (ProfileChanges, error) {
+	path := s.opts.ConfigFile
+	if path == "" {
+		return ProfileChanges{}, ErrNoConfigFile
+	}
+	custom, err := slimjson.ParseProfiles(path)
+	if err != nil {
+		return ProfileChanges{}, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(custom)) {
+		if err := custom[name].Config.Validate(); err != nil {
+			return ProfileChanges{}, fmt.Errorf("%s: profile %s: %s", path, name, oneLine(err))
+		}
+	}
+
+	profiles := slimjson.AllProfiles()
+	for name, p := range custom {
+		profiles[name] = p.Config
+	}
+
+	s.mu.Lock()
+	changes := diffProfiles(s.custom, custom)
+	s.profiles, s.custom = profiles, custom
+	s.mu.Unlock()
+	return changes, nil
+}
+
+// diffProfiles returns the changes from the custom profiles old to new
+func diffProfiles(old, new map[string]slimjson.Profile) ProfileChanges {
+	changes := ProfileChanges{Added: []string{}, Removed: []string{}, Modified: []string{}}
+	for _, name := range slices.Sorted(maps.Keys(new)) {
+		before, ok := old[name]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, name)
+		case before.Description != new[name].Description || !reflect.DeepEqual(before.Config, new[name].Config):
+			changes.Modified = append(changes.Modified, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := new[name]; !ok {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+	return changes
+}
+
+// reloadHandler returns the handler for the /reload endpoint, which calls
+// Reload and answers with its ProfileChanges. Like profile changes, it is
+// only allowed when Options.AuthToken is set.
+func (s *Server) reloadHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if s.opts.AuthToken == "" {
+			http.Error(w, "Forbidden: reloading profiles requires an auth token", http.StatusForbidden)
+			return
+		}
+
+		changes, err := s.Reload()
+		switch {
+		case errors.Is(err, ErrNoConfigFile):
+			http.Error(w, "Not found: the server has no config file to reload", http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, fmt.Sprintf("Reload failed, keeping the current profiles: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(changes)
+	}
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+// writeConfig replaces the contents of the config file at path
+func writeConfig(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+}
+
+// reloadRequest sends an authenticated request to /reload
+func reloadRequest(h http.Handler, method string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/reload", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// reloadServer returns a Server serving the profiles of the config file at path
+func reloadServer(t *testing.T, path string, logs io.Writer) *Server {
+	t.Helper()
+	profiles, err := slimjson.ParseProfiles(path)
+	if err != nil {
+		t.Fatalf("ParseProfiles() error: %v", err)
+	}
+	return New(Options{AuthToken: "secret", ConfigFile: path, Profiles: profiles, Logger: log.New(logs, "", 0)})
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".slimjson")
+	writeConfig(t, path, "[short]\nlist-len=1\n\n[old]\ndepth=2\n")
+	var logs bytes.Buffer
+	srv := reloadServer(t, path, &logs)
+
+	writeConfig(t, path, "[short]\nlist-len=2\n\n[new]\nlist-len=3\n")
+	if got, want := slimWithProfile(srv, "short", `[1, 2, 3]`), "[1]\n"; got != want {
+		t.Errorf("Before reload: expected %q, got %q", want, got)
+	}
+
+	w := reloadRequest(srv, http.MethodPost)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var changes ProfileChanges
+	if err := json.NewDecoder(w.Body).Decode(&changes); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := ProfileChanges{Added: []string{"new"}, Removed: []string{"old"}, Modified: []string{"short"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Expected changes %+v, got %+v", want, changes)
+	}
+	if !strings.Contains(logs.String(), "added new; removed old; modified short") {
+		t.Errorf("Expected the changes to be logged, got %q", logs.String())
+	}
+
+	if got, want := slimWithProfile(srv, "short", `[1, 2, 3]`), "[1,2]\n"; got != want {
+		t.Errorf("After reload: expected %q, got %q", want, got)
+	}
+	if got := slimWithProfile(srv, "old", `{}`); !strings.Contains(got, "Unknown profile") {
+		t.Errorf("After reload: expected the removed profile to be gone, got %q", got)
+	}
+	if w := profileRequest(srv, http.MethodGet, "new", ""); w.Code != http.StatusOK {
+		t.Errorf("After reload: expected the added profile, got %d", w.Code)
+	}
+}
+
+func TestReloadKeepsProfilesOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".slimjson")
+	writeConfig(t, path, "[short]\nlist-len=1\n")
+	var logs bytes.Buffer
+	srv := reloadServer(t, path, &logs)
+
+	for name, contents := range map[string]string{
+		"Parse error":      "[short]\nlist-len=many\n",
+		"Invalid profile":  "[short]\nlist-len=2\n\n[broken]\nkeep-null=true\n",
+		"Missing extended": "[short]\nextends=nowhere\n",
+	} {
+		writeConfig(t, path, contents)
+		w := reloadRequest(srv, http.MethodPost)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s: expected status 500, got %d: %s", name, w.Code, w.Body.String())
+		}
+		if got, want := slimWithProfile(srv, "short", `[1, 2, 3]`), "[1]\n"; got != want {
+			t.Errorf("%s: expected the current profiles to be kept, %q, got %q", name, want, got)
+		}
+	}
+	if !strings.Contains(logs.String(), "Warning: reload failed") {
+		t.Errorf("Expected the failures to be logged, got %q", logs.String())
+	}
+}
+
+func TestReloadInFlight(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".slimjson")
+	writeConfig(t, path, "[short]\nlist-len=1\n")
+	srv := reloadServer(t, path, io.Discard)
+
+	// The request picks its profile before reading the body
+	pr, pw := io.Pipe()
+	req := httptest.NewRequest(http.MethodPost, "/slim?profile=short", pr)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.ServeHTTP(w, req)
+	}()
+	if _, err := io.WriteString(pw, `[1, `); err != nil {
+		t.Fatalf("Failed to write the body: %v", err)
+	}
+
+	writeConfig(t, path, "[short]\nlist-len=2\n")
+	if _, err := srv.Reload(); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	_, _ = io.WriteString(pw, `2, 3]`)
+	_ = pw.Close()
+	<-done
+
+	if got, want := w.Body.String(), "[1]\n"; got != want {
+		t.Errorf("Expected the in-flight request to keep its profile, %q, got %q", want, got)
+	}
+	if got, want := slimWithProfile(srv, "short", `[1, 2, 3]`), "[1,2]\n"; got != want {
+		t.Errorf("Expected new requests to use the reloaded profile, %q, got %q", want, got)
+	}
+}
+
+func TestReloadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		method string
+		status int
+	}{
+		{"Without config file", Options{AuthToken: "secret"}, http.MethodPost, http.StatusNotFound},
+		{"Without auth token", Options{ConfigFile: "unused"}, http.MethodPost, http.StatusForbidden},
+		{"Wrong method", Options{AuthToken: "secret", ConfigFile: "unused"}, http.MethodGet, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := reloadRequest(New(tt.opts), tt.method)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
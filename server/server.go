@@ -0,0 +1,471 @@
+// Package server provides the slimjson HTTP API, as served by slimjson -d,
+// as an http.Handler that can be embedded in other programs:
+//
+//	srv := server.New(server.Options{Addr: ":8080", MaxBodyBytes: 10 << 20})
+//	http.Handle("/slimjson/", http.StripPrefix("/slimjson", srv))
+//
+// The endpoints are registered on the Server's own ServeMux, never on
+// http.DefaultServeMux:
+//
+//	POST /slim?profile=<name>  Compress JSON
+//	POST /slim/ndjson          Compress NDJSON line by line
+//	POST /slim/batch           Compress a JSON array or NDJSON body of documents
+//	POST /unslim               Restore compressed JSON
+//	GET  /health               Health check with build info
+//	GET  /profiles             List profiles
+//	GET  /profiles/{name}      Get the resolved config of a profile
+//	PUT  /profiles/{name}      Create or replace a custom profile
+//	DELETE /profiles/{name}    Delete a custom profile
+//	POST /reload               Reload the custom profiles from the config file
+//
+// With Options.AuthToken, every endpoint but /health requires the header
+// "Authorization: Bearer <token>", and Options.CORSOrigins lets browsers on
+// other origins call the API. Options.MaxConcurrent and Options.RequestTimeout
+// protect the server from large documents, and Options.AccessLog logs every
+// request with an ID. All five are also available as Middleware.
+package server
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"maps"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tradik/slimjson"
+)
+
+// Options configures a Server. The zero value serves the built-in and
+// registered profiles with no body size limit and no timeouts.
+type Options struct {
+	// Addr is the address ListenAndServe listens on: a TCP address such as
+	// ":8080", or a Unix domain socket such as "unix:///var/run/slimjson.sock"
+	Addr string
+
+	// SocketMode is the file mode of the Unix domain socket of Addr, e.g.
+	// 0660 (0 = as created, subject to the umask)
+	SocketMode os.FileMode
+
+	// TLSCertFile and TLSKeyFile are PEM files with the certificate and key
+	// ListenAndServe serves HTTPS with. Both or neither must be set.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// Profiles are custom profiles served besides the built-in and registered
+	// ones, replacing those of the same name
+	Profiles map[string]slimjson.Profile
+
+	// DefaultConfig is used by requests without ?profile=. If nil,
+	// DefaultRequestConfig() is used.
+	DefaultConfig *slimjson.Config
+
+	// MaxBodyBytes rejects larger request bodies with 413 (0 = unlimited)
+	MaxBodyBytes int64
+
+	// MaxBatchBytes is the body size limit of /slim/batch (0 = MaxBodyBytes)
+	MaxBatchBytes int64
+
+	// MaxBatchSize rejects /slim/batch requests with more documents with 413
+	// (0 = unlimited)
+	MaxBatchSize int
+
+	// BatchWorkers is the number of documents of a /slim/batch request slimmed
+	// concurrently (0 = runtime.GOMAXPROCS(0))
+	BatchWorkers int
+
+	// Timeouts of the http.Server started by ListenAndServe (0 = none).
+	// ShutdownTimeout bounds the graceful shutdown when the context is done.
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+
+	// MaxHeaderBytes limits the request headers read by the http.Server started
+	// by ListenAndServe (0 = http.DefaultMaxHeaderBytes)
+	MaxHeaderBytes int
+
+	// MaxConcurrent is the number of requests served at a time; others get
+	// 429 with Retry-After (0 = unlimited). /health is not limited.
+	MaxConcurrent int
+
+	// RequestTimeout bounds the time spent slimming a request; requests that
+	// take longer get 504 (0 = none)
+	RequestTimeout time.Duration
+
+	// AuthToken, if set, is the bearer token requests to endpoints other than
+	// /health must send in the Authorization header; others get 401
+	AuthToken string
+
+	// CORSOrigins are the origins browsers may call the API from, or "*" for
+	// any (none = no CORS headers)
+	CORSOrigins []string
+
+	// ConfigFile, if set, is the config file the custom profiles were loaded
+	// from, which Reload and POST /reload parse again
+	ConfigFile string
+
+	// ProfilesFile, if set, is the .slimjson file the custom profiles are
+	// written to with slimjson.WriteConfigFile after each change through
+	// PUT or DELETE /profiles/{name}. Comments and descriptions in it are not
+	// kept. Otherwise changes only live in memory.
+	ProfilesFile string
+
+	// BuildInfo is reported by /health
+	BuildInfo BuildInfo
+
+	// Logger receives warnings about requests, and the errors of the
+	// http.Server started by ListenAndServe. If nil, log.Default() is used.
+	Logger *log.Logger
+
+	// AccessLog, if set, receives one structured line per request, see
+	// LogRequests, and requests get an X-Request-ID (nil = no access log)
+	AccessLog *slog.Logger
+}
+
+// BuildInfo describes the program serving the API, as reported by /health.
+// An empty Version is reported as "dev" and an empty GoVersion as the Go
+// version of the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go"`
+}
+
+// DefaultRequestConfig returns the config used by requests without
+// ?profile= unless Options.DefaultConfig is set. Unlike slimjson.DefaultConfig,
+// which turns every option off, it limits depth and list length and strips
+// empty values.
+func DefaultRequestConfig() slimjson.Config {
+	return slimjson.Config{
+		MaxDepth:      5,
+		MaxListLength: 10,
+		StripEmpty:    true,
+	}
+}
+
+// Server serves the slimjson HTTP API
+type Server struct {
+	opts     Options
+	mu       sync.RWMutex                // Guards profiles and custom
+	profiles map[string]slimjson.Config  // Built-in, registered and custom profiles
+	custom   map[string]slimjson.Profile // Custom profiles, as listed by /profiles
+	logger   *log.Logger
+	mux      *http.ServeMux
+	handler  http.Handler // mux wrapped in the middleware of opts
+}
+
+// New returns a Server for opts. Profiles registered with slimjson.RegisterProfile
+// after New are not served.
+func New(opts Options) *Server {
+	s := &Server{
+		opts:     opts,
+		profiles: slimjson.AllProfiles(),
+		custom:   maps.Clone(opts.Profiles),
+		logger:   opts.Logger,
+		mux:      http.NewServeMux(),
+	}
+	if s.custom == nil {
+		s.custom = make(map[string]slimjson.Profile)
+	}
+	for name, p := range opts.Profiles {
+		s.profiles[name] = p.Config
+	}
+	if s.logger == nil {
+		s.logger = log.Default()
+	}
+
+	s.mux.HandleFunc("/health", s.healthHandler())
+	s.mux.HandleFunc("/profiles", s.profilesHandler())
+	s.mux.HandleFunc("/profiles/{name}", s.profileHandler())
+	s.mux.HandleFunc("/reload", s.reloadHandler())
+	s.mux.HandleFunc("/slim", s.slimHandler())
+	s.mux.HandleFunc("/slim/ndjson", s.ndjsonHandler())
+	s.mux.HandleFunc("/slim/batch", s.batchHandler())
+	s.mux.HandleFunc("/unslim", s.unslimHandler())
+
+	// CORS runs first so preflight requests, which carry no credentials, and
+	// 401 responses get CORS headers. Rejected requests take no slot, and are
+	// logged like the others.
+	handler := LimitConcurrency(opts.MaxConcurrent, "/health")(Timeout(opts.RequestTimeout)(s.mux))
+	handler = CORS(opts.CORSOrigins)(RequireToken(opts.AuthToken, "/health")(handler))
+	s.handler = LogRequests(opts.AccessLog)(handler)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the API on Options.Addr, with TLS if Options.TLSCertFile
+// is set, until ctx is done, then shuts down gracefully, waiting up to
+// Options.ShutdownTimeout (0 = no limit) for requests in flight. It returns
+// nil after a shutdown caused by ctx. A Unix domain socket file left behind
+// by a server that did not shut down is replaced, and removed on shutdown.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	var tlsConfig *tls.Config
+	switch {
+	case (s.opts.TLSCertFile == "") != (s.opts.TLSKeyFile == ""):
+		return errors.New("TLSCertFile and TLSKeyFile must be set together")
+	case s.opts.TLSCertFile != "":
+		cert, err := tls.LoadX509KeyPair(s.opts.TLSCertFile, s.opts.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("loading TLS certificate: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Addr:           s.opts.Addr,
+		Handler:        s,
+		ReadTimeout:    s.opts.ReadTimeout,
+		WriteTimeout:   s.opts.WriteTimeout,
+		IdleTimeout:    s.opts.IdleTimeout,
+		MaxHeaderBytes: s.opts.MaxHeaderBytes,
+		TLSConfig:      tlsConfig,
+		ErrorLog:       s.logger,
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			errc <- srv.ServeTLS(ln, "", "")
+		} else {
+			errc <- srv.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx := context.Background()
+	if s.opts.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, s.opts.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listen returns the listener of Options.Addr
+func (s *Server) listen() (net.Listener, error) {
+	path, isUnix := strings.CutPrefix(s.opts.Addr, "unix://")
+	if !isUnix {
+		return net.Listen("tcp", s.opts.Addr)
+	}
+	if path == "" {
+		return nil, errors.New("missing Unix socket path")
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if s.opts.SocketMode != 0 {
+		if err := os.Chmod(path, s.opts.SocketMode); err != nil {
+			_ = ln.Close()
+			return nil, fmt.Errorf("setting the socket mode: %w", err)
+		}
+	}
+	return ln, nil
+}
+
+// removeStaleSocket removes the Unix domain socket file at path if no server
+// accepts connections on it. Other files and sockets in use are left alone
+// and reported.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+	return os.Remove(path)
+}
+
+// reservedParams are the query parameters that are not config keys
+var reservedParams = map[string]bool{"profile": true, "inline": true, "inline_config": true, "include_stats": true}
+
+// requestConfig returns the Config of the ?profile= query parameter, or the
+// default config without one, with the other query parameters layered on top
+// as config keys or CLI flag names, e.g. ?depth=3&block=a,b&strip-empty=false.
+// Repeated parameters are joined with commas. Unknown profiles and invalid
+// parameters are answered with 400.
+func (s *Server) requestConfig(w http.ResponseWriter, r *http.Request) (slimjson.Config, bool) {
+	query := r.URL.Query()
+	cfg := DefaultRequestConfig()
+	if s.opts.DefaultConfig != nil {
+		cfg = *s.opts.DefaultConfig
+	}
+	if profileName := query.Get("profile"); profileName != "" {
+		var ok bool
+		s.mu.RLock()
+		cfg, ok = s.profiles[strings.ToLower(profileName)]
+		s.mu.RUnlock()
+		if !ok {
+			http.Error(w, fmt.Sprintf("Unknown profile: %s", profileName), http.StatusBadRequest)
+			return cfg, false
+		}
+	}
+
+	// Sorted so the first invalid parameter reported does not vary
+	layered := false
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		if reservedParams[key] {
+			continue
+		}
+		if err := cfg.Set(key, strings.Join(query[key], ",")); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid query parameter %s: %v", key, err), http.StatusBadRequest)
+			return cfg, false
+		}
+		layered = true
+	}
+	if layered {
+		if err := cfg.Validate(); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid query parameters: %s", oneLine(err)), http.StatusBadRequest)
+			return cfg, false
+		}
+	}
+	return cfg, true
+}
+
+// oneLine joins the problems reported by Config.Validate into a single line
+func oneLine(err error) string {
+	return strings.ReplaceAll(err.Error(), "\n", "; ")
+}
+
+// healthHandler serves /health with the build info of the server
+func (s *Server) healthHandler() http.HandlerFunc {
+	info := s.opts.BuildInfo
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+	body := struct {
+		Status string `json:"status"`
+		BuildInfo
+	}{"ok", info}
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+// writeBodyError answers a request whose body could not be decoded with 413 if
+// it is over the size limit, or 400 with prefix and err otherwise
+func writeBodyError(w http.ResponseWriter, prefix string, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("Request body too large: limit is %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, fmt.Sprintf("%s: %v", prefix, err), http.StatusBadRequest)
+}
+
+// writeSlimError answers a request whose slimming failed: with 504 if its
+// context timed out, see Options.RequestTimeout, or 500 otherwise, and logs
+// the failure with the request ID. Nothing is written if the client went away.
+func (s *Server) writeSlimError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		s.logger.Printf("Warning: %s%s timed out after %s", r.URL.Path, requestTag(r), s.opts.RequestTimeout)
+		http.Error(w, "Request timed out", http.StatusGatewayTimeout)
+	case errors.Is(err, context.Canceled):
+	default:
+		s.logger.Printf("Warning: %s%s failed: %v", r.URL.Path, requestTag(r), err)
+		http.Error(w, fmt.Sprintf("Failed to encode result: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// requestTag returns " (request <id>)" for the log if r has a request ID, see
+// LogRequests, or ""
+func requestTag(r *http.Request) string {
+	if id := RequestID(r.Context()); id != "" {
+		return " (request " + id + ")"
+	}
+	return ""
+}
+
+// limitBody applies Options.MaxBodyBytes to the request body
+func (s *Server) limitBody(w http.ResponseWriter, r *http.Request) {
+	if s.opts.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxBodyBytes)
+	}
+}
+
+// openBody prepares the body of a /slim or /unslim request: a body with
+// Content-Encoding gzip is decompressed, and Options.MaxBodyBytes applies to
+// both the compressed and the decompressed body. Other encodings are answered
+// with 415 and a body that is not gzip with 400, returning false.
+func (s *Server) openBody(w http.ResponseWriter, r *http.Request) bool {
+	s.limitBody(w, r)
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return true
+	case "gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeBodyError(w, "Invalid gzip body", err)
+			return false
+		}
+		r.Body = zr
+		s.limitBody(w, r)
+		return true
+	default:
+		http.Error(w, fmt.Sprintf("Unsupported Content-Encoding: %s", encoding), http.StatusUnsupportedMediaType)
+		return false
+	}
+}
+
+// isJSONContentType reports whether the Content-Type header of a request
+// denotes JSON. A missing header is taken as JSON, for clients that do not
+// send one.
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
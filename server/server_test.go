@@ -0,0 +1,427 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestHealthEndpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		info BuildInfo
+		want map[string]string
+	}{
+		{
+			name: "Build info",
+			info: BuildInfo{Version: "v9.8.7", Commit: "0123456789ab", GoVersion: "go1.99"},
+			want: map[string]string{"status": "ok", "version": "v9.8.7", "commit": "0123456789ab", "go": "go1.99"},
+		},
+		{
+			name: "Defaults",
+			want: map[string]string{"status": "ok", "version": "dev", "go": runtime.Version()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			w := httptest.NewRecorder()
+			New(Options{BuildInfo: tt.info}).ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", w.Code)
+			}
+			var response map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response) != len(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, response)
+			}
+			for key, want := range tt.want {
+				if response[key] != want {
+					t.Errorf("Expected %s %q, got %q", key, want, response[key])
+				}
+			}
+		})
+	}
+}
+
+func TestProfilesEndpoint(t *testing.T) {
+	customProfiles := map[string]slimjson.Profile{
+		"test-profile": {
+			Description: "Short previews",
+			Config: slimjson.Config{
+				MaxDepth:      3,
+				MaxListLength: 5,
+				StripEmpty:    true,
+			},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/profiles", nil)
+	w := httptest.NewRecorder()
+	New(Options{Profiles: customProfiles}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Builtin  []string `json:"builtin"`
+		Custom   []string `json:"custom"`
+		Profiles []struct {
+			Name        string          `json:"name"`
+			Description string          `json:"description"`
+			Source      string          `json:"source"`
+			Config      slimjson.Config `json:"config"`
+		} `json:"profiles"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(response.Builtin) != 4 {
+		t.Errorf("Expected 4 built-in profiles, got %d", len(response.Builtin))
+	}
+
+	if len(response.Custom) != 1 {
+		t.Errorf("Expected 1 custom profile, got %d", len(response.Custom))
+	}
+
+	if len(response.Profiles) != 5 {
+		t.Fatalf("Expected 5 profiles, got %+v", response.Profiles)
+	}
+	for _, p := range response.Profiles {
+		switch p.Name {
+		case "test-profile":
+			if p.Source != "custom" || p.Description != "Short previews" || p.Config.MaxListLength != 5 {
+				t.Errorf("Unexpected custom profile %+v", p)
+			}
+		case "aggressive":
+			if p.Source != "builtin" || p.Description == "" || len(p.Config.BlockList) == 0 {
+				t.Errorf("Expected the built-in aggressive profile with its description and config, got %+v", p)
+			}
+		}
+	}
+}
+
+func TestDefaultConfigOption(t *testing.T) {
+	cfg := slimjson.Config{MaxListLength: 1}
+	req := httptest.NewRequest(http.MethodPost, "/slim", strings.NewReader(`{"list":[1,2,3],"empty":""}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	New(Options{DefaultConfig: &cfg}).ServeHTTP(w, req)
+
+	if got, want := w.Body.String(), "{\"empty\":\"\",\"list\":[1]}\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestDefaultServeMuxUntouched(t *testing.T) {
+	New(Options{})
+
+	for _, path := range []string{"/health", "/profiles", "/slim", "/slim/ndjson", "/unslim"} {
+		if _, pattern := http.DefaultServeMux.Handler(httptest.NewRequest(http.MethodGet, path, nil)); pattern != "" {
+			t.Errorf("%s is registered on http.DefaultServeMux", path)
+		}
+	}
+}
+
+// testClient does not keep connections alive, so a connection it dialed but
+// did not use cannot hold up a graceful shutdown
+var testClient = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+// startServer runs ListenAndServe with opts on a free local port until cancel
+// is called, and returns the address and the channel of its result
+func startServer(t *testing.T, opts Options) (addr string, cancel context.CancelFunc, errc chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	opts.Addr = ln.Addr().String()
+	_ = ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc = make(chan error, 1)
+	go func() { errc <- New(opts).ListenAndServe(ctx) }()
+
+	for i := 0; i < 50; i++ {
+		var resp *http.Response
+		if resp, err = testClient.Get("http://" + opts.Addr + "/health"); err == nil {
+			_ = resp.Body.Close()
+			return opts.Addr, cancel, errc
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	t.Fatalf("GET /health: %v", err)
+	return
+}
+
+func TestListenAndServe(t *testing.T) {
+	addr, cancel, errc := startServer(t, Options{ReadTimeout: time.Second, ShutdownTimeout: time.Second})
+
+	resp, err := testClient.Get("http://" + addr + "/health")
+	if err != nil {
+		t.Fatalf("GET /health: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("ListenAndServe() = %v after the context was canceled, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListenAndServe() did not return after the context was canceled")
+	}
+}
+
+func TestListenAndServeWaitsForRequests(t *testing.T) {
+	addr, cancel, errc := startServer(t, Options{ShutdownTimeout: 5 * time.Second})
+
+	// A request whose body is still being sent is in flight
+	body, bodyWriter := io.Pipe()
+	respc := make(chan *http.Response, 1)
+	go func() {
+		resp, err := testClient.Post("http://"+addr+"/slim", "application/json", body)
+		if err != nil {
+			t.Errorf("POST /slim: %v", err)
+		}
+		respc <- resp
+	}()
+	if _, err := bodyWriter.Write([]byte(`{"id": `)); err != nil {
+		t.Fatalf("Failed to write the request body: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond) // Let the server start reading it
+
+	cancel()
+	select {
+	case err := <-errc:
+		t.Fatalf("ListenAndServe() = %v before the request in flight was answered", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	_, _ = bodyWriter.Write([]byte(`1}`))
+	_ = bodyWriter.Close()
+	resp := <-respc
+	if resp == nil {
+		return
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(respBody)) != `{"id":1}` {
+		t.Errorf("Response = %d %s, want 200 {\"id\":1}", resp.StatusCode, respBody)
+	}
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("ListenAndServe() = %v after the context was canceled, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListenAndServe() did not return after the request in flight was answered")
+	}
+}
+
+// unixClient returns a client sending every request to the Unix socket at path
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slimjson.sock")
+
+	// A socket file left behind by a server that did not shut down
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to create a socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- New(Options{Addr: "unix://" + path, SocketMode: 0o600}).ListenAndServe(ctx) }()
+
+	client := unixClient(path)
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("http://slimjson/health"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		cancel()
+		t.Fatalf("GET /health over the socket: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected socket mode 0600, got %v, %v", info.Mode(), err)
+	}
+
+	// The socket in use is not replaced
+	if err := New(Options{Addr: "unix://" + path}).ListenAndServe(context.Background()); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("Expected a second server to fail with the socket in use, got %v", err)
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("ListenAndServe() = %v after the context was canceled, want nil", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket file to be removed on shutdown, got %v", err)
+	}
+
+	// Other files are not replaced
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := New(Options{Addr: "unix://" + path}).ListenAndServe(context.Background()); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("Expected a regular file to be refused, got %v", err)
+	}
+}
+
+// writeSelfSignedCert writes a certificate for 127.0.0.1 and its key to dir
+// and returns their paths and a pool trusting the certificate
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "slimjson test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	pool = x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	return certFile, keyFile, pool
+}
+
+func TestListenAndServeTLS(t *testing.T) {
+	certFile, keyFile, pool := writeSelfSignedCert(t, t.TempDir())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- New(Options{Addr: addr, TLSCertFile: certFile, TLSKeyFile: keyFile, Logger: log.New(io.Discard, "", 0)}).ListenAndServe(ctx)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true, TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("https://" + addr + "/health"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		cancel()
+		t.Fatalf("GET /health over TLS: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Errorf("Expected status 200 over TLS, got %d", resp.StatusCode)
+	}
+
+	// Plain HTTP is refused
+	if resp, err := testClient.Get("http://" + addr + "/health"); err == nil {
+		if resp.StatusCode == http.StatusOK {
+			t.Errorf("Expected plain HTTP to fail on the TLS port")
+		}
+		_ = resp.Body.Close()
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("ListenAndServe() = %v after the context was canceled, want nil", err)
+	}
+}
+
+func TestListenAndServeTLSErrors(t *testing.T) {
+	certFile, _, _ := writeSelfSignedCert(t, t.TempDir())
+	tests := []struct {
+		name string
+		opts Options
+		err  string
+	}{
+		{"Certificate without key", Options{Addr: "127.0.0.1:0", TLSCertFile: certFile}, "must be set together"},
+		{"Key without certificate", Options{Addr: "127.0.0.1:0", TLSKeyFile: certFile}, "must be set together"},
+		{"Invalid key", Options{Addr: "127.0.0.1:0", TLSCertFile: certFile, TLSKeyFile: certFile}, "loading TLS certificate"},
+		{"Missing socket path", Options{Addr: "unix://"}, "missing Unix socket path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(tt.opts).ListenAndServe(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ListenAndServe() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
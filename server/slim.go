@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tradik/slimjson"
+)
+
+// inlineRequest is the /slim?inline=true request envelope
+type inlineRequest struct {
+	Config json.RawMessage `json:"config"`
+	Data   interface{}     `json:"data"`
+}
+
+// isEnvelope reports whether the /slim request body is an inlineRequest: with
+// ?inline=true or ?inline_config=1, or the application/vnd.slimjson+json
+// Content-Type
+func isEnvelope(r *http.Request) bool {
+	query := r.URL.Query()
+	inline, _ := strconv.ParseBool(query.Get("inline"))
+	inlineConfig, _ := strconv.ParseBool(query.Get("inline_config"))
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return inline || inlineConfig || mediaType == envelopeMediaType
+}
+
+// decodeInlineConfig decodes the config of an inlineRequest into a new
+// Config, for Config.Merge, and returns the keys it sets. Decoding into the
+// selected profile instead would reuse its maps and slices, which it shares
+// with every other request.
+func decodeInlineConfig(raw json.RawMessage) (slimjson.Config, []string, error) {
+	var cfg slimjson.Config
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return cfg, nil, err
+	}
+	// Keys match the json tags of Config regardless of case, as in Decode
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, strings.ToLower(key))
+	}
+	return cfg, keys, nil
+}
+
+// envelopeMediaType is the Content-Type of /slim request envelopes
+const envelopeMediaType = "application/vnd.slimjson+json"
+
+// slimHandler returns the handler for the /slim endpoint.
+// Query parameters other than profile override fields of the profile, and an
+// envelope body {"config": {...}, "data": ...} (see isEnvelope) overrides both
+// for this request only. The response has X-SlimJSON-* headers with the
+// compression statistics, which ?include_stats=1 also puts in the body as
+// {"result": ..., "stats": {...}}.
+func (s *Server) slimHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !isJSONContentType(r.Header.Get("Content-Type")) {
+			http.Error(w, "Unsupported Content-Type: expected application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if !s.openBody(w, r) {
+			return
+		}
+
+		cfg, ok := s.requestConfig(w, r)
+		if !ok {
+			return
+		}
+
+		inline := isEnvelope(r)
+
+		// Parse JSON from request body
+		var data interface{}
+		var body interface{} = &data
+		var envelope inlineRequest
+		if inline {
+			body = &envelope
+		}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			writeBodyError(w, "Invalid JSON", err)
+			return
+		}
+
+		// Layer inline config on top of the selected profile
+		if inline {
+			if len(envelope.Config) > 0 {
+				override, keys, err := decodeInlineConfig(envelope.Config)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("Invalid inline config: %v", err), http.StatusBadRequest)
+					return
+				}
+				cfg = cfg.Merge(override, keys)
+				if err := cfg.Validate(); err != nil {
+					http.Error(w, fmt.Sprintf("Invalid inline config: %s", oneLine(err)), http.StatusBadRequest)
+					return
+				}
+			}
+			data = envelope.Data
+		}
+
+		// Process
+		result, st, err := slimEncoded(r.Context(), slimjson.New(cfg), data)
+		if err != nil {
+			s.writeSlimError(w, r, err)
+			return
+		}
+		if cfg.WarnOnExpansion {
+			s.warnIfExpanded(r, r.URL.Path, st)
+		}
+
+		// Return result
+		stats := newSlimStats(st, r.URL.Query().Get("profile"))
+		stats.setHeaders(w.Header())
+		if includeStats, _ := strconv.ParseBool(r.URL.Query().Get("include_stats")); includeStats {
+			if result, err = json.Marshal(statsResponse{Result: result, Stats: stats}); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to encode result: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(append(result, '\n'))
+	}
+}
+
+// slimEncoded slims data with slimmer until ctx is done and returns the result
+// as compact JSON with the Stats SlimWithStats would report, encoding the
+// result only once
+func slimEncoded(ctx context.Context, slimmer *slimjson.Slimmer, data interface{}) ([]byte, slimjson.Stats, error) {
+	var st slimjson.Stats
+	if orig, err := json.Marshal(data); err == nil {
+		st.OriginalBytes, st.OriginalTokens = len(orig), slimjson.EstimateTokens(orig)
+	}
+	slimmed, err := slimmer.SlimContext(ctx, data)
+	if err != nil {
+		return nil, st, err
+	}
+	result, err := json.Marshal(slimmed)
+	if err != nil {
+		return nil, st, err
+	}
+	st.SlimmedBytes, st.SlimmedTokens = len(result), slimjson.EstimateTokens(result)
+	return result, st, nil
+}
+
+// slimStats are the compression statistics of a /slim response, sent as
+// X-SlimJSON-* headers and, with ?include_stats=1, in the body
+type slimStats struct {
+	OriginalBytes    int     `json:"original_bytes"`
+	SlimmedBytes     int     `json:"slimmed_bytes"`
+	ReductionPercent float64 `json:"reduction_percent"`
+	TokensEstimated  int     `json:"tokens_estimated"`
+	Profile          string  `json:"profile"`
+}
+
+// statsResponse is the /slim?include_stats=1 response body
+type statsResponse struct {
+	Result json.RawMessage `json:"result"`
+	Stats  slimStats       `json:"stats"`
+}
+
+// newSlimStats returns the statistics of st for the named profile, "default"
+// without one. The reduction is rounded to one decimal and negative if
+// slimming expanded the document.
+func newSlimStats(st slimjson.Stats, profile string) slimStats {
+	if profile == "" {
+		profile = "default"
+	}
+	return slimStats{
+		OriginalBytes:    st.OriginalBytes,
+		SlimmedBytes:     st.SlimmedBytes,
+		ReductionPercent: math.Round((1-st.Ratio())*1000) / 10,
+		TokensEstimated:  st.SlimmedTokens,
+		Profile:          strings.ToLower(profile),
+	}
+}
+
+// setHeaders adds the X-SlimJSON-* headers of stats to h
+func (stats slimStats) setHeaders(h http.Header) {
+	h.Set("X-SlimJSON-Original-Bytes", strconv.Itoa(stats.OriginalBytes))
+	h.Set("X-SlimJSON-Slimmed-Bytes", strconv.Itoa(stats.SlimmedBytes))
+	h.Set("X-SlimJSON-Reduction-Percent", strconv.FormatFloat(stats.ReductionPercent, 'f', 1, 64))
+	h.Set("X-SlimJSON-Tokens-Estimated", strconv.Itoa(stats.TokensEstimated))
+	h.Set("X-SlimJSON-Profile", stats.Profile)
+}
+
+// slim slims data with slimmer until the context of r is done. With
+// Config.WarnOnExpansion, an output larger than the input is logged as a
+// warning about source.
+func (s *Server) slim(slimmer *slimjson.Slimmer, data interface{}, r *http.Request, source string) (interface{}, error) {
+	if !slimmer.Config.WarnOnExpansion {
+		return slimmer.SlimContext(r.Context(), data)
+	}
+	result, st, err := slimEncoded(r.Context(), slimmer, data)
+	if err != nil {
+		return nil, err
+	}
+	s.warnIfExpanded(r, source, st)
+	return json.RawMessage(result), nil
+}
+
+// warnIfExpanded logs a warning about source if st reports an output larger
+// than the input
+func (s *Server) warnIfExpanded(r *http.Request, source string, st slimjson.Stats) {
+	if st.Expanded() {
+		s.logger.Printf("Warning: %s%s with profile %q: output is larger than the input, %d -> %d bytes (ratio %.2f)",
+			source, requestTag(r), r.URL.Query().Get("profile"), st.OriginalBytes, st.SlimmedBytes, st.Ratio())
+	}
+}
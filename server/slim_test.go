@@ -0,0 +1,553 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestSlimEndpoint(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		profile        string
+		input          string
+		expectedStatus int
+		checkResult    bool
+	}{
+		{
+			name:           "Valid request with medium profile",
+			method:         http.MethodPost,
+			profile:        "medium",
+			input:          `{"users":[{"id":1,"name":"Alice"},{"id":2,"name":"Bob"}],"prices":[19.999,29.123]}`,
+			expectedStatus: http.StatusOK,
+			checkResult:    true,
+		},
+		{
+			name:           "Valid request without profile",
+			method:         http.MethodPost,
+			profile:        "",
+			input:          `{"test":"data"}`,
+			expectedStatus: http.StatusOK,
+			checkResult:    true,
+		},
+		{
+			name:           "Invalid method GET",
+			method:         http.MethodGet,
+			profile:        "",
+			input:          `{}`,
+			expectedStatus: http.StatusMethodNotAllowed,
+			checkResult:    false,
+		},
+		{
+			name:           "Invalid JSON",
+			method:         http.MethodPost,
+			profile:        "",
+			input:          `{invalid json}`,
+			expectedStatus: http.StatusBadRequest,
+			checkResult:    false,
+		},
+		{
+			name:           "Unknown profile",
+			method:         http.MethodPost,
+			profile:        "nonexistent",
+			input:          `{"test":"data"}`,
+			expectedStatus: http.StatusBadRequest,
+			checkResult:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := "/slim"
+			if tt.profile != "" {
+				url += "?profile=" + tt.profile
+			}
+
+			req := httptest.NewRequest(tt.method, url, bytes.NewBufferString(tt.input))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			New(Options{}).ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.checkResult && w.Code == http.StatusOK {
+				var result interface{}
+				if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+					t.Errorf("Failed to decode result: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSlimHandlerLimits(t *testing.T) {
+	handler := New(Options{MaxBodyBytes: 64})
+
+	tests := []struct {
+		name           string
+		contentType    string
+		input          string
+		expectedStatus int
+	}{
+		{
+			name:           "Valid JSON within limit",
+			contentType:    "application/json",
+			input:          `{"test":"data"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "JSON content type with charset",
+			contentType:    "application/json; charset=utf-8",
+			input:          `{"test":"data"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Oversized body",
+			contentType:    "application/json",
+			input:          `{"test":"` + strings.Repeat("x", 128) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "Wrong content type",
+			contentType:    "text/plain",
+			input:          `{"test":"data"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Missing content type",
+			contentType:    "",
+			input:          `{"test":"data"}`,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/slim", bytes.NewBufferString(tt.input))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSlimHandlerGzipBody(t *testing.T) {
+	handler := New(Options{MaxBodyBytes: 64})
+
+	tests := []struct {
+		name           string
+		encoding       string
+		body           []byte
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "Gzip", encoding: "gzip", body: gzipBytes(t, []byte(`{"test":"data","empty":null}`)), expectedStatus: http.StatusOK, expectedBody: `{"test":"data"}`},
+		{name: "Identity", encoding: "identity", body: []byte(`{"test":"data"}`), expectedStatus: http.StatusOK, expectedBody: `{"test":"data"}`},
+		{name: "Oversized once decompressed", encoding: "gzip", body: gzipBytes(t, []byte(`{"test":"`+strings.Repeat("x", 128)+`"}`)), expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Not gzip", encoding: "gzip", body: []byte(`{"test":"data"}`), expectedStatus: http.StatusBadRequest, expectedBody: "Invalid gzip body"},
+		{name: "Unsupported encoding", encoding: "br", body: []byte(`{"test":"data"}`), expectedStatus: http.StatusUnsupportedMediaType, expectedBody: "Unsupported Content-Encoding: br"},
+	}
+
+	for _, path := range []string{"/slim", "/unslim"} {
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Content-Encoding", tt.encoding)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				if w.Code != tt.expectedStatus {
+					t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+				}
+				if path == "/slim" && !strings.Contains(w.Body.String(), tt.expectedBody) {
+					t.Errorf("Expected body containing %q, got %q", tt.expectedBody, w.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestSlimHandlerInlineConfig(t *testing.T) {
+	handler := New(Options{})
+
+	tests := []struct {
+		name           string
+		query          string
+		input          string
+		expectedStatus int
+		expected       string
+	}{
+		{
+			name:           "Inline depth and list length",
+			query:          "?inline=true",
+			input:          `{"config": {"max-depth": 3, "max-list-length": 2, "strip-empty": false}, "data": {"a": {"b": {"c": {"d": 1}}}, "list": [1, 2, 3]}}`,
+			expectedStatus: http.StatusOK,
+			expected:       `{"a": {"b": {"c": null}}, "list": [1, 2]}`,
+		},
+		{
+			name:           "Inline blocklist overrides profile",
+			query:          "?inline=true&profile=aggressive",
+			input:          `{"config": {"block-list": ["secret"]}, "data": {"secret": "x", "description": "kept"}}`,
+			expectedStatus: http.StatusOK,
+			expected:       `{"description": "kept"}`,
+		},
+		{
+			name:           "Envelope without inline flag is a plain document",
+			query:          "",
+			input:          `{"config": {"MaxDepth": 1}, "data": {"a": 1}}`,
+			expectedStatus: http.StatusOK,
+			expected:       `{"config": {"MaxDepth": 1}, "data": {"a": 1}}`,
+		},
+		{
+			name:           "Unknown inline config field",
+			query:          "?inline=true",
+			input:          `{"config": {"MaxDepth": 1}, "data": {}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid inline config value",
+			query:          "?inline=true",
+			input:          `{"config": {"sample-strategy": "frist_last"}, "data": {}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/slim"+tt.query, bytes.NewBufferString(tt.input))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expected == "" {
+				return
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to decode result: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &want); err != nil {
+				t.Fatalf("Failed to decode expected: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	// The shared profile must not be modified by an inline override
+	if got := handler.profiles["aggressive"].BlockList[0]; got != "description" {
+		t.Errorf("Inline config mutated the aggressive profile: BlockList[0] = %q", got)
+	}
+}
+
+func TestSlimHandlerInlineKeepsProfile(t *testing.T) {
+	profile := func() slimjson.Config {
+		suffix := "~"
+		return slimjson.Config{
+			DecimalPlaces:      2,
+			FieldDecimalPlaces: map[string]int{"lat": 3},
+			MaxStringLength:    4,
+			TruncationSuffix:   &suffix,
+			StripEmpty:         true,
+			EmptyValues:        []interface{}{"n/a"},
+			PreserveFields:     []string{"id"},
+			DropIfEquals:       map[string][]interface{}{"status": {"x"}},
+			Rules:              []slimjson.PathRule{{Path: "logs", Config: slimjson.Config{MaxListLength: 1}}},
+		}
+	}
+	handler := New(Options{Profiles: map[string]slimjson.Profile{"p": {Config: profile()}}})
+	input := `{"id": "abcdefgh", "name": "abcdefgh", "lat": 1.23456, "status": "x", "note": "n/a", "logs": [1, 2, 3]}`
+	before := slimWithProfile(handler, "p", input)
+
+	envelope := `{"config": {"truncation-suffix": "!!", "field-decimal-places": {"lat": 0}, "empty-values": ["none"], ` +
+		`"preserve-fields": ["name"], "drop-if": {"status": ["y"]}, "rules": [{"path": "logs", "config": {"max-list-length": 2}}]}, "data": ` + input + `}`
+	req := httptest.NewRequest(http.MethodPost, "/slim?inline=true&profile=p", strings.NewReader(envelope))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Body.String() == before {
+		t.Errorf("Expected the inline config to change the result, got %s", before)
+	}
+
+	if got := handler.profiles["p"]; !reflect.DeepEqual(got, profile()) {
+		t.Errorf("Inline config mutated the profile: %+v", got)
+	}
+	if after := slimWithProfile(handler, "p", input); after != before {
+		t.Errorf("Expected the profile to slim as before the inline request, %s, got %s", before, after)
+	}
+}
+
+func TestSlimHandlerWarnOnExpansion(t *testing.T) {
+	var logs bytes.Buffer
+	handler := New(Options{
+		Profiles: map[string]slimjson.Profile{
+			"heavy": {Config: slimjson.Config{DecimalPlaces: -1, StringPooling: true, EnumDetection: true, EmitVersion: true, WarnOnExpansion: true}},
+		},
+		Logger: log.New(&logs, "", 0),
+	})
+	req := httptest.NewRequest(http.MethodPost, "/slim?profile=heavy", strings.NewReader(`{"s":["abcd","abcd"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(logs.String(), `Warning: /slim with profile "heavy": output is larger than the input, 21 -> `) {
+		t.Errorf("Expected an expansion warning, got %q", logs.String())
+	}
+	if !strings.Contains(w.Body.String(), `"_strings":["abcd"]`) {
+		t.Errorf("Expected the slimmed document, got %s", w.Body.String())
+	}
+}
+
+func TestSlimHandlerQueryConfig(t *testing.T) {
+	handler := New(Options{
+		Profiles: map[string]slimjson.Profile{"short": {Config: slimjson.Config{MaxListLength: 3, StripEmpty: true}}},
+	})
+	input := `{"list": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12], "secret": "x", "empty": ""}`
+	envelope := `{"config": {"max-list-length": 1}, "data": ` + input + `}`
+
+	tests := []struct {
+		name           string
+		query          string
+		contentType    string
+		input          string
+		expectedStatus int
+		expected       string
+	}{
+		{
+			name:           "Default",
+			input:          input,
+			expectedStatus: http.StatusOK,
+			expected:       `{"list": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10], "secret": "x"}`,
+		},
+		{
+			name:           "Profile over default",
+			query:          "?profile=short",
+			input:          input,
+			expectedStatus: http.StatusOK,
+			expected:       `{"list": [1, 2, 3], "secret": "x"}`,
+		},
+		{
+			name:           "Query over profile",
+			query:          "?profile=short&list-len=2",
+			input:          input,
+			expectedStatus: http.StatusOK,
+			expected:       `{"list": [1, 2], "secret": "x"}`,
+		},
+		{
+			name:           "Query over default",
+			query:          "?block=secret,list&strip-empty=false",
+			input:          input,
+			expectedStatus: http.StatusOK,
+			expected:       `{"empty": ""}`,
+		},
+		{
+			name:           "Repeated parameter",
+			query:          "?block=secret&block=empty&strip-empty=false",
+			input:          input,
+			expectedStatus: http.StatusOK,
+			expected:       `{"list": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]}`,
+		},
+		{
+			name:           "Envelope over query",
+			query:          "?profile=short&list-len=2&inline_config=1",
+			input:          envelope,
+			expectedStatus: http.StatusOK,
+			expected:       `{"list": [1], "secret": "x"}`,
+		},
+		{
+			name:           "Envelope content type",
+			query:          "?list-len=2",
+			contentType:    "application/vnd.slimjson+json",
+			input:          envelope,
+			expectedStatus: http.StatusOK,
+			expected:       `{"list": [1], "secret": "x"}`,
+		},
+		{
+			name:           "Invalid value",
+			query:          "?depth=deep",
+			input:          input,
+			expectedStatus: http.StatusBadRequest,
+			expected:       "Invalid query parameter depth: invalid depth value: deep",
+		},
+		{
+			name:           "Unknown parameter",
+			query:          "?profile=short&colour=red",
+			input:          input,
+			expectedStatus: http.StatusBadRequest,
+			expected:       "Invalid query parameter colour: unknown parameter: colour",
+		},
+		{
+			name:           "Invalid combination",
+			query:          "?inline-enum-threshold=2",
+			input:          input,
+			expectedStatus: http.StatusBadRequest,
+			expected:       "Invalid query parameters: inline-enum-threshold requires enum-detection",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/slim"+tt.query, strings.NewReader(tt.input))
+			req.Header.Set("Content-Type", cmp.Or(tt.contentType, "application/json"))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				if !strings.Contains(w.Body.String(), tt.expected) {
+					t.Errorf("Expected body containing %q, got %q", tt.expected, w.Body.String())
+				}
+				return
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to decode result: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &want); err != nil {
+				t.Fatalf("Failed to decode expected: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	// Query parameters must not modify the shared profile
+	if got := handler.profiles["short"].MaxListLength; got != 3 {
+		t.Errorf("Query parameters modified the profile: MaxListLength = %d", got)
+	}
+}
+
+func TestSlimHandlerStats(t *testing.T) {
+	handler := New(Options{})
+	input := `{
+		"users": [{"id": 1, "name": "Alice", "bio": ""}, {"id": 2, "name": "Bob", "tags": []}],
+		"description": "` + strings.Repeat("x", 40) + `"
+	}`
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(input)); err != nil {
+		t.Fatalf("Failed to compact input: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		profile string
+	}{
+		{name: "Default config", query: "", profile: "default"},
+		{name: "Profile", query: "?profile=Medium", profile: "medium"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/slim"+tt.query, strings.NewReader(input))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			body := bytes.TrimSuffix(w.Body.Bytes(), []byte("\n"))
+			reduction := strconv.FormatFloat(math.Round((1-float64(len(body))/float64(compact.Len()))*1000)/10, 'f', 1, 64)
+			expected := map[string]string{
+				"X-SlimJSON-Original-Bytes":    strconv.Itoa(compact.Len()),
+				"X-SlimJSON-Slimmed-Bytes":     strconv.Itoa(len(body)),
+				"X-SlimJSON-Reduction-Percent": reduction,
+				"X-SlimJSON-Tokens-Estimated":  strconv.Itoa(slimjson.EstimateTokens(body)),
+				"X-SlimJSON-Profile":           tt.profile,
+			}
+			for header, want := range expected {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("Stats in the body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/slim?profile=medium&include_stats=1", strings.NewReader(input))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+			Stats  struct {
+				OriginalBytes    int     `json:"original_bytes"`
+				SlimmedBytes     int     `json:"slimmed_bytes"`
+				ReductionPercent float64 `json:"reduction_percent"`
+				TokensEstimated  int     `json:"tokens_estimated"`
+				Profile          string  `json:"profile"`
+			} `json:"stats"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Stats.OriginalBytes != compact.Len() || resp.Stats.SlimmedBytes != len(resp.Result) || resp.Stats.Profile != "medium" {
+			t.Errorf("stats = %+v, want %d original and %d slimmed bytes with profile medium", resp.Stats, compact.Len(), len(resp.Result))
+		}
+		if resp.Stats.ReductionPercent <= 0 || resp.Stats.TokensEstimated != slimjson.EstimateTokens(resp.Result) {
+			t.Errorf("stats = %+v, want a positive reduction and %d tokens", resp.Stats, slimjson.EstimateTokens(resp.Result))
+		}
+		if got := w.Header().Get("X-SlimJSON-Slimmed-Bytes"); got != strconv.Itoa(len(resp.Result)) {
+			t.Errorf("X-SlimJSON-Slimmed-Bytes = %q, want the size of the result, %d", got, len(resp.Result))
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tradik/slimjson"
+)
+
+// unslimHandler returns the handler for the /unslim endpoint, which restores a
+// document slimmed by /slim with UnslimLenient. Metadata it cannot expand is
+// passed through, and the X-SlimJSON-Applied-Transforms and
+// X-SlimJSON-Kept-Transforms headers list the transforms reversed and kept.
+// The other X-SlimJSON-* headers are those /slim would send for the pair,
+// with the response as the original; only a _slimjson marker of another
+// version or a _checksum mismatch is rejected with 400.
+func (s *Server) unslimHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !isJSONContentType(r.Header.Get("Content-Type")) {
+			http.Error(w, "Unsupported Content-Type: expected application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+		if !s.openBody(w, r) {
+			return
+		}
+
+		var data interface{}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			writeBodyError(w, "Invalid JSON", err)
+			return
+		}
+		result, report, err := slimjson.UnslimLenient(data)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid slimjson metadata: %v", err), http.StatusBadRequest)
+			return
+		}
+		restored, err := json.Marshal(result)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to encode result: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		st := slimjson.Stats{OriginalBytes: len(restored), OriginalTokens: slimjson.EstimateTokens(restored)}
+		if slimmed, err := json.Marshal(data); err == nil {
+			st.SlimmedBytes, st.SlimmedTokens = len(slimmed), slimjson.EstimateTokens(slimmed)
+		}
+		h := w.Header()
+		newSlimStats(st, "").setHeaders(h)
+		h.Del("X-SlimJSON-Profile")
+		h.Set("X-SlimJSON-Applied-Transforms", strings.Join(report.Applied, ", "))
+		h.Set("X-SlimJSON-Kept-Transforms", strings.Join(report.Kept, ", "))
+		h.Set("Content-Type", "application/json")
+		_, _ = w.Write(append(restored, '\n'))
+	}
+}
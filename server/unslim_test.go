@@ -0,0 +1,200 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tradik/slimjson"
+)
+
+func TestUnslimEndpoint(t *testing.T) {
+	input, err := os.ReadFile("../testing/fixtures/users.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	// Slim through /slim with a reversible inline config
+	envelope := `{"config":{"lossless":true,"decimal-places":-1,"max-depth":0,"max-list-length":0,"strip-empty":false,` +
+		`"string-pooling":true,"type-inference":true,"shorten-keys":true,"checksum":true},"data":` + string(input) + `}`
+	req := httptest.NewRequest(http.MethodPost, "/slim?inline=true", strings.NewReader(envelope))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler := New(Options{})
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("/slim status %d: %s", w.Code, w.Body.String())
+	}
+	slimmed := w.Body.String()
+	if len(slimmed) >= len(input) {
+		t.Errorf("Expected slimmed output smaller than %d bytes, got %d", len(input), len(slimmed))
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/unslim", strings.NewReader(slimmed))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("/unslim status %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", ct)
+	}
+	var want, got interface{}
+	if err := json.Unmarshal(input, &want); err != nil {
+		t.Fatalf("Failed to unmarshal fixture: %v", err)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("/unslim did not restore the original document:\n%s", w.Body.String())
+	}
+}
+
+func TestUnslimRoundTrip(t *testing.T) {
+	// Small integers would be taken for string pool indices, which only
+	// Lossless output, where nothing is removed, tells apart
+	var orders []interface{}
+	for i := range 12 {
+		orders = append(orders, map[string]interface{}{
+			"id":     fmt.Sprintf("order-%d", i),
+			"status": []string{"shipped", "pending", "cancelled"}[i%3],
+			"total":  float64(100 + 10*i),
+			"note":   nil,
+			"tags":   []interface{}{},
+		})
+	}
+	input, err := json.Marshal(map[string]interface{}{"owner": "Ada", "orders": orders})
+	if err != nil {
+		t.Fatalf("Failed to marshal input: %v", err)
+	}
+	handler := New(Options{})
+
+	req := httptest.NewRequest(http.MethodPost, "/slim?string-pooling=true&type-inference=true", bytes.NewReader(input))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("/slim status %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/unslim", bytes.NewReader(gzipBytes(t, w.Body.Bytes())))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("/unslim status %d: %s", w.Code, w.Body.String())
+	}
+	if got, want := w.Header().Get("X-SlimJSON-Applied-Transforms"), "string-pooling, type-inference"; got != want {
+		t.Errorf("Expected applied transforms %q, got %q", want, got)
+	}
+	if got := w.Header().Get("X-SlimJSON-Kept-Transforms"); got != "" {
+		t.Errorf("Expected no kept transforms, got %q", got)
+	}
+	if got, want := w.Header().Get("X-SlimJSON-Original-Bytes"), strconv.Itoa(w.Body.Len()-1); got != want {
+		t.Errorf("Expected X-SlimJSON-Original-Bytes %s, got %s", want, got)
+	}
+
+	// The original minus what the default profile removes: empty values and
+	// orders past the tenth
+	var original, got interface{}
+	if err := json.Unmarshal(input, &original); err != nil {
+		t.Fatalf("Failed to unmarshal input: %v", err)
+	}
+	want, err := json.Marshal(slimjson.New(DefaultRequestConfig()).Slim(original))
+	if err != nil {
+		t.Fatalf("Failed to marshal expected result: %v", err)
+	}
+	if err := json.Unmarshal(want, &original); err != nil {
+		t.Fatalf("Failed to unmarshal expected result: %v", err)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !reflect.DeepEqual(got, original) {
+		t.Errorf("/unslim did not restore the slimmed document:\n%s\nwant:\n%s", w.Body.String(), want)
+	}
+}
+
+func TestUnslimPassThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		applied string
+		kept    string
+	}{
+		{name: "Unknown metadata", input: `{"a":{"_future":[1]},"_strings":["xyz"],"b":0}`, want: `{"a":{"_future":[1]},"b":"xyz"}`, applied: "string-pooling"},
+		{name: "Missing data", input: `{"u":{"_schema":["a"]},"v":{"_range":[1,2]}}`, want: `{"u":{"_schema":["a"]},"v":[1,2]}`, applied: "number-delta", kept: "type-inference"},
+		{name: "Malformed pool", input: `{"a":0,"_strings":[1]}`, want: `{"_strings":[1],"a":0}`, kept: "string-pooling"},
+		{name: "Irreversible", input: `{"a":0,"_nulls":["b"]}`, want: `{"_nulls":["b"],"a":0}`, kept: "null-compression"},
+	}
+
+	handler := New(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/unslim", strings.NewReader(tt.input))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+			if got := w.Header().Get("X-SlimJSON-Applied-Transforms"); got != tt.applied {
+				t.Errorf("Expected applied transforms %q, got %q", tt.applied, got)
+			}
+			if got := w.Header().Get("X-SlimJSON-Kept-Transforms"); got != tt.kept {
+				t.Errorf("Expected kept transforms %q, got %q", tt.kept, got)
+			}
+		})
+	}
+}
+
+func TestUnslimHandlerErrors(t *testing.T) {
+	handler := New(Options{MaxBodyBytes: 64})
+
+	tests := []struct {
+		name           string
+		method         string
+		contentType    string
+		input          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "Wrong method", method: http.MethodGet, contentType: "application/json", input: `{}`, expectedStatus: http.StatusMethodNotAllowed},
+		{name: "Wrong content type", method: http.MethodPost, contentType: "text/plain", input: `{}`, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "Oversized body", method: http.MethodPost, contentType: "application/json", input: `{"a":"` + strings.Repeat("x", 128) + `"}`, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Invalid JSON", method: http.MethodPost, contentType: "application/json", input: `{"a":`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid JSON"},
+		{name: "Unsupported version", method: http.MethodPost, contentType: "application/json", input: `{"a":0,"_slimjson":{"v":99}}`, expectedStatus: http.StatusBadRequest, expectedBody: "Invalid slimjson metadata: unsupported _slimjson version 99"},
+		{name: "Checksum mismatch", method: http.MethodPost, contentType: "application/json", input: `{"a":0,"_checksum":"sha256:0"}`, expectedStatus: http.StatusBadRequest, expectedBody: "checksum mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/unslim", strings.NewReader(tt.input))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body containing %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
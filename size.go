@@ -0,0 +1,133 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// Bounds of the MaxOutputBytes search
+const (
+	maxSizeRounds      = 10 // Bisection rounds after the first two passes
+	minSizeListLength  = 1  // Lowest MaxListLength tried
+	minSizeStringLimit = 16 // Lowest MaxStringLength tried
+)
+
+// slimToSize slims data and, if the result encoded as compact JSON is larger than
+// MaxOutputBytes, searches for the highest MaxListLength and MaxStringLength that fit.
+//
+// Both limits start at their configured value, or at the longest list or string
+// in the first result if unlimited, and are scaled down together by one factor.
+// The floors (minSizeListLength and minSizeStringLimit, or the configured limit
+// if lower) are tried next: if they do not fit, that result gets a _truncated
+// marker with its size. Otherwise the factor is bisected for at most
+// maxSizeRounds rounds, so Slim runs at most maxSizeRounds+4 times.
+//
+// Trial rounds do not call OnRemove or record Explain changes; the chosen limits
+// are run once more with them. Path rules keep their own limits.
+func (s *Slimmer) slimToSize(data interface{}) interface{} {
+	orig, changes := s.Config, s.changes
+	defer func() { s.Config, s.changes = orig, changes }()
+	s.Config.OnRemove, s.changes = nil, nil
+
+	limit := orig.MaxOutputBytes
+	result := s.slim(data)
+	size := encodedSize(result)
+	if size <= limit && orig.OnRemove == nil && changes == nil {
+		return result
+	}
+
+	if size > limit {
+		listLen, strLen := orig.MaxListLength, orig.MaxStringLength
+		if listLen == 0 || strLen == 0 {
+			longestList, longestString := longestValues(result)
+			if listLen == 0 {
+				listLen = longestList
+			}
+			if strLen == 0 {
+				strLen = longestString
+			}
+		}
+		listFloor, strFloor := min(listLen, minSizeListLength), min(strLen, minSizeStringLimit)
+
+		// scaled sets both limits to factor (0 to 1) of their start value
+		scaled := func(factor float64) (int, int) {
+			return max(listFloor, int(float64(listLen)*factor)), max(strFloor, int(float64(strLen)*factor))
+		}
+		try := func(factor float64) bool {
+			s.Config.MaxListLength, s.Config.MaxStringLength = scaled(factor)
+			s.Config.SampleSize = min(orig.SampleSize, s.Config.MaxListLength)
+			result = s.slim(data)
+			size = encodedSize(result)
+			return size <= limit
+		}
+
+		if try(0) {
+			fits, tooBig := 0.0, 1.0
+			for round := 0; round < maxSizeRounds; round++ {
+				mid := (fits + tooBig) / 2
+				midList, midStr := scaled(mid)
+				if fitsList, fitsStr := scaled(fits); midList == fitsList && midStr == fitsStr {
+					break
+				}
+				if try(mid) {
+					fits = mid
+				} else {
+					tooBig = mid
+				}
+			}
+			if size > limit {
+				try(fits)
+			}
+		}
+	}
+
+	if orig.OnRemove != nil || changes != nil {
+		s.Config.OnRemove, s.changes = orig.OnRemove, changes
+		result = s.slim(data)
+		size, changes = encodedSize(result), s.changes
+	}
+
+	if size > limit {
+		marker := map[string]interface{}{"bytes": size, "max-output-bytes": limit}
+		switch r := result.(type) {
+		case map[string]interface{}:
+			r["_truncated"] = marker
+		case *OrderedMap:
+			r.Set("_truncated", marker)
+		case []interface{}:
+			result = append(r, map[string]interface{}{"_truncated": marker})
+		}
+	}
+	return result
+}
+
+// encodedSize returns the length of v encoded as compact JSON
+func encodedSize(v interface{}) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// longestValues returns the length of the longest list and string (in runes) in a Slim result
+func longestValues(v interface{}) (list, str int) {
+	switch val := v.(type) {
+	case string:
+		return 0, utf8.RuneCountInString(val)
+	case []interface{}:
+		list = len(val)
+		for _, item := range val {
+			l, s := longestValues(item)
+			list, str = max(list, l), max(str, s)
+		}
+	case map[string]interface{}:
+		for _, item := range val {
+			l, s := longestValues(item)
+			list, str = max(list, l), max(str, s)
+		}
+	case *OrderedMap:
+		return longestValues(val.Values)
+	}
+	return list, str
+}
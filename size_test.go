@@ -0,0 +1,116 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// largeDocument builds an object with n users, each with a long bio and tag list
+func largeDocument(n int) map[string]interface{} {
+	users := make([]interface{}, n)
+	for i := range users {
+		tags := make([]interface{}, 30)
+		for j := range tags {
+			tags[j] = fmt.Sprintf("tag-%d-%d", i, j)
+		}
+		users[i] = map[string]interface{}{
+			"id":   float64(i),
+			"name": fmt.Sprintf("User %d", i),
+			"bio":  strings.Repeat(fmt.Sprintf("Biography of user %d. ", i), 40),
+			"tags": tags,
+		}
+	}
+	return map[string]interface{}{"users": users, "total": float64(n)}
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+	doc := largeDocument(500)
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "Unlimited lists and strings", config: Config{DecimalPlaces: -1, MaxOutputBytes: 8192}},
+		{name: "Configured limits", config: Config{DecimalPlaces: -1, MaxListLength: 100, MaxStringLength: 500, MaxOutputBytes: 8192}},
+		{name: "Sampling", config: Config{DecimalPlaces: -1, SampleStrategy: "first_last", SampleSize: 50, MaxOutputBytes: 4096}},
+		{name: "Sorted keys", config: Config{DecimalPlaces: -1, SortKeys: true, MaxOutputBytes: 2048}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.config)
+			data, err := json.Marshal(s.Slim(doc))
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if len(data) > tt.config.MaxOutputBytes {
+				t.Errorf("Expected at most %d bytes, got %d", tt.config.MaxOutputBytes, len(data))
+			}
+			if len(data) < tt.config.MaxOutputBytes/2 {
+				t.Errorf("Expected the output to use more of the %d byte budget, got %d bytes", tt.config.MaxOutputBytes, len(data))
+			}
+			if strings.Contains(string(data), "_truncated") {
+				t.Errorf("Expected no _truncated marker for output that fits, got %s", data)
+			}
+			if s.Config.MaxListLength != tt.config.MaxListLength || s.Config.MaxStringLength != tt.config.MaxStringLength {
+				t.Errorf("Expected the config to be restored, got %+v", s.Config)
+			}
+		})
+	}
+}
+
+func TestMaxOutputBytesFits(t *testing.T) {
+	doc := map[string]interface{}{"items": []interface{}{"a", "b", "c"}}
+	got := New(Config{DecimalPlaces: -1, MaxOutputBytes: 1000}).Slim(doc)
+	expected := New(Config{DecimalPlaces: -1}).Slim(doc)
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected output that fits to be unchanged, got %v, want %v", got, expected)
+	}
+}
+
+func TestMaxOutputBytesMarker(t *testing.T) {
+	// Many short fields cannot be shortened by list or string limits
+	doc := make(map[string]interface{})
+	for i := 0; i < 200; i++ {
+		doc[fmt.Sprintf("field%03d", i)] = float64(i)
+	}
+
+	result := New(Config{DecimalPlaces: -1, MaxOutputBytes: 500}).Slim(doc).(map[string]interface{})
+	marker, ok := result["_truncated"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a _truncated marker, got %v", result["_truncated"])
+	}
+	if marker["max-output-bytes"] != 500 || marker["bytes"].(int) <= 500 {
+		t.Errorf("Unexpected marker %v", marker)
+	}
+
+	arr := New(Config{DecimalPlaces: -1, MaxOutputBytes: 10}).Slim([]interface{}{
+		map[string]interface{}{"a": 1.0, "b": 2.0},
+	}).([]interface{})
+	if last, ok := arr[len(arr)-1].(map[string]interface{}); !ok || last["_truncated"] == nil {
+		t.Errorf("Expected a trailing _truncated element, got %v", arr)
+	}
+}
+
+func TestMaxOutputBytesCallbacks(t *testing.T) {
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = float64(i)
+	}
+
+	removed := 0
+	cfg := Config{DecimalPlaces: -1, MaxOutputBytes: 200,
+		OnRemove: func(path string, reason RemoveReason, value interface{}) { removed++ }}
+	s := New(cfg)
+	result, changes := s.Explain(map[string]interface{}{"items": items})
+
+	kept := len(result.(map[string]interface{})["items"].([]interface{}))
+	if removed != len(items)-kept {
+		t.Errorf("Expected OnRemove once per removed element (%d), got %d", len(items)-kept, removed)
+	}
+	if len(changes) != 1 || changes[0].To != kept {
+		t.Errorf("Expected one change truncating to %d elements, got %+v", kept, changes)
+	}
+}
@@ -2,100 +2,403 @@
 package slimjson
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"maps"
 	"math"
 	"math/rand/v2"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // Config holds the configuration for the slimming process.
+// JSON tags match the .slimjson config file keys.
 type Config struct {
 	// MaxDepth is the maximum nesting depth allowed.
 	// Objects/Arrays deeper than this will be truncated (removed or replaced).
 	// 0 means no limit (or use a very high default if preferred, but let's say 0 is unlimited).
 	// However, to "cut too deep nesting", we should probably default to something reasonable if 0.
 	// Let's make 0 mean "unlimited" and user must set it, or we handle it in logic.
-	MaxDepth int
+	MaxDepth int `json:"max-depth,omitempty"`
+
+	// DepthTruncationMarker is what replaces a value cut by MaxDepth: "null"
+	// (the default), "empty" for {} or [] by the type of the object or array
+	// (other values still become null), or "ellipsis" for "...". Empty and
+	// ellipsis markers are kept under StripEmpty, except for objects and arrays
+	// with nothing but blocked fields and empty values, which are removed.
+	// TruncationSummaries takes precedence.
+	DepthTruncationMarker string `json:"depth-truncation-marker,omitempty"`
+
+	// ReportDepthElision replaces a non-empty object or array cut by MaxDepth
+	// with {"_depth_elided": N}, where N is its number of keys or elements
+	// (without blocked fields, and empty values under StripEmpty), so
+	// the output shows that data existed below the cut. It takes precedence over
+	// DepthTruncationMarker for objects and arrays; TruncationSummaries takes
+	// precedence over both.
+	ReportDepthElision bool `json:"report-depth-elision,omitempty"`
 
 	// MaxListLength is the maximum number of elements allowed in a list.
 	// Elements beyond this count are removed.
-	MaxListLength int
+	MaxListLength int `json:"max-list-length,omitempty"`
 
 	// MaxStringLength is the maximum number of characters (runes) allowed in a string.
 	// Strings longer than this will be truncated.
-	MaxStringLength int
+	MaxStringLength int `json:"max-string-length,omitempty"`
+
+	// TruncateBoundary controls where MaxStringLength cuts a string: "rune"
+	// (the default) cuts at any character, "grapheme" never splits a
+	// user-perceived character such as an emoji ZWJ sequence, a flag or a letter
+	// with combining marks, and "word" cuts at the last space before the limit,
+	// falling back to "grapheme" for a single long word.
+	TruncateBoundary string `json:"truncate-boundary,omitempty"`
+
+	// StringLengthUnit is the unit of MaxStringLength: "runes" (the default),
+	// "bytes" of UTF-8, or "tokens" as counted by EstimateTokens. Strings are
+	// still cut between runes, so a multibyte character is never split.
+	StringLengthUnit string `json:"string-length-unit,omitempty"`
+
+	// TruncationSuffix is appended to strings shortened by MaxStringLength,
+	// "..." if nil. An empty suffix cuts strings without a marker. The suffix
+	// counts toward MaxStringLength, and is left out if it does not fit with
+	// at least one unit of the string.
+	TruncationSuffix *string `json:"truncation-suffix,omitempty"`
+
+	// MaxOutputBytes is the maximum size of the result encoded as compact JSON (0 = unlimited).
+	// If the result is larger, Slim lowers MaxListLength and MaxStringLength and slims
+	// again until it fits. A result that still does not fit gets a _truncated marker.
+	MaxOutputBytes int `json:"max-output-bytes,omitempty"`
 
 	// StripEmpty removes fields with null values, empty strings, empty arrays, or empty objects.
-	StripEmpty bool
+	StripEmpty bool `json:"strip-empty,omitempty"`
 
-	// BlockList is a list of field names to remove.
-	BlockList []string
+	// EmptyValues lists additional values StripEmpty removes, such as "N/A"
+	// or "-". They are compared with each input value before it is slimmed;
+	// numbers compare by value, so listing 0 also removes 0.0. Zero, false
+	// and other legitimate values are only removed when they are listed.
+	EmptyValues []interface{} `json:"empty-values,omitempty"`
 
-	// DecimalPlaces rounds floats to N decimal places (-1 = no rounding, default)
-	DecimalPlaces int
+	// KeepNull keeps explicit nulls under StripEmpty, which still removes
+	// empty strings, arrays and objects, so a field that is present but null
+	// stays distinct from one that is absent. Kept nulls are not listed in
+	// _nulls by NullCompression.
+	KeepNull bool `json:"keep-null,omitempty"`
 
-	// DeduplicateArrays removes duplicate values from arrays
-	DeduplicateArrays bool
+	// BlockList is a list of field names to remove.
+	BlockList []string `json:"block-list,omitempty"`
 
-	// SampleStrategy defines array sampling strategy: "none", "first_last", "random", "representative"
-	SampleStrategy string
+	// PreserveFields lists field names (case-insensitive) or dotted paths without
+	// array indices ("user.signature") whose values are kept exactly as they are:
+	// nothing in their subtree is truncated, sampled, rounded, pooled, stripped,
+	// removed or cut by MaxDepth. They take precedence over BlockList, DropIfEquals,
+	// StripEmpty and path rules. ShortenKeys and SortKeys, which Unslim reverses,
+	// still apply to the keys inside them.
+	PreserveFields []string `json:"preserve-fields,omitempty"`
+
+	// DecimalPlaces rounds floats to N decimal places (-1 = no rounding, default)
+	DecimalPlaces int `json:"decimal-places,omitempty"`
+
+	// FieldDecimalPlaces overrides DecimalPlaces for the floats of matching
+	// fields, e.g. {"price": 2, "lat": 6}. Keys are field names or dotted paths
+	// without array indices, matched case-insensitively; a path takes precedence
+	// over a name. Floats in an array match the field holding the array.
+	// -1 disables rounding for the field.
+	FieldDecimalPlaces map[string]int `json:"field-decimal-places,omitempty"`
+
+	// DeduplicateArrays removes duplicate values from arrays. Objects and arrays are
+	// compared structurally, so objects with the same keys in another order are duplicates.
+	DeduplicateArrays bool `json:"deduplicate-arrays,omitempty"`
+
+	// SampleStrategy defines array sampling strategy: "none", "first_last", "random", "representative",
+	// "largest", "smallest" or "frequency". largest/smallest keep the N elements with the highest/lowest
+	// value, numbers by value and objects by their SampleSortKey field, in their original order.
+	// frequency keeps the N most common distinct values, most common first.
+	// stratified samples objects from each SampleStratifyKey group in proportion to its size.
+	SampleStrategy string `json:"sample-strategy,omitempty"`
+
+	// SampleSortKey is the field (or dotted path) that ranks objects for the "largest"
+	// and "smallest" sample strategies
+	SampleSortKey string `json:"sample-sort-key,omitempty"`
+
+	// SampleStratifyKey is the field (or dotted path) that groups objects for the
+	// "stratified" sample strategy, e.g. "level" for logs. Arrays without the field
+	// are sampled like "representative".
+	SampleStratifyKey string `json:"sample-stratify-key,omitempty"`
+
+	// SampleCounts replaces each value kept by the "frequency" sample strategy
+	// with {"_value": value, "_count": occurrences}
+	SampleCounts bool `json:"sample-counts,omitempty"`
 
 	// SampleSize is the number of items to keep when sampling (0 = use MaxListLength)
-	SampleSize int
+	SampleSize int `json:"sample-size,omitempty"`
+
+	// RandomSeed makes "random" sampling reproducible: every Slim call with the
+	// same seed and input keeps the same elements (0 = a new random seed per call)
+	RandomSeed int64 `json:"random-seed,omitempty"`
 
 	// NullCompression tracks removed null fields in _nulls array
-	NullCompression bool
+	NullCompression bool `json:"null-compression,omitempty"`
 
 	// TypeInference converts uniform arrays to schema+data format
-	TypeInference bool
+	TypeInference bool `json:"type-inference,omitempty"`
+
+	// TypeInferenceColumnar emits type-inferred arrays column-major as _schema+_cols
+	// instead of row-major _schema+_data. Requires TypeInference.
+	TypeInferenceColumnar bool `json:"type-inference-columnar,omitempty"`
+
+	// MatrixColumnar converts uniform arrays of numeric rows, such as
+	// [[1,2,3],[4,5,6],[7,8,9]], to column-major {"_matrix": {"cols": 3,
+	// "data": [[1,4,7],[2,5,8],[3,6,9]]}}, the non-object counterpart of
+	// TypeInferenceColumnar. Columns are delta encoded independently when
+	// NumberDeltaEncoding is set.
+	MatrixColumnar bool `json:"matrix-columnar,omitempty"`
+
+	// RunLengthEncode replaces arrays of strings, numbers and bools with runs of
+	// equal consecutive values, such as ["ok","ok","ok","err"], with
+	// {"_rle": [["ok", 3], ["err", 1]]} when that is smaller. It is tried
+	// before DeduplicateArrays and sampling, and used only when the runs fit
+	// in SampleSize or MaxListLength, so encoded arrays keep every element.
+	RunLengthEncode bool `json:"run-length-encode,omitempty"`
 
 	// BoolCompression converts booleans to bit flags
-	BoolCompression bool
+	BoolCompression bool `json:"bool-compression,omitempty"`
+
+	// BoolArrayPacking replaces arrays of at least 16 booleans, such as presence
+	// bitmaps, with {"_bits": "<base64>", "n": length} when that is smaller.
+	// Element i is bit i%8, least significant first, of byte i/8. Like
+	// RunLengthEncode it is tried before DeduplicateArrays and sampling, so
+	// packed arrays keep every element. BoolCompression packs the booleans of
+	// objects instead.
+	BoolArrayPacking bool `json:"bool-array-packing,omitempty"`
 
 	// TimestampCompression converts ISO timestamps to unix timestamps
-	TimestampCompression bool
+	TimestampCompression bool `json:"timestamp-compression,omitempty"`
 
 	// StringPooling deduplicates repeated strings using a string pool
-	StringPooling bool
+	StringPooling bool `json:"string-pooling,omitempty"`
 
 	// StringPoolMinOccurrences minimum occurrences for string to be pooled (default: 2)
-	StringPoolMinOccurrences int
+	StringPoolMinOccurrences int `json:"string-pool-min,omitempty"`
 
 	// NumberDeltaEncoding uses delta encoding for sequential numbers
-	NumberDeltaEncoding bool
+	NumberDeltaEncoding bool `json:"number-delta,omitempty"`
 
 	// NumberDeltaThreshold minimum array size for delta encoding (default: 5)
-	NumberDeltaThreshold int
+	NumberDeltaThreshold int `json:"number-delta-threshold,omitempty"`
 
 	// EnumDetection converts repeated categorical values to enum indices
-	EnumDetection bool
+	EnumDetection bool `json:"enum-detection,omitempty"`
 
 	// EnumMaxValues maximum unique values to consider as enum (default: 10)
-	EnumMaxValues int
+	EnumMaxValues int `json:"enum-max-values,omitempty"`
+
+	// InlineEnumThreshold leaves a field's values inline, without an _enums
+	// entry, unless the field occurs more than this many times (0 = always
+	// extracted), so rarely repeated fields do not cost a table entry
+	InlineEnumThreshold int `json:"inline-enum-threshold,omitempty"`
+
+	// ShortenKeys replaces frequently repeated object keys with short aliases
+	// (k0, k1, ...) and lists the original names in a _keys dictionary at the root
+	ShortenKeys bool `json:"shorten-keys,omitempty"`
+
+	// MinSavingsBytes keeps type inference, defaults, number delta encoding and
+	// bool compression of a value, and the _strings and _enums tables, only when
+	// they make the compact JSON output at least this many bytes smaller, so
+	// slimming small inputs never makes them bigger (0 = always applied). It
+	// also lets type inference try two-element arrays. Each check encodes the
+	// value both ways, which costs extra time.
+	MinSavingsBytes int `json:"min-savings-bytes,omitempty"`
+
+	// DropIfEquals removes fields whose value equals one of the listed values.
+	// Keys are field names or dotted paths without array indices (e.g. "meta.status").
+	// Numbers compare by value, so 0 matches 0.0. Applied before StripEmpty.
+	DropIfEquals map[string][]interface{} `json:"drop-if,omitempty"`
+
+	// DropIfEqualsIgnoreCase compares strings in DropIfEquals case-insensitively
+	DropIfEqualsIgnoreCase bool `json:"drop-if-ignore-case,omitempty"`
+
+	// StripHTML removes HTML tags and comments, and the content of script and
+	// style elements, from strings, keeping the visible text; tags of block
+	// elements such as <p> and <br> become a space. It is a lightweight
+	// scanner, not an HTML parser: a "<" that does not start a complete tag,
+	// as in "a < b", is kept. Applied before the other string options, so
+	// MaxStringLength counts the cleaned text.
+	StripHTML bool `json:"strip-html,omitempty"`
+
+	// StripMarkdown removes common Markdown syntax from strings: heading
+	// hashes, emphasis and strikethrough markers, link and image URLs, keeping
+	// their text, and code fence lines. Code blocks and inline code are kept
+	// verbatim, and markers are removed only in matching pairs at word
+	// boundaries, so snake_case and 2*3*4 are left alone. Applied after
+	// StripHTML.
+	StripMarkdown bool `json:"strip-markdown,omitempty"`
+
+	// DecodeHTMLEntities replaces HTML entities in strings, such as &amp;,
+	// &nbsp; and &#39;, with their characters, after StripHTML and
+	// StripMarkdown
+	DecodeHTMLEntities bool `json:"decode-html-entities,omitempty"`
+
+	// NormalizeUnicode applies a Unicode normalization form to strings before
+	// StripUTF8Emoji and MaxStringLength: "NFC", "NFD", "NFKC" or "none" (the
+	// default). NFD keeps the base letter of accented characters when
+	// StripUTF8Emoji removes the accents, and NFKC folds compatibility
+	// characters such as full-width letters and ligatures to their plain forms.
+	NormalizeUnicode string `json:"normalize-unicode,omitempty"`
+
+	// CleanWhitespace replaces Unicode spaces such as U+00A0 (no-break space)
+	// with an ASCII space and line separators with a newline, and removes
+	// zero-width characters such as U+200B and U+FEFF from strings.
+	CleanWhitespace bool `json:"clean-whitespace,omitempty"`
+
+	// NormalizeQuotes replaces curly quotes (U+2018/2019, U+201C/201D...),
+	// guillemets, dashes (U+2013 en dash, U+2014 em dash...) and U+2026
+	// (ellipsis) in strings with their ASCII equivalents ' " - and ...
+	NormalizeQuotes bool `json:"normalize-quotes,omitempty"`
+
+	// CollapseWhitespace replaces each run of whitespace in strings, such as
+	// the indentation and line breaks of text copied from source files, with
+	// a single space, and trims leading and trailing whitespace. Applied after
+	// the other string options, so the spaces left by removed characters
+	// collapse too, and before MaxStringLength.
+	CollapseWhitespace bool `json:"collapse-whitespace,omitempty"`
+
+	// CollapseWhitespaceKeepNewlines makes CollapseWhitespace replace runs
+	// that contain a line break with a single newline instead of a space
+	CollapseWhitespaceKeepNewlines bool `json:"keep-newlines,omitempty"`
 
 	// StripUTF8Emoji removes emoji and other non-ASCII characters from strings
 	// This can significantly reduce token count for LLM contexts
-	StripUTF8Emoji bool
+	StripUTF8Emoji bool `json:"strip-emoji,omitempty"`
+
+	// Defaults maps field names to default values. In arrays of objects, fields
+	// equal to their default are removed and listed once under _defaults.
+	// Under StripEmpty, empty defaults never match because empty fields are already removed.
+	Defaults map[string]interface{} `json:"defaults,omitempty"`
+
+	// DetectDefaults uses the most common value of each field across an array of objects as its default
+	DetectDefaults bool `json:"detect-defaults,omitempty"`
+
+	// Flatten merges nested objects into their parent using dotted keys
+	// ({"data": {"name": "x"}} becomes {"data.name": "x"}). Arrays are not flattened.
+	// BlockList entries also match the dotted path of a field.
+	Flatten bool `json:"flatten,omitempty"`
+
+	// FlattenMaxDepth is the maximum number of segments in a flattened key (0 = unlimited)
+	FlattenMaxDepth int `json:"flatten-max-depth,omitempty"`
+
+	// FlattenSingleKeyChains merges objects with a single key into their parent using
+	// dotted keys ({"data": {"result": {"id": 1, "name": "x"}}} becomes
+	// {"data.result": {"id": 1, "name": "x"}}). Collapsed levels do not count toward
+	// MaxDepth. It is skipped if the root is not an object or any key contains a dot,
+	// so Unslim can restore the nesting. FlattenMaxDepth limits the segments per key.
+	FlattenSingleKeyChains bool `json:"flatten-single-key-chains,omitempty"`
+
+	// SortKeys makes Slim return objects as *OrderedMap with keys sorted alphabetically,
+	// recursively including metadata objects, for canonical byte-identical output.
+	SortKeys bool `json:"sort-keys,omitempty"`
+
+	// PreserveKeyOrder makes SlimBytes and SlimStream decode objects as *OrderedMap and
+	// return objects in their original key order. Slim keeps the order of any
+	// *OrderedMap in its input. Generated metadata keys follow the original keys.
+	// SortKeys takes precedence.
+	PreserveKeyOrder bool `json:"preserve-key-order,omitempty"`
+
+	// TruncationSummaries describes content cut by MaxDepth and MaxListLength.
+	// An object whose values are cut by MaxDepth becomes {"_truncated": {"keys": [...], "depth": N}},
+	// where N is the object's nesting depth (root = 0). An array whose elements are cut
+	// becomes [{"_omitted": N}], and a shortened array gets a trailing {"_omitted": N} element.
+	TruncationSummaries bool `json:"truncation-summaries,omitempty"`
+
+	// Lossless makes Validate and NewStrict reject every option that loses data
+	// or that Unslim cannot reverse, so Unslim restores the exact input. Reversible
+	// transforms are skipped for inputs they cannot encode unambiguously: string
+	// pooling when the input has integers that could be read as pool indices,
+	// Flatten when the root is not an object or a key contains a dot. Input keys
+	// that Slim uses for metadata (_schema, _keys, _flat, ...) are not supported.
+	Lossless bool `json:"lossless,omitempty"`
+
+	// Checksum stores a SHA-256 hash of the input's canonical JSON form in
+	// _checksum at the root, and Unslim returns an error when the restored
+	// document does not match it. Requires Lossless; inputs whose root is not
+	// an object get no checksum.
+	Checksum bool `json:"checksum,omitempty"`
+
+	// EmitVersion adds a _slimjson marker to the root, {"v": FormatVersion,
+	// "features": [...]}, listing the enabled options that change the output
+	// format by config key, so decoders can tell how to read it. No marker is
+	// added when none are enabled or the root is not an object.
+	EmitVersion bool `json:"emit-version,omitempty"`
+
+	// WarnOnExpansion makes the CLI and the daemon log a warning when the
+	// slimmed document is larger than the input, which usually means the
+	// profile does not suit the data. Slim itself does not check it; use
+	// SlimWithStats to detect expansion in Go.
+	WarnOnExpansion bool `json:"warn-on-expansion,omitempty"`
+
+	// Parallelism prunes the elements of arrays with at least 512 elements on
+	// that many goroutines, each taking a contiguous share, and reassembles
+	// them in order; the output is the same as without it. Only the outermost
+	// such array of each branch fans out, and "random" sampling, whose draws
+	// depend on the order elements are visited in, stays serial. OnRemove and
+	// ValueTransform are called concurrently. 0 or 1 = serial.
+	Parallelism int `json:"parallelism,omitempty"`
+
+	// OnRemove is called for every field or array element that Slim drops and
+	// every string it shortens, with the path, the reason and the value before
+	// slimming. It is called after the value was removed and cannot change the
+	// output. It must be safe for concurrent use if the Config is shared.
+	OnRemove func(path string, reason RemoveReason, value interface{}) `json:"-"`
+
+	// Rules slim selected parts of the document with their own Config, e.g. a
+	// low MaxDepth for "$.logs[*]" while "$.summary" is left untouched.
+	// The most specific matching rule applies to the matched value and its subtree.
+	Rules []PathRule `json:"rules,omitempty"`
+
+	// ValueTransform rewrites leaf values (strings, numbers, booleans and nulls)
+	// before the built-in string and number processing. It receives the dot path
+	// ("users.3.id") and the original value and returns the replacement and true,
+	// or false to keep the original. Returning nil removes the value under StripEmpty.
+	// It must be safe for concurrent use if the Config is shared.
+	ValueTransform func(path string, value interface{}) (interface{}, bool) `json:"-"`
 }
 
 // Slimmer provides methods to slim down JSON data.
 type Slimmer struct {
 	Config     Config
-	stringPool map[string]int      // String -> index mapping
+	stringPool map[string]int      // String -> index mapping, nil without StringPooling
 	stringList []string            // Index -> string mapping
-	enumPools  map[string][]string // Field -> enum values
+	enumPools  map[string][]string // Field -> enum values, nil without EnumDetection
 	nullFields []string            // Tracked null fields
+
+	flattened   bool // At least one object was flattened
+	literalDots bool // Input contains keys with dots, so flattening is not reversible
+	chainsOK    bool // FlattenSingleKeyChains can be applied to this input
+	flattenOK   bool // Flatten can be applied to this input
+
+	keyOrder map[string]map[string]int // Field path -> key -> position, from *OrderedMap input
+	rules    []*compiledRule           // Config.Rules with parsed selectors
+	rng      *rand.Rand                // Random sampling source, reseeded by each Slim
+	changes  []Change                  // Lossy edits, collected only by Explain
+
+	ctx     context.Context // Of SlimContext, nil otherwise
+	visited int             // Values visited since SlimContext started
+
+	forked bool // Prunes a share of an array for Parallelism, see fork
 }
 
 // New creates a new Slimmer with the given config.
 func New(cfg Config) *Slimmer {
-	s := &Slimmer{
-		Config:     cfg,
-		stringPool: make(map[string]int),
-		stringList: make([]string, 0),
-		enumPools:  make(map[string][]string),
-		nullFields: make([]string, 0),
-	}
+	s := &Slimmer{Config: cfg}
 
 	// Set default values if not specified
 	if cfg.StringPoolMinOccurrences == 0 {
@@ -111,16 +414,48 @@ func New(cfg Config) *Slimmer {
 	return s
 }
 
+// NewStrict creates a new Slimmer like New, but returns an error if cfg is invalid.
+func NewStrict(cfg Config) (*Slimmer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return New(cfg), nil
+}
+
 // Slim processes the input data (expected to be map[string]interface{}, []interface{}, or basic types)
 // and returns the slimmed version.
 func (s *Slimmer) Slim(data interface{}) interface{} {
+	if s.Config.MaxOutputBytes > 0 {
+		return s.slimToSize(data)
+	}
+	return s.slim(data)
+}
+
+// slimPass runs a single pass of Slim with the current Config
+func (s *Slimmer) slimPass(data interface{}) interface{} {
+	// Statistics describe this input only. The pools are only allocated by
+	// the statistics pass, which no other option needs.
+	s.stringPool, s.stringList = nil, nil
+	s.enumPools, s.nullFields = nil, nil
+
 	// First pass: collect statistics for string pooling and enum detection
 	if s.Config.StringPooling || s.Config.EnumDetection {
 		s.collectStatistics(data)
 	}
+	// Pool indices must not be confused with numbers from the input
+	if s.Config.Lossless && len(s.stringList) > 0 && hasPoolIndex(data, len(s.stringList)) {
+		s.stringPool, s.stringList = nil, nil
+	}
+
+	s.flattened, s.literalDots = false, false
+	s.keyOrder = nil
+	s.compileRules()
+	s.rng = s.newRand()
+	s.chainsOK = s.usesChains() && isObject(data) && !hasDottedKey(data)
+	s.flattenOK = !s.Config.Lossless || (isObject(data) && !hasDottedKey(data))
 
 	// Second pass: prune and apply transformations
-	result := s.prune(data, 0)
+	result := s.prune(data, 0, "")
 
 	// Post-process: add metadata if needed
 	if resultMap, ok := result.(map[string]interface{}); ok {
@@ -136,48 +471,343 @@ func (s *Slimmer) Slim(data interface{}) interface{} {
 
 		// Add null fields if tracked
 		if s.Config.NullCompression && len(s.nullFields) > 0 {
+			slices.Sort(s.nullFields)
 			resultMap["_nulls"] = s.nullFields
 		}
+
+		// Mark flattened output so Unslim can restore nesting
+		if s.flattened && !s.literalDots {
+			resultMap["_flat"] = true
+		}
+	}
+
+	if s.Config.SortKeys {
+		result = sortKeys(result)
+	} else if s.keyOrder != nil {
+		result = s.orderKeys(result, "")
+	}
+
+	// Keys are shortened last so ordering still sees the original names
+	if s.Config.ShortenKeys {
+		result = shortenKeys(result)
+	}
+
+	if s.Config.Checksum {
+		switch r := result.(type) {
+		case map[string]interface{}:
+			r["_checksum"] = checksum(data)
+		case *OrderedMap:
+			r.Set("_checksum", checksum(data))
+		}
+	}
+	if s.Config.EmitVersion {
+		if marker := versionMarker(s.Config); marker != nil {
+			switch r := result.(type) {
+			case map[string]interface{}:
+				r["_slimjson"] = marker
+			case *OrderedMap:
+				r.Set("_slimjson", marker)
+			}
+		}
 	}
 
 	return result
 }
 
-func (s *Slimmer) prune(data interface{}, depth int) interface{} {
+// SlimBytes decodes a JSON document and slims it.
+// With PreserveKeyOrder, objects in the result keep their original key order.
+func (s *Slimmer) SlimBytes(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	v, err := s.decode(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON document")
+	}
+	return s.Slim(v), nil
+}
+
+// SlimStream reads one JSON document from r and writes the slimmed document to w
+// as a single line of JSON. It returns io.EOF if r contains no document.
+//
+// A top-level array is read one element at a time when the config allows it
+// (see streamArray), so "none" and "random" sampling keep only the sampled
+// elements in memory: O(k) for a sample of k elements rather than O(n).
+func (s *Slimmer) SlimStream(r io.Reader, w io.Writer) error {
+	return s.SlimNext(json.NewDecoder(r), w)
+}
+
+// SlimNext is like SlimStream, but reads the next JSON document from dec, so
+// a stream of concatenated documents can be slimmed one document at a time.
+// It returns io.EOF when dec has no more documents.
+func (s *Slimmer) SlimNext(dec *json.Decoder, w io.Writer) error {
+	if !dec.More() {
+		// io.EOF at the end of the input, or the error of a stray ] or }
+		var v interface{}
+		return dec.Decode(&v)
+	}
+	// More leaves the next value's first byte at the start of the buffer
+	first := make([]byte, 1)
+	if _, err := dec.Buffered().Read(first); err != nil {
+		return err
+	}
+	if first[0] == '[' && s.streamable() {
+		return s.streamArray(dec, w)
+	}
+
+	v, err := s.decode(dec)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(s.Slim(v))
+}
+
+// decode reads the next JSON value, as *OrderedMap objects when PreserveKeyOrder is set
+func (s *Slimmer) decode(dec *json.Decoder) (interface{}, error) {
+	if s.Config.PreserveKeyOrder {
+		return decodeOrdered(dec)
+	}
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
+
+// prune slims a single value. path is the dot-separated location of the value,
+// with array elements addressed by index (e.g. "users.3.name").
+func (s *Slimmer) prune(data interface{}, depth int, path string) interface{} {
+	s.checkContext()
+	if s.Config.ValueTransform != nil && isLeaf(data) {
+		if v, ok := s.Config.ValueTransform(path, data); ok {
+			data = v
+		}
+	}
+
 	if data == nil {
 		return s.handleNil()
 	}
 
+	// Slim values selected by a path rule with the rule's config
+	if rule := s.matchRule(path); rule != nil {
+		return s.pruneRule(rule, data, path)
+	}
+	return s.pruneValue(data, depth, path)
+}
+
+// pruneValue slims a non-nil value with the current config
+func (s *Slimmer) pruneValue(data interface{}, depth int, path string) interface{} {
 	// Check depth
-	if s.Config.MaxDepth > 0 && depth >= s.Config.MaxDepth {
-		return nil
+	if s.depthExceeded(depth) {
+		s.removed(path, ReasonDepthExceeded, data)
+		return s.depthMarker(data)
+	}
+
+	// Remember the key order of ordered objects and prune their values as a map
+	if om, ok := data.(*OrderedMap); ok {
+		s.recordKeyOrder(fieldPath(path), om.Keys)
+		data = om.Values
+	}
+
+	// Values decoded by encoding/json skip reflection
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return s.pruneMap(v, depth, path)
+	case []interface{}:
+		return s.pruneArray(v, depth, path, data)
+	case string:
+		return s.pruneString(v, path)
+	case float64:
+		return s.pruneFloat(v, path, data)
+	case bool:
+		return data
 	}
+	return s.pruneReflect(data, depth, path)
+}
 
+// pruneReflect slims a value of another type than those encoding/json decodes
+// to, such as map[string]string, []string or float32, by its kind. Maps and
+// slices are copied to map[string]interface{} and []interface{} for pruning.
+func (s *Slimmer) pruneReflect(data interface{}, depth int, path string) interface{} {
 	val := reflect.ValueOf(data)
-
 	switch val.Kind() {
 	case reflect.Map:
-		return s.pruneMap(val, depth)
+		if val.Len() == 0 {
+			if s.Config.StripEmpty {
+				return nil
+			}
+			return data
+		}
+		m := make(map[string]interface{}, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return s.pruneMap(m, depth, path)
 	case reflect.Slice, reflect.Array:
-		return s.pruneArray(val, depth, data)
-
+		arr := make([]interface{}, val.Len())
+		for i := range arr {
+			arr[i] = val.Index(i).Interface()
+		}
+		return s.pruneArray(arr, depth, path, data)
 	case reflect.String:
-		return s.pruneString(val)
-
+		return s.pruneString(val.String(), path)
 	case reflect.Float32, reflect.Float64:
-		// Round floats if DecimalPlaces is set
-		if s.Config.DecimalPlaces >= 0 {
-			floatVal := val.Float()
-			multiplier := math.Pow(10, float64(s.Config.DecimalPlaces))
-			return math.Round(floatVal*multiplier) / multiplier
-		}
-		return data
-
+		return s.pruneFloat(val.Float(), path, data)
 	default:
 		return data
 	}
 }
 
+// pruneFloat rounds f, the value of data, if DecimalPlaces or
+// FieldDecimalPlaces is set for path
+func (s *Slimmer) pruneFloat(f float64, path string, data interface{}) interface{} {
+	if places := s.decimalPlaces(path); places >= 0 {
+		multiplier := math.Pow(10, float64(places))
+		return math.Round(f*multiplier) / multiplier
+	}
+	return data
+}
+
+// decimalPlaces returns the rounding precision for a float at path: the
+// FieldDecimalPlaces entry of its dotted path or field name, or DecimalPlaces
+func (s *Slimmer) decimalPlaces(path string) int {
+	if len(s.Config.FieldDecimalPlaces) == 0 {
+		return s.Config.DecimalPlaces
+	}
+	fp := fieldPath(path)
+	name := fp[strings.LastIndexByte(fp, '.')+1:]
+	found, places := false, s.Config.DecimalPlaces
+	for field, n := range s.Config.FieldDecimalPlaces {
+		if strings.EqualFold(field, fp) && strings.Contains(field, ".") {
+			return n
+		}
+		if !found && strings.EqualFold(field, name) {
+			found, places = true, n
+		}
+	}
+	return places
+}
+
+// depthMarker returns the _depth_elided annotation or DepthTruncationMarker
+// that replaces data cut by MaxDepth. Under StripEmpty, an object or array
+// with nothing but blocked or empty values is removed rather than marked, so
+// the objects above it can be removed too.
+func (s *Slimmer) depthMarker(data interface{}) interface{} {
+	n, container := s.keptChildren(data)
+	if container && n == 0 && s.Config.StripEmpty {
+		return nil
+	}
+	if s.Config.ReportDepthElision && n > 0 {
+		return map[string]interface{}{"_depth_elided": n}
+	}
+	switch s.Config.DepthTruncationMarker {
+	case "ellipsis":
+		return "..."
+	case "empty":
+		if _, ok := data.(*OrderedMap); ok {
+			return map[string]interface{}{}
+		}
+		switch reflect.ValueOf(data).Kind() {
+		case reflect.Map:
+			return map[string]interface{}{}
+		case reflect.Slice, reflect.Array:
+			return []interface{}{}
+		}
+	}
+	return nil
+}
+
+// keptChildren returns the number of keys or elements of data, an object or
+// array cut by MaxDepth, that slimming it would keep: those of fields that
+// are not blocked whose values keptBelowCut. container is false if data is
+// neither an object nor an array.
+func (s *Slimmer) keptChildren(data interface{}) (n int, container bool) {
+	if om, ok := data.(*OrderedMap); ok {
+		data = om.Values
+	}
+	switch val := reflect.ValueOf(data); val.Kind() {
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			if !s.isBlocked(iter.Key().String()) && s.keptBelowCut(iter.Value().Interface()) {
+				n++
+			}
+		}
+		return n, true
+	case reflect.Slice, reflect.Array:
+		for i := range val.Len() {
+			if s.keptBelowCut(val.Index(i).Interface()) {
+				n++
+			}
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// keptBelowCut reports whether v, a value below a MaxDepth cut, has content
+// slimming would keep. Without StripEmpty it always does; with it, v must not
+// be empty, and an object or array needs a field that is not blocked, or an
+// element, whose value has content. The search stops at the first one found.
+func (s *Slimmer) keptBelowCut(v interface{}) bool {
+	if !s.Config.StripEmpty {
+		return true
+	}
+	if om, ok := v.(*OrderedMap); ok {
+		v = om.Values
+	}
+	if s.isEmptyValue(v, v) {
+		return false
+	}
+	switch val := reflect.ValueOf(v); val.Kind() {
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			if !s.isBlocked(iter.Key().String()) && s.keptBelowCut(iter.Value().Interface()) {
+				return true
+			}
+		}
+		return false
+	case reflect.Slice, reflect.Array:
+		for i := range val.Len() {
+			if s.keptBelowCut(val.Index(i).Interface()) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// isDepthMarker reports whether v at depth is an empty {} or [] marker of a
+// value cut by MaxDepth, which StripEmpty keeps
+func (s *Slimmer) isDepthMarker(v interface{}, depth int) bool {
+	return v != nil && s.Config.DepthTruncationMarker == "empty" && s.depthExceeded(depth)
+}
+
+// isPreserved reports whether the field key at path is listed in PreserveFields
+func (s *Slimmer) isPreserved(key, path string) bool {
+	for _, name := range s.Config.PreserveFields {
+		if strings.EqualFold(name, key) || (strings.Contains(name, ".") && strings.EqualFold(name, fieldPath(path))) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPreserved reports whether the object m at path has a preserved field
+func (s *Slimmer) hasPreserved(m map[string]interface{}, path string) bool {
+	if len(s.Config.PreserveFields) == 0 {
+		return false
+	}
+	for k := range m {
+		if s.isPreserved(k, joinPath(path, k)) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Slimmer) isBlocked(key string) bool {
 	for _, blocked := range s.Config.BlockList {
 		if strings.EqualFold(blocked, key) {
@@ -187,6 +817,101 @@ func (s *Slimmer) isBlocked(key string) bool {
 	return false
 }
 
+// shouldDrop reports whether a field matches a DropIfEquals rule by name or path
+func (s *Slimmer) shouldDrop(key, path string, val interface{}) bool {
+	if len(s.Config.DropIfEquals) == 0 {
+		return false
+	}
+	values, ok := s.Config.DropIfEquals[key]
+	if !ok {
+		values, ok = s.Config.DropIfEquals[fieldPath(path)]
+	}
+	if !ok {
+		return false
+	}
+	for _, dropVal := range values {
+		if looseEqual(val, dropVal, s.Config.DropIfEqualsIgnoreCase) {
+			return true
+		}
+	}
+	return false
+}
+
+// looseEqual compares two values with JSON semantics: numbers by value
+// regardless of Go type, strings optionally case-insensitive.
+func looseEqual(a, b interface{}, ignoreCase bool) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	if as, ok := a.(string); ok {
+		bs, ok := b.(string)
+		if !ok {
+			return false
+		}
+		if ignoreCase {
+			return strings.EqualFold(as, bs)
+		}
+		return as == bs
+	}
+	return canonicalJSON(plainMaps(a)) == canonicalJSON(plainMaps(b))
+}
+
+// toFloat converts any Go numeric value to float64
+func toFloat(v interface{}) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+	return 0, false
+}
+
+// joinPath appends a segment to a dot-separated path
+func joinPath(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
+
+// fieldPath strips array indices from a path ("users.3.name" -> "users.name")
+func fieldPath(path string) string {
+	if !strings.ContainsAny(path, "0123456789") {
+		return path
+	}
+	parts := strings.Split(path, ".")
+	kept := parts[:0]
+	for _, part := range parts {
+		if _, err := strconv.Atoi(part); err != nil {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, ".")
+}
+
+// isLeaf reports whether val is a JSON scalar or null rather than an object or array
+func isLeaf(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+	if _, ok := val.(*OrderedMap); ok {
+		return false
+	}
+	switch reflect.ValueOf(val).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return false
+	}
+	return true
+}
+
 func isEmpty(val interface{}) bool {
 	if val == nil {
 		return true
@@ -201,16 +926,32 @@ func isEmpty(val interface{}) bool {
 	return false
 }
 
+// isEmptyValue reports whether StripEmpty removes a value, given both the
+// input value and its slimmed form. EmptyValues are matched against the input
+// so slimming, such as string pooling, never turns a value into a match.
+func (s *Slimmer) isEmptyValue(original, pruned interface{}) bool {
+	if original == nil && s.Config.KeepNull {
+		return false
+	}
+	if isEmpty(pruned) {
+		return true
+	}
+	for _, empty := range s.Config.EmptyValues {
+		if looseEqual(original, empty, false) {
+			return true
+		}
+	}
+	return false
+}
+
 // deduplicateArray removes duplicate values from an array
 func (s *Slimmer) deduplicateArray(arr []interface{}) []interface{} {
-	seen := make(map[string]bool)
+	seen := make(structuralSet)
 	result := make([]interface{}, 0, len(arr))
 
 	for _, item := range arr {
-		// Create a simple string representation for comparison
-		key := valueToString(item)
-		if !seen[key] {
-			seen[key] = true
+		// Structurally equal values (e.g. objects with reordered keys) are duplicates
+		if seen.add(item) {
 			result = append(result, item)
 		}
 	}
@@ -226,67 +967,216 @@ func (s *Slimmer) handleNil() interface{} {
 }
 
 // pruneArray handles array/slice pruning
-func (s *Slimmer) pruneArray(val reflect.Value, depth int, data interface{}) interface{} {
-	if val.Len() == 0 {
+func (s *Slimmer) pruneArray(arr []interface{}, depth int, path string, data interface{}) interface{} {
+	if len(arr) == 0 {
 		if s.Config.StripEmpty {
 			return nil
 		}
 		return data
 	}
-
-	// First, prune all elements
-	fullList := make([]interface{}, 0, val.Len())
-	for i := 0; i < val.Len(); i++ {
-		v := val.Index(i).Interface()
-		prunedV := s.prune(v, depth+1)
-
-		if s.Config.StripEmpty && isEmpty(prunedV) {
+	s.recordDepthCut(path, depth)
+	if s.Config.TruncationSummaries && s.depthExceeded(depth+1) {
+		n, _ := s.keptChildren(data)
+		if n == 0 {
+			return nil // Only empty elements, under StripEmpty
+		}
+		return []interface{}{map[string]interface{}{"_omitted": n}}
+	}
+
+	// First, prune all elements into a pooled working list, which is copied
+	// before it could escape into the result
+	buf := getList(len(arr))
+	defer putList(buf)
+	fullList := *buf
+	var fullIdx []int // Original index of each element, tracked only by Explain and OnRemove
+	trackIdx := s.changes != nil || s.Config.OnRemove != nil
+	var pruned []interface{}
+	var kept []bool
+	if workers := s.parallelWorkers(len(arr)); workers > 1 {
+		pruned, kept = s.pruneElementsParallel(arr, depth, path, workers)
+	}
+	for i := range arr {
+		var prunedV interface{}
+		var keep bool
+		if pruned != nil {
+			prunedV, keep = pruned[i], kept[i]
+		} else {
+			prunedV, keep = s.pruneElement(arr, i, depth, path)
+		}
+		if !keep {
 			continue
 		}
 		fullList = append(fullList, prunedV)
+		if trackIdx {
+			fullIdx = append(fullIdx, i)
+		}
+	}
+	*buf = fullList
+
+	// Run-length encode repeated values and pack booleans before deduplication
+	// and sampling drop them, keeping the smaller encoding if both apply
+	var packed interface{}
+	if s.Config.RunLengthEncode {
+		packed = s.applyRunLength(fullList)
+	}
+	if s.Config.BoolArrayPacking {
+		if bits := s.applyBitPacking(fullList); bits != nil && (packed == nil || encodedSize(bits) < encodedSize(packed)) {
+			packed = bits
+		}
+	}
+	if packed != nil {
+		return packed
 	}
 
 	// Apply deduplication if enabled
 	if s.Config.DeduplicateArrays {
+		n := len(fullList)
 		fullList = s.deduplicateArray(fullList)
+		if len(fullList) < n {
+			s.record(Change{Path: path, Kind: ChangeTruncatedArray, Reason: "deduplicate", From: n, To: len(fullList)})
+		}
 	}
 
 	// Apply sampling strategy
-	finalList := s.sampleArray(fullList)
+	var finalList []interface{}
+	if trackIdx && len(fullIdx) == len(fullList) {
+		finalList = s.sampleTracked(fullList, fullIdx, path, arr)
+	} else {
+		finalList = s.sampleArray(fullList)
+	}
+	if len(finalList) < len(fullList) {
+		s.record(Change{Path: path, Kind: ChangeTruncatedArray, Reason: s.sampleReason(), From: len(fullList), To: len(finalList)})
+	}
+	omitted := len(fullList) - len(finalList)
+	if s.Config.SampleCounts && s.Config.SampleStrategy == "frequency" {
+		finalList = annotateCounts(fullList, finalList)
+	}
 
 	if s.Config.StripEmpty && len(finalList) == 0 {
 		return nil
 	}
+	if inList(finalList, buf) {
+		finalList = slices.Clone(finalList)
+	}
 
 	// Apply advanced array transformations
 	result := interface{}(finalList)
 
 	// Try type inference (schema+data format)
 	if s.Config.TypeInference {
-		result = s.applyTypeInference(finalList)
+		result = s.smaller(finalList, s.applyTypeInference(finalList, path))
+	}
+
+	// Try columnar encoding of numeric matrices
+	if s.Config.MatrixColumnar {
+		if arrResult, ok := result.([]interface{}); ok {
+			result = s.smaller(arrResult, s.applyMatrix(arrResult))
+		}
+	}
+
+	// Try sparse encoding against field defaults
+	if len(s.Config.Defaults) > 0 || s.Config.DetectDefaults {
+		if arrResult, ok := result.([]interface{}); ok {
+			result = s.smaller(arrResult, s.applyDefaults(arrResult))
+		}
 	}
 
 	// Try number delta encoding
 	if s.Config.NumberDeltaEncoding {
 		if arrResult, ok := result.([]interface{}); ok {
-			result = s.applyNumberDelta(arrResult)
+			result = s.smaller(arrResult, s.applyNumberDelta(arrResult))
+		}
+	}
+
+	// Note how many elements sampling left out
+	if s.Config.TruncationSummaries && omitted > 0 {
+		switch r := result.(type) {
+		case []interface{}:
+			result = append(r, map[string]interface{}{"_omitted": omitted})
+		case map[string]interface{}:
+			r["_omitted"] = omitted
 		}
 	}
 
 	return result
 }
 
+// pruneElement prunes element i of the array arr at depth and path, and
+// reports whether it is kept rather than stripped as empty
+func (s *Slimmer) pruneElement(arr []interface{}, i, depth int, path string) (interface{}, bool) {
+	v := arr[i]
+	elemPath := joinPath(path, strconv.Itoa(i))
+	prunedV := s.prune(v, depth+1, elemPath)
+
+	if s.Config.StripEmpty && s.isEmptyValue(v, prunedV) && !s.isDepthMarker(prunedV, depth+1) {
+		s.recordEmpty(elemPath, v, depth+1)
+		return nil, false
+	}
+	return prunedV, true
+}
+
+// truncatedObject summarizes an object whose values are all cut by MaxDepth.
+// Blocked fields are left out and, under StripEmpty, so are fields with
+// nothing but empty values; the object is removed if no field is left.
+func (s *Slimmer) truncatedObject(m map[string]interface{}, depth int, path string) interface{} {
+	keys := make([]string, 0, len(m))
+	for k, v := range m {
+		if !s.isBlocked(k) && s.keptBelowCut(v) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 && s.Config.StripEmpty {
+		return nil
+	}
+	slices.Sort(keys)
+	if s.keyOrder != nil {
+		slices.SortFunc(keys, func(a, b string) int {
+			return s.compareKeys(fieldPath(path), a, b)
+		})
+	}
+	for i, k := range keys {
+		keys[i] = s.truncateString(k)
+	}
+
+	return map[string]interface{}{
+		"_truncated": map[string]interface{}{
+			"keys":  keys,
+			"depth": depth,
+		},
+	}
+}
+
 // pruneString handles string pruning and transformations
-func (s *Slimmer) pruneString(val reflect.Value) interface{} {
-	str := val.String()
+func (s *Slimmer) pruneString(original, path string) interface{} {
+	str := original
 	if s.Config.StripEmpty && str == "" {
 		return nil
 	}
 
+	if s.Config.StripHTML {
+		str = stripHTML(str)
+	}
+	if s.Config.StripMarkdown {
+		str = stripMarkdown(str)
+	}
+	if s.Config.DecodeHTMLEntities {
+		str = html.UnescapeString(str)
+	}
+	str = normalizeUnicode(str, s.Config.NormalizeUnicode)
+	if s.Config.CleanWhitespace {
+		str = whitespaceReplacer.Replace(str)
+	}
+	if s.Config.NormalizeQuotes {
+		str = quoteReplacer.Replace(str)
+	}
+
 	// Strip emoji and non-ASCII characters if configured
 	if s.Config.StripUTF8Emoji {
 		str = stripEmoji(str)
 	}
+	if s.Config.CollapseWhitespace {
+		str = collapseWhitespace(str, s.Config.CollapseWhitespaceKeepNewlines)
+	}
 
 	// Apply string pooling
 	if s.Config.StringPooling {
@@ -301,166 +1191,478 @@ func (s *Slimmer) pruneString(val reflect.Value) interface{} {
 	}
 
 	// Apply string truncation if configured
-	if s.Config.MaxStringLength > 0 {
-		runes := []rune(str)
-		if len(runes) > s.Config.MaxStringLength {
-			// Truncate and add ellipsis to indicate truncation
-			if s.Config.MaxStringLength > 3 {
-				return string(runes[:s.Config.MaxStringLength-3]) + "..."
-			}
-			return string(runes[:s.Config.MaxStringLength])
+	if s.Config.MaxStringLength > 0 && s.stringLength(str) > s.Config.MaxStringLength {
+		if s.Config.OnRemove != nil {
+			s.Config.OnRemove(path, ReasonStringTruncated, original)
 		}
+		truncated := s.truncateString(str)
+		s.record(Change{Path: path, Kind: ChangeTruncatedString, Reason: "max-string-length",
+			From: utf8.RuneCountInString(str), To: utf8.RuneCountInString(truncated)})
+		return truncated
 	}
 	return str
 }
 
+// stringLength measures str in the StringLengthUnit
+func (s *Slimmer) stringLength(str string) int {
+	switch s.Config.StringLengthUnit {
+	case "bytes":
+		return len(str)
+	case "tokens":
+		return EstimateTokens([]byte(str))
+	}
+	return utf8.RuneCountInString(str)
+}
+
+// truncateString shortens str to MaxStringLength in the StringLengthUnit, cut
+// at the TruncateBoundary
+func (s *Slimmer) truncateString(str string) string {
+	if s.Config.MaxStringLength <= 0 || s.stringLength(str) <= s.Config.MaxStringLength {
+		return str
+	}
+	runes := []rune(str)
+
+	// Truncate and add the suffix to indicate truncation, if it leaves room for content
+	limit, suffix := s.Config.MaxStringLength, s.Config.truncationSuffix()
+	if s.stringLength(suffix) >= limit {
+		suffix = ""
+	}
+	var n int // Runes kept
+	switch s.Config.StringLengthUnit {
+	case "bytes", "tokens":
+		budget := limit
+		if s.Config.StringLengthUnit == "tokens" {
+			budget = limit * bytesPerToken // At most limit tokens by EstimateTokens
+		}
+		budget -= len(suffix)
+		for n < len(runes) && budget >= utf8.RuneLen(runes[n]) {
+			budget -= utf8.RuneLen(runes[n])
+			n++
+		}
+	default:
+		n = limit - utf8.RuneCountInString(suffix)
+	}
+	switch s.Config.TruncateBoundary {
+	case "grapheme":
+		n = graphemeCut(runes, n)
+	case "word":
+		n = wordCut(runes, n)
+	}
+	return string(runes[:n]) + suffix
+}
+
+// truncationSuffix returns the TruncationSuffix, "..." by default
+func (c Config) truncationSuffix() string {
+	if c.TruncationSuffix == nil {
+		return "..."
+	}
+	return *c.TruncationSuffix
+}
+
+// graphemeCut returns the largest cut of runes at most n that does not split
+// a grapheme cluster
+func graphemeCut(runes []rune, n int) int {
+	for n > 0 && n < len(runes) && extendsCluster(runes, n) {
+		n--
+	}
+	return n
+}
+
+// extendsCluster reports whether runes[i] belongs to the grapheme cluster of
+// runes[i-1]. It covers combining marks and Hangul jamo (through their
+// normalization properties), emoji ZWJ sequences, variation selectors, skin
+// tone modifiers, tag sequences, regional indicator pairs and CR LF.
+func extendsCluster(runes []rune, i int) bool {
+	prev, r := runes[i-1], runes[i]
+	switch {
+	case prev == '\r' && r == '\n',
+		prev == '\u200d' || r == '\u200d',
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Variation_Selector),
+		r >= 0x1f3fb && r <= 0x1f3ff, // Emoji modifiers (skin tones)
+		r >= 0xe0020 && r <= 0xe007f, // Tags of subdivision flags
+		!norm.NFC.PropertiesString(string(r)).BoundaryBefore():
+		return true
+	case isRegionalIndicator(prev) && isRegionalIndicator(r):
+		// Flags are pairs of regional indicators, counted from the first
+		pairs := 0
+		for j := i - 1; j >= 0 && isRegionalIndicator(runes[j]); j-- {
+			pairs++
+		}
+		return pairs%2 == 1
+	}
+	return false
+}
+
+// isRegionalIndicator reports whether r is one of the letters of flag emoji
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// wordCut returns the cut of runes at most n before the last space, without
+// trailing spaces, or the grapheme cut if the first word is longer than n
+func wordCut(runes []rune, n int) int {
+	for i := n; i > 0; i-- {
+		if !unicode.IsSpace(runes[i]) {
+			continue
+		}
+		for i > 0 && unicode.IsSpace(runes[i-1]) {
+			i--
+		}
+		if i > 0 {
+			return i
+		}
+		break
+	}
+	return graphemeCut(runes, n)
+}
+
 // pruneMap handles map/object pruning
-func (s *Slimmer) pruneMap(val reflect.Value, depth int) interface{} {
-	if val.Len() == 0 {
+func (s *Slimmer) pruneMap(m map[string]interface{}, depth int, path string) interface{} {
+	if len(m) == 0 {
 		if s.Config.StripEmpty {
 			return nil
 		}
-		return val.Interface()
+		return m
+	}
+	s.recordDepthCut(path, depth)
+	if s.Config.TruncationSummaries && s.depthExceeded(depth+1) && !s.hasPreserved(m, path) {
+		return s.truncatedObject(m, depth, path)
 	}
 
 	newMap := make(map[string]interface{})
-	iter := val.MapRange()
-	for iter.Next() {
-		k := iter.Key().String()
-		v := iter.Value().Interface()
+	var preserved map[string]interface{} // Only needed with PreserveFields
+	if len(s.Config.PreserveFields) > 0 {
+		preserved = make(map[string]interface{})
+	}
+	for k, v := range m {
+		childPath := joinPath(path, k)
+
+		// Preserved fields skip every other option, including the merging below
+		if preserved != nil && s.isPreserved(k, childPath) {
+			if s.Config.Flatten && strings.Contains(k, ".") {
+				s.literalDots = true
+			}
+			preserved[k] = v
+			continue
+		}
 
 		// Check BlockList
-		if s.isBlocked(k) {
+		if s.isBlocked(k) || (s.Config.Flatten && s.isBlocked(fieldPath(childPath))) {
+			s.removed(childPath, ReasonBlocked, v)
+			s.record(Change{Path: childPath, Kind: ChangeRemoved, Reason: "blocked"})
+			continue
+		}
+
+		if s.Config.Flatten && strings.Contains(k, ".") {
+			s.literalDots = true
+		}
+
+		// Drop fields matching a DropIfEquals value (before StripEmpty)
+		if s.shouldDrop(k, childPath, v) {
+			s.removed(childPath, ReasonDropIf, v)
+			s.record(Change{Path: childPath, Kind: ChangeRemoved, Reason: "drop-if"})
 			continue
 		}
 
 		// Track null fields if null compression is enabled
-		if v == nil && s.Config.NullCompression {
+		if v == nil && s.Config.NullCompression && !s.Config.KeepNull {
 			s.nullFields = append(s.nullFields, k)
 		}
 
-		prunedV := s.prune(v, depth+1)
+		// A single-key object merged into this one does not use a level of depth
+		childDepth := depth + 1
+		if s.chainsOK && s.Config.FlattenSingleKeyChains && isSingleKeyChain(v) {
+			childDepth = depth
+		}
+		prunedV := s.prune(v, childDepth, childPath)
 
-		if s.Config.StripEmpty && isEmpty(prunedV) {
+		if s.Config.StripEmpty && s.isEmptyValue(v, prunedV) && !s.isDepthMarker(prunedV, childDepth) {
+			s.recordEmpty(childPath, v, depth+1)
 			continue
 		}
 
 		newMap[k] = prunedV
 	}
 
-	if s.Config.StripEmpty && len(newMap) == 0 {
+	if s.Config.StripEmpty && len(newMap) == 0 && len(preserved) == 0 {
 		return nil
 	}
 
+	if s.Config.Flatten && s.flattenOK {
+		newMap = s.flattenMap(newMap)
+	}
+	if s.chainsOK && s.Config.FlattenSingleKeyChains {
+		newMap = s.flattenChains(newMap)
+	}
+
 	// Apply boolean compression if enabled
 	if s.Config.BoolCompression {
-		newMap = s.applyBoolCompression(newMap)
+		newMap = s.compressBools(newMap)
 	}
 
+	maps.Copy(newMap, preserved)
 	return newMap
 }
 
 // sampleArray applies sampling strategy to reduce array size
 func (s *Slimmer) sampleArray(arr []interface{}) []interface{} {
-	if len(arr) == 0 {
-		return arr
+	indices := s.sampleIndices(arr)
+	if indices == nil {
+		return arr // No sampling needed
+	}
+	result := make([]interface{}, len(indices))
+	for i, idx := range indices {
+		result[i] = arr[idx]
 	}
+	return result
+}
 
-	// Determine target size
-	targetSize := s.Config.SampleSize
-	if targetSize == 0 && s.Config.MaxListLength > 0 {
-		targetSize = s.Config.MaxListLength
+// sampleIndices returns the indices of the elements of arr kept by the
+// sampling strategy, or nil if arr is kept whole
+func (s *Slimmer) sampleIndices(arr []interface{}) []int {
+	targetSize := s.sampleTarget()
+	if targetSize == 0 {
+		return nil
 	}
-	if targetSize == 0 || targetSize >= len(arr) {
-		return arr // No sampling needed
+	// Frequency sampling also drops repeated values from short arrays
+	if s.Config.SampleStrategy == "frequency" {
+		return sampleFrequency(arr, targetSize)
+	}
+	if targetSize >= len(arr) {
+		return nil
 	}
 
 	switch s.Config.SampleStrategy {
 	case "first_last":
-		return s.sampleFirstLast(arr, targetSize)
+		return sampleFirstLast(len(arr), targetSize)
 	case "random":
-		return s.sampleRandom(arr, targetSize)
+		return s.sampleRandom(len(arr), targetSize)
 	case "representative":
-		return s.sampleRepresentative(arr, targetSize)
+		return sampleRepresentative(len(arr), targetSize)
+	case "largest":
+		return s.sampleByValue(arr, targetSize, true)
+	case "smallest":
+		return s.sampleByValue(arr, targetSize, false)
+	case "stratified":
+		return s.sampleStratified(arr, targetSize)
 	default: // "none" or empty
 		// Just truncate to targetSize
-		if targetSize < len(arr) {
-			return arr[:targetSize]
-		}
-		return arr
+		return sampleFirst(targetSize)
 	}
 }
 
-// sampleFirstLast takes first N/2 and last N/2 elements
-func (s *Slimmer) sampleFirstLast(arr []interface{}, n int) []interface{} {
-	if n >= len(arr) {
-		return arr
+// sampleFirst takes the first n elements
+func sampleFirst(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
 	}
+	return indices
+}
+
+// sampleFirstLast takes first N/2 and last N/2 elements of an array of length size
+func sampleFirstLast(size, n int) []int {
 	firstHalf := n / 2
 	secondHalf := n - firstHalf
 
-	result := make([]interface{}, 0, n)
-	result = append(result, arr[:firstHalf]...)
-	result = append(result, arr[len(arr)-secondHalf:]...)
-	return result
+	indices := sampleFirst(firstHalf)
+	for i := size - secondHalf; i < size; i++ {
+		indices = append(indices, i)
+	}
+	return indices
 }
 
 // sampleRandom takes N random elements
-func (s *Slimmer) sampleRandom(arr []interface{}, n int) []interface{} {
-	if n >= len(arr) {
-		return arr
-	}
-
-	indices := rand.Perm(len(arr))[:n]
-	result := make([]interface{}, n)
-	for i, idx := range indices {
-		result[i] = arr[idx]
-	}
-	return result
+func (s *Slimmer) sampleRandom(size, n int) []int {
+	return s.rng.Perm(size)[:n]
 }
 
 // sampleRepresentative tries to pick diverse elements (simple heuristic)
-func (s *Slimmer) sampleRepresentative(arr []interface{}, n int) []interface{} {
-	if n >= len(arr) {
-		return arr
-	}
-
+func sampleRepresentative(size, n int) []int {
 	// Simple strategy: evenly spaced sampling
-	step := float64(len(arr)) / float64(n)
-	result := make([]interface{}, 0, n)
+	step := float64(size) / float64(n)
+	indices := make([]int, 0, n)
 
 	for i := 0; i < n; i++ {
 		idx := int(float64(i) * step)
-		if idx >= len(arr) {
-			idx = len(arr) - 1
+		if idx >= size {
+			idx = size - 1
 		}
-		result = append(result, arr[idx])
+		indices = append(indices, idx)
+	}
+	return indices
+}
+
+// sampleFrequency takes the first occurrence of the N most common distinct
+// values, most common first. Ties keep the value that occurs first.
+func sampleFrequency(arr []interface{}, n int) []int {
+	counts := countValues(arr)
+	first := make(map[string]int, len(counts))
+	var distinct []int
+	for i, item := range arr {
+		key := valueToString(item)
+		if _, ok := first[key]; !ok {
+			first[key] = i
+			distinct = append(distinct, i)
+		}
+	}
+	slices.SortStableFunc(distinct, func(a, b int) int {
+		return counts[valueToString(arr[b])] - counts[valueToString(arr[a])]
+	})
+	if len(distinct) > n {
+		distinct = distinct[:n]
+	}
+	return distinct
+}
+
+// countValues counts the occurrences of each value by its valueToString key
+func countValues(arr []interface{}) map[string]int {
+	counts := make(map[string]int)
+	for _, item := range arr {
+		counts[valueToString(item)]++
+	}
+	return counts
+}
+
+// annotateCounts wraps each sampled value with its number of occurrences in arr
+func annotateCounts(arr, sampled []interface{}) []interface{} {
+	counts := countValues(arr)
+	result := make([]interface{}, len(sampled))
+	for i, item := range sampled {
+		result[i] = map[string]interface{}{"_value": item, "_count": counts[valueToString(item)]}
 	}
 	return result
 }
 
-// valueToString converts a value to a string for comparison
-func valueToString(v interface{}) string {
-	if v == nil {
-		return "null"
+// sampleByValue takes the N largest (or smallest) elements, keeping their
+// original order. Numbers are compared directly and objects by their
+// SampleSortKey field. Elements without a numeric value are kept last,
+// and ties keep the earlier element.
+func (s *Slimmer) sampleByValue(arr []interface{}, n int, largest bool) []int {
+	type ranked struct {
+		idx   int
+		value float64
+		ok    bool
+	}
+	items := make([]ranked, len(arr))
+	for i, item := range arr {
+		v, ok := s.sortValue(item)
+		items[i] = ranked{idx: i, value: v, ok: ok}
+	}
+	slices.SortStableFunc(items, func(a, b ranked) int {
+		switch {
+		case a.ok && !b.ok:
+			return -1
+		case !a.ok && b.ok:
+			return 1
+		case largest:
+			return cmp.Compare(b.value, a.value)
+		default:
+			return cmp.Compare(a.value, b.value)
+		}
+	})
+
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = items[i].idx
 	}
-	val := reflect.ValueOf(v)
-	switch val.Kind() {
-	case reflect.String:
-		return val.String()
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return string(rune(val.Int()))
-	case reflect.Float32, reflect.Float64:
-		return string(rune(int(val.Float())))
-	case reflect.Bool:
-		if val.Bool() {
-			return "true"
+	slices.Sort(indices)
+	return indices
+}
+
+// sortValue returns the number that ranks item for largest/smallest sampling:
+// the item itself or, for objects, the value at SampleSortKey (a field name or dotted path)
+func (s *Slimmer) sortValue(item interface{}) (float64, bool) {
+	if _, ok := item.(map[string]interface{}); ok {
+		v, ok := lookupField(item, s.Config.SampleSortKey)
+		if !ok {
+			return 0, false
 		}
-		return "false"
-	default:
-		// For complex types, use reflection string (not perfect but works)
-		return val.String()
+		item = v
+	}
+	if f, ok := toFloat(item); ok && !math.IsNaN(f) {
+		return f, true
+	}
+	return 0, false
+}
+
+// lookupField returns the value at a field name or dotted path inside an object
+func lookupField(item interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+	v := item
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// sampleStratified takes n elements of arr, allocated to the groups of objects
+// sharing a SampleStratifyKey value in proportion to their size, and evenly
+// spaced within each group. Elements without the key form their own group.
+// Without any keyed element it falls back to representative sampling.
+func (s *Slimmer) sampleStratified(arr []interface{}, n int) []int {
+	var order []string // Group keys in order of first appearance
+	groups := make(map[string][]int)
+	keyed := false
+	for i, item := range arr {
+		key := "" // Elements without the key; valueToString is never empty
+		if v, ok := lookupField(item, s.Config.SampleStratifyKey); ok {
+			key = valueToString(v)
+			keyed = true
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	if !keyed {
+		return sampleRepresentative(len(arr), n)
+	}
+
+	// Largest remainder allocation: floor of each exact share, then one more
+	// for the groups with the largest fractional parts
+	quotas := make(map[string]int, len(order))
+	remainders := make([]string, len(order))
+	copy(remainders, order)
+	allocated := 0
+	for _, key := range order {
+		quotas[key] = n * len(groups[key]) / len(arr)
+		allocated += quotas[key]
+	}
+	slices.SortStableFunc(remainders, func(a, b string) int {
+		return (n*len(groups[b]))%len(arr) - (n*len(groups[a]))%len(arr)
+	})
+	for _, key := range remainders[:n-allocated] {
+		quotas[key]++
+	}
+
+	indices := make([]int, 0, n)
+	for _, key := range order {
+		members := groups[key]
+		for _, i := range sampleRepresentative(len(members), quotas[key]) {
+			indices = append(indices, members[i])
+		}
+	}
+	slices.Sort(indices)
+	return indices
+}
+
+// valueToString converts a value to a string key for comparison. Values equal
+// as JSON get the same key: numbers compare by value regardless of Go type,
+// and strings never collide with numbers, booleans or null.
+func valueToString(v interface{}) string {
+	if f, ok := toFloat(v); ok {
+		return strconv.FormatFloat(f, 'g', -1, 64)
 	}
+	return canonicalJSON(plainMaps(v))
 }
 
 // collectStatistics performs first pass to collect string and enum statistics
@@ -472,8 +1674,10 @@ func (s *Slimmer) collectStatistics(data interface{}) {
 
 	// Build string pool from strings that occur >= min times
 	if s.Config.StringPooling {
-		for str, count := range stringCounts {
-			if count >= s.Config.StringPoolMinOccurrences && len(str) > 3 {
+		s.stringPool = make(map[string]int)
+		// Iterate in sorted order so pool indices are deterministic
+		for _, str := range slices.Sorted(maps.Keys(stringCounts)) {
+			if count := stringCounts[str]; count >= s.Config.StringPoolMinOccurrences && len(str) > 3 {
 				idx := len(s.stringList)
 				s.stringPool[str] = idx
 				s.stringList = append(s.stringList, str)
@@ -483,13 +1687,17 @@ func (s *Slimmer) collectStatistics(data interface{}) {
 
 	// Build enum pools from fields with limited unique values
 	if s.Config.EnumDetection {
+		s.enumPools = make(map[string][]string)
 		for field, values := range enumCandidates {
+			occurrences := 0
+			for _, n := range values {
+				occurrences += n
+			}
+			if occurrences <= s.Config.InlineEnumThreshold {
+				continue // Too rare to pay for an _enums entry
+			}
 			if len(values) > 0 && len(values) <= s.Config.EnumMaxValues {
-				enumList := make([]string, 0, len(values))
-				for val := range values {
-					enumList = append(enumList, val)
-				}
-				s.enumPools[field] = enumList
+				s.enumPools[field] = slices.Sorted(maps.Keys(values))
 			}
 		}
 	}
@@ -497,10 +1705,15 @@ func (s *Slimmer) collectStatistics(data interface{}) {
 
 // collectStatsRecursive recursively collects statistics
 func (s *Slimmer) collectStatsRecursive(data interface{}, fieldPath string, stringCounts map[string]int, enumCandidates map[string]map[string]int) {
+	s.checkContext()
 	if data == nil {
 		return
 	}
 
+	if om, ok := data.(*OrderedMap); ok {
+		data = om.Values
+	}
+
 	val := reflect.ValueOf(data)
 	switch val.Kind() {
 	case reflect.Map:
@@ -511,6 +1724,9 @@ func (s *Slimmer) collectStatsRecursive(data interface{}, fieldPath string, stri
 			if fieldPath != "" {
 				newPath = fieldPath + "." + key
 			}
+			if s.isPreserved(key, newPath) {
+				continue // Not pooled or enum-encoded
+			}
 			s.collectStatsRecursive(v, newPath, stringCounts, enumCandidates)
 		}
 
@@ -536,6 +1752,38 @@ func (s *Slimmer) collectStatsRecursive(data interface{}, fieldPath string, stri
 	}
 }
 
+// hasPoolIndex reports whether data contains an integer in [0, n), which
+// Unslim could not tell apart from a string pool index
+func hasPoolIndex(data interface{}, n int) bool {
+	if om, ok := data.(*OrderedMap); ok {
+		data = om.Values
+	}
+	if data == nil {
+		return false
+	}
+	val := reflect.ValueOf(data)
+	switch val.Kind() {
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			if hasPoolIndex(iter.Value().Interface(), n) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			if hasPoolIndex(val.Index(i).Interface(), n) {
+				return true
+			}
+		}
+	default:
+		if f, ok := toFloat(data); ok {
+			return f >= 0 && f < float64(n) && f == math.Trunc(f)
+		}
+	}
+	return false
+}
+
 // applyStringPooling replaces string with pool index if applicable
 func (s *Slimmer) applyStringPooling(str string) interface{} {
 	if !s.Config.StringPooling {
@@ -607,10 +1855,26 @@ func (s *Slimmer) applyNumberDelta(arr []interface{}) interface{} {
 		}
 	}
 
+	// Lossless output must expand to exactly the same numbers
+	if s.Config.Lossless && numbers[0] != math.Trunc(numbers[0]) {
+		return arr
+	}
+	for i, n := range numbers {
+		if s.Config.Lossless && n != numbers[0]+float64(i) {
+			return arr
+		}
+	}
+
+	// Unslim only expands ranges of integers
+	first, last := numbers[0], numbers[len(numbers)-1]
+	if first != math.Trunc(first) || last != math.Trunc(last) {
+		return arr
+	}
+
 	if isSequential && math.Abs(firstDelta-1.0) < 0.0001 {
 		// Sequential with delta=1, use range notation
 		return map[string]interface{}{
-			"_range": []float64{numbers[0], numbers[len(numbers)-1]},
+			"_range": []float64{first, last},
 		}
 	}
 
@@ -618,13 +1882,14 @@ func (s *Slimmer) applyNumberDelta(arr []interface{}) interface{} {
 }
 
 // applyTypeInference converts uniform array of objects to schema+data format
-func (s *Slimmer) applyTypeInference(arr []interface{}) interface{} {
+func (s *Slimmer) applyTypeInference(arr []interface{}, path string) interface{} {
 	if !s.Config.TypeInference {
 		return arr
 	}
 
-	if len(arr) < 3 {
-		return arr // Too small to benefit
+	// Too small to benefit, unless MinSavingsBytes measures it
+	if len(arr) < 2 || (len(arr) < 3 && s.Config.MinSavingsBytes <= 0) {
+		return arr
 	}
 
 	// Check if all elements are maps with same keys
@@ -635,10 +1900,7 @@ func (s *Slimmer) applyTypeInference(arr []interface{}) interface{} {
 			return arr // Not all objects
 		}
 
-		keys := make([]string, 0, len(itemMap))
-		for k := range itemMap {
-			keys = append(keys, k)
-		}
+		keys := slices.Sorted(maps.Keys(itemMap))
 
 		if i == 0 {
 			firstKeys = keys
@@ -660,6 +1922,17 @@ func (s *Slimmer) applyTypeInference(arr []interface{}) interface{} {
 		}
 	}
 
+	// Keep the original field order if it is known
+	if s.keyOrder != nil {
+		slices.SortFunc(firstKeys, func(a, b string) int {
+			return s.compareKeys(fieldPath(path), a, b)
+		})
+	}
+
+	if s.Config.TypeInferenceColumnar {
+		return s.buildColumns(arr, firstKeys)
+	}
+
 	// Convert to schema+data format
 	data := make([][]interface{}, len(arr))
 	for i, item := range arr {
@@ -677,6 +1950,305 @@ func (s *Slimmer) applyTypeInference(arr []interface{}) interface{} {
 	}
 }
 
+// buildColumns converts uniform objects to column-major schema+cols format.
+// Numeric columns are delta encoded independently when NumberDeltaEncoding is set.
+func (s *Slimmer) buildColumns(arr []interface{}, keys []string) interface{} {
+	cols := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		col := make([]interface{}, len(arr))
+		for i, item := range arr {
+			col[i] = item.(map[string]interface{})[key]
+		}
+		if s.Config.NumberDeltaEncoding {
+			cols[key] = s.applyNumberDelta(col)
+		} else {
+			cols[key] = col
+		}
+	}
+
+	return map[string]interface{}{
+		"_schema": keys,
+		"_cols":   cols,
+	}
+}
+
+// applyMatrix converts a uniform array of numeric rows to the column-major
+// {"_matrix": {"cols": N, "data": [col0, col1, ...]}} format, where data[j]
+// holds the j-th value of every row, so row i decodes as
+// [data[0][i], data[1][i], ..., data[N-1][i]]. Numeric columns are delta
+// encoded independently when NumberDeltaEncoding is set.
+func (s *Slimmer) applyMatrix(arr []interface{}) interface{} {
+	if !s.Config.MatrixColumnar {
+		return arr
+	}
+
+	// Too small to benefit, unless MinSavingsBytes measures it
+	if len(arr) < 2 || (len(arr) < 3 && s.Config.MinSavingsBytes <= 0) {
+		return arr
+	}
+
+	// Check if all elements are number arrays of the same length
+	cols := -1
+	for _, item := range arr {
+		row, ok := item.([]interface{})
+		if !ok || len(row) == 0 || (cols >= 0 && len(row) != cols) {
+			return arr // Not a matrix
+		}
+		cols = len(row)
+		for _, v := range row {
+			if _, ok := toFloat(v); !ok {
+				return arr // Not all numbers
+			}
+		}
+	}
+
+	data := make([]interface{}, cols)
+	for j := range data {
+		col := make([]interface{}, len(arr))
+		for i, item := range arr {
+			col[i] = item.([]interface{})[j]
+		}
+		if s.Config.NumberDeltaEncoding {
+			data[j] = s.applyNumberDelta(col)
+		} else {
+			data[j] = col
+		}
+	}
+
+	return map[string]interface{}{
+		"_matrix": map[string]interface{}{
+			"cols": cols,
+			"data": data,
+		},
+	}
+}
+
+// applyDefaults removes fields equal to their default from an array of objects
+// and returns {"_defaults": {...}, "_items": [...]}. Only fields present in every
+// element are considered, so Unslim can restore the array exactly.
+func (s *Slimmer) applyDefaults(arr []interface{}) interface{} {
+	if len(arr) < 2 {
+		return arr
+	}
+
+	// Collect fields common to all elements
+	var common map[string]bool
+	for _, item := range arr {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			return arr // Not all objects
+		}
+		if common == nil {
+			common = make(map[string]bool, len(itemMap))
+			for k := range itemMap {
+				common[k] = true
+			}
+			continue
+		}
+		for k := range common {
+			if _, ok := itemMap[k]; !ok {
+				delete(common, k)
+			}
+		}
+	}
+
+	defaults := make(map[string]interface{})
+	for key := range common {
+		def, ok := s.Config.Defaults[key]
+		if !ok && s.Config.DetectDefaults {
+			def, ok = modalValue(arr, key)
+		}
+		if !ok {
+			continue
+		}
+
+		// Only worth it when the default replaces at least two values.
+		// Emit a matching value from the data so Unslim restores its exact type.
+		defKey := canonicalJSON(def)
+		matches := 0
+		for _, item := range arr {
+			if v := item.(map[string]interface{})[key]; canonicalJSON(v) == defKey {
+				def = v
+				matches++
+			}
+		}
+		if matches >= 2 {
+			defaults[key] = def
+		}
+	}
+
+	if len(defaults) == 0 {
+		return arr
+	}
+
+	items := make([]interface{}, len(arr))
+	for i, item := range arr {
+		itemMap := item.(map[string]interface{})
+		sparse := make(map[string]interface{}, len(itemMap))
+		for k, v := range itemMap {
+			if def, ok := defaults[k]; ok && canonicalJSON(v) == canonicalJSON(def) {
+				continue
+			}
+			sparse[k] = v
+		}
+		items[i] = sparse
+	}
+
+	return map[string]interface{}{
+		"_defaults": defaults,
+		"_items":    items,
+	}
+}
+
+// modalValue returns the most common value of a field across an array of objects
+func modalValue(arr []interface{}, key string) (interface{}, bool) {
+	counts := make(map[string]int)
+	values := make(map[string]interface{})
+	best := ""
+	for _, item := range arr {
+		v := item.(map[string]interface{})[key]
+		k := canonicalJSON(v)
+		counts[k]++
+		values[k] = v
+		if counts[k] > counts[best] || (counts[k] == counts[best] && k < best) {
+			best = k
+		}
+	}
+	if counts[best] < 2 {
+		return nil, false
+	}
+	return values[best], true
+}
+
+// canonicalJSON returns a JSON encoding usable as an equality key.
+// encoding/json sorts map keys, so structurally equal values encode identically.
+func canonicalJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(b)
+}
+
+// flattenMap merges nested object values into m using dotted keys.
+// Children are pruned first, so they are already flattened. A child is kept
+// nested when it is empty, holds metadata (keys starting with "_"), would
+// exceed FlattenMaxDepth, or would collide with an existing key.
+func (s *Slimmer) flattenMap(m map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		child, ok := m[k].(map[string]interface{})
+		if !ok || len(child) == 0 || !s.canFlatten(m, k, child) {
+			continue
+		}
+		delete(m, k)
+		for ck, cv := range child {
+			m[k+"."+ck] = cv
+		}
+		s.flattened = true
+	}
+	return m
+}
+
+// flattenChains merges values that are objects with a single key into m under
+// a dotted key. Children are pruned first, so chains are already collapsed below.
+func (s *Slimmer) flattenChains(m map[string]interface{}) map[string]interface{} {
+	for _, k := range slices.Collect(maps.Keys(m)) {
+		child, ok := m[k].(map[string]interface{})
+		if !ok || len(child) != 1 || strings.HasPrefix(k, "_") {
+			continue
+		}
+		for ck, cv := range child {
+			flatKey := k + "." + ck
+			if _, exists := m[flatKey]; exists || strings.HasPrefix(ck, "_") ||
+				(s.Config.FlattenMaxDepth > 0 && strings.Count(flatKey, ".")+1 > s.Config.FlattenMaxDepth) {
+				continue
+			}
+			delete(m, k)
+			m[flatKey] = cv
+			s.flattened = true
+		}
+	}
+	return m
+}
+
+// isSingleKeyChain reports whether v is an object with one key that
+// FlattenSingleKeyChains merges into its parent
+func isSingleKeyChain(v interface{}) bool {
+	if om, ok := v.(*OrderedMap); ok {
+		return om.Len() == 1 && !strings.HasPrefix(om.Keys[0], "_")
+	}
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Map || val.Len() != 1 {
+		return false
+	}
+	key := val.MapKeys()[0]
+	return key.Kind() == reflect.String && !strings.HasPrefix(key.String(), "_")
+}
+
+// usesChains reports whether the config or a path rule enables FlattenSingleKeyChains
+func (s *Slimmer) usesChains() bool {
+	if s.Config.FlattenSingleKeyChains {
+		return true
+	}
+	return slices.ContainsFunc(s.Config.Rules, func(r PathRule) bool { return r.Config.FlattenSingleKeyChains })
+}
+
+// isObject reports whether data is a JSON object
+func isObject(data interface{}) bool {
+	if _, ok := data.(*OrderedMap); ok {
+		return true
+	}
+	return data != nil && reflect.ValueOf(data).Kind() == reflect.Map
+}
+
+// hasDottedKey reports whether any object key in data contains a dot
+func hasDottedKey(data interface{}) bool {
+	if om, ok := data.(*OrderedMap); ok {
+		data = om.Values
+	}
+	if data == nil {
+		return false
+	}
+	val := reflect.ValueOf(data)
+	switch val.Kind() {
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			if strings.Contains(iter.Key().String(), ".") || hasDottedKey(iter.Value().Interface()) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			if hasDottedKey(val.Index(i).Interface()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canFlatten reports whether child can be merged into parent under key
+func (s *Slimmer) canFlatten(parent map[string]interface{}, key string, child map[string]interface{}) bool {
+	for ck := range child {
+		if strings.HasPrefix(ck, "_") {
+			return false
+		}
+		if s.Config.FlattenMaxDepth > 0 && strings.Count(key, ".")+strings.Count(ck, ".")+2 > s.Config.FlattenMaxDepth {
+			return false
+		}
+		if _, exists := parent[key+"."+ck]; exists {
+			s.literalDots = true
+			return false
+		}
+	}
+	return true
+}
+
 // applyBoolCompression converts booleans in a map to bit flags
 func (s *Slimmer) applyBoolCompression(m map[string]interface{}) map[string]interface{} {
 	if !s.Config.BoolCompression {
@@ -691,9 +2263,10 @@ func (s *Slimmer) applyBoolCompression(m map[string]interface{}) map[string]inte
 		}
 	}
 
-	if len(boolKeys) < 3 {
-		return m // Not enough booleans to compress
+	if len(boolKeys) < 3 || len(boolKeys) > maxBoolKeys {
+		return m // Not enough booleans to compress, or too many for exact flags
 	}
+	slices.Sort(boolKeys)
 
 	// Create bit flags
 	var flags int
@@ -712,6 +2285,70 @@ func (s *Slimmer) applyBoolCompression(m map[string]interface{}) map[string]inte
 	return m
 }
 
+// maxBoolKeys is the most booleans bool compression packs into one flags
+// number, which must be exact as a JSON number (float64)
+const maxBoolKeys = 53
+
+// normalizeUnicode returns s in the Unicode normalization form named by form,
+// or s itself for "none" or an unknown form
+func normalizeUnicode(s, form string) string {
+	switch strings.ToUpper(form) {
+	case "NFC":
+		return norm.NFC.String(s)
+	case "NFD":
+		return norm.NFD.String(s)
+	case "NFKC":
+		return norm.NFKC.String(s)
+	}
+	return s
+}
+
+// whitespaceReplacer maps Unicode spaces to an ASCII space and line
+// separators to a newline, and removes zero-width characters
+var whitespaceReplacer = strings.NewReplacer(
+	"\u00a0", " ", "\u1680", " ", "\u2000", " ", "\u2001", " ", "\u2002", " ",
+	"\u2003", " ", "\u2004", " ", "\u2005", " ", "\u2006", " ", "\u2007", " ",
+	"\u2008", " ", "\u2009", " ", "\u200a", " ", "\u202f", " ", "\u205f", " ",
+	"\u3000", " ", "\u2028", "\n", "\u2029", "\n",
+	"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "",
+)
+
+// collapseWhitespace replaces each run of whitespace in s with a single space,
+// or with a newline if keepNewlines is set and the run has a line break, and
+// trims leading and trailing whitespace
+func collapseWhitespace(s string, keepNewlines bool) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	inRun, newline := false, false
+	for _, r := range strings.TrimSpace(s) {
+		if unicode.IsSpace(r) {
+			inRun = true
+			newline = newline || r == '\n' || r == '\u2028' || r == '\u2029'
+			continue
+		}
+		if inRun {
+			if keepNewlines && newline {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(' ')
+			}
+			inRun, newline = false, false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// quoteReplacer maps typographic quotes, dashes and ellipses to ASCII
+var quoteReplacer = strings.NewReplacer(
+	"\u2018", "'", "\u2019", "'", "\u201a", "'", "\u201b", "'", "\u2032", "'",
+	"\u201c", `"`, "\u201d", `"`, "\u201e", `"`, "\u201f", `"`, "\u2033", `"`,
+	"\u00ab", `"`, "\u00bb", `"`,
+	"\u2010", "-", "\u2011", "-", "\u2012", "-", "\u2013", "-", "\u2014", "-",
+	"\u2015", "-", "\u2212", "-",
+	"\u2026", "...",
+)
+
 // stripEmoji removes emoji and non-ASCII characters from a string
 func stripEmoji(s string) string {
 	var result strings.Builder
@@ -731,3 +2368,14 @@ func stripEmoji(s string) string {
 
 	return result.String()
 }
+
+// bytesPerToken is the number of bytes EstimateTokens counts as a token
+const bytesPerToken = 4
+
+// EstimateTokens approximates the number of LLM tokens in text.
+// It assumes roughly 4 characters per token, which is typical for JSON and
+// English in GPT-style tokenizers; real counts vary by model.
+func EstimateTokens(text []byte) int {
+	n := len(bytes.TrimSpace(text))
+	return (n + bytesPerToken - 1) / bytesPerToken // Round up
+}
@@ -2,6 +2,7 @@ package slimjson
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
 )
@@ -156,8 +157,52 @@ func BenchmarkSlim_Parallel(b *testing.B) {
 	})
 }
 
+// BenchmarkTypeInference_Rows tests row-major type inference on a 1,000-row table
+func BenchmarkTypeInference_Rows(b *testing.B) {
+	benchmarkTypeInferenceLayout(b, false)
+}
+
+// BenchmarkTypeInference_Columnar tests column-major type inference on a 1,000-row table
+func BenchmarkTypeInference_Columnar(b *testing.B) {
+	benchmarkTypeInferenceLayout(b, true)
+}
+
+func benchmarkTypeInferenceLayout(b *testing.B, columnar bool) {
+	data := syntheticTable(1000)
+	cfg := Config{
+		TypeInference:         true,
+		TypeInferenceColumnar: columnar,
+		NumberDeltaEncoding:   true,
+	}
+
+	out, err := json.Marshal(New(cfg).Slim(data))
+	if err != nil {
+		b.Fatalf("Failed to marshal result: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = New(cfg).Slim(data)
+	}
+	b.ReportMetric(float64(len(out)), "output-bytes")
+}
+
+// syntheticTable builds a uniform numeric table with a sequential id column
+func syntheticTable(rows int) interface{} {
+	items := make([]interface{}, rows)
+	for i := range items {
+		items[i] = map[string]interface{}{
+			"id":    float64(i + 1),
+			"score": float64(i % 97),
+			"price": float64(i%13) * 1.5,
+			"qty":   float64(i % 7),
+		}
+	}
+	return map[string]interface{}{"rows": items}
+}
+
 // Helper function to load test data
-func loadTestData(b *testing.B, filepath string) interface{} {
+func loadTestData(b testing.TB, filepath string) interface{} {
 	b.Helper()
 
 	fileData, err := os.ReadFile(filepath)
@@ -172,3 +217,66 @@ func loadTestData(b *testing.B, filepath string) interface{} {
 
 	return data
 }
+
+// BenchmarkSlim_LargeArray compares serial and parallel pruning of a top-level
+// array of 20,000 records
+func BenchmarkSlim_LargeArray(b *testing.B) {
+	data := syntheticRecords(20000)
+	for _, parallelism := range []int{0, 2, 4, 8} {
+		cfg := Config{MaxStringLength: 40, StripEmpty: true, DecimalPlaces: 2, BlockList: []string{"trace"}, Parallelism: parallelism}
+		b.Run(fmt.Sprintf("parallelism-%d", parallelism), func(b *testing.B) {
+			slimmer := New(cfg)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = slimmer.Slim(data)
+			}
+		})
+	}
+}
+
+// BenchmarkSlim_TypeSwitch compares slimming the resume fixture as decoded by
+// encoding/json, which the type switch of pruneValue handles, with the same
+// document in other types, which take the reflection path
+func BenchmarkSlim_TypeSwitch(b *testing.B) {
+	data := loadTestData(b, "testing/fixtures/resume.json")
+	cfg := Config{MaxDepth: 5, MaxListLength: 10, StripEmpty: true}
+	for _, bench := range []struct {
+		name string
+		data interface{}
+	}{{"type-switch", data}, {"reflect", reflectTypes(data)}} {
+		b.Run(bench.name, func(b *testing.B) {
+			slimmer := New(cfg)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = slimmer.Slim(bench.data)
+			}
+		})
+	}
+}
+
+// BenchmarkSlim_BasicConfig measures the fixed cost of New and Slim with only
+// basic options, which need neither the statistics pass nor its pools, on a
+// small document where that cost dominates
+func BenchmarkSlim_BasicConfig(b *testing.B) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(`{"id": 7, "name": "Ada Lovelace", "email": "", "tags": ["math", "poetry"], "address": {"city": "London"}}`), &data); err != nil {
+		b.Fatalf("Failed to unmarshal test data: %v", err)
+	}
+	cfg := Config{MaxDepth: 5, MaxListLength: 10, StripEmpty: true}
+
+	b.Run("new-and-slim", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = New(cfg).Slim(data)
+		}
+	})
+	b.Run("slim", func(b *testing.B) {
+		slimmer := New(cfg)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = slimmer.Slim(data)
+		}
+	})
+}
@@ -1,11 +1,19 @@
 package slimjson
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
+	"io"
+	"os"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
+var update = flag.Bool("update", false, "Update golden files")
+
 func TestSlimmer_Slim(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -116,6 +124,72 @@ func TestSlimmer_Slim(t *testing.T) {
 }
 
 // TestBooleanCompression tests boolean compression to bit flags
+// Types the type switch of pruneValue does not match, so that values of them
+// are slimmed through reflection
+type (
+	reflectObject map[string]interface{}
+	reflectArray  []interface{}
+	reflectString string
+	reflectNumber float64
+	reflectBool   bool
+)
+
+// reflectTypes returns a copy of data, decoded JSON, with every value
+// converted to the reflect types
+func reflectTypes(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		m := make(reflectObject, len(v))
+		for k, val := range v {
+			m[k] = reflectTypes(val)
+		}
+		return m
+	case []interface{}:
+		arr := make(reflectArray, len(v))
+		for i, val := range v {
+			arr[i] = reflectTypes(val)
+		}
+		return arr
+	case string:
+		return reflectString(v)
+	case float64:
+		return reflectNumber(v)
+	case bool:
+		return reflectBool(v)
+	}
+	return data
+}
+
+func TestPruneReflectMatchesTypeSwitch(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"Default", Config{}},
+		{"Limits", Config{MaxDepth: 3, MaxListLength: 2, MaxStringLength: 10, StripEmpty: true, DecimalPlaces: 1}},
+		{"Summaries", Config{MaxDepth: 2, MaxListLength: 2, TruncationSummaries: true, BlockList: []string{"email"}}},
+		{"Sampling", Config{MaxListLength: 3, SampleStrategy: "representative", DeduplicateArrays: true}},
+		{"Empty markers", Config{MaxDepth: 2, DepthTruncationMarker: "empty"}},
+	}
+
+	data := loadTestData(t, "testing/fixtures/resume.json")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.Marshal(New(tt.config).Slim(data))
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			got, err := json.Marshal(New(tt.config).Slim(reflectTypes(data)))
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Reflection path differs from the type switch:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
 func TestBooleanCompression(t *testing.T) {
 	input := map[string]interface{}{
 		"name":     "John",
@@ -310,300 +384,1716 @@ func TestTypeInference(t *testing.T) {
 	t.Logf("Type inference successful: %d rows with %d columns", len(dataArr), len(schemaArr))
 }
 
-// TestNullCompression tests null field tracking
-func TestNullCompression(t *testing.T) {
+// TestTypeInferenceColumnar tests schema+cols format with per-column delta encoding
+func TestTypeInferenceColumnar(t *testing.T) {
 	input := map[string]interface{}{
-		"name":  "John",
-		"email": nil,
-		"phone": nil,
-		"age":   30,
+		"users": []interface{}{
+			map[string]interface{}{"id": 1, "name": "Alice"},
+			map[string]interface{}{"id": 2, "name": "Bob"},
+			map[string]interface{}{"id": 3, "name": "Charlie"},
+			map[string]interface{}{"id": 4, "name": "David"},
+			map[string]interface{}{"id": 5, "name": "Eve"},
+		},
 	}
 
 	cfg := Config{
-		NullCompression: true,
-		StripEmpty:      true,
+		TypeInference:         true,
+		TypeInferenceColumnar: true,
+		NumberDeltaEncoding:   true,
 	}
 
 	slimmer := New(cfg)
 	result := slimmer.Slim(input)
 
-	resultMap, ok := result.(map[string]interface{})
-	if !ok {
-		t.Fatal("Expected map result")
-	}
-
-	// Check that _nulls exists
-	nulls, ok := resultMap["_nulls"]
+	usersMap, ok := result.(map[string]interface{})["users"].(map[string]interface{})
 	if !ok {
-		t.Fatal("Expected _nulls field")
-	}
-
-	nullList := nulls.([]string)
-	if len(nullList) != 2 {
-		t.Errorf("Expected 2 null fields tracked, got %d", len(nullList))
+		t.Fatal("Expected type-inferred users as map")
 	}
 
-	t.Logf("Null compression successful: %d null fields tracked", len(nullList))
-}
-
-// TestDecimalPlaces tests numeric precision control
-func TestDecimalPlaces(t *testing.T) {
-	input := map[string]interface{}{
-		"price":  19.99999,
-		"rating": 4.666666,
-		"score":  89.12345,
+	if _, ok := usersMap["_data"]; ok {
+		t.Error("Did not expect _data field in columnar output")
 	}
 
-	cfg := Config{
-		DecimalPlaces: 2,
+	cols, ok := usersMap["_cols"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected _cols field")
 	}
 
-	slimmer := New(cfg)
-	result := slimmer.Slim(input)
-
-	resultMap, ok := result.(map[string]interface{})
+	ids, ok := cols["id"].(map[string]interface{})
 	if !ok {
-		t.Fatal("Expected map result")
+		t.Fatalf("Expected delta-encoded id column, got %T", cols["id"])
 	}
-
-	price := resultMap["price"].(float64)
-	rating := resultMap["rating"].(float64)
-	score := resultMap["score"].(float64)
-
-	if price != 20.0 {
-		t.Errorf("Expected price=20.0, got %v", price)
+	rangeArr := ids["_range"].([]float64)
+	if rangeArr[0] != 1 || rangeArr[1] != 5 {
+		t.Errorf("Expected id range [1, 5], got %v", rangeArr)
 	}
 
-	if rating != 4.67 {
-		t.Errorf("Expected rating=4.67, got %v", rating)
+	names, ok := cols["name"].([]interface{})
+	if !ok || len(names) != 5 {
+		t.Fatalf("Expected 5 names in name column, got %v", cols["name"])
 	}
-
-	if score != 89.12 {
-		t.Errorf("Expected score=89.12, got %v", score)
+	if names[0] != "Alice" || names[4] != "Eve" {
+		t.Errorf("Unexpected name column order: %v", names)
 	}
-
-	t.Logf("Decimal places successful: price=%v, rating=%v, score=%v", price, rating, score)
 }
 
-// TestDeduplication tests array deduplication
-func TestDeduplication(t *testing.T) {
-	input := map[string]interface{}{
-		"tags": []interface{}{"go", "json", "go", "json", "go", "api"},
+// TestMatrixColumnar tests column-major _matrix encoding of numeric 2D arrays
+func TestMatrixColumnar(t *testing.T) {
+	// 10 rows of 5 columns: an index, a constant, and three irregular readings
+	rows := make([]interface{}, 10)
+	for i := range rows {
+		rows[i] = []interface{}{float64(i + 1), 0.5, float64(i*i%7) + 0.25, float64(100 - 3*i), float64((i * 13) % 10)}
 	}
+	input := map[string]interface{}{"readings": rows, "labels": []interface{}{[]interface{}{"a", "b"}, []interface{}{"c", "d"}, []interface{}{"e", "f"}}}
 
-	cfg := Config{
-		DeduplicateArrays: true,
+	result := New(Config{DecimalPlaces: -1, MatrixColumnar: true, NumberDeltaEncoding: true}).Slim(input)
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Failed to marshal result: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
 	}
 
-	slimmer := New(cfg)
-	result := slimmer.Slim(input)
-
-	resultMap, ok := result.(map[string]interface{})
+	readings, ok := decoded["readings"].(map[string]interface{})
 	if !ok {
-		t.Fatal("Expected map result")
+		t.Fatalf("Expected a _matrix object for readings, got %s", encoded)
 	}
-
-	tags := resultMap["tags"].([]interface{})
-	if len(tags) != 3 {
-		t.Errorf("Expected 3 unique tags, got %d", len(tags))
+	matrix, _ := readings["_matrix"].(map[string]interface{})
+	data, _ := matrix["data"].([]interface{})
+	if matrix["cols"] != 5.0 || len(data) != 5 {
+		t.Fatalf("Expected 5 columns, got %s", encoded)
 	}
-
-	t.Logf("Deduplication successful: 6 items reduced to %d unique", len(tags))
-}
-
-// TestSamplingFirstLast tests first_last sampling strategy
-func TestSamplingFirstLast(t *testing.T) {
-	input := map[string]interface{}{
-		"items": []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+	if got, _ := json.Marshal(data[0]); string(got) != `{"_range":[1,10]}` {
+		t.Errorf("Expected the index column delta encoded, got %s", got)
 	}
-
-	cfg := Config{
-		SampleStrategy: "first_last",
-		SampleSize:     6,
+	if got, _ := json.Marshal(data[3]); string(got) != `[100,97,94,91,88,85,82,79,76,73]` {
+		t.Errorf("Expected the fourth column in row order, got %s", got)
 	}
 
-	slimmer := New(cfg)
-	result := slimmer.Slim(input)
-
-	resultMap, ok := result.(map[string]interface{})
-	if !ok {
-		t.Fatal("Expected map result")
+	// Documented decode: row i is [data[0][i], ..., data[cols-1][i]]
+	cols := make([][]interface{}, len(data))
+	for j, col := range data {
+		if r, ok := col.(map[string]interface{}); ok {
+			bounds := r["_range"].([]interface{})
+			for n := bounds[0].(float64); n <= bounds[1].(float64); n++ {
+				cols[j] = append(cols[j], n)
+			}
+			continue
+		}
+		cols[j] = col.([]interface{})
 	}
-
-	items := resultMap["items"].([]interface{})
-	if len(items) != 6 {
-		t.Errorf("Expected 6 sampled items, got %d", len(items))
+	for i, row := range rows {
+		decodedRow := make([]interface{}, len(cols))
+		for j := range cols {
+			decodedRow[j] = cols[j][i]
+		}
+		if !reflect.DeepEqual(decodedRow, row) {
+			t.Errorf("Row %d decoded as %v, want %v", i, decodedRow, row)
+		}
 	}
 
-	// Should have first 3 and last 3
-	if items[0].(int) != 1 || items[1].(int) != 2 || items[2].(int) != 3 {
-		t.Error("Expected first 3 items: [1, 2, 3]")
+	// Arrays of non-numeric rows are left as they are
+	if _, ok := decoded["labels"].([]interface{}); !ok {
+		t.Errorf("Expected string rows to stay an array, got %s", encoded)
 	}
 
-	if items[3].(int) != 18 || items[4].(int) != 19 || items[5].(int) != 20 {
-		t.Error("Expected last 3 items: [18, 19, 20]")
+	restored, err := Unslim(decoded)
+	if err != nil {
+		t.Fatalf("Unslim() error: %v", err)
+	}
+	var want interface{}
+	if wantJSON, err := json.Marshal(input); err != nil || json.Unmarshal(wantJSON, &want) != nil {
+		t.Fatalf("Failed to round trip input: %v", err)
+	}
+	if !reflect.DeepEqual(restored, want) {
+		t.Errorf("Unslim() = %v, want %v", restored, want)
 	}
-
-	t.Logf("First-last sampling successful: 20 items sampled to %d", len(items))
 }
 
-// TestSamplingRepresentative tests representative sampling strategy
-func TestSamplingRepresentative(t *testing.T) {
-	input := map[string]interface{}{
-		"items": []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+// TestDefaults tests sparse encoding of object arrays against field defaults
+func TestDefaults(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{"id": "a", "enabled": true, "weight": 1.0},
+		map[string]interface{}{"id": "b", "enabled": true, "weight": 1.0},
+		map[string]interface{}{"id": "c", "enabled": false, "weight": 1.0},
+		map[string]interface{}{"id": "d", "enabled": true},
 	}
 
 	cfg := Config{
-		SampleStrategy: "representative",
-		SampleSize:     4,
+		Defaults: map[string]interface{}{"enabled": true, "weight": 1},
 	}
 
 	slimmer := New(cfg)
-	result := slimmer.Slim(input)
-
-	resultMap, ok := result.(map[string]interface{})
+	result, ok := slimmer.Slim(input).(map[string]interface{})
 	if !ok {
-		t.Fatal("Expected map result")
+		t.Fatal("Expected sparse-encoded map result")
 	}
 
-	items := resultMap["items"].([]interface{})
-	if len(items) != 4 {
-		t.Errorf("Expected 4 sampled items, got %d", len(items))
+	defaults := result["_defaults"].(map[string]interface{})
+	if _, ok := defaults["weight"]; ok {
+		t.Error("weight is missing from one element and must not be a default")
+	}
+	if defaults["enabled"] != true {
+		t.Errorf("Expected enabled default true, got %v", defaults["enabled"])
 	}
 
-	t.Logf("Representative sampling successful: 10 items sampled to %d", len(items))
+	items := result["_items"].([]interface{})
+	expected := []interface{}{
+		map[string]interface{}{"id": "a", "weight": 1.0},
+		map[string]interface{}{"id": "b", "weight": 1.0},
+		map[string]interface{}{"id": "c", "enabled": false, "weight": 1.0},
+		map[string]interface{}{"id": "d"},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Expected items %v, got %v", expected, items)
+	}
 }
 
-// TestCombinedOptimizations tests multiple optimizations together
-func TestCombinedOptimizations(t *testing.T) {
-	input := map[string]interface{}{
-		"users": []interface{}{
-			map[string]interface{}{
-				"id":       1,
-				"name":     "Alice",
-				"email":    "alice@example.com",
-				"verified": true,
-				"premium":  false,
-			},
-			map[string]interface{}{
-				"id":       2,
-				"name":     "Bob",
-				"email":    "bob@example.com",
-				"verified": false,
-				"premium":  false,
-			},
-			map[string]interface{}{
-				"id":       3,
-				"name":     "Alice",
-				"email":    "alice@example.com",
-				"verified": true,
-				"premium":  true,
-			},
-		},
-		"prices": []interface{}{19.99999, 29.12345, 39.99999},
+// TestDefaultsStripEmpty tests that empty defaults never match stripped fields
+func TestDefaultsStripEmpty(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{"id": "a", "note": ""},
+		map[string]interface{}{"id": "b", "note": ""},
+		map[string]interface{}{"id": "c", "note": "x"},
 	}
 
 	cfg := Config{
-		StringPooling:            true,
-		StringPoolMinOccurrences: 2,
-		TypeInference:            true,
-		DecimalPlaces:            2,
+		StripEmpty: true,
+		Defaults:   map[string]interface{}{"note": ""},
 	}
 
 	slimmer := New(cfg)
 	result := slimmer.Slim(input)
 
-	resultMap, ok := result.(map[string]interface{})
-	if !ok {
-		t.Fatal("Expected map result")
-	}
-
-	// Check string pool
-	if _, ok := resultMap["_strings"]; !ok {
-		t.Error("Expected _strings field")
-	}
-
-	// Check type inference on users
-	users := resultMap["users"]
-	if usersMap, ok := users.(map[string]interface{}); ok {
-		if _, ok := usersMap["_schema"]; !ok {
-			t.Error("Expected _schema in users")
-		}
-		if _, ok := usersMap["_data"]; !ok {
-			t.Error("Expected _data in users")
-		}
-	}
-
-	// Check decimal places on prices
-	prices := resultMap["prices"].([]interface{})
-	for i, p := range prices {
-		price := p.(float64)
-		if price != 20.0 && price != 29.12 && price != 40.0 {
-			t.Errorf("Price %d not rounded correctly: %v", i, price)
-		}
+	if _, ok := result.([]interface{}); !ok {
+		t.Fatalf("Expected plain array when no common field matches, got %v", result)
 	}
-
-	// Marshal to JSON to see size
-	jsonBytes, _ := json.Marshal(result)
-	t.Logf("Combined optimizations successful. Result size: %d bytes", len(jsonBytes))
-	t.Logf("Result: %s", string(jsonBytes))
 }
 
-// TestStripEmoji tests emoji and non-ASCII character removal
-func TestStripEmoji(t *testing.T) {
+func TestEmptyValues(t *testing.T) {
 	tests := []struct {
 		name     string
-		input    map[string]interface{}
-		expected map[string]interface{}
+		config   Config
+		input    string
+		expected string
 	}{
 		{
-			name: "Remove emoji from strings",
-			input: map[string]interface{}{
-				"message": "Hello 👋 World 🌍!",
-				"user":    "John 😊 Doe",
-				"status":  "✅ Completed",
-			},
-			expected: map[string]interface{}{
-				"message": "Hello  World !",
-				"user":    "John  Doe",
-				"status":  " Completed",
-			},
+			name:     "Sentinel removed, zero kept",
+			config:   Config{StripEmpty: true, EmptyValues: []interface{}{"N/A"}},
+			input:    `{"phone": "N/A", "retries": 0, "active": false, "name": "x", "tags": ["a", "N/A", "b"]}`,
+			expected: `{"retries": 0, "active": false, "name": "x", "tags": ["a", "b"]}`,
 		},
 		{
-			name: "Remove non-ASCII characters",
-			input: map[string]interface{}{
-				"text": "Café ☕ München 中文 日本語",
-			},
-			expected: map[string]interface{}{
-				"text": "Caf  Mnchen  ",
-			},
+			name:     "Zero removed only when listed",
+			config:   Config{StripEmpty: true, EmptyValues: []interface{}{0.0, "-"}},
+			input:    `{"retries": 0, "score": 0.0, "count": 3, "code": "0", "dash": "-"}`,
+			expected: `{"count": 3, "code": "0"}`,
 		},
 		{
-			name: "Preserve ASCII characters",
-			input: map[string]interface{}{
-				"text": "Hello World! 123 @#$%",
-			},
-			expected: map[string]interface{}{
-				"text": "Hello World! 123 @#$%",
-			},
+			name:     "Objects left empty are removed",
+			config:   Config{StripEmpty: true, EmptyValues: []interface{}{"unknown"}},
+			input:    `{"address": {"city": "unknown", "zip": "unknown"}, "id": 1}`,
+			expected: `{"id": 1}`,
 		},
 		{
-			name: "Mixed content",
-			input: map[string]interface{}{
-				"description": "Product 🎁 price: $99.99 💰",
-				"rating":      "⭐⭐⭐⭐⭐ 5/5",
-			},
-			expected: map[string]interface{}{
-				"description": "Product  price: $99.99 ",
-				"rating":      " 5/5",
-			},
+			name:     "Ignored without StripEmpty",
+			config:   Config{EmptyValues: []interface{}{"N/A"}},
+			input:    `{"phone": "N/A"}`,
+			expected: `{"phone": "N/A"}`,
+		},
+		{
+			name:     "Compared before string pooling",
+			config:   Config{StripEmpty: true, StringPooling: true, StringPoolMinOccurrences: 2, EmptyValues: []interface{}{0.0}},
+			input:    `{"from": "Amsterdam", "to": "Amsterdam"}`,
+			expected: `{"_strings": ["Amsterdam"], "from": 0, "to": 0}`,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := Config{
-				StripUTF8Emoji: true,
+			var inputData, expectedData interface{}
+			if err := json.Unmarshal([]byte(tt.input), &inputData); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expectedData); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			tt.config.DecimalPlaces = -1
+			got := New(tt.config).Slim(inputData)
+			gotBytes, _ := json.Marshal(got)
+			var gotData interface{}
+			if err := json.Unmarshal(gotBytes, &gotData); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if !reflect.DeepEqual(gotData, expectedData) {
+				t.Errorf("Slim() = %s, want %s", gotBytes, tt.expected)
+			}
+		})
+	}
+}
+
+// TestKeepNull tests that KeepNull keeps explicit nulls while StripEmpty
+// removes other empty values
+func TestKeepNull(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{
+			name:     "Null kept, empty values removed",
+			config:   Config{StripEmpty: true, KeepNull: true},
+			input:    `{"deleted_at": null, "note": "", "tags": [], "meta": {}, "items": [null, "", "a"], "id": 1}`,
+			expected: `{"deleted_at": null, "items": [null, "a"], "id": 1}`,
+		},
+		{
+			name:     "Object of nulls is not empty",
+			config:   Config{StripEmpty: true, KeepNull: true},
+			input:    `{"address": {"city": null, "zip": ""}}`,
+			expected: `{"address": {"city": null}}`,
+		},
+		{
+			name:     "Null listed in EmptyValues is still kept",
+			config:   Config{StripEmpty: true, KeepNull: true, EmptyValues: []interface{}{nil, "N/A"}},
+			input:    `{"a": null, "b": "N/A"}`,
+			expected: `{"a": null}`,
+		},
+		{
+			name:     "Wins over NullCompression",
+			config:   Config{StripEmpty: true, KeepNull: true, NullCompression: true},
+			input:    `{"a": null, "b": null, "c": "", "d": 1}`,
+			expected: `{"a": null, "b": null, "d": 1}`,
+		},
+		{
+			name:     "Nulls stripped without KeepNull",
+			config:   Config{StripEmpty: true},
+			input:    `{"a": null, "b": 1}`,
+			expected: `{"b": 1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputData, expectedData interface{}
+			if err := json.Unmarshal([]byte(tt.input), &inputData); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expectedData); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			tt.config.DecimalPlaces = -1
+			got := New(tt.config).Slim(inputData)
+			gotBytes, _ := json.Marshal(got)
+			var gotData interface{}
+			if err := json.Unmarshal(gotBytes, &gotData); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if !reflect.DeepEqual(gotData, expectedData) {
+				t.Errorf("Slim() = %s, want %s", gotBytes, tt.expected)
+			}
+		})
+	}
+}
+
+// TestStripEmptyCascade tests that objects left empty by stripping their
+// descendants are removed all the way up
+func TestStripEmptyCascade(t *testing.T) {
+	const chain = `{"id": 1, "a": {"b": {"c": {"secret": "x"}}}}`
+
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{
+			name:     "Blocked deepest field",
+			config:   Config{StripEmpty: true, BlockList: []string{"secret"}},
+			input:    chain,
+			expected: `{"id": 1}`,
+		},
+		{
+			name:     "Chains in arrays",
+			config:   Config{StripEmpty: true, BlockList: []string{"secret"}},
+			input:    `{"id": 1, "items": [{"x": {"y": {"secret": 1}}}, [[{"secret": 2}]]]}`,
+			expected: `{"id": 1}`,
+		},
+		{
+			name:     "Custom empty value",
+			config:   Config{StripEmpty: true, EmptyValues: []interface{}{"N/A"}},
+			input:    `{"id": 1, "a": {"b": {"c": {"d": "N/A", "e": null}}}}`,
+			expected: `{"id": 1}`,
+		},
+		{
+			name:     "Cut by MaxDepth with summaries",
+			config:   Config{StripEmpty: true, BlockList: []string{"secret"}, MaxDepth: 3, TruncationSummaries: true},
+			input:    `{"id": 1, "a": {"b": {"c": {"secret": "x"}}, "d": {"e": {"secret": "x", "f": ""}}, "g": [[[""]]]}}`,
+			expected: `{"id": 1}`,
+		},
+		{
+			name:     "Cut by MaxDepth with elision counts",
+			config:   Config{StripEmpty: true, BlockList: []string{"secret"}, MaxDepth: 3, ReportDepthElision: true},
+			input:    chain,
+			expected: `{"id": 1}`,
+		},
+		{
+			name:     "Cut by MaxDepth with markers",
+			config:   Config{StripEmpty: true, BlockList: []string{"secret"}, MaxDepth: 3, DepthTruncationMarker: "ellipsis"},
+			input:    `{"id": 1, "a": {"b": {"c": {"secret": "x"}, "kept": {"n": 1}}}}`,
+			expected: `{"id": 1, "a": {"b": {"kept": "..."}}}`,
+		},
+		{
+			name:     "Blocked fields are not counted",
+			config:   Config{StripEmpty: true, BlockList: []string{"secret"}, MaxDepth: 3, ReportDepthElision: true},
+			input:    `{"a": {"b": {"c": {"secret": "x", "n": 1, "s": ""}}}}`,
+			expected: `{"a": {"b": {"c": {"_depth_elided": 1}}}}`,
+		},
+		{
+			name:     "Kept without StripEmpty",
+			config:   Config{BlockList: []string{"secret"}},
+			input:    chain,
+			expected: `{"id": 1, "a": {"b": {"c": {}}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputData, expectedData interface{}
+			if err := json.Unmarshal([]byte(tt.input), &inputData); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expectedData); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			tt.config.DecimalPlaces = -1
+			gotBytes, _ := json.Marshal(New(tt.config).Slim(inputData))
+			var gotData interface{}
+			if err := json.Unmarshal(gotBytes, &gotData); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if !reflect.DeepEqual(gotData, expectedData) {
+				t.Errorf("Slim() = %s, want %s", gotBytes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseEmptyValues(t *testing.T) {
+	got := ParseEmptyValues(` N/A ; - ;0; "0" ;null;;false`)
+	want := []interface{}{"N/A", "-", 0.0, "0", nil, false}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseEmptyValues() = %#v, want %#v", got, want)
+	}
+
+	var cfg Config
+	if err := cfg.Set("empty-values", "N/A;-"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "empty-values requires strip-empty") {
+		t.Errorf("Validate() = %v, want empty-values requires strip-empty", err)
+	}
+}
+
+// TestDropIfEquals tests conditional field removal by value
+func TestDropIfEquals(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{
+			name: "Strings by field name",
+			config: Config{
+				DropIfEquals: map[string][]interface{}{"status": {"ok"}, "error": {"none"}},
+			},
+			input:    `{"status": "ok", "error": "none", "items": [{"status": "ok"}, {"status": "failed"}]}`,
+			expected: `{"items": [{}, {"status": "failed"}]}`,
+		},
+		{
+			name: "Numbers compare loosely",
+			config: Config{
+				DecimalPlaces: -1,
+				DropIfEquals:  map[string][]interface{}{"retries": {0}, "score": {-1.0}},
+			},
+			input:    `{"retries": 0.0, "score": -1, "other": 0}`,
+			expected: `{"other": 0}`,
+		},
+		{
+			name: "Booleans and null",
+			config: Config{
+				DropIfEquals: map[string][]interface{}{"deleted": {false}, "parent": {nil}},
+			},
+			input:    `{"deleted": false, "parent": null, "active": false}`,
+			expected: `{"active": false}`,
+		},
+		{
+			name: "Nested path",
+			config: Config{
+				DropIfEquals: map[string][]interface{}{"meta.status": {"ok"}, "users.meta.error": {"none"}},
+			},
+			input:    `{"status": "ok", "meta": {"status": "ok"}, "users": [{"meta": {"status": "ok", "error": "none"}}]}`,
+			expected: `{"status": "ok", "meta": {}, "users": [{"meta": {"status": "ok"}}]}`,
+		},
+		{
+			name: "Case-insensitive strings",
+			config: Config{
+				DropIfEquals:           map[string][]interface{}{"status": {"ok"}},
+				DropIfEqualsIgnoreCase: true,
+			},
+			input:    `{"status": "OK", "state": "OK"}`,
+			expected: `{"state": "OK"}`,
+		},
+		{
+			name: "Dropped before StripEmpty",
+			config: Config{
+				StripEmpty:   true,
+				DropIfEquals: map[string][]interface{}{"status": {"ok"}},
+			},
+			input:    `{"meta": {"status": "ok"}, "name": "x"}`,
+			expected: `{"name": "x"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputData, expectedData interface{}
+			if err := json.Unmarshal([]byte(tt.input), &inputData); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expectedData); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			got := New(tt.config).Slim(inputData)
+			if !reflect.DeepEqual(got, expectedData) {
+				gotBytes, _ := json.Marshal(got)
+				t.Errorf("Slim() = %s, want %s", gotBytes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPreserveFields(t *testing.T) {
+	signature := strings.Repeat("ab01", 64)
+	input := map[string]interface{}{
+		"id": 12345.6789,
+		"user": map[string]interface{}{
+			"name":      "A name long enough to be cut",
+			"bio":       "dropped by the block list",
+			"signature": signature,
+		},
+		"payload": map[string]interface{}{
+			"a": map[string]interface{}{"b": map[string]interface{}{"c": map[string]interface{}{"d": []interface{}{1.0, 2.0, 3.0, 4.0, "🔑 key", ""}}}},
+		},
+		"items": []interface{}{"one", "two", "three"},
+	}
+	aggressive := Config{
+		MaxDepth: 3, MaxListLength: 1, MaxStringLength: 10, StripEmpty: true, DecimalPlaces: 0,
+		BlockList: []string{"bio", "payload"}, StripUTF8Emoji: true, StringPooling: true, BoolCompression: true,
+		DropIfEquals:   map[string][]interface{}{"signature": {signature}},
+		PreserveFields: []string{"payload", "user.signature", "ID"},
+	}
+
+	result := New(aggressive).Slim(input).(map[string]interface{})
+
+	if !reflect.DeepEqual(result["payload"], input["payload"]) {
+		t.Errorf("Expected payload to be kept verbatim despite depth, list, emoji and block list limits, got %v", result["payload"])
+	}
+	if result["id"] != 12345.6789 {
+		t.Errorf("Expected id to keep its decimals, got %v", result["id"])
+	}
+	user := result["user"].(map[string]interface{})
+	if user["signature"] != signature {
+		t.Errorf("Expected the long signature to be kept, got %v", user["signature"])
+	}
+	if user["name"] != "A name ..." {
+		t.Errorf("Expected other strings to be truncated, got %v", user["name"])
+	}
+	if _, ok := user["bio"]; ok {
+		t.Error("Expected blocked fields outside PreserveFields to be removed")
+	}
+	if items := result["items"].([]interface{}); len(items) != 1 {
+		t.Errorf("Expected other lists to be truncated, got %v", items)
+	}
+	if pool, ok := result["_strings"].([]string); ok && slices.Contains(pool, signature) {
+		t.Error("Expected preserved strings not to be pooled")
+	}
+
+	// A preserved field survives an object otherwise cut by depth
+	summaries := Config{MaxDepth: 2, StripEmpty: true, TruncationSummaries: true, PreserveFields: []string{"id"}}
+	got := New(summaries).Slim(map[string]interface{}{"meta": map[string]interface{}{"id": "x", "tags": []interface{}{"a"}}})
+	if expected := map[string]interface{}{"meta": map[string]interface{}{"id": "x"}}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Slim() = %v, want %v", got, expected)
+	}
+}
+
+// TestFlatten tests merging nested objects into dotted keys
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{
+			name:     "Nested wrappers",
+			config:   Config{Flatten: true},
+			input:    `{"data": {"attributes": {"name": "x", "age": 3}}, "id": 1}`,
+			expected: `{"data.attributes.name": "x", "data.attributes.age": 3, "id": 1, "_flat": true}`,
+		},
+		{
+			name:     "Stops at arrays",
+			config:   Config{Flatten: true},
+			input:    `{"data": {"items": [{"meta": {"id": 1}}]}}`,
+			expected: `{"data.items": [{"meta.id": 1}], "_flat": true}`,
+		},
+		{
+			name:     "Depth limit",
+			config:   Config{Flatten: true, FlattenMaxDepth: 2},
+			input:    `{"a": {"b": {"c": 1}}, "d": {"e": 2}}`,
+			expected: `{"a": {"b.c": 1}, "d.e": 2, "_flat": true}`,
+		},
+		{
+			name:     "Collision with literal dotted key",
+			config:   Config{Flatten: true},
+			input:    `{"a": {"b": 1}, "a.b": 2, "c": {"d": 3}}`,
+			expected: `{"a": {"b": 1}, "a.b": 2, "c.d": 3}`,
+		},
+		{
+			name:     "BlockList matches flattened names",
+			config:   Config{Flatten: true, BlockList: []string{"data.secret"}},
+			input:    `{"data": {"secret": "x", "name": "y"}, "secret": "z"}`,
+			expected: `{"data.name": "y", "secret": "z", "_flat": true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputData, expectedData interface{}
+			if err := json.Unmarshal([]byte(tt.input), &inputData); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expectedData); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			got := New(tt.config).Slim(inputData)
+			if !reflect.DeepEqual(got, expectedData) {
+				gotBytes, _ := json.Marshal(got)
+				t.Errorf("Slim() = %s, want %s", gotBytes, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFlattenSingleKeyChains tests collapsing chains of single-key objects
+func TestFlattenSingleKeyChains(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{
+			name:     "Four-level chain",
+			config:   Config{FlattenSingleKeyChains: true},
+			input:    `{"data": {"result": {"item": {"payload": {"id": 1, "name": "x"}}}}, "ok": true}`,
+			expected: `{"data.result.item.payload": {"id": 1, "name": "x"}, "ok": true, "_flat": true}`,
+		},
+		{
+			name:     "Chain interrupted by a multi-key object",
+			config:   Config{FlattenSingleKeyChains: true},
+			input:    `{"data": {"result": {"items": {"a": {"b": 1}}, "count": 2}}}`,
+			expected: `{"data.result": {"items.a.b": 1, "count": 2}, "_flat": true}`,
+		},
+		{
+			name:     "Collapsed levels do not count toward MaxDepth",
+			config:   Config{FlattenSingleKeyChains: true, MaxDepth: 3, StripEmpty: true},
+			input:    `{"data": {"result": {"item": {"id": 1, "tags": {"a": 1, "b": 2}}}}}`,
+			expected: `{"data.result.item.id": 1, "_flat": true}`,
+		},
+		{
+			name:     "Segment limit",
+			config:   Config{FlattenSingleKeyChains: true, FlattenMaxDepth: 2},
+			input:    `{"a": {"b": {"c": {"d": 1}}}}`,
+			expected: `{"a.b": {"c.d": 1}, "_flat": true}`,
+		},
+		{
+			name:     "Arrays and metadata keys",
+			config:   Config{FlattenSingleKeyChains: true},
+			input:    `{"list": [{"x": {"y": 1}}], "meta": {"_id": 1}}`,
+			expected: `{"list": [{"x.y": 1}], "meta": {"_id": 1}, "_flat": true}`,
+		},
+		{
+			name:     "Skipped when a key contains a dot",
+			config:   Config{FlattenSingleKeyChains: true},
+			input:    `{"data": {"result": {"v1.2": 1}}}`,
+			expected: `{"data": {"result": {"v1.2": 1}}}`,
+		},
+		{
+			name:     "Skipped for a root array",
+			config:   Config{FlattenSingleKeyChains: true},
+			input:    `[{"data": {"id": 1}}]`,
+			expected: `[{"data": {"id": 1}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inputData, expectedData interface{}
+			if err := json.Unmarshal([]byte(tt.input), &inputData); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expectedData); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			got := New(tt.config).Slim(inputData)
+			if !reflect.DeepEqual(got, expectedData) {
+				gotBytes, _ := json.Marshal(got)
+				t.Errorf("Slim() = %s, want %s", gotBytes, tt.expected)
+			}
+
+			// Without limits, Unslim restores the input
+			if tt.config.MaxDepth == 0 {
+				restored, err := Unslim(got)
+				if err != nil {
+					t.Fatalf("Unslim() error: %v", err)
+				}
+				if !reflect.DeepEqual(restored, inputData) {
+					t.Errorf("Unslim() = %v, want %v", restored, inputData)
+				}
+			}
+		})
+	}
+}
+
+// TestSortKeys tests that repeated runs produce byte-identical output
+func TestSortKeys(t *testing.T) {
+	fileData, err := os.ReadFile("testing/fixtures/resume.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	cfg := Config{
+		MaxDepth:        5,
+		StripEmpty:      true,
+		SortKeys:        true,
+		StringPooling:   true,
+		EnumDetection:   true,
+		BoolCompression: true,
+		TypeInference:   true,
+		NullCompression: true,
+	}
+
+	var outputs []string
+	for i := 0; i < 5; i++ {
+		var data interface{}
+		if err := json.Unmarshal(fileData, &data); err != nil {
+			t.Fatalf("Failed to unmarshal fixture: %v", err)
+		}
+		out, err := json.Marshal(New(cfg).Slim(data))
+		if err != nil {
+			t.Fatalf("Failed to marshal result: %v", err)
+		}
+		outputs = append(outputs, string(out))
+	}
+
+	for i := 1; i < len(outputs); i++ {
+		if outputs[i] != outputs[0] {
+			t.Fatalf("Run %d output differs from run 0", i)
+		}
+	}
+
+	result, ok := New(cfg).Slim(map[string]interface{}{"b": 1, "a": map[string]interface{}{"d": 1, "c": 2}}).(*OrderedMap)
+	if !ok {
+		t.Fatal("Expected *OrderedMap result")
+	}
+	if result.Keys[0] != "a" || result.Keys[1] != "b" {
+		t.Errorf("Expected sorted keys, got %v", result.Keys)
+	}
+	nested := result.Values["a"].(*OrderedMap)
+	if nested.Keys[0] != "c" || nested.Keys[1] != "d" {
+		t.Errorf("Expected sorted nested keys, got %v", nested.Keys)
+	}
+}
+
+func TestPreserveKeyOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{
+			name:     "Nested objects and arrays",
+			config:   Config{DecimalPlaces: -1},
+			input:    `{"zeta": 1, "alpha": {"y": true, "b": "x"}, "mid": [{"k2": 1, "k1": 2}]}`,
+			expected: `{"zeta":1,"alpha":{"y":true,"b":"x"},"mid":[{"k2":1,"k1":2}]}`,
+		},
+		{
+			name:     "Removed fields keep the remaining order",
+			config:   Config{DecimalPlaces: -1, StripEmpty: true, BlockList: []string{"secret"}},
+			input:    `{"z": "", "y": 1, "secret": "s", "x": 2}`,
+			expected: `{"y":1,"x":2}`,
+		},
+		{
+			name:     "Type inference schema",
+			config:   Config{DecimalPlaces: -1, TypeInference: true},
+			input:    `{"rows": [{"id": 1, "name": "a", "age": 3}, {"id": 2, "name": "b", "age": 4}, {"id": 3, "name": "c", "age": 5}]}`,
+			expected: `{"rows":{"_data":[[1,"a",3],[2,"b",4],[3,"c",5]],"_schema":["id","name","age"]}}`,
+		},
+		{
+			name:     "Flattened keys",
+			config:   Config{DecimalPlaces: -1, Flatten: true},
+			input:    `{"z": 1, "data": {"y": 2, "x": {"w": 3, "v": 4}}, "a": 5}`,
+			expected: `{"z":1,"data.y":2,"data.x.w":3,"data.x.v":4,"a":5,"_flat":true}`,
+		},
+		{
+			name:     "Sort keys takes precedence",
+			config:   Config{DecimalPlaces: -1, SortKeys: true},
+			input:    `{"b": 1, "a": 2}`,
+			expected: `{"a":2,"b":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.PreserveKeyOrder = true
+			result, err := New(tt.config).SlimBytes([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("SlimBytes() error: %v", err)
+			}
+			got, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("SlimBytes() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSlimStream(t *testing.T) {
+	cfg := Config{DecimalPlaces: -1, StripEmpty: true, PreserveKeyOrder: true}
+
+	var out bytes.Buffer
+	if err := New(cfg).SlimStream(strings.NewReader(`{"b": 1, "a": null, "c": [1, 2]}`), &out); err != nil {
+		t.Fatalf("SlimStream() error: %v", err)
+	}
+	if got, expected := out.String(), "{\"b\":1,\"c\":[1,2]}\n"; got != expected {
+		t.Errorf("SlimStream() = %q, want %q", got, expected)
+	}
+
+	if err := New(cfg).SlimStream(strings.NewReader(""), &out); err != io.EOF {
+		t.Errorf("Expected io.EOF for empty input, got %v", err)
+	}
+	if err := New(cfg).SlimStream(strings.NewReader(`{"a": [1,`), &out); err == nil || err == io.EOF {
+		t.Errorf("Expected error for truncated input, got %v", err)
+	}
+	if _, err := New(cfg).SlimBytes([]byte(`{"a": 1} {"b": 2}`)); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+
+	// Without PreserveKeyOrder the map-based path is used
+	result, err := New(Config{}).SlimBytes([]byte(`{"a": 1}`))
+	if err != nil {
+		t.Fatalf("SlimBytes() error: %v", err)
+	}
+	if _, ok := result.(map[string]interface{}); !ok {
+		t.Errorf("Expected map result, got %T", result)
+	}
+}
+
+func TestTruncationSummaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{
+			name:     "Object cut by depth",
+			config:   Config{MaxDepth: 3, StripEmpty: true, BlockList: []string{"secret"}},
+			input:    `{"user": {"profile": {"name": "a", "secret": "x", "age": 3}}}`,
+			expected: `{"user": {"profile": {"_truncated": {"keys": ["age", "name"], "depth": 2}}}}`,
+		},
+		{
+			name:     "Array cut by depth",
+			config:   Config{MaxDepth: 3},
+			input:    `{"a": {"list": [1, 2, 3]}}`,
+			expected: `{"a": {"list": [{"_omitted": 3}]}}`,
+		},
+		{
+			name:     "Truncated array",
+			config:   Config{MaxListLength: 2},
+			input:    `{"items": [1, 2, 3, 4, 5]}`,
+			expected: `{"items": [1, 2, {"_omitted": 3}]}`,
+		},
+		{
+			name:     "Truncated type-inferred array",
+			config:   Config{MaxListLength: 3, TypeInference: true},
+			input:    `[{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}]`,
+			expected: `{"_schema": ["id"], "_data": [[1], [2], [3]], "_omitted": 1}`,
+		},
+		{
+			name:     "Key names respect MaxStringLength",
+			config:   Config{MaxDepth: 1, MaxStringLength: 5},
+			input:    `{"description": "x", "id": 1}`,
+			expected: `{"_truncated": {"keys": ["de...", "id"], "depth": 0}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.TruncationSummaries = true
+			tt.config.DecimalPlaces = -1
+
+			var input, expected interface{}
+			if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			result := normalizeJSON(New(tt.config).Slim(input))
+			if !reflect.DeepEqual(result, expected) {
+				got, _ := json.Marshal(result)
+				t.Errorf("Slim() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("Golden schema-resume", func(t *testing.T) {
+		fileData, err := os.ReadFile("testing/fixtures/schema-resume.json")
+		if err != nil {
+			t.Fatalf("Failed to read fixture: %v", err)
+		}
+		var data interface{}
+		if err := json.Unmarshal(fileData, &data); err != nil {
+			t.Fatalf("Failed to unmarshal fixture: %v", err)
+		}
+
+		cfg := Config{MaxDepth: 2, MaxListLength: 3, StripEmpty: true, DecimalPlaces: -1, TruncationSummaries: true}
+		got, err := json.MarshalIndent(New(cfg).Slim(data), "", "  ")
+		if err != nil {
+			t.Fatalf("Failed to marshal result: %v", err)
+		}
+		got = append(got, '\n')
+
+		golden := "testdata/truncation_summaries.golden.json"
+		if *update {
+			if err := os.WriteFile(golden, got, 0o644); err != nil {
+				t.Fatalf("Failed to update golden file: %v", err)
+			}
+		}
+		expected, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("Failed to read golden file: %v", err)
+		}
+		if string(got) != string(expected) {
+			t.Errorf("Slim() output differs from %s:\n%s", golden, got)
+		}
+	})
+}
+
+// TestNullCompression tests null field tracking
+func TestValueTransform(t *testing.T) {
+	upperTags := func(path string, value interface{}) (interface{}, bool) {
+		if str, ok := value.(string); ok && strings.HasPrefix(path, "tags.") {
+			return strings.ToUpper(str), true
+		}
+		return nil, false
+	}
+	dropInternal := func(path string, value interface{}) (interface{}, bool) {
+		if strings.HasSuffix(path, ".internal") {
+			return nil, true
+		}
+		return nil, false
+	}
+
+	tests := []struct {
+		name      string
+		config    Config
+		transform func(path string, value interface{}) (interface{}, bool)
+		input     string
+		expected  string
+	}{
+		{
+			name:      "Upper-case tags",
+			config:    Config{DecimalPlaces: -1},
+			transform: upperTags,
+			input:     `{"tags": ["go", "json"], "name": "slim"}`,
+			expected:  `{"name":"slim","tags":["GO","JSON"]}`,
+		},
+		{
+			name:      "Built-in processing runs after the transform",
+			config:    Config{DecimalPlaces: -1, MaxStringLength: 5},
+			transform: upperTags,
+			input:     `{"tags": ["abcdefgh"]}`,
+			expected:  `{"tags":["AB..."]}`,
+		},
+		{
+			name:      "Nil removes the value under StripEmpty",
+			config:    Config{DecimalPlaces: -1, StripEmpty: true},
+			transform: dropInternal,
+			input:     `{"a": {"internal": 1, "x": 2}, "b": {"internal": true}}`,
+			expected:  `{"a":{"x":2}}`,
+		},
+		{
+			name:      "Nil is kept as null without StripEmpty",
+			config:    Config{DecimalPlaces: -1},
+			transform: dropInternal,
+			input:     `{"a": {"internal": 1}}`,
+			expected:  `{"a":{"internal":null}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			tt.config.ValueTransform = tt.transform
+			got, err := json.Marshal(New(tt.config).Slim(data))
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Slim() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNullCompression(t *testing.T) {
+	input := map[string]interface{}{
+		"name":  "John",
+		"email": nil,
+		"phone": nil,
+		"age":   30,
+	}
+
+	cfg := Config{
+		NullCompression: true,
+		StripEmpty:      true,
+	}
+
+	slimmer := New(cfg)
+	result := slimmer.Slim(input)
+
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected map result")
+	}
+
+	// Check that _nulls exists
+	nulls, ok := resultMap["_nulls"]
+	if !ok {
+		t.Fatal("Expected _nulls field")
+	}
+
+	nullList := nulls.([]string)
+	if len(nullList) != 2 {
+		t.Errorf("Expected 2 null fields tracked, got %d", len(nullList))
+	}
+
+	t.Logf("Null compression successful: %d null fields tracked", len(nullList))
+}
+
+// TestDecimalPlaces tests numeric precision control
+func TestDecimalPlaces(t *testing.T) {
+	input := map[string]interface{}{
+		"price":  19.99999,
+		"rating": 4.666666,
+		"score":  89.12345,
+	}
+
+	cfg := Config{
+		DecimalPlaces: 2,
+	}
+
+	slimmer := New(cfg)
+	result := slimmer.Slim(input)
+
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected map result")
+	}
+
+	price := resultMap["price"].(float64)
+	rating := resultMap["rating"].(float64)
+	score := resultMap["score"].(float64)
+
+	if price != 20.0 {
+		t.Errorf("Expected price=20.0, got %v", price)
+	}
+
+	if rating != 4.67 {
+		t.Errorf("Expected rating=4.67, got %v", rating)
+	}
+
+	if score != 89.12 {
+		t.Errorf("Expected score=89.12, got %v", score)
+	}
+
+	t.Logf("Decimal places successful: price=%v, rating=%v, score=%v", price, rating, score)
+}
+
+func TestDepthTruncationMarker(t *testing.T) {
+	input := `{"a": {"b": {"c": 1}, "list": [1, 2], "n": 3, "e": ""}}`
+	tests := []struct {
+		marker   string
+		expected string
+		stripped string // With StripEmpty
+	}{
+		{marker: "", expected: `{"a": {"b": null, "list": null, "n": null, "e": null}}`, stripped: `null`},
+		{marker: "null", expected: `{"a": {"b": null, "list": null, "n": null, "e": null}}`, stripped: `null`},
+		{marker: "empty", expected: `{"a": {"b": {}, "list": [], "n": null, "e": null}}`, stripped: `{"a": {"b": {}, "list": []}}`},
+		{marker: "ellipsis", expected: `{"a": {"b": "...", "list": "...", "n": "...", "e": "..."}}`, stripped: `{"a": {"b": "...", "list": "...", "n": "...", "e": "..."}}`},
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(input), &data); err != nil {
+		t.Fatalf("Failed to unmarshal input: %v", err)
+	}
+	fixture, err := os.ReadFile("testing/fixtures/users.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	var users []interface{}
+	if err := json.Unmarshal(fixture, &users); err != nil {
+		t.Fatalf("Failed to unmarshal fixture: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run("Marker "+tt.marker, func(t *testing.T) {
+			for _, strip := range []bool{false, true} {
+				cfg := Config{MaxDepth: 2, DepthTruncationMarker: tt.marker, StripEmpty: strip, DecimalPlaces: -1}
+				want := tt.expected
+				if strip {
+					want = tt.stripped
+				}
+				var expected interface{}
+				if err := json.Unmarshal([]byte(want), &expected); err != nil {
+					t.Fatalf("Failed to unmarshal expected: %v", err)
+				}
+				if got := normalizeJSON(New(cfg).Slim(data)); !reflect.DeepEqual(got, expected) {
+					out, _ := json.Marshal(got)
+					t.Errorf("StripEmpty=%v: Slim() = %s, want %s", strip, out, want)
+				}
+			}
+
+			// The values of each user's address are cut at depth 3
+			cfg := Config{MaxDepth: 3, DepthTruncationMarker: tt.marker, StripEmpty: true, DecimalPlaces: -1}
+			user := New(cfg).Slim(users).([]interface{})[0].(map[string]interface{})
+			address, ok := user["address"].(map[string]interface{})
+			switch tt.marker {
+			case "empty":
+				if expected := map[string]interface{}{"geo": map[string]interface{}{}}; !reflect.DeepEqual(address, expected) {
+					t.Errorf("Expected address %v, got %v", expected, user["address"])
+				}
+			case "ellipsis":
+				if !ok || address["geo"] != "..." || address["city"] != "..." {
+					t.Errorf("Expected \"...\" address values, got %v", user["address"])
+				}
+			default:
+				if _, found := user["address"]; found {
+					t.Errorf("Expected address to be stripped, got %v", user["address"])
+				}
+			}
+		})
+	}
+}
+
+func TestReportDepthElision(t *testing.T) {
+	input := `{"id": 1, "a": {"b": {"c": 1, "d": [1, 2, 3]}, "list": [1, 2], "empty": {}, "n": 3}}`
+	var data interface{}
+	if err := json.Unmarshal([]byte(input), &data); err != nil {
+		t.Fatalf("Failed to unmarshal input: %v", err)
+	}
+
+	var elided []string
+	cfg := Config{MaxDepth: 2, ReportDepthElision: true, DepthTruncationMarker: "ellipsis", DecimalPlaces: -1}
+	cfg.OnRemove = func(path string, reason RemoveReason, _ interface{}) {
+		if reason == ReasonDepthExceeded {
+			elided = append(elided, path)
+		}
+	}
+	got, err := json.Marshal(New(cfg).Slim(data))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	// Non-empty objects and arrays are annotated, other values get the marker
+	expected := `{"a":{"b":{"_depth_elided":2},"empty":"...","list":{"_depth_elided":2},"n":"..."},"id":1}`
+	if string(got) != expected {
+		t.Errorf("Slim() = %s, want %s", got, expected)
+	}
+	slices.Sort(elided)
+	if want := []string{"a.b", "a.empty", "a.list", "a.n"}; !reflect.DeepEqual(elided, want) {
+		t.Errorf("Expected %d values cut by depth %v, got %v", len(want), want, elided)
+	}
+
+	// Annotations are kept under StripEmpty, and TruncationSummaries takes precedence
+	cfg = Config{MaxDepth: 2, ReportDepthElision: true, StripEmpty: true, DecimalPlaces: -1}
+	got, _ = json.Marshal(New(cfg).Slim(data))
+	if expected := `{"a":{"b":{"_depth_elided":2},"list":{"_depth_elided":2}},"id":1}`; string(got) != expected {
+		t.Errorf("With StripEmpty, Slim() = %s, want %s", got, expected)
+	}
+	cfg.TruncationSummaries = true
+	got, _ = json.Marshal(New(cfg).Slim(data))
+	if strings.Contains(string(got), "_depth_elided") {
+		t.Errorf("Expected TruncationSummaries to take precedence, got %s", got)
+	}
+}
+
+func TestFieldDecimalPlaces(t *testing.T) {
+	input := map[string]interface{}{
+		"price": 19.98765,
+		"score": 4.666666,
+		"raw":   1.23456789,
+		"store": map[string]interface{}{
+			"lat":  52.229675812,
+			"lng":  21.012228734,
+			"path": []interface{}{1.23456789, 2.34567891},
+		},
+		"items": []interface{}{
+			map[string]interface{}{"price": 5.5555, "Lat": 10.123456789},
+		},
+	}
+	cfg := Config{
+		DecimalPlaces:      1,
+		FieldDecimalPlaces: map[string]int{"price": 2, "lat": 6, "lng": 6, "store.lat": 3, "store.path": 3, "raw": -1},
+	}
+
+	got, err := json.Marshal(New(cfg).Slim(input))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	want := `{"items":[{"Lat":10.123457,"price":5.56}],"price":19.99,"raw":1.23456789,"score":4.7,` +
+		`"store":{"lat":52.23,"lng":21.012229,"path":[1.235,2.346]}}`
+	if string(got) != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+// TestDeduplication tests array deduplication
+func TestDeduplication(t *testing.T) {
+	input := map[string]interface{}{
+		"tags": []interface{}{"go", "json", "go", "json", "go", "api"},
+	}
+
+	cfg := Config{
+		DeduplicateArrays: true,
+	}
+
+	slimmer := New(cfg)
+	result := slimmer.Slim(input)
+
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected map result")
+	}
+
+	tags := resultMap["tags"].([]interface{})
+	if len(tags) != 3 {
+		t.Errorf("Expected 3 unique tags, got %d", len(tags))
+	}
+
+	t.Logf("Deduplication successful: 6 items reduced to %d unique", len(tags))
+}
+
+// TestDeduplicationObjects tests that structurally equal objects and arrays are deduplicated
+func TestDeduplicationObjects(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Key-reordered objects",
+			input:    `{"users": [{"id": 1, "name": "a"}, {"name": "a", "id": 1}, {"id": 2, "name": "a"}]}`,
+			expected: `{"users":[{"id":1,"name":"a"},{"id":2,"name":"a"}]}`,
+		},
+		{
+			name:     "Nested arrays",
+			input:    `{"pairs": [[1, {"x": 1, "y": [2]}], [1, {"y": [2], "x": 1.0}], [{"x": 1, "y": [2]}, 1]]}`,
+			expected: `{"pairs":[[1,{"x":1,"y":[2]}],[{"x":1,"y":[2]},1]]}`,
+		},
+		{
+			name:     "Different types",
+			input:    `{"values": [1, "1", true, "true", null, "null", {}, []]}`,
+			expected: `{"values":[1,"1",true,"true",null,"null",{},[]]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Config{DeduplicateArrays: true, PreserveKeyOrder: true, DecimalPlaces: -1})
+			result, err := s.SlimBytes([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("SlimBytes() error: %v", err)
+			}
+			got, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Deduplicated = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSamplingFirstLast tests first_last sampling strategy
+func TestSamplingFirstLast(t *testing.T) {
+	input := map[string]interface{}{
+		"items": []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+	}
+
+	cfg := Config{
+		SampleStrategy: "first_last",
+		SampleSize:     6,
+	}
+
+	slimmer := New(cfg)
+	result := slimmer.Slim(input)
+
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected map result")
+	}
+
+	items := resultMap["items"].([]interface{})
+	if len(items) != 6 {
+		t.Errorf("Expected 6 sampled items, got %d", len(items))
+	}
+
+	// Should have first 3 and last 3
+	if items[0].(int) != 1 || items[1].(int) != 2 || items[2].(int) != 3 {
+		t.Error("Expected first 3 items: [1, 2, 3]")
+	}
+
+	if items[3].(int) != 18 || items[4].(int) != 19 || items[5].(int) != 20 {
+		t.Error("Expected last 3 items: [18, 19, 20]")
+	}
+
+	t.Logf("First-last sampling successful: 20 items sampled to %d", len(items))
+}
+
+// TestSamplingRepresentative tests representative sampling strategy
+func TestSamplingRepresentative(t *testing.T) {
+	input := map[string]interface{}{
+		"items": []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+	}
+
+	cfg := Config{
+		SampleStrategy: "representative",
+		SampleSize:     4,
+	}
+
+	slimmer := New(cfg)
+	result := slimmer.Slim(input)
+
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected map result")
+	}
+
+	items := resultMap["items"].([]interface{})
+	if len(items) != 4 {
+		t.Errorf("Expected 4 sampled items, got %d", len(items))
+	}
+
+	t.Logf("Representative sampling successful: 10 items sampled to %d", len(items))
+}
+
+// TestSamplingByValue tests largest and smallest sampling strategies
+func TestSamplingByValue(t *testing.T) {
+	scores := `[
+		{"id": 1, "score": 40}, {"id": 2, "score": 95}, {"id": 3, "score": 12},
+		{"id": 4, "score": 77}, {"id": 5, "score": 3}, {"id": 6, "score": 88},
+		{"id": 7}, {"id": 8, "score": 61}, {"id": 9, "score": 95}, {"id": 10, "score": 50}
+	]`
+
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{
+			name:     "Largest 3 objects by score",
+			config:   Config{SampleStrategy: "largest", SampleSortKey: "score", SampleSize: 3},
+			input:    scores,
+			expected: `[{"id":2,"score":95},{"id":6,"score":88},{"id":9,"score":95}]`,
+		},
+		{
+			name:     "Smallest 3 objects by score",
+			config:   Config{SampleStrategy: "smallest", SampleSortKey: "score", SampleSize: 3},
+			input:    scores,
+			expected: `[{"id":1,"score":40},{"id":3,"score":12},{"id":5,"score":3}]`,
+		},
+		{
+			name:     "Objects without the key are kept last",
+			config:   Config{SampleStrategy: "smallest", SampleSortKey: "score", MaxListLength: 2},
+			input:    `[{"id": 1}, {"id": 2, "score": 5}, {"id": 3, "score": 1}]`,
+			expected: `[{"id":2,"score":5},{"id":3,"score":1}]`,
+		},
+		{
+			name:     "Nested sort key",
+			config:   Config{SampleStrategy: "largest", SampleSortKey: "stats.hits", SampleSize: 1},
+			input:    `[{"stats": {"hits": 2}}, {"stats": {"hits": 9}}, {"stats": {"hits": 4}}]`,
+			expected: `[{"stats":{"hits":9}}]`,
+		},
+		{
+			name:     "Numbers",
+			config:   Config{SampleStrategy: "largest", MaxListLength: 3},
+			input:    `[5, 1, 9, 3, 7, 2]`,
+			expected: `[5,9,7]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			tt.config.DecimalPlaces = -1
+			got, err := json.Marshal(New(tt.config).Slim(data))
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Slim() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSamplingFrequency tests frequency sampling over a skewed tag array
+func TestSamplingFrequency(t *testing.T) {
+	tags := `["go", "json", "go", "api", "go", "json", "cli", "go", "json", "go", "api", "db"]`
+
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{
+			name:     "Top 3 tags, most common first",
+			config:   Config{SampleStrategy: "frequency", SampleSize: 3},
+			input:    tags,
+			expected: `["go","json","api"]`,
+		},
+		{
+			name:     "Top 2 tags with counts",
+			config:   Config{SampleStrategy: "frequency", SampleSize: 2, SampleCounts: true},
+			input:    tags,
+			expected: `[{"_count":5,"_value":"go"},{"_count":3,"_value":"json"}]`,
+		},
+		{
+			name:     "Fewer distinct values than the sample size",
+			config:   Config{SampleStrategy: "frequency", MaxListLength: 10},
+			input:    `["b", "a", "a", "b", "a"]`,
+			expected: `["a","b"]`,
+		},
+		{
+			name:     "Numbers compare by value",
+			config:   Config{SampleStrategy: "frequency", SampleSize: 2},
+			input:    `[1.5, 1.7, 1.7, "1.7", 2]`,
+			expected: `[1.7,1.5]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			tt.config.DecimalPlaces = -1
+			got, err := json.Marshal(New(tt.config).Slim(data))
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Slim() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSamplingStratified tests that stratified sampling preserves category proportions
+func TestSamplingStratified(t *testing.T) {
+	logs := make([]interface{}, 100)
+	for i := range logs {
+		level := "info"
+		switch {
+		case i%10 == 3:
+			level = "error"
+		case i%5 == 1:
+			level = "warn"
+		}
+		logs[i] = map[string]interface{}{"id": i, "level": level}
+	}
+
+	tests := []struct {
+		name     string
+		size     int
+		expected map[string]int
+	}{
+		{name: "70/20/10 split of 20", size: 20, expected: map[string]int{"info": 14, "warn": 4, "error": 2}},
+		{name: "Ties in rounding go to the first group", size: 15, expected: map[string]int{"info": 11, "warn": 3, "error": 1}},
+		{name: "Single element", size: 1, expected: map[string]int{"info": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Config{SampleStrategy: "stratified", SampleStratifyKey: "level", SampleSize: tt.size})
+			result := s.Slim(logs).([]interface{})
+
+			counts := make(map[string]int)
+			prev := -1
+			for _, item := range result {
+				m := item.(map[string]interface{})
+				counts[m["level"].(string)]++
+				if id := m["id"].(int); id <= prev {
+					t.Errorf("Expected original order, got id %d after %d", id, prev)
+				} else {
+					prev = id
+				}
+			}
+			if !reflect.DeepEqual(counts, tt.expected) {
+				t.Errorf("Sampled levels = %v, want %v", counts, tt.expected)
+			}
+		})
+	}
+
+	// Without the key, stratified sampling is representative
+	items := []interface{}{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	stratified := New(Config{SampleStrategy: "stratified", SampleStratifyKey: "level", SampleSize: 5}).Slim(items)
+	representative := New(Config{SampleStrategy: "representative", SampleSize: 5}).Slim(items)
+	if !reflect.DeepEqual(stratified, representative) {
+		t.Errorf("Expected representative fallback %v, got %v", representative, stratified)
+	}
+}
+
+func TestValueToString(t *testing.T) {
+	tests := []struct {
+		a, b  interface{}
+		equal bool
+	}{
+		{a: 1, b: 1.0, equal: true},
+		{a: 1.5, b: 1.7, equal: false},
+		{a: "1", b: 1, equal: false},
+		{a: nil, b: "null", equal: false},
+		{a: map[string]interface{}{"a": 1, "b": 2}, b: map[string]interface{}{"b": 2, "a": 1}, equal: true},
+		{a: map[string]interface{}{"a": 1}, b: map[string]interface{}{"a": 2}, equal: false},
+		{a: []interface{}{1, 2}, b: []interface{}{2, 1}, equal: false},
+	}
+
+	for _, tt := range tests {
+		if got := valueToString(tt.a) == valueToString(tt.b); got != tt.equal {
+			t.Errorf("valueToString(%#v) == valueToString(%#v) is %v, want %v", tt.a, tt.b, got, tt.equal)
+		}
+	}
+}
+
+// TestCombinedOptimizations tests multiple optimizations together
+func TestCombinedOptimizations(t *testing.T) {
+	input := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{
+				"id":       1,
+				"name":     "Alice",
+				"email":    "alice@example.com",
+				"verified": true,
+				"premium":  false,
+			},
+			map[string]interface{}{
+				"id":       2,
+				"name":     "Bob",
+				"email":    "bob@example.com",
+				"verified": false,
+				"premium":  false,
+			},
+			map[string]interface{}{
+				"id":       3,
+				"name":     "Alice",
+				"email":    "alice@example.com",
+				"verified": true,
+				"premium":  true,
+			},
+		},
+		"prices": []interface{}{19.99999, 29.12345, 39.99999},
+	}
+
+	cfg := Config{
+		StringPooling:            true,
+		StringPoolMinOccurrences: 2,
+		TypeInference:            true,
+		DecimalPlaces:            2,
+	}
+
+	slimmer := New(cfg)
+	result := slimmer.Slim(input)
+
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected map result")
+	}
+
+	// Check string pool
+	if _, ok := resultMap["_strings"]; !ok {
+		t.Error("Expected _strings field")
+	}
+
+	// Check type inference on users
+	users := resultMap["users"]
+	if usersMap, ok := users.(map[string]interface{}); ok {
+		if _, ok := usersMap["_schema"]; !ok {
+			t.Error("Expected _schema in users")
+		}
+		if _, ok := usersMap["_data"]; !ok {
+			t.Error("Expected _data in users")
+		}
+	}
+
+	// Check decimal places on prices
+	prices := resultMap["prices"].([]interface{})
+	for i, p := range prices {
+		price := p.(float64)
+		if price != 20.0 && price != 29.12 && price != 40.0 {
+			t.Errorf("Price %d not rounded correctly: %v", i, price)
+		}
+	}
+
+	// Marshal to JSON to see size
+	jsonBytes, _ := json.Marshal(result)
+	t.Logf("Combined optimizations successful. Result size: %d bytes", len(jsonBytes))
+	t.Logf("Result: %s", string(jsonBytes))
+}
+
+// TestStripEmoji tests emoji and non-ASCII character removal
+func TestInlineEnumThreshold(t *testing.T) {
+	// status occurs 6 times, priority twice
+	input := `{"tasks":[{"status":"open"},{"status":"done"},{"status":"open"},{"status":"done"},{"status":"open"},` +
+		`{"status":"open"}],"meta":{"priority":"high"},"owner":{"priority":"low"}}`
+	tests := []struct {
+		name      string
+		threshold int
+		expected  map[string][]string
+	}{
+		{name: "Disabled", threshold: 0, expected: map[string][]string{"tasks.status": {"done", "open"}, "meta.priority": {"high"}, "owner.priority": {"low"}}},
+		{name: "Rare fields inline", threshold: 3, expected: map[string][]string{"tasks.status": {"done", "open"}}},
+		{name: "All inline", threshold: 6, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			result := New(Config{EnumDetection: true, InlineEnumThreshold: tt.threshold}).Slim(data).(map[string]interface{})
+			enums, _ := result["_enums"].(map[string][]string)
+			if !reflect.DeepEqual(enums, tt.expected) {
+				t.Errorf("Expected _enums %v, got %v", tt.expected, result["_enums"])
+			}
+			if meta := result["meta"].(map[string]interface{}); meta["priority"] != "high" {
+				t.Errorf("Expected priority to stay inline, got %v", meta["priority"])
+			}
+		})
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"", 0},
+		{"   ", 0},
+		{"{}", 1},
+		{`{"a":1}`, 2},
+		{"  12345678  ", 2},
+	}
+
+	for _, tt := range tests {
+		if got := EstimateTokens([]byte(tt.input)); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestStripEmoji(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name: "Remove emoji from strings",
+			input: map[string]interface{}{
+				"message": "Hello 👋 World 🌍!",
+				"user":    "John 😊 Doe",
+				"status":  "✅ Completed",
+			},
+			expected: map[string]interface{}{
+				"message": "Hello  World !",
+				"user":    "John  Doe",
+				"status":  " Completed",
+			},
+		},
+		{
+			name: "Remove non-ASCII characters",
+			input: map[string]interface{}{
+				"text": "Café ☕ München 中文 日本語",
+			},
+			expected: map[string]interface{}{
+				"text": "Caf  Mnchen  ",
+			},
+		},
+		{
+			name: "Preserve ASCII characters",
+			input: map[string]interface{}{
+				"text": "Hello World! 123 @#$%",
+			},
+			expected: map[string]interface{}{
+				"text": "Hello World! 123 @#$%",
+			},
+		},
+		{
+			name: "Mixed content",
+			input: map[string]interface{}{
+				"description": "Product 🎁 price: $99.99 💰",
+				"rating":      "⭐⭐⭐⭐⭐ 5/5",
+			},
+			expected: map[string]interface{}{
+				"description": "Product  price: $99.99 ",
+				"rating":      " 5/5",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{
+				StripUTF8Emoji: true,
 			}
 
 			slimmer := New(cfg)
@@ -629,6 +2119,220 @@ func TestStripEmoji(t *testing.T) {
 	}
 }
 
+func TestCleanWhitespaceAndQuotes(t *testing.T) {
+	const input = "\u201cSmart\u201d quotes\u00a0and\u200b zero\u2011width \u2018spaces\u2019 \u2014 done\u2026 \U0001F600"
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{name: "Off", config: Config{}, expected: input},
+		{name: "Clean whitespace", config: Config{CleanWhitespace: true}, expected: "\u201cSmart\u201d quotes and zero\u2011width \u2018spaces\u2019 \u2014 done\u2026 \U0001F600"},
+		{name: "Normalize quotes", config: Config{NormalizeQuotes: true}, expected: "\"Smart\" quotes\u00a0and\u200b zero-width 'spaces' - done... \U0001F600"},
+		{name: "Both", config: Config{CleanWhitespace: true, NormalizeQuotes: true}, expected: "\"Smart\" quotes and zero-width 'spaces' - done... \U0001F600"},
+		{name: "Both before stripping emoji", config: Config{CleanWhitespace: true, NormalizeQuotes: true, StripUTF8Emoji: true}, expected: "\"Smart\" quotes and zero-width 'spaces' - done... "},
+		{name: "Stripping emoji alone drops the characters", config: Config{StripUTF8Emoji: true}, expected: "Smart quotesand zerowidth spaces  done "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DecimalPlaces = -1
+			result := New(tt.config).Slim(map[string]interface{}{"text": input})
+			if got := result.(map[string]interface{})["text"]; got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	// Line separators become newlines, and other Unicode spaces plain spaces
+	got := New(Config{CleanWhitespace: true, DecimalPlaces: -1}).Slim("a\u2028b\u3000c\u2009d\ufeff")
+	if got != "a\nb c d" {
+		t.Errorf("Expected %q, got %q", "a\nb c d", got)
+	}
+}
+
+func TestCollapseWhitespace(t *testing.T) {
+	const (
+		indented = "\n    func main() {\n        fmt.Println(\"hi\")\n    }\n\n    Done.  \n"
+		tabbed   = "id\tname\t\tscore\r\n1\tAlice\t\t90\r\n"
+	)
+
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{name: "Off", config: Config{}, input: tabbed, expected: tabbed},
+		{name: "Indented multi-line text", config: Config{CollapseWhitespace: true}, input: indented, expected: "func main() { fmt.Println(\"hi\") } Done."},
+		{name: "Keep newlines", config: Config{CollapseWhitespace: true, CollapseWhitespaceKeepNewlines: true}, input: indented, expected: "func main() {\nfmt.Println(\"hi\")\n}\nDone."},
+		{name: "Tab-separated content", config: Config{CollapseWhitespace: true}, input: tabbed, expected: "id name score 1 Alice 90"},
+		{name: "Tab-separated lines kept", config: Config{CollapseWhitespace: true, CollapseWhitespaceKeepNewlines: true}, input: tabbed, expected: "id name score\n1 Alice 90"},
+		{name: "Unicode spaces", config: Config{CollapseWhitespace: true}, input: "a\u00a0\u00a0b\u2003 c", expected: "a b c"},
+		{name: "Gaps left by stripped emoji", config: Config{CollapseWhitespace: true, StripUTF8Emoji: true}, input: "Launch \U0001F680 today", expected: "Launch today"},
+		{name: "Before truncation", config: Config{CollapseWhitespace: true, MaxStringLength: 9, TruncationSuffix: stringPtr("")}, input: "one\n\n\ttwo\n\n\tthree", expected: "one two t"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DecimalPlaces = -1
+			result := New(tt.config).Slim(map[string]interface{}{"text": tt.input})
+			if got := result.(map[string]interface{})["text"]; got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeUnicode(t *testing.T) {
+	const (
+		composed   = "Caf\u00e9 M\u00fcnchen"                        // é and ü as single code points
+		decomposed = "Cafe\u0301 Mu\u0308nchen"                      // e and u followed by combining marks
+		fullWidth  = "\uff21\uff22\uff23\uff11\uff12\uff13 \ufb01le" // ＡＢＣ１２３ ﬁle
+	)
+
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{name: "None keeps decomposed input", config: Config{}, input: decomposed, expected: decomposed},
+		{name: "NFC composes", config: Config{NormalizeUnicode: "NFC"}, input: decomposed, expected: composed},
+		{name: "NFD decomposes", config: Config{NormalizeUnicode: "NFD"}, input: composed, expected: decomposed},
+		{name: "Lowercase form name", config: Config{NormalizeUnicode: "nfc"}, input: decomposed, expected: composed},
+		{name: "NFD before stripping keeps base letters", config: Config{NormalizeUnicode: "NFD", StripUTF8Emoji: true}, input: composed, expected: "Cafe Munchen"},
+		{name: "NFD strips composed and decomposed input alike", config: Config{NormalizeUnicode: "NFD", StripUTF8Emoji: true}, input: decomposed, expected: "Cafe Munchen"},
+		{name: "NFC before stripping removes whole characters", config: Config{NormalizeUnicode: "NFC", StripUTF8Emoji: true}, input: decomposed, expected: "Caf Mnchen"},
+		{name: "NFKC folds full-width characters and ligatures", config: Config{NormalizeUnicode: "NFKC"}, input: fullWidth, expected: "ABC123 file"},
+		{name: "NFKC before stripping keeps folded characters", config: Config{NormalizeUnicode: "NFKC", StripUTF8Emoji: true}, input: fullWidth, expected: "ABC123 file"},
+		{name: "NFC before truncation counts composed characters", config: Config{NormalizeUnicode: "NFC", MaxStringLength: 4}, input: "Cafe\u0301", expected: "Caf\u00e9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DecimalPlaces = -1
+			result := New(tt.config).Slim(map[string]interface{}{"text": tt.input})
+			if got := result.(map[string]interface{})["text"]; got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTruncateBoundary(t *testing.T) {
+	const (
+		family   = "Hi \U0001F468\u200d\U0001F469\u200d\U0001F467\u200d\U0001F466 family" // Man, woman, girl and boy joined by U+200D
+		sentence = "The quick brown fox jumps over the lazy dog"
+	)
+
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		expected string
+	}{
+		{name: "Rune splits the family emoji", config: Config{MaxStringLength: 8}, input: family, expected: "Hi \U0001F468\u200d..."},
+		{name: "Grapheme drops the whole family emoji", config: Config{MaxStringLength: 8, TruncateBoundary: "grapheme"}, input: family, expected: "Hi ..."},
+		{name: "Grapheme keeps the whole family emoji", config: Config{MaxStringLength: 13, TruncateBoundary: "grapheme"}, input: family, expected: "Hi \U0001F468\u200d\U0001F469\u200d\U0001F467\u200d\U0001F466..."},
+		{name: "Grapheme keeps flag pairs", config: Config{MaxStringLength: 6, TruncateBoundary: "grapheme"}, input: "\U0001F1EB\U0001F1F7\U0001F1E9\U0001F1EAe\u0301e", expected: "\U0001F1EB\U0001F1F7..."},
+		{name: "Grapheme keeps combining marks", config: Config{MaxStringLength: 7, TruncateBoundary: "grapheme"}, input: "Cafe\u0301 au lait", expected: "Caf..."},
+		{name: "Grapheme keeps skin tones", config: Config{MaxStringLength: 2, TruncateBoundary: "grapheme"}, input: "a\U0001F44B\U0001F3FDb", expected: "a"},
+		{name: "Rune splits a word", config: Config{MaxStringLength: 15}, input: sentence, expected: "The quick br..."},
+		{name: "Word cuts at the last space", config: Config{MaxStringLength: 15, TruncateBoundary: "word"}, input: sentence, expected: "The quick..."},
+		{name: "Word keeps a word ending at the limit", config: Config{MaxStringLength: 12, TruncateBoundary: "word"}, input: sentence, expected: "The quick..."},
+		{name: "Word drops the whole emoji", config: Config{MaxStringLength: 8, TruncateBoundary: "word"}, input: family, expected: "Hi..."},
+		{name: "Word cuts a single long word", config: Config{MaxStringLength: 8, TruncateBoundary: "word"}, input: "Supercalifragilistic", expected: "Super..."},
+		{name: "Short strings are kept", config: Config{MaxStringLength: 50, TruncateBoundary: "word"}, input: sentence, expected: sentence},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DecimalPlaces = -1
+			result := New(tt.config).Slim(map[string]interface{}{"text": tt.input})
+			if got := result.(map[string]interface{})["text"]; got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStringLengthUnit(t *testing.T) {
+	// 2-byte, 3-byte and 4-byte characters: 20 runes, 50 bytes
+	const input = "Gr\u00fc\u00dfe aus M\u00fcnchen \u65e5\u672c \U0001F600\U0001F600"
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{name: "Runes", config: Config{MaxStringLength: 12}, expected: "Gr\u00fc\u00dfe aus..."},
+		{name: "Bytes", config: Config{MaxStringLength: 12, StringLengthUnit: "bytes"}, expected: "Gr\u00fc\u00dfe a..."},
+		{name: "Bytes never split a character", config: Config{MaxStringLength: 26, StringLengthUnit: "bytes"}, expected: "Gr\u00fc\u00dfe aus M\u00fcnchen ..."},
+		{name: "Tokens", config: Config{MaxStringLength: 5, StringLengthUnit: "tokens"}, expected: "Gr\u00fc\u00dfe aus M\u00fcnc..."},
+		{name: "Tokens under the limit", config: Config{MaxStringLength: 13, StringLengthUnit: "tokens"}, expected: input},
+		{name: "Tokens with word boundary", config: Config{MaxStringLength: 5, StringLengthUnit: "tokens", TruncateBoundary: "word"}, expected: "Gr\u00fc\u00dfe aus..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DecimalPlaces = -1
+			result := New(tt.config).Slim(map[string]interface{}{"text": input})
+			got := result.(map[string]interface{})["text"].(string)
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+			if n := New(tt.config).stringLength(got); n > tt.config.MaxStringLength {
+				t.Errorf("Expected at most %d %s, got %d", tt.config.MaxStringLength, tt.config.StringLengthUnit, n)
+			}
+		})
+	}
+}
+
+// stringPtr returns a pointer to s, for options such as TruncationSuffix
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestTruncationSuffix(t *testing.T) {
+	const input = "Gr\u00fc\u00dfe aus M\u00fcnchen"
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{name: "Default", config: Config{MaxStringLength: 10}, expected: "Gr\u00fc\u00dfe a..."},
+		{name: "Empty suffix", config: Config{MaxStringLength: 10, TruncationSuffix: stringPtr("")}, expected: "Gr\u00fc\u00dfe aus "},
+		{name: "Custom suffix", config: Config{MaxStringLength: 10, TruncationSuffix: stringPtr(" [\u2026]")}, expected: "Gr\u00fc\u00dfe  [\u2026]"},
+		{name: "Custom suffix in bytes", config: Config{MaxStringLength: 10, StringLengthUnit: "bytes", TruncationSuffix: stringPtr("\u2026")}, expected: "Gr\u00fc\u00dfe\u2026"},
+		{name: "Limit smaller than the suffix", config: Config{MaxStringLength: 3, TruncationSuffix: stringPtr(" [\u2026]")}, expected: "Gr\u00fc"},
+		{name: "Limit equal to the suffix", config: Config{MaxStringLength: 4, TruncationSuffix: stringPtr(" [\u2026]")}, expected: "Gr\u00fc\u00df"},
+		{name: "Short strings keep no suffix", config: Config{MaxStringLength: 20, TruncationSuffix: stringPtr(" [\u2026]")}, expected: input},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DecimalPlaces = -1
+			result := New(tt.config).Slim(map[string]interface{}{"text": input})
+			got := result.(map[string]interface{})["text"].(string)
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+			if n := New(tt.config).stringLength(got); n > tt.config.MaxStringLength {
+				t.Errorf("Expected at most %d, got %d", tt.config.MaxStringLength, n)
+			}
+		})
+	}
+
+	// An empty suffix survives a config file round trip
+	params, err := configParams(Config{MaxStringLength: 10, DecimalPlaces: -1, TruncationSuffix: stringPtr("")}, false)
+	if err != nil || !slices.Contains(params, "truncation-suffix=") {
+		t.Errorf("Expected truncation-suffix= in %v (%v)", params, err)
+	}
+}
+
 // BenchmarkBooleanCompression benchmarks boolean compression
 func BenchmarkBooleanCompression(b *testing.B) {
 	input := map[string]interface{}{
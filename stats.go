@@ -0,0 +1,42 @@
+package slimjson
+
+import "encoding/json"
+
+// Stats compares the compact JSON encodings of a document and its slimmed
+// version, as returned by SlimWithStats
+type Stats struct {
+	OriginalBytes  int
+	SlimmedBytes   int
+	OriginalTokens int // Estimated with EstimateTokens
+	SlimmedTokens  int
+}
+
+// Ratio returns SlimmedBytes / OriginalBytes. A ratio above 1 means slimming
+// made the document larger, usually because metadata such as _strings or
+// _schema costs more than it saves on small inputs.
+func (st Stats) Ratio() float64 {
+	if st.OriginalBytes == 0 {
+		return 0
+	}
+	return float64(st.SlimmedBytes) / float64(st.OriginalBytes)
+}
+
+// Expanded reports whether the slimmed document is larger than the original
+func (st Stats) Expanded() bool {
+	return st.SlimmedBytes > st.OriginalBytes
+}
+
+// SlimWithStats slims data like Slim and also returns the sizes of data and
+// the result encoded as compact JSON, so callers can detect configurations
+// that expand their documents (see Stats.Expanded and Config.WarnOnExpansion).
+func (s *Slimmer) SlimWithStats(data interface{}) (interface{}, Stats) {
+	var st Stats
+	if orig, err := json.Marshal(data); err == nil {
+		st.OriginalBytes, st.OriginalTokens = len(orig), EstimateTokens(orig)
+	}
+	result := s.Slim(data)
+	if slim, err := json.Marshal(result); err == nil {
+		st.SlimmedBytes, st.SlimmedTokens = len(slim), EstimateTokens(slim)
+	}
+	return result, st
+}
@@ -0,0 +1,51 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestSlimWithStats(t *testing.T) {
+	heavy := Config{
+		StringPooling: true, EnumDetection: true, TypeInference: true, BoolCompression: true,
+		NumberDeltaEncoding: true, ShortenKeys: true, EmitVersion: true, DecimalPlaces: -1,
+	}
+	tests := []struct {
+		name     string
+		input    string
+		config   Config
+		expanded bool
+	}{
+		{name: "Tiny input under a heavy profile", input: `{"s":["abcd","abcd"]}`, config: heavy, expanded: true},
+		{name: "Blocked fields", input: `{"id":1,"debug":{"trace":"abcdef"}}`, config: Config{BlockList: []string{"debug"}}},
+		{name: "Unchanged", input: `{"a":1}`, config: Config{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			result, st := New(tt.config).SlimWithStats(data)
+			out, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+
+			if st.OriginalBytes != len(tt.input) || st.SlimmedBytes != len(out) {
+				t.Errorf("Expected %d -> %d bytes, got %d -> %d", len(tt.input), len(out), st.OriginalBytes, st.SlimmedBytes)
+			}
+			if st.OriginalTokens != EstimateTokens([]byte(tt.input)) || st.SlimmedTokens != EstimateTokens(out) {
+				t.Errorf("Unexpected token estimates %d -> %d", st.OriginalTokens, st.SlimmedTokens)
+			}
+			if want := float64(len(out)) / float64(len(tt.input)); math.Abs(st.Ratio()-want) > 1e-9 {
+				t.Errorf("Expected ratio %f, got %f", want, st.Ratio())
+			}
+			if st.Expanded() != tt.expanded || (st.Ratio() > 1) != tt.expanded {
+				t.Errorf("Expected expanded %v, got %v (ratio %.2f, output %s)", tt.expanded, st.Expanded(), st.Ratio(), out)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package slimjson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+)
+
+// newRand returns the random source for one Slim call, seeded by RandomSeed if set
+func (s *Slimmer) newRand() *rand.Rand {
+	if s.Config.RandomSeed != 0 {
+		seed := uint64(s.Config.RandomSeed)
+		return rand.New(rand.NewPCG(seed, seed))
+	}
+	return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+}
+
+// streamable reports whether a top-level array can be slimmed one element at a
+// time: no option may need to see the whole array (deduplication, type
+// inference, defaults, delta encoding, string pooling or enum statistics),
+// sampling must be "none" or "random", and no rule or depth limit may apply
+// to the array itself.
+func (s *Slimmer) streamable() bool {
+	c := s.Config
+	switch c.SampleStrategy {
+	case "", "none", "random":
+	default:
+		return false
+	}
+	if c.DeduplicateArrays || c.TypeInference || len(c.Defaults) > 0 || c.DetectDefaults ||
+		c.NumberDeltaEncoding || c.StringPooling || c.EnumDetection || c.MaxOutputBytes > 0 || s.depthExceeded(1) {
+		return false
+	}
+	for _, r := range c.Rules {
+		if segments, err := parseSelector(r.Path); err == nil && len(segments) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SlimLines slims newline-delimited JSON (NDJSON, JSON Lines): each non-blank
+// line of r is decoded as one JSON value, slimmed independently and written to w
+// as one line of compact JSON. Lines are processed one at a time, so memory use
+// does not grow with the input. Decoding stops at the first invalid line, after
+// the lines before it were written, with an error naming its line number.
+func (s *Slimmer) SlimLines(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("reading line %d: %w", lineNum, readErr)
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			result, err := s.SlimBytes(trimmed)
+			if err != nil {
+				return fmt.Errorf("decoding line %d: %w", lineNum, err)
+			}
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				return fmt.Errorf("encoding line %d: %w", lineNum, err)
+			}
+			// Written line by line so streamed input is passed on immediately
+			if _, err := w.Write(append(encoded, '\n')); err != nil {
+				return err
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// streamArray slims a top-level array read element by element from dec, like
+// Slim would, and writes it to w. With a sample size of k, "none" sampling
+// keeps the first k elements and "random" sampling keeps a reservoir of k
+// elements (Algorithm R), so memory use is O(k) regardless of the array length.
+// Randomly sampled elements keep their original order.
+func (s *Slimmer) streamArray(dec *json.Decoder, w io.Writer) error {
+	s.flattened, s.literalDots, s.chainsOK = false, false, false
+	s.flattenOK = !s.Config.Lossless // No root object to mark flattened output
+	s.keyOrder = nil
+	s.compileRules()
+	s.rng = s.newRand()
+
+	if _, err := dec.Token(); err != nil { // Opening bracket
+		return err
+	}
+
+	res := &reservoir{k: s.sampleTarget(), random: s.Config.SampleStrategy == "random", rng: s.rng}
+	if s.Config.OnRemove != nil {
+		res.evicted = func(idx int, original interface{}) {
+			s.removed(strconv.Itoa(idx), ReasonListTruncated, original)
+		}
+	}
+
+	for i := 0; dec.More(); i++ {
+		v, err := s.decode(dec)
+		if err != nil {
+			return fmt.Errorf("decoding element %d: %w", i, err)
+		}
+		elemPath := strconv.Itoa(i)
+		pruned := s.prune(v, 1, elemPath)
+		if s.Config.StripEmpty && s.isEmptyValue(v, pruned) {
+			s.recordEmpty(elemPath, v, 1)
+			continue
+		}
+		res.add(i, v, pruned)
+	}
+	if _, err := nextToken(dec); err != nil { // Closing bracket
+		return err
+	}
+
+	var result interface{} = res.items()
+	if res.seen == 0 && s.Config.StripEmpty {
+		result = nil
+	}
+	if omitted := res.seen - len(res.kept); s.Config.TruncationSummaries && omitted > 0 {
+		result = append(result.([]interface{}), map[string]interface{}{"_omitted": omitted})
+	}
+
+	if s.Config.SortKeys {
+		result = sortKeys(result)
+	} else if s.keyOrder != nil {
+		result = s.orderKeys(result, "")
+	}
+	return json.NewEncoder(w).Encode(result)
+}
+
+// sampleTarget returns the number of elements sampling keeps (0 = all)
+func (s *Slimmer) sampleTarget() int {
+	if s.Config.SampleSize > 0 {
+		return s.Config.SampleSize
+	}
+	return s.Config.MaxListLength
+}
+
+// reservoir keeps up to k elements of a stream: the first k, or with random
+// set a uniform random sample of k (Algorithm R). k = 0 keeps every element.
+type reservoir struct {
+	k      int
+	random bool
+	rng    *rand.Rand
+
+	seen int           // Elements offered so far
+	kept []sampledItem // At most k elements
+
+	// evicted, if set, is called with the original index and value of each dropped element
+	evicted func(idx int, original interface{})
+}
+
+type sampledItem struct {
+	idx      int
+	original interface{} // Kept only for evicted
+	value    interface{}
+}
+
+// add offers the element at index idx with its original and slimmed value
+func (r *reservoir) add(idx int, original, value interface{}) {
+	r.seen++
+	item := sampledItem{idx: idx, value: value}
+	if r.evicted != nil {
+		item.original = original
+	}
+
+	if r.k == 0 || len(r.kept) < r.k {
+		r.kept = append(r.kept, item)
+		return
+	}
+
+	// Replace a random kept element with probability k/seen
+	if r.random {
+		if j := r.rng.IntN(r.seen); j < r.k {
+			item, r.kept[j] = r.kept[j], item
+		}
+	}
+	if r.evicted != nil {
+		r.evicted(item.idx, item.original)
+	}
+}
+
+// items returns the kept values in their original order
+func (r *reservoir) items() []interface{} {
+	slices.SortFunc(r.kept, func(a, b sampledItem) int { return a.idx - b.idx })
+	result := make([]interface{}, len(r.kept))
+	for i, item := range r.kept {
+		result[i] = item.value
+	}
+	return result
+}
@@ -0,0 +1,291 @@
+package slimjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// streamIDs writes a JSON array of n objects to a pipe without materializing it
+func streamIDs(n int) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = io.WriteString(pw, "[")
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				_, _ = io.WriteString(pw, ",")
+			}
+			_, _ = fmt.Fprintf(pw, `{"id": %d, "pad": ""}`, i)
+		}
+		_, _ = io.WriteString(pw, "]")
+		_ = pw.Close()
+	}()
+	return pr
+}
+
+// streamLines writes n NDJSON lines of about 1KB each to a pipe
+func streamLines(n int) io.Reader {
+	pr, pw := io.Pipe()
+	pad := strings.Repeat("x", 1000)
+	go func() {
+		for i := 0; i < n; i++ {
+			_, _ = fmt.Fprintf(pw, "{\"id\": %d, \"pad\": %q, \"tags\": []}\n", i, pad)
+		}
+		_ = pw.Close()
+	}()
+	return pr
+}
+
+// lineCounter counts the lines written to it, sampling the live heap every 1000 lines
+type lineCounter struct {
+	lines    int
+	baseline uint64 // Heap after the first 1000 lines
+	peak     uint64
+}
+
+func (lc *lineCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b != '\n' {
+			continue
+		}
+		if lc.lines++; lc.lines%1000 == 0 {
+			var m runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&m)
+			if lc.baseline == 0 {
+				lc.baseline = m.HeapAlloc
+			}
+			lc.peak = max(lc.peak, m.HeapAlloc)
+		}
+	}
+	return len(p), nil
+}
+
+func TestSlimLines(t *testing.T) {
+	t.Run("10k lines in constant memory", func(t *testing.T) {
+		const n = 10000
+		var out lineCounter
+		if err := New(Config{MaxStringLength: 10, StripEmpty: true}).SlimLines(streamLines(n), &out); err != nil {
+			t.Fatalf("SlimLines() error: %v", err)
+		}
+		if out.lines != n {
+			t.Errorf("Expected %d output lines, got %d", n, out.lines)
+		}
+		// The input is about 10MB; the heap must not grow with it
+		if growth := int64(out.peak) - int64(out.baseline); growth > 2<<20 {
+			t.Errorf("Expected flat memory use, heap grew by %d bytes", growth)
+		}
+	})
+
+	t.Run("Blank lines and missing final newline", func(t *testing.T) {
+		var out bytes.Buffer
+		input := "\n{\"a\": 1}\n  \r\n[1, 2]\n\"s\""
+		if err := New(Config{DecimalPlaces: -1}).SlimLines(strings.NewReader(input), &out); err != nil {
+			t.Fatalf("SlimLines() error: %v", err)
+		}
+		if expected := "{\"a\":1}\n[1,2]\n\"s\"\n"; out.String() != expected {
+			t.Errorf("Output = %q, want %q", out.String(), expected)
+		}
+	})
+
+	t.Run("Error names the line", func(t *testing.T) {
+		var out bytes.Buffer
+		err := New(Config{}).SlimLines(strings.NewReader("{}\n\n{\"a\":}\n{}\n"), &out)
+		if err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Errorf("Expected an error for line 3, got %v", err)
+		}
+		if out.String() != "{}\n" {
+			t.Errorf("Expected lines before the error to be written, got %q", out.String())
+		}
+	})
+}
+
+func TestSlimNext(t *testing.T) {
+	dec := json.NewDecoder(strings.NewReader(`{"a": 1, "b": ""} [1, 2, 3]` + "\n\"x\"\n"))
+	s := New(Config{StripEmpty: true, MaxListLength: 2, DecimalPlaces: -1})
+	var out bytes.Buffer
+	for i := 0; i < 3; i++ {
+		if err := s.SlimNext(dec, &out); err != nil {
+			t.Fatalf("SlimNext() error on document %d: %v", i+1, err)
+		}
+	}
+	if expected := "{\"a\":1}\n[1,2]\n\"x\"\n"; out.String() != expected {
+		t.Errorf("Output = %q, want %q", out.String(), expected)
+	}
+	if err := s.SlimNext(dec, &out); err != io.EOF {
+		t.Errorf("Expected io.EOF after the last document, got %v", err)
+	}
+
+	if err := s.SlimNext(json.NewDecoder(strings.NewReader(" }")), &out); err == nil || err == io.EOF {
+		t.Errorf("Expected a syntax error for a stray delimiter, got %v", err)
+	}
+}
+
+func TestSlimStreamReservoir(t *testing.T) {
+	const n, k = 20000, 50
+
+	run := func(seed int64) ([]int, int) {
+		removed := 0
+		cfg := Config{SampleStrategy: "random", SampleSize: k, RandomSeed: seed, StripEmpty: true, DecimalPlaces: -1,
+			OnRemove: func(path string, reason RemoveReason, value interface{}) {
+				if reason == ReasonListTruncated {
+					removed++
+				}
+			}}
+		var out bytes.Buffer
+		if err := New(cfg).SlimStream(streamIDs(n), &out); err != nil {
+			t.Fatalf("SlimStream() error: %v", err)
+		}
+
+		var items []map[string]int
+		if err := json.Unmarshal(out.Bytes(), &items); err != nil {
+			t.Fatalf("Failed to decode output %q: %v", out.String(), err)
+		}
+		ids := make([]int, len(items))
+		for i, item := range items {
+			ids[i] = item["id"]
+		}
+		return ids, removed
+	}
+
+	ids, removed := run(42)
+	if len(ids) != k {
+		t.Fatalf("Expected %d sampled elements, got %d", k, len(ids))
+	}
+	if removed != n-k {
+		t.Errorf("Expected %d removed elements, got %d", n-k, removed)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("Expected elements in original order, got %v", ids)
+		}
+	}
+	if ids[len(ids)-1] < n/2 {
+		t.Errorf("Expected the sample to reach into the second half of the stream, got %v", ids)
+	}
+
+	again, _ := run(42)
+	if !reflect.DeepEqual(ids, again) {
+		t.Errorf("Expected the same sample for the same seed:\n%v\n%v", ids, again)
+	}
+	if other, _ := run(7); reflect.DeepEqual(ids, other) {
+		t.Errorf("Expected a different sample for a different seed, got %v", other)
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	const n, k = 10000, 10
+
+	evicted := 0
+	r := &reservoir{k: k, random: true, rng: rand.New(rand.NewPCG(1, 1))}
+	r.evicted = func(int, interface{}) { evicted++ }
+	for i := 0; i < n; i++ {
+		r.add(i, i, i)
+		if len(r.kept) > k {
+			t.Fatalf("Reservoir grew to %d elements, want at most %d", len(r.kept), k)
+		}
+	}
+	if r.seen != n || len(r.items()) != k || evicted != n-k {
+		t.Errorf("Expected %d seen, %d kept and %d evicted, got %d, %d and %d", n, k, n-k, r.seen, len(r.kept), evicted)
+	}
+
+	// Without random, the first k elements are kept
+	r = &reservoir{k: 3}
+	for i := 0; i < 10; i++ {
+		r.add(i, i, i*10)
+	}
+	if got := r.items(); !reflect.DeepEqual(got, []interface{}{0, 10, 20}) {
+		t.Errorf("Expected the first 3 elements, got %v", got)
+	}
+}
+
+func TestSlimStreamArrayMatchesSlim(t *testing.T) {
+	input := `[
+		{"id": 1, "name": "a", "password": "x", "tags": []},
+		{},
+		{"id": 2, "name": "b", "nested": {"deep": {"deeper": 1}}},
+		{"id": 3, "name": "c"},
+		{"id": 4, "name": "d"}
+	]`
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "No limits", config: Config{DecimalPlaces: -1}},
+		{name: "Strip empty and block", config: Config{DecimalPlaces: -1, StripEmpty: true, BlockList: []string{"password"}}},
+		{name: "Truncated", config: Config{DecimalPlaces: -1, StripEmpty: true, MaxListLength: 2, MaxDepth: 3}},
+		{name: "Truncation summaries", config: Config{DecimalPlaces: -1, MaxListLength: 2, TruncationSummaries: true}},
+		{name: "Preserve key order", config: Config{DecimalPlaces: -1, PreserveKeyOrder: true, MaxListLength: 3}},
+		{name: "Sort keys", config: Config{DecimalPlaces: -1, SortKeys: true}},
+		{name: "Rules", config: Config{DecimalPlaces: -1, Rules: []PathRule{{Path: "[*]", Config: Config{DecimalPlaces: -1, BlockList: []string{"name"}}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.config)
+			if !s.streamable() {
+				t.Fatal("Expected config to be streamable")
+			}
+			var streamed bytes.Buffer
+			if err := s.SlimStream(strings.NewReader(input), &streamed); err != nil {
+				t.Fatalf("SlimStream() error: %v", err)
+			}
+
+			v, err := New(tt.config).SlimBytes([]byte(input))
+			if err != nil {
+				t.Fatalf("SlimBytes() error: %v", err)
+			}
+			expected, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if got := strings.TrimSpace(streamed.String()); got != string(expected) {
+				t.Errorf("SlimStream() = %s, want %s", got, expected)
+			}
+		})
+	}
+}
+
+func TestStreamable(t *testing.T) {
+	tests := []struct {
+		config   Config
+		expected bool
+	}{
+		{config: Config{}, expected: true},
+		{config: Config{SampleStrategy: "random", MaxListLength: 5}, expected: true},
+		{config: Config{SampleStrategy: "first_last"}, expected: false},
+		{config: Config{DeduplicateArrays: true}, expected: false},
+		{config: Config{TypeInference: true}, expected: false},
+		{config: Config{StringPooling: true}, expected: false},
+		{config: Config{MaxDepth: 1}, expected: false},
+		{config: Config{Rules: []PathRule{{Path: "$"}}}, expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := New(tt.config).streamable(); got != tt.expected {
+			t.Errorf("streamable(%+v) = %v, want %v", tt.config, got, tt.expected)
+		}
+	}
+}
+
+func TestRandomSeed(t *testing.T) {
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = i
+	}
+	cfg := Config{SampleStrategy: "random", SampleSize: 10, RandomSeed: 3}
+	first := New(cfg).Slim(items)
+	s := New(cfg)
+	for i := 0; i < 3; i++ {
+		if got := s.Slim(items); !reflect.DeepEqual(got, first) {
+			t.Fatalf("Expected the same sample for the same seed, got %v and %v", first, got)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package slimjson
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// templateKeys describes each config file key in the file written by
+// WriteConfigTemplate, with its default value where it is not the zero value
+var templateKeys = map[string]struct{ value, doc string }{
+	"max-depth":                 {"", "Maximum nesting depth (0 = unlimited)"},
+	"depth-truncation-marker":   {"null", "What replaces values cut by max-depth: null, empty ({} or []) or ellipsis"},
+	"report-depth-elision":      {"", `Replace objects and arrays cut by max-depth with {"_depth_elided": N}`},
+	"max-list-length":           {"", "Maximum array length (0 = unlimited)"},
+	"max-string-length":         {"", "Maximum string length in characters (0 = unlimited)"},
+	"truncate-boundary":         {"rune", "Where max-string-length cuts: rune, grapheme (whole emoji and accented letters) or word"},
+	"string-length-unit":        {"runes", "Unit of max-string-length: runes, bytes or tokens (about 4 bytes each)"},
+	"truncation-suffix":         {"...", "Appended to truncated strings; leave empty for none"},
+	"max-output-bytes":          {"", "Tighten list and string lengths until the output fits in N bytes (0 = unlimited)"},
+	"strip-empty":               {"", "Remove nulls, empty strings, arrays and objects"},
+	"empty-values":              {"", "More values strip-empty removes, e.g. N/A;-;0"},
+	"keep-null":                 {"", "Keep explicit nulls under strip-empty"},
+	"block-list":                {"", "Comma-separated field names to remove"},
+	"preserve-fields":           {"", "Comma-separated field names or dotted paths kept verbatim"},
+	"decimal-places":            {"-1", "Round floats to N decimal places (-1 = no rounding)"},
+	"field-decimal-places":      {"", "Decimal places of single fields, e.g. price:2;lat:6"},
+	"deduplicate-arrays":        {"", "Remove duplicate values from arrays"},
+	"sample-strategy":           {"none", "none, first_last, random, representative, largest, smallest, frequency or stratified"},
+	"sample-sort-key":           {"", "Object field ranking elements for largest/smallest sampling"},
+	"sample-stratify-key":       {"", "Object field grouping elements for stratified sampling"},
+	"sample-counts":             {"", "Annotate values kept by frequency sampling with their counts"},
+	"sample-size":               {"", "Number of items when sampling (0 = max-list-length)"},
+	"random-seed":               {"", "Seed for reproducible random sampling (0 = random)"},
+	"null-compression":          {"", "Track removed null fields in _nulls"},
+	"type-inference":            {"", "Convert uniform object arrays to _schema + _data"},
+	"type-inference-columnar":   {"", "Emit type-inferred arrays column-major (_schema + _cols)"},
+	"matrix-columnar":           {"", "Store uniform numeric 2D arrays column-major (_matrix)"},
+	"run-length-encode":         {"", "Replace runs of repeated values with [value, count] pairs (_rle)"},
+	"bool-compression":          {"", "Convert booleans to bit flags"},
+	"bool-array-packing":        {"", "Pack arrays of 16+ booleans into a base64 bitmap (_bits)"},
+	"timestamp-compression":     {"", "Convert ISO timestamps to Unix timestamps"},
+	"string-pooling":            {"", "Replace repeated strings with indices into _strings"},
+	"string-pool-min":           {"", "Minimum occurrences for string pooling (0 = 2)"},
+	"number-delta":              {"", "Delta-encode sequential numbers"},
+	"number-delta-threshold":    {"", "Minimum array size for delta encoding (0 = 5)"},
+	"enum-detection":            {"", "Replace repeated categorical values with _enums indices"},
+	"enum-max-values":           {"", "Maximum distinct values of an enum (0 = 10)"},
+	"inline-enum-threshold":     {"", "Leave fields occurring at most N times out of _enums"},
+	"shorten-keys":              {"", "Replace repeated object keys with short aliases listed in _keys"},
+	"min-savings-bytes":         {"", "Use schemas, pools, bit flags and ranges only where they save N bytes"},
+	"drop-if":                   {"", "Remove fields equal to a value, e.g. status:ok;error:none"},
+	"drop-if-ignore-case":       {"", "Compare drop-if strings case-insensitively"},
+	"strip-html":                {"", "Remove HTML tags from strings, keeping the visible text"},
+	"strip-markdown":            {"", "Remove Markdown headings, emphasis, link URLs and code fences from strings"},
+	"decode-html-entities":      {"", "Replace HTML entities such as &amp; in strings with their characters"},
+	"normalize-unicode":         {"none", "Normalize strings to NFC, NFD or NFKC before stripping and truncation"},
+	"clean-whitespace":          {"", "Replace Unicode spaces with ASCII spaces, remove zero-width characters"},
+	"normalize-quotes":          {"", "Replace curly quotes, dashes and ellipses with ASCII"},
+	"strip-emoji":               {"", "Remove emoji and other non-ASCII characters"},
+	"collapse-whitespace":       {"", "Collapse runs of whitespace in strings to one space and trim them"},
+	"keep-newlines":             {"", "With collapse-whitespace, collapse runs with a line break to a newline"},
+	"defaults":                  {"", "Omit fields of object arrays equal to a default, e.g. level:info"},
+	"detect-defaults":           {"", "Factor the most common field values of object arrays into _defaults"},
+	"flatten":                   {"", "Merge nested objects into dotted keys"},
+	"flatten-max-depth":         {"", "Maximum segments in a flattened key (0 = unlimited)"},
+	"flatten-single-key-chains": {"", "Merge single-key objects into dotted keys"},
+	"sort-keys":                 {"", "Sort object keys for canonical output"},
+	"preserve-key-order":        {"", "Keep object keys in their input order"},
+	"truncation-summaries":      {"", "Describe content cut by max-depth and max-list-length"},
+	"lossless":                  {"", "Reject options that lose data, so Unslim restores the input"},
+	"checksum":                  {"", "Store a SHA-256 hash of the input in _checksum (requires lossless)"},
+	"emit-version":              {"", "Add a _slimjson marker with the format version"},
+	"warn-on-expansion":         {"", "Warn when the output is larger than the input (CLI and daemon)"},
+	"parallelism":               {"", "Goroutines pruning arrays of 512+ elements (0 = serial)"},
+	"rules":                     {"$.logs[*] max-depth=2", "Slim the values at a path with other options; repeat for several rules"},
+}
+
+// templateHeader introduces the file written by WriteConfigTemplate
+const templateHeader = `# SlimJSON configuration file
+#
+# Profiles are [name] sections of key=value parameters, used with
+# slimjson -profile name. A profile with extends=name starts from another
+# profile, and description= is listed by slimjson -list-profiles.
+# Lines starting with # are comments.
+#
+# Every parameter with its default value. Copy a line into a profile and
+# remove the # to change it.
+#
+`
+
+// WriteConfigTemplate writes a commented .slimjson file to w that lists every
+// config file key with its default value and a short description, followed by
+// the built-in profiles as sections to customize. ParseConfigFile reads the
+// sections as the built-in profiles.
+func WriteConfigTemplate(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(templateHeader)
+
+	t := reflect.TypeOf(Config{})
+	var lines [][2]string
+	width := 0
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		entry, ok := templateKeys[key]
+		if !ok {
+			return fmt.Errorf("config key %s is not documented", key)
+		}
+		value := entry.value
+		if value == "" {
+			switch t.Field(i).Type.Kind() {
+			case reflect.Bool:
+				value = "false"
+			case reflect.Int, reflect.Int64:
+				value = "0"
+			}
+		}
+		param := key + "=" + value
+		lines = append(lines, [2]string{param, entry.doc})
+		width = max(width, len(param))
+	}
+	for _, line := range lines {
+		fmt.Fprintf(&b, "# %-*s  %s\n", width, line[0], line[1])
+	}
+
+	builtins := GetBuiltinProfiles()
+	for _, name := range []string{"light", "medium", "aggressive", "ai-optimized"} {
+		section, err := formatProfile(name, builtins[name])
+		if err != nil {
+			return err
+		}
+		header, params, _ := strings.Cut(section, "\n")
+		fmt.Fprintf(&b, "\n%s\ndescription=%s\n%s", header, builtinDescriptions[name], params)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
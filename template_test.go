@@ -0,0 +1,91 @@
+package slimjson
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteConfigTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteConfigTemplate(&buf); err != nil {
+		t.Fatalf("WriteConfigTemplate() error: %v", err)
+	}
+	data := buf.String()
+
+	// Every config file key is listed with a description
+	keys := make(map[string]bool)
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		key, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		keys[key] = true
+		if !strings.Contains(data, "\n# "+key+"=") {
+			t.Errorf("Expected %s in the template", key)
+		}
+	}
+	for key := range templateKeys {
+		if !keys[key] {
+			t.Errorf("Template key %s is not a config key", key)
+		}
+	}
+
+	t.Run("Sections are the built-in profiles", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".slimjson")
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseProfiles(path)
+		if err != nil {
+			t.Fatalf("ParseProfiles() error: %v\n%s", err, data)
+		}
+		want := builtinProfiles()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected the built-in profiles, got %+v", got)
+		}
+	})
+
+	t.Run("Uncommented defaults parse", func(t *testing.T) {
+		var section strings.Builder
+		section.WriteString("[defaults]\n")
+		for _, line := range strings.Split(data, "\n") {
+			param, ok := strings.CutPrefix(line, "# ")
+			key, _, isParam := strings.Cut(param, "=")
+			if !ok || !isParam || strings.Contains(key, " ") {
+				continue
+			}
+			if _, known := templateKeys[key]; !known || key == "rules" {
+				continue
+			}
+			value, _, _ := strings.Cut(strings.TrimPrefix(param, key+"="), "  ")
+			section.WriteString(key + "=" + value + "\n")
+		}
+
+		path := filepath.Join(t.TempDir(), ".slimjson")
+		if err := os.WriteFile(path, []byte(section.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseConfigFile(path)
+		if err != nil {
+			t.Fatalf("ParseConfigFile() error: %v\n%s", err, section.String())
+		}
+		cfg := got["defaults"]
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate() error: %v", err)
+		}
+
+		// The explicit defaults slim like the zero config
+		input := map[string]interface{}{
+			"name": "Café “quoted”", "empty": "", "price": 9.999, "tags": []interface{}{"a", "a", nil},
+			"nested": map[string]interface{}{"deeper": map[string]interface{}{"level": "info"}},
+		}
+		if got, want := New(cfg).Slim(input), New(Config{DecimalPlaces: -1}).Slim(input); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+}
@@ -251,19 +251,9 @@ func printResult(result CompressionResult) {
 	fmt.Println()
 }
 
-// countTokens estimates token count using a simple word-based approach
-// This approximates GPT-style tokenization (roughly 1 token per 4 characters for English)
+// countTokens estimates token count (roughly 1 token per 4 characters)
 func countTokens(text string) int {
-	// Remove whitespace and count characters
-	text = strings.TrimSpace(text)
-	if text == "" {
-		return 0
-	}
-
-	// Approximate: 1 token ≈ 4 characters (common for JSON/English in GPT models)
-	// This is a rough estimate; real tokenization varies by model
-	charCount := len(text)
-	return (charCount + 3) / 4 // Round up
+	return slimjson.EstimateTokens([]byte(text))
 }
 
 func formatBytes(bytes int) string {
@@ -0,0 +1,169 @@
+package slimjson
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// OriginalBytesHeader is the response header marking a response slimmed by
+// the RoundTripper of NewTransport, with the size of the body it replaced
+const OriginalBytesHeader = "X-SlimJSON-Original-Bytes"
+
+// TransportOption changes which responses the RoundTripper of NewTransport
+// slims
+type TransportOption func(*transport)
+
+// WithHosts only slims the responses of requests to hosts, such as
+// "api.example.com", compared with the host of the request URL without its
+// port unless the entry has one. Without it, every response may be slimmed.
+func WithHosts(hosts ...string) TransportOption {
+	return func(t *transport) { t.hosts = append(t.hosts, hosts...) }
+}
+
+// transport slims the JSON responses of base, see NewTransport
+type transport struct {
+	base  http.RoundTripper
+	cfg   Config
+	hosts []string
+}
+
+// NewTransport returns an http.RoundTripper sending requests with base, or
+// http.DefaultTransport if base is nil, and slimming their responses with
+// cfg, so that the responses of third-party APIs are slimmed before a client
+// reads them:
+//
+//	client := &http.Client{Transport: slimjson.NewTransport(nil, cfg, slimjson.WithHosts("api.example.com"))}
+//
+// A response is slimmed when its status is successful, its Content-Type is
+// JSON (application/json or +json) and it is not compressed, or compressed
+// with gzip, which is decoded. Its body is read whole, slimmed, and returned
+// without Content-Encoding, with a new Content-Length and the upstream size
+// in OriginalBytesHeader. Error statuses, other content types and encodings,
+// responses to HEAD requests and bodies that are not valid JSON or fail to
+// slim are returned untouched.
+func NewTransport(base http.RoundTripper, cfg Config, opts ...TransportOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &transport{base: base, cfg: cfg}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// RoundTrip sends req with the base RoundTripper and slims the response if
+// it can
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || req.Method == http.MethodHead || !t.matches(req) || !slimmableResponse(resp) {
+		return resp, err
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	body, gzipped, err := decodeBody(resp, raw)
+	if err == nil {
+		body, err = t.slim(req, body)
+	}
+	if err != nil {
+		resp.Body = io.NopCloser(bytes.NewReader(raw))
+		return resp, nil
+	}
+
+	if gzipped {
+		resp.Header.Del("Content-Encoding")
+		resp.Uncompressed = true
+	}
+	resp.Header.Set(OriginalBytesHeader, strconv.Itoa(len(raw)))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	resp.ContentLength = int64(len(body))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the base RoundTripper
+// if it keeps any, for http.Client.CloseIdleConnections
+func (t *transport) CloseIdleConnections() {
+	if c, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// matches reports whether the response to req may be slimmed, see WithHosts
+func (t *transport) matches(req *http.Request) bool {
+	if len(t.hosts) == 0 {
+		return true
+	}
+	for _, host := range t.hosts {
+		if strings.EqualFold(host, req.URL.Hostname()) || strings.EqualFold(host, req.URL.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// slim returns the JSON document body slimmed with the config of t until
+// the context of req is done
+func (t *transport) slim(req *http.Request, body []byte) ([]byte, error) {
+	s := New(t.cfg)
+	dec := json.NewDecoder(bytes.NewReader(body))
+	data, err := s.decode(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON document")
+	}
+	result, err := s.SlimContext(req.Context(), data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// slimmableResponse reports whether the status and headers of resp allow
+// slimming it
+func slimmableResponse(resp *http.Response) bool {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || resp.Body == nil || resp.Body == http.NoBody {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusPartialContent:
+		return false
+	}
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "", "identity", "gzip":
+	default:
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// decodeBody returns the body raw of resp decompressed if it is gzipped, and
+// whether it was
+func decodeBody(resp *http.Response, raw []byte) ([]byte, bool, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return raw, false, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false, err
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, false, err
+	}
+	return body, true, nil
+}
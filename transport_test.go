@@ -0,0 +1,138 @@
+package slimjson
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// upstream returns a server answering each path with a canned response
+func upstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	doc := []byte(`{"name": "Ada", "bio": "", "tags": []}`)
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	_, _ = zw.Write(doc)
+	_ = zw.Close()
+
+	mux := http.NewServeMux()
+	respond := func(path, contentType, encoding string, status int, body []byte) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			if encoding != "" {
+				w.Header().Set("Content-Encoding", encoding)
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(status)
+			_, _ = w.Write(body)
+		})
+	}
+	respond("/json", "application/json; charset=utf-8", "", http.StatusOK, doc)
+	respond("/problem", "application/problem+json", "", http.StatusOK, doc)
+	respond("/gzip", "application/json", "gzip", http.StatusOK, gzipped.Bytes())
+	respond("/brotli", "application/json", "br", http.StatusOK, doc)
+	respond("/binary", "application/octet-stream", "", http.StatusOK, []byte{0x89, 'P', 'N', 'G', 0, 1})
+	respond("/error", "application/json", "", http.StatusInternalServerError, doc)
+	respond("/invalid", "application/json", "", http.StatusOK, []byte(`{"name": `))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// fetch sends a request for url with rt and returns the response and its body
+func fetch(t *testing.T, rt http.RoundTripper, method, url string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+	// Asked for explicitly, gzip is left to the RoundTripper to decode
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return resp, body
+}
+
+func TestTransport(t *testing.T) {
+	srv := upstream(t)
+	rt := NewTransport(nil, Config{StripEmpty: true})
+
+	slimmed := `{"name":"Ada"}`
+	tests := []struct {
+		name     string
+		path     string
+		method   string
+		want     string // Empty when the body is passed through untouched
+		encoding string
+	}{
+		{"JSON", "/json", http.MethodGet, slimmed, ""},
+		{"JSON suffix", "/problem", http.MethodGet, slimmed, ""},
+		{"Gzip", "/gzip", http.MethodGet, slimmed, ""},
+		{"Other encoding", "/brotli", http.MethodGet, "", "br"},
+		{"Binary", "/binary", http.MethodGet, "", ""},
+		{"Error status", "/error", http.MethodGet, "", ""},
+		{"Invalid JSON", "/invalid", http.MethodGet, "", ""},
+		{"HEAD", "/json", http.MethodHead, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, upstreamBody := fetch(t, srv.Client().Transport, tt.method, srv.URL+tt.path)
+			resp, body := fetch(t, rt, tt.method, srv.URL+tt.path)
+
+			if got := resp.Header.Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("Expected Content-Encoding %q, got %q", tt.encoding, got)
+			}
+			if tt.want == "" {
+				if !bytes.Equal(body, upstreamBody) || resp.Header.Get(OriginalBytesHeader) != "" {
+					t.Errorf("Expected the response untouched, %q, got %q with %s %q", upstreamBody, body, OriginalBytesHeader, resp.Header.Get(OriginalBytesHeader))
+				}
+				return
+			}
+			if string(body) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, body)
+			}
+			if got, want := resp.Header.Get("Content-Length"), strconv.Itoa(len(body)); got != want || resp.ContentLength != int64(len(body)) {
+				t.Errorf("Expected Content-Length %s, got %s (%d)", want, got, resp.ContentLength)
+			}
+			if got, want := resp.Header.Get(OriginalBytesHeader), strconv.Itoa(len(upstreamBody)); got != want {
+				t.Errorf("Expected %s %s, got %q", OriginalBytesHeader, want, got)
+			}
+		})
+	}
+}
+
+func TestTransportHosts(t *testing.T) {
+	srv := upstream(t)
+	host := srv.Listener.Addr().String()
+	tests := []struct {
+		name    string
+		hosts   []string
+		slimmed bool
+	}{
+		{"Host", []string{"example.com", "127.0.0.1"}, true},
+		{"Host and port", []string{host}, true},
+		{"Other host", []string{"example.com"}, false},
+		{"Other port", []string{"127.0.0.1:1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := fetch(t, NewTransport(srv.Client().Transport, Config{StripEmpty: true}, WithHosts(tt.hosts...)), http.MethodGet, srv.URL+"/json")
+			if got := resp.Header.Get(OriginalBytesHeader) != ""; got != tt.slimmed {
+				t.Errorf("Expected slimmed %v, got %v", tt.slimmed, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,793 @@
+package slimjson
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Unslim reverses the reversible transformations applied by Slim and returns
+// the rehydrated document. Lossy transformations (truncation, sampling,
+// stripping) cannot be undone; their results are passed through unchanged.
+//
+// Currently supported:
+//   - Type inference, both row (_schema+_data) and columnar (_schema+_cols) layouts
+//   - Number delta encoding (_range)
+//   - Columnar numeric matrices (_matrix)
+//   - Run-length encoded arrays (_rle)
+//   - Packed boolean arrays (_bits bitmaps)
+//   - Sparse encoding against field defaults (_defaults+_items)
+//   - Flattened objects (dotted keys, marked by _flat at the root)
+//   - Shortened keys (_keys dictionary at the root)
+//   - String pooling (_strings at the root). Integers that are pool indices are
+//     replaced by their strings, which only restores numbers from the input
+//     exactly in Lossless output.
+//   - Bool compression (_bools bit flags)
+//   - Enum detection (the _enums table at the root, which only lists the
+//     values of each field, is removed)
+//
+// If the root has a _checksum (see Config.Checksum), Unslim returns an error
+// when the restored document does not match it. A _slimjson marker (see
+// Config.EmitVersion) newer than FormatVersion is an error. So is a _range
+// whose bounds are not integers of at most 2^53, or _range and _rle metadata
+// that would rebuild more than 16,777,216 array elements in one document,
+// counting what the value of a run rebuilds once for every repeat.
+func Unslim(data interface{}) (interface{}, error) {
+	result, _, err := unslim(data, false)
+	return result, err
+}
+
+// UnslimReport lists the transforms UnslimLenient reversed and those whose
+// metadata it kept as is, by config key such as "string-pooling", sorted
+type UnslimReport struct {
+	Applied []string `json:"applied"`
+	Kept    []string `json:"kept"`
+}
+
+// UnslimLenient is Unslim for documents it may not fully restore. Metadata
+// that is malformed or incomplete, such as _schema without _data, is kept as
+// it is rather than failing, like the metadata of transforms CheckReversible
+// reports, while everything else is expanded. Only a _slimjson marker Unslim
+// rejects is an error, and a _checksum mismatch when no metadata was kept;
+// otherwise the checksum is not verified and listed as kept.
+func UnslimLenient(data interface{}) (interface{}, UnslimReport, error) {
+	result, u, err := unslim(data, true)
+	if err != nil {
+		return nil, UnslimReport{Applied: []string{}, Kept: []string{}}, err
+	}
+	report := UnslimReport{
+		Applied: slices.AppendSeq([]string{}, maps.Keys(u.applied)),
+		Kept:    slices.AppendSeq([]string{}, maps.Keys(u.kept)),
+	}
+	slices.Sort(report.Applied)
+	slices.Sort(report.Kept)
+	return result, report, nil
+}
+
+// unslim does the work of Unslim and UnslimLenient, returning the unslimmer
+// with the transforms it applied and kept
+func unslim(data interface{}, lenient bool) (interface{}, *unslimmer, error) {
+	u := &unslimmer{lenient: lenient, applied: make(map[string]bool), kept: make(map[string]bool)}
+	if _, ok := data.(*OrderedMap); ok || lenient {
+		data = plainMaps(data)
+	}
+	if lenient {
+		u.kept = irreversibleTransforms(data)
+	}
+	var sum string
+	if root, ok := data.(map[string]interface{}); ok {
+		if marker, ok := root["_slimjson"]; ok {
+			if err := checkVersion(marker); err != nil {
+				return nil, u, err
+			}
+			root = copyMapWithout(root, "_slimjson")
+			data = root
+		}
+		if c, ok := root["_checksum"]; ok {
+			str, ok := c.(string)
+			switch {
+			case ok:
+				sum = str
+				root = copyMapWithout(root, "_checksum")
+				data = root
+			case !lenient:
+				return nil, u, fmt.Errorf("invalid _checksum: expected a string, got %T", c)
+			default:
+				u.kept["checksum"] = true
+			}
+		}
+		if flat, ok := root["_flat"].(bool); ok {
+			u.flat = flat
+			if flat {
+				u.applied["flatten"] = true
+			}
+			root = copyMapWithout(root, "_flat")
+			data = root
+		}
+		if dict, ok := root["_keys"]; ok {
+			keys, err := toKeyDictionary(dict)
+			switch {
+			case err == nil:
+				u.keys = keys
+				u.applied["shorten-keys"] = true
+				root = copyMapWithout(root, "_keys")
+				data = root
+			case !lenient:
+				return nil, u, err
+			default:
+				u.kept["shorten-keys"] = true
+			}
+		}
+		if _, ok := root["_enums"]; ok {
+			u.applied["enum-detection"] = true
+			root = copyMapWithout(root, "_enums")
+			data = root
+		}
+		if pool, ok := root["_strings"]; ok {
+			strs, err := toStringSlice(pool)
+			switch {
+			case err == nil:
+				u.strings = strs
+				u.applied["string-pooling"] = true
+				data = copyMapWithout(root, "_strings")
+			case !lenient:
+				return nil, u, fmt.Errorf("invalid _strings: %w", err)
+			default:
+				u.kept["string-pooling"] = true
+			}
+		}
+	}
+	result, err := u.value(data)
+	if err != nil {
+		return nil, u, err
+	}
+	if sum != "" {
+		if lenient && len(u.kept) > 0 {
+			u.kept["checksum"] = true
+		} else if got := checksum(result); got != sum {
+			return nil, u, fmt.Errorf("checksum mismatch: restored document has %s, expected %s", got, sum)
+		} else {
+			u.applied["checksum"] = true
+		}
+	}
+	return result, u, nil
+}
+
+// irreversibleFeatures are the _slimjson features that Unslim does not reverse
+var irreversibleFeatures = []string{
+	"null-compression", "timestamp-compression", "truncation-summaries", "report-depth-elision", "sample-counts",
+}
+
+// CheckReversible returns an error naming the transforms in data, a slimmed
+// document, that Unslim cannot reverse: those listed by its _slimjson marker
+// and those that leave metadata (_nulls, _truncated, _omitted, _depth_elided,
+// _value+_count). Lossy options that leave no trace, such as MaxDepth without
+// TruncationSummaries, are not detected.
+func CheckReversible(data interface{}) error {
+	found := irreversibleTransforms(plainMaps(data))
+	if len(found) == 0 {
+		return nil
+	}
+	return fmt.Errorf("input was slimmed with %s, which Unslim cannot reverse",
+		strings.Join(slices.Sorted(maps.Keys(found)), ", "))
+}
+
+// irreversibleTransforms returns the config keys of the transforms in data
+// that Unslim cannot reverse, see CheckReversible
+func irreversibleTransforms(data interface{}) map[string]bool {
+	found := make(map[string]bool)
+	if root, ok := data.(map[string]interface{}); ok {
+		if marker, ok := root["_slimjson"].(map[string]interface{}); ok {
+			features, _ := marker["features"].([]interface{})
+			for _, f := range features {
+				if name, ok := f.(string); ok && slices.Contains(irreversibleFeatures, name) {
+					found[name] = true
+				}
+			}
+		}
+		if _, ok := root["_nulls"]; ok {
+			found["null-compression"] = true
+		}
+	}
+	findLossyMarkers(data, found)
+	return found
+}
+
+// findLossyMarkers adds the config keys of lossy transforms whose markers appear in data to found
+func findLossyMarkers(data interface{}, found map[string]bool) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if _, ok := v["_truncated"]; ok {
+			found["truncation-summaries"] = true
+		}
+		if _, ok := v["_omitted"]; ok && len(v) == 1 {
+			found["truncation-summaries"] = true
+		}
+		if _, ok := v["_depth_elided"]; ok && len(v) == 1 {
+			found["report-depth-elision"] = true
+		}
+		if _, ok := v["_count"]; ok && len(v) == 2 {
+			if _, ok := v["_value"]; ok {
+				found["sample-counts"] = true
+			}
+		}
+		for _, val := range v {
+			findLossyMarkers(val, found)
+		}
+	case []interface{}:
+		for _, item := range v {
+			findLossyMarkers(item, found)
+		}
+	}
+}
+
+// unslimmer holds document-level state needed while expanding
+type unslimmer struct {
+	flat    bool              // Dotted keys are flattened nesting
+	keys    map[string]string // Key alias -> original key, from _keys
+	strings []string          // String pool, from _strings
+	lenient bool              // Keep malformed metadata instead of failing
+	applied map[string]bool   // Config keys of the transforms reversed
+	kept    map[string]bool   // Config keys of the transforms whose metadata was kept
+
+	expanded int // Array elements rebuilt from _range and _rle so far, counting repeats, see reserve
+}
+
+// maxExpandedValues is the most array elements Unslim rebuilds from _range
+// and _rle metadata in one document, so that a few bytes of metadata cannot make it
+// allocate without bound
+const maxExpandedValues = 1 << 24
+
+// maxExactInteger is 2^53, above which float64 no longer holds every integer
+const maxExactInteger = 1 << 53
+
+// reserve counts n more elements rebuilt from the metadata what, and returns
+// an error if the document exceeds maxExpandedValues
+func (u *unslimmer) reserve(what string, n float64) error {
+	if n > float64(maxExpandedValues-u.expanded) {
+		return fmt.Errorf("invalid %s: expands to more than %d values", what, maxExpandedValues)
+	}
+	u.expanded += int(n)
+	return nil
+}
+
+func (u *unslimmer) value(data interface{}) (interface{}, error) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return u.object(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded, err := u.value(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = expanded
+		}
+		return result, nil
+	default:
+		if f, ok := toFloat(data); ok && f >= 0 && f < float64(len(u.strings)) && f == math.Trunc(f) {
+			return u.strings[int(f)], nil
+		}
+		return data, nil
+	}
+}
+
+func (u *unslimmer) object(m map[string]interface{}) (interface{}, error) {
+	if _, ok := m["_schema"]; ok {
+		if _, ok := m["_cols"]; ok {
+			return u.expand(m, "type-inference", u.expandColumns)
+		}
+		if _, ok := m["_data"]; ok {
+			return u.expand(m, "type-inference", u.expandRows)
+		}
+		return u.expand(m, "type-inference", func(map[string]interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("_schema without _data or _cols")
+		})
+	}
+	if _, ok := m["_defaults"]; ok {
+		if _, ok := m["_items"]; ok {
+			return u.expand(m, "defaults", u.expandDefaults)
+		}
+		return u.expand(m, "defaults", func(map[string]interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("_defaults without _items")
+		})
+	}
+	if _, ok := m["_range"]; ok && len(m) == 1 {
+		return u.expand(m, "number-delta", func(m map[string]interface{}) (interface{}, error) {
+			return u.expandRange(m["_range"])
+		})
+	}
+	if _, ok := m["_matrix"]; ok {
+		return u.expand(m, "matrix-columnar", func(m map[string]interface{}) (interface{}, error) {
+			return u.expandMatrix(m["_matrix"])
+		})
+	}
+	if _, ok := m["_rle"]; ok && len(m) == 1 {
+		return u.expand(m, "run-length-encode", func(m map[string]interface{}) (interface{}, error) {
+			return u.expandRunLength(m["_rle"])
+		})
+	}
+	if _, ok := m["_bits"]; ok && len(m) == 2 {
+		if _, ok := m["n"]; ok {
+			return u.expand(m, "bool-array-packing", func(m map[string]interface{}) (interface{}, error) {
+				return expandBits(m["_bits"], m["n"])
+			})
+		}
+	}
+
+	result := make(map[string]interface{}, len(m))
+	if b, ok := m["_bools"]; ok {
+		bools, err := expandBools(b)
+		switch {
+		case err == nil:
+			maps.Copy(result, bools)
+			m = copyMapWithout(m, "_bools")
+			u.applied["bool-compression"] = true
+		case !u.lenient:
+			return nil, fmt.Errorf("invalid _bools: %w", err)
+		default:
+			u.kept["bool-compression"] = true
+		}
+	}
+	for _, alias := range u.fieldOrder(m) {
+		v := m[alias]
+		k, transform := alias, "shorten-keys"
+		if key, ok := u.keys[alias]; ok {
+			k = key
+		}
+		expanded, err := u.value(v)
+		if err != nil {
+			return nil, err
+		}
+		if u.flat && strings.Contains(k, ".") && !strings.HasPrefix(k, "_") {
+			transform = "flatten"
+			err = setNested(result, strings.Split(k, "."), expanded)
+		} else {
+			err = mergeValue(result, k, expanded)
+		}
+		switch {
+		case err == nil:
+		case !u.lenient:
+			return nil, err
+		default:
+			// Keep the field under its key in the input
+			result[alias] = expanded
+			u.kept[transform] = true
+		}
+	}
+	return result, nil
+}
+
+// fieldOrder returns the keys of m to expand, sorted in lenient mode so that
+// of two conflicting fields, such as "a" and the flattened "a.b", the same one
+// is kept as it is every time
+func (u *unslimmer) fieldOrder(m map[string]interface{}) []string {
+	keys := slices.AppendSeq(make([]string, 0, len(m)), maps.Keys(m))
+	if u.lenient {
+		slices.Sort(keys)
+	}
+	return keys
+}
+
+// expand returns m expanded by fn, the reversal of the transform with config
+// key name. In lenient mode, m is kept as it is if fn fails, and so are the
+// transforms fn applied within it.
+func (u *unslimmer) expand(m map[string]interface{}, name string, fn func(map[string]interface{}) (interface{}, error)) (interface{}, error) {
+	var applied map[string]bool
+	if u.lenient {
+		applied = maps.Clone(u.applied)
+	}
+	result, err := fn(m)
+	switch {
+	case err == nil:
+		u.applied[name] = true
+		return result, nil
+	case !u.lenient:
+		return nil, err
+	}
+	u.applied = applied
+	u.kept[name] = true
+	return m, nil
+}
+
+// expandBools returns the fields of a {"flags": N, "keys": [...]} bit set, where
+// bit i of N is the value of the i-th key
+func expandBools(b interface{}) (map[string]interface{}, error) {
+	m, ok := b.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", b)
+	}
+	keys, err := toStringSlice(m["keys"])
+	if err != nil {
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+	flags, ok := toFloat(m["flags"])
+	if !ok || flags < 0 || flags != math.Trunc(flags) || flags >= math.Exp2(float64(len(keys))) {
+		return nil, fmt.Errorf("flags %v do not fit %d keys", m["flags"], len(keys))
+	}
+	if len(keys) > maxBoolKeys {
+		return nil, fmt.Errorf("%d keys exceed the %d bits of a JSON number", len(keys), maxBoolKeys)
+	}
+	bits := uint64(flags)
+	result := make(map[string]interface{}, len(keys))
+	for i, key := range keys {
+		result[key] = bits&(1<<i) != 0
+	}
+	return result, nil
+}
+
+// setNested assigns value at the nested location described by keys
+func setNested(m map[string]interface{}, keys []string, value interface{}) error {
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := m[key].(map[string]interface{})
+		if !ok {
+			if _, exists := m[key]; exists {
+				return fmt.Errorf("flattened key conflicts with non-object field %q", key)
+			}
+			child = make(map[string]interface{})
+			m[key] = child
+		}
+		m = child
+	}
+	return mergeValue(m, keys[len(keys)-1], value)
+}
+
+// mergeValue sets m[key], merging objects that were partially unflattened
+func mergeValue(m map[string]interface{}, key string, value interface{}) error {
+	existing, exists := m[key]
+	if !exists {
+		m[key] = value
+		return nil
+	}
+	existingMap, ok1 := existing.(map[string]interface{})
+	valueMap, ok2 := value.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return fmt.Errorf("duplicate field %q after unflattening", key)
+	}
+	for k, v := range valueMap {
+		if err := mergeValue(existingMap, k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyMapWithout(m map[string]interface{}, key string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// expandRows rebuilds objects from the row-major _schema+_data layout
+func (u *unslimmer) expandRows(m map[string]interface{}) (interface{}, error) {
+	schema, err := toStringSlice(m["_schema"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid _schema: %w", err)
+	}
+	rows, err := toSlice(m["_data"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid _data: %w", err)
+	}
+
+	result := make([]interface{}, len(rows))
+	for i, r := range rows {
+		row, err := toSlice(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid _data row %d: %w", i, err)
+		}
+		if len(row) != len(schema) {
+			return nil, fmt.Errorf("_data row %d has %d values, schema has %d", i, len(row), len(schema))
+		}
+		obj := make(map[string]interface{}, len(schema))
+		for j, key := range schema {
+			obj[key] = row[j]
+		}
+		if result[i], err = u.object(obj); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// expandColumns rebuilds objects from the column-major _schema+_cols layout
+func (u *unslimmer) expandColumns(m map[string]interface{}) (interface{}, error) {
+	schema, err := toStringSlice(m["_schema"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid _schema: %w", err)
+	}
+	colsMap, ok := m["_cols"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid _cols: expected object, got %T", m["_cols"])
+	}
+
+	cols := make([][]interface{}, len(schema))
+	rowCount := -1
+	for i, key := range schema {
+		col, err := u.expandColumn(colsMap[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid column %q: %w", key, err)
+		}
+		if rowCount >= 0 && len(col) != rowCount {
+			return nil, fmt.Errorf("column %q has %d values, expected %d", key, len(col), rowCount)
+		}
+		rowCount = len(col)
+		cols[i] = col
+	}
+	if rowCount < 0 {
+		rowCount = 0
+	}
+
+	result := make([]interface{}, rowCount)
+	for i := range result {
+		obj := make(map[string]interface{}, len(schema))
+		for j, key := range schema {
+			obj[key] = cols[j][i]
+		}
+		if result[i], err = u.object(obj); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// expandMatrix rebuilds the rows of a {"cols": N, "data": [col0, col1, ...]}
+// _matrix, where row i is [data[0][i], data[1][i], ..., data[N-1][i]]
+func (u *unslimmer) expandMatrix(mx interface{}) (interface{}, error) {
+	m, ok := mx.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid _matrix: expected object, got %T", mx)
+	}
+	data, err := toSlice(m["data"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid _matrix data: %w", err)
+	}
+	if n, ok := toFloat(m["cols"]); !ok || n != float64(len(data)) {
+		return nil, fmt.Errorf("invalid _matrix: cols %v does not match %d columns", m["cols"], len(data))
+	}
+
+	cols := make([][]interface{}, len(data))
+	rowCount := -1
+	for j, c := range data {
+		col, err := u.expandColumn(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid _matrix column %d: %w", j, err)
+		}
+		if rowCount >= 0 && len(col) != rowCount {
+			return nil, fmt.Errorf("_matrix column %d has %d values, expected %d", j, len(col), rowCount)
+		}
+		rowCount = len(col)
+		cols[j] = col
+	}
+
+	result := make([]interface{}, max(rowCount, 0))
+	for i := range result {
+		row := make([]interface{}, len(cols))
+		for j, col := range cols {
+			if row[j], err = u.value(col[i]); err != nil {
+				return nil, err
+			}
+		}
+		result[i] = row
+	}
+	return result, nil
+}
+
+// expandRunLength rebuilds an array from the [value, count] pairs of _rle
+func (u *unslimmer) expandRunLength(r interface{}) (interface{}, error) {
+	runs, err := toSlice(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid _rle: %w", err)
+	}
+	// Every count is checked against the limit before the array is built
+	pairs, total := make([][]interface{}, len(runs)), 0
+	for i, run := range runs {
+		pair, err := toSlice(run)
+		if err != nil || len(pair) != 2 {
+			return nil, fmt.Errorf("invalid _rle run %d: expected [value, count], got %v", i, run)
+		}
+		n, ok := toFloat(pair[1])
+		if !ok || n < 1 || n != math.Trunc(n) {
+			return nil, fmt.Errorf("invalid _rle run %d: count %v", i, pair[1])
+		}
+		if err := u.reserve("_rle", n); err != nil {
+			return nil, err
+		}
+		pairs[i], total = pair, total+int(n)
+	}
+
+	result := make([]interface{}, 0, total)
+	for _, pair := range pairs {
+		// The value is expanded once and repeated, so what it rebuilt counts
+		// once more for every repeat
+		before := u.expanded
+		value, err := u.value(pair[0])
+		if err != nil {
+			return nil, err
+		}
+		n, _ := toFloat(pair[1])
+		if err := u.reserve("_rle", (n-1)*float64(u.expanded-before)); err != nil {
+			return nil, err
+		}
+		for range int(n) {
+			result = append(result, value)
+		}
+	}
+	return result, nil
+}
+
+// expandBits unpacks the n booleans of the base64 bitmap of _bits
+func expandBits(b, n interface{}) (interface{}, error) {
+	encoded, ok := b.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid _bits: expected a string, got %T", b)
+	}
+	bitmap, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid _bits: %w", err)
+	}
+	count, ok := toFloat(n)
+	if !ok || count < 0 || count != math.Trunc(count) || (int(count)+7)/8 != len(bitmap) {
+		return nil, fmt.Errorf("invalid _bits: n %v does not match %d bytes", n, len(bitmap))
+	}
+	result := make([]interface{}, int(count))
+	for i := range result {
+		result[i] = bitmap[i/8]&(1<<(i%8)) != 0
+	}
+	return result, nil
+}
+
+// expandColumn returns the values of a _cols or _matrix column, expanding a
+// delta encoded {"_range": [start, end]} column
+func (u *unslimmer) expandColumn(c interface{}) ([]interface{}, error) {
+	r, ok := c.(map[string]interface{})
+	if !ok || r["_range"] == nil {
+		return toSlice(c)
+	}
+	expanded, err := u.expandRange(r["_range"])
+	if err != nil {
+		return nil, err
+	}
+	u.applied["number-delta"] = true
+	return toSlice(expanded)
+}
+
+// expandDefaults re-applies _defaults to every element of _items
+func (u *unslimmer) expandDefaults(m map[string]interface{}) (interface{}, error) {
+	defaults, ok := m["_defaults"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid _defaults: expected object, got %T", m["_defaults"])
+	}
+	items, err := toSlice(m["_items"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid _items: %w", err)
+	}
+
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		sparse, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid _items element %d: expected object, got %T", i, item)
+		}
+		obj := make(map[string]interface{}, len(sparse)+len(defaults))
+		for k, v := range defaults {
+			obj[k] = v
+		}
+		for k, v := range sparse {
+			obj[k] = v
+		}
+		if result[i], err = u.object(obj); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// expandRange rebuilds a sequential number array from a _range pair of
+// integers
+func (u *unslimmer) expandRange(r interface{}) (interface{}, error) {
+	bounds, err := toFloatSlice(r)
+	if err != nil || len(bounds) != 2 {
+		return nil, fmt.Errorf("invalid _range: %v", r)
+	}
+	for _, b := range bounds {
+		if b != math.Trunc(b) || math.Abs(b) > maxExactInteger {
+			return nil, fmt.Errorf("invalid _range: bound %v is not an integer of at most 2^53", b)
+		}
+	}
+	if bounds[1] < bounds[0] {
+		return nil, fmt.Errorf("invalid _range: end %v before start %v", bounds[1], bounds[0])
+	}
+	// Counted again for every repeat when the range is the value of a run,
+	// see expandRunLength
+	if err := u.reserve("_range", bounds[1]-bounds[0]+1); err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, int(bounds[1]-bounds[0])+1)
+	for i := range result {
+		result[i] = bounds[0] + float64(i)
+	}
+	return result, nil
+}
+
+// toSlice accepts both the in-memory Slim output and its JSON-decoded form
+func toSlice(v interface{}) ([]interface{}, error) {
+	switch arr := v.(type) {
+	case []interface{}:
+		return arr, nil
+	case [][]interface{}:
+		result := make([]interface{}, len(arr))
+		for i, item := range arr {
+			result[i] = item
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("expected array, got %T", v)
+	}
+}
+
+func toStringSlice(v interface{}) ([]string, error) {
+	switch arr := v.(type) {
+	case []string:
+		return arr, nil
+	case []interface{}:
+		result := make([]string, len(arr))
+		for i, item := range arr {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string, got %T", item)
+			}
+			result[i] = str
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("expected array, got %T", v)
+	}
+}
+
+// toKeyDictionary accepts the _keys dictionary from Slim output or decoded JSON
+func toKeyDictionary(v interface{}) (map[string]string, error) {
+	switch dict := v.(type) {
+	case map[string]string:
+		return dict, nil
+	case map[string]interface{}:
+		result := make(map[string]string, len(dict))
+		for alias, key := range dict {
+			str, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid _keys entry %q: expected string, got %T", alias, key)
+			}
+			result[alias] = str
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("invalid _keys: expected object, got %T", v)
+	}
+}
+
+func toFloatSlice(v interface{}) ([]float64, error) {
+	switch arr := v.(type) {
+	case []float64:
+		return arr, nil
+	case []interface{}:
+		result := make([]float64, len(arr))
+		for i, item := range arr {
+			f, ok := item.(float64)
+			if !ok {
+				return nil, fmt.Errorf("expected number, got %T", item)
+			}
+			result[i] = f
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("expected array, got %T", v)
+	}
+}
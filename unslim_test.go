@@ -0,0 +1,545 @@
+package slimjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnslimTypeInference(t *testing.T) {
+	input := `{"users": [
+		{"id": 1, "name": "Alice", "age": 30},
+		{"id": 2, "name": "Bob", "age": 25},
+		{"id": 3, "name": "Charlie", "age": 35},
+		{"id": 4, "name": "David", "age": 40},
+		{"id": 5, "name": "Eve", "age": 28}
+	]}`
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{
+			name:   "Row layout",
+			config: Config{TypeInference: true},
+		},
+		{
+			name:   "Columnar layout",
+			config: Config{TypeInference: true, TypeInferenceColumnar: true},
+		},
+		{
+			name:   "Columnar layout with delta encoding",
+			config: Config{TypeInference: true, TypeInferenceColumnar: true, NumberDeltaEncoding: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original interface{}
+			if err := json.Unmarshal([]byte(input), &original); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+
+			slimmed := New(tt.config).Slim(original)
+
+			// Unslim must work on both the in-memory result and its JSON form
+			encoded, err := json.Marshal(slimmed)
+			if err != nil {
+				t.Fatalf("Failed to marshal slimmed: %v", err)
+			}
+			var decoded interface{}
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("Failed to unmarshal slimmed: %v", err)
+			}
+
+			for _, slim := range []interface{}{slimmed, decoded} {
+				restored, err := Unslim(slim)
+				if err != nil {
+					t.Fatalf("Unslim() error: %v", err)
+				}
+				if !reflect.DeepEqual(restored, original) {
+					t.Errorf("Unslim() = %v, want %v", restored, original)
+				}
+			}
+		})
+	}
+}
+
+func TestUnslimInvalidMetadata(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "Row length mismatch",
+			input: `{"_schema": ["a", "b"], "_data": [[1]]}`,
+		},
+		{
+			name:  "Column length mismatch",
+			input: `{"_schema": ["a", "b"], "_cols": {"a": [1, 2], "b": [1]}}`,
+		},
+		{
+			name:  "Invalid range",
+			input: `{"_range": [5]}`,
+		},
+		{
+			name:  "Matrix column count mismatch",
+			input: `{"m": {"_matrix": {"cols": 3, "data": [[1, 2], [3, 4]]}}}`,
+		},
+		{
+			name:  "Matrix column length mismatch",
+			input: `{"m": {"_matrix": {"cols": 2, "data": [[1, 2], [3]]}}}`,
+		},
+		{
+			name:  "Schema without data",
+			input: `{"users": {"_schema": ["a", "b"]}}`,
+		},
+		{
+			name:  "Defaults without items",
+			input: `{"users": {"_defaults": {"a": 1}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if _, err := Unslim(data); err == nil {
+				t.Error("Expected error for invalid metadata")
+			}
+		})
+	}
+}
+
+func TestUnslimExpansionLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"Fractional range", `{"a": {"_range": [0.5, 3.5]}}`, "not an integer"},
+		{"Range beyond 2^53", `{"a": {"_range": [1e17, 100000000000000064]}}`, "not an integer of at most 2^53"},
+		{"Huge range", `{"a": {"_range": [0, 1e300]}}`, "not an integer of at most 2^53"},
+		{"Long range", `{"a": {"_range": [0, 9007199254740992]}}`, "expands to more than"},
+		{"Range in a run", `{"a": {"_rle": [[{"_range": [1, 5000]}, 5000]]}}`, "expands to more than"},
+		{"Range column in a run", `{"a": {"_rle": [[{"_schema": ["x"], "_cols": {"x": {"_range": [1, 5000]}}}, 5000]]}}`, "expands to more than"},
+		{"Long run", `{"a": {"_rle": [["x", 1e12]]}}`, "expands to more than"},
+		{"Huge run", `{"a": {"_rle": [["x", 1e300]]}}`, "expands to more than"},
+		{"Runs beyond the limit", `{"a": {"_rle": [["x", 16777216], ["y", 1]]}}`, "expands to more than"},
+		{"Nested runs", `{"a":{"_rle":[[{"_rle":[[{"_rle":[["xxxxxxxxxx",4000]]},4000]]},4000]]}}`, "expands to more than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if _, err := Unslim(data); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Unslim() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			// Lenient mode keeps the metadata it cannot expand
+			restored, _, err := UnslimLenient(data)
+			if err != nil {
+				t.Fatalf("UnslimLenient() error: %v", err)
+			}
+			if !reflect.DeepEqual(restored, data) {
+				t.Errorf("UnslimLenient() = %v, want the input kept", restored)
+			}
+		})
+	}
+
+	// The limit applies to the whole document
+	u := &unslimmer{applied: make(map[string]bool), expanded: maxExpandedValues - 2}
+	if _, err := u.expandRange([]interface{}{0.0, 2.0}); err == nil {
+		t.Error("Expected an error beyond the limit of the document")
+	}
+	if _, err := u.expandRange([]interface{}{0.0, 1.0}); err != nil {
+		t.Errorf("Expected the last values within the limit, got %v", err)
+	}
+	if _, err := u.expandRunLength([]interface{}{[]interface{}{"x", 1.0}}); err == nil {
+		t.Error("Expected an error for a run beyond the limit of the document")
+	}
+}
+
+func TestUnslimLossless(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name: "Records",
+			input: `{"meta":{"source":{"name":"export","version":2}},"records":[` +
+				`{"created_at":"2024-01-01T10:00:00Z","id":1,"status":"active","tags":["alpha","beta"],"score":0.125},` +
+				`{"created_at":"2024-01-02T10:00:00Z","id":2,"status":"active","tags":["alpha"],"score":7.5},` +
+				`{"created_at":"2024-01-03T10:00:00Z","id":3,"status":"inactive","tags":[],"score":null},` +
+				`{"created_at":"2024-01-04T10:00:00Z","id":4,"status":"active","tags":["beta"],"score":1e-7}],` +
+				`"sequence":[10,11,12,13,14,15],"empty":{},"note":""}`,
+		},
+		{
+			// 0 and 1 would be read as string pool indices
+			name:  "Numbers that look like pool indices",
+			input: `{"a":["active","active","pending","pending"],"count":1,"first":0}`,
+		},
+		{
+			// Delta encoding would expand [0.5, 1.5, 2.5000001] to [0.5, 1.5, 2.5]
+			name:  "Inexact sequences",
+			input: `{"values":[0.5,1.5,2.5000001,3.5,4.5],"half":[0.5,1.5,2.5,3.5,4.5]}`,
+		},
+		{
+			name:  "Keys with dots",
+			input: `{"a.b":{"c":1},"d":{"e":{"f":2}}}`,
+		},
+		{
+			name:  "Top-level array",
+			input: `[{"data":{"id":1}},{"data":{"id":2}}]`,
+		},
+	}
+
+	cfg := Config{
+		Lossless: true, Checksum: true, DecimalPlaces: -1,
+		StringPooling: true, ShortenKeys: true, TypeInference: true, NumberDeltaEncoding: true,
+		NumberDeltaThreshold: 3, DetectDefaults: true, Flatten: true, FlattenSingleKeyChains: true,
+	}
+	s, err := NewStrict(cfg)
+	if err != nil {
+		t.Fatalf("NewStrict() error: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original interface{}
+			if err := json.Unmarshal([]byte(tt.input), &original); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			want, err := json.Marshal(original)
+			if err != nil {
+				t.Fatalf("Failed to marshal input: %v", err)
+			}
+
+			// Store the slimmed JSON and read it back, as a client would
+			slimmed, err := json.Marshal(s.Slim(original))
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			var stored interface{}
+			if err := json.Unmarshal(slimmed, &stored); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			restored, err := Unslim(stored)
+			if err != nil {
+				t.Fatalf("Unslim() error: %v", err)
+			}
+			got, err := json.Marshal(restored)
+			if err != nil {
+				t.Fatalf("Failed to marshal restored document: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Round trip is not byte-identical:\ngot  %s\nwant %s\nslimmed %s", got, want, slimmed)
+			}
+		})
+	}
+}
+
+func TestUnslimChecksum(t *testing.T) {
+	input := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"name": "alice", "status": "active"},
+			map[string]interface{}{"name": "bob", "status": "active"},
+			map[string]interface{}{"name": "carol", "status": "inactive"},
+		},
+	}
+	s, err := NewStrict(Config{Lossless: true, Checksum: true, DecimalPlaces: -1, StringPooling: true, TypeInference: true})
+	if err != nil {
+		t.Fatalf("NewStrict() error: %v", err)
+	}
+	slimmed, err := json.Marshal(s.Slim(input))
+	if err != nil {
+		t.Fatalf("Failed to marshal result: %v", err)
+	}
+	if !bytes.Contains(slimmed, []byte(`"_strings":["active"]`)) || !bytes.Contains(slimmed, []byte(`"_checksum":"sha256:`)) {
+		t.Fatalf("Expected pooled strings and a checksum, got %s", slimmed)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func([]byte) []byte
+		wantErr string
+	}{
+		{name: "Intact", corrupt: func(b []byte) []byte { return b }},
+		{
+			name: "Flipped byte in pooled string",
+			corrupt: func(b []byte) []byte {
+				i := bytes.Index(b, []byte(`"_strings":["active"]`)) + len(`"_strings":["`)
+				b[i] ^= 0x01
+				return b
+			},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name: "Invalid checksum",
+			corrupt: func(b []byte) []byte {
+				return bytes.Replace(b, []byte(`"_checksum":"`), []byte(`"_checksum":1,"x":"`), 1)
+			},
+			wantErr: "invalid _checksum",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored interface{}
+			if err := json.Unmarshal(tt.corrupt(bytes.Clone(slimmed)), &stored); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			restored, err := Unslim(stored)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unslim() error: %v", err)
+				}
+				if want := roundTrip(t, input); !reflect.DeepEqual(restored, want) {
+					t.Errorf("Unslim() = %v, want %v", restored, want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Unslim() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnslimDefaults(t *testing.T) {
+	input := `{"rules": [
+		{"name": "a", "enabled": true, "weight": 1, "note": ""},
+		{"name": "b", "enabled": true, "weight": 1, "note": ""},
+		{"name": "c", "enabled": false, "weight": 1, "note": "x"},
+		{"name": "d", "enabled": true, "weight": 2.5, "note": ""}
+	]}`
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{
+			name:   "Explicit defaults",
+			config: Config{DecimalPlaces: -1, Defaults: map[string]interface{}{"enabled": true, "weight": 1, "note": ""}},
+		},
+		{
+			name:   "Detected defaults",
+			config: Config{DecimalPlaces: -1, DetectDefaults: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original interface{}
+			if err := json.Unmarshal([]byte(input), &original); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+
+			slimmed := New(tt.config).Slim(original)
+			rules, ok := slimmed.(map[string]interface{})["rules"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("Expected sparse-encoded rules, got %v", slimmed)
+			}
+			if _, ok := rules["_defaults"]; !ok {
+				t.Fatal("Expected _defaults field")
+			}
+
+			restored, err := Unslim(slimmed)
+			if err != nil {
+				t.Fatalf("Unslim() error: %v", err)
+			}
+			if !reflect.DeepEqual(restored, original) {
+				t.Errorf("Unslim() = %v, want %v", restored, original)
+			}
+		})
+	}
+}
+
+func TestUnslimFlatten(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+	}{
+		{
+			name:   "Nested objects and arrays",
+			config: Config{Flatten: true},
+			input:  `{"data": {"attributes": {"name": "x", "tags": ["a", "b"]}}, "items": [{"meta": {"id": 1}}, {"meta": {"id": 2}}]}`,
+		},
+		{
+			name:   "Depth limit",
+			config: Config{Flatten: true, FlattenMaxDepth: 2},
+			input:  `{"a": {"b": {"c": {"d": 1}}}, "e": {"f": 2}}`,
+		},
+		{
+			name:   "Flattened columns",
+			config: Config{Flatten: true, TypeInference: true, TypeInferenceColumnar: true},
+			input:  `{"rows": [{"a": {"b": 1}}, {"a": {"b": 2}}, {"a": {"b": 3}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original interface{}
+			if err := json.Unmarshal([]byte(tt.input), &original); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+
+			restored, err := Unslim(New(tt.config).Slim(original))
+			if err != nil {
+				t.Fatalf("Unslim() error: %v", err)
+			}
+			if !reflect.DeepEqual(restored, original) {
+				t.Errorf("Unslim() = %v, want %v", restored, original)
+			}
+		})
+	}
+}
+
+func TestCheckReversible(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{name: "Reversible", config: Config{StringPooling: true, TypeInference: true, ShortenKeys: true, EnumDetection: true, BoolCompression: true}},
+		{name: "Null compression", config: Config{NullCompression: true, EmitVersion: true}, wantErr: "input was slimmed with null-compression, which Unslim cannot reverse"},
+		{name: "Truncation summaries", config: Config{MaxListLength: 2, TruncationSummaries: true}, wantErr: "truncation-summaries"},
+		{name: "Depth elision", config: Config{MaxDepth: 2, ReportDepthElision: true}, wantErr: "report-depth-elision"},
+		{name: "Marker", config: Config{TimestampCompression: true, EmitVersion: true}, wantErr: "timestamp-compression"},
+	}
+
+	input := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"role": "admin", "active": true, "ok": false, "new": true, "seen": "2024-01-01T00:00:00Z"},
+			map[string]interface{}{"role": "admin", "active": false, "ok": true, "new": true, "seen": "2024-01-02T00:00:00Z"},
+			map[string]interface{}{"role": "user", "active": true, "ok": true, "new": true, "seen": "2024-01-03T00:00:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.DecimalPlaces = -1
+			err := CheckReversible(roundTrip(t, New(tt.config).Slim(input)))
+			if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("CheckReversible() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnslimLenient(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		applied []string
+		kept    []string
+	}{
+		{
+			name:    "Fully reversible",
+			input:   `{"_strings": ["admin"], "users": {"_schema": ["role"], "_data": [[0], [0]]}}`,
+			want:    `{"users": [{"role": "admin"}, {"role": "admin"}]}`,
+			applied: []string{"string-pooling", "type-inference"},
+			kept:    []string{},
+		},
+		{
+			name:    "Schema without data",
+			input:   `{"_strings": ["admin"], "a": {"_schema": ["b"]}, "c": {"_range": [1, 3]}}`,
+			want:    `{"a": {"_schema": ["b"]}, "c": [1, 2, 3]}`,
+			applied: []string{"number-delta", "string-pooling"},
+			kept:    []string{"type-inference"},
+		},
+		{
+			name:    "Nested transforms",
+			input:   `{"a": {"_schema": ["b"], "_data": [[{"_rle": [[1, 2]]}]]}}`,
+			want:    `{"a": [{"b": [1, 1]}]}`,
+			applied: []string{"run-length-encode", "type-inference"},
+			kept:    []string{},
+		},
+		{
+			name:    "Malformed rows",
+			input:   `{"a": {"_schema": ["b", "c"], "_data": [[{"_rle": [[1, 2]]}]]}}`,
+			want:    `{"a": {"_schema": ["b", "c"], "_data": [[{"_rle": [[1, 2]]}]]}}`,
+			applied: []string{},
+			kept:    []string{"type-inference"},
+		},
+		{
+			name:    "Malformed pool",
+			input:   `{"_strings": [1], "a": 0}`,
+			want:    `{"_strings": [1], "a": 0}`,
+			applied: []string{},
+			kept:    []string{"string-pooling"},
+		},
+		{
+			name:    "Malformed bools",
+			input:   `{"_bools": {"flags": 4, "keys": ["a"]}, "b": {"_bits": "Aw==", "n": 2}}`,
+			want:    `{"_bools": {"flags": 4, "keys": ["a"]}, "b": [true, true]}`,
+			applied: []string{"bool-array-packing"},
+			kept:    []string{"bool-compression"},
+		},
+		{
+			name:    "Flatten conflict",
+			input:   `{"_flat": true, "a": 1, "a.b": 2}`,
+			want:    `{"a": 1, "a.b": 2}`,
+			applied: []string{"flatten"},
+			kept:    []string{"flatten"},
+		},
+		{
+			name:    "Lossy metadata",
+			input:   `{"a": 0, "_nulls": ["b"], "_checksum": "sha256:0"}`,
+			want:    `{"a": 0, "_nulls": ["b"]}`,
+			applied: []string{},
+			kept:    []string{"checksum", "null-compression"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data, want interface{}
+			if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("Failed to unmarshal want: %v", err)
+			}
+			got, report, err := UnslimLenient(data)
+			if err != nil {
+				t.Fatalf("UnslimLenient() error: %v", err)
+			}
+			if !reflect.DeepEqual(roundTrip(t, got), want) {
+				t.Errorf("UnslimLenient() = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(report, UnslimReport{Applied: tt.applied, Kept: tt.kept}) {
+				t.Errorf("UnslimLenient() report = %+v, want applied %v, kept %v", report, tt.applied, tt.kept)
+			}
+		})
+	}
+}
+
+func TestUnslimLenientErrors(t *testing.T) {
+	slimmed := roundTrip(t, New(Config{Lossless: true, DecimalPlaces: -1, Checksum: true}).Slim(map[string]interface{}{"a": "b"}))
+	slimmed.(map[string]interface{})["a"] = "c"
+
+	tests := []struct {
+		name    string
+		input   interface{}
+		wantErr string
+	}{
+		{"Unsupported version", map[string]interface{}{"_slimjson": map[string]interface{}{"v": float64(FormatVersion + 1)}}, "unsupported _slimjson version"},
+		{"Checksum mismatch", slimmed, "checksum mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := UnslimLenient(tt.input); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("UnslimLenient() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package slimjson
+
+import "fmt"
+
+// FormatVersion is the version of the metadata format written by Slim to the
+// _slimjson marker (see Config.EmitVersion) and the newest one Unslim reads.
+const FormatVersion = 1
+
+// formatFeatures are the options that change the output format, by config key
+var formatFeatures = []struct {
+	key     string
+	enabled func(c Config) bool
+}{
+	{"type-inference", func(c Config) bool { return c.TypeInference }},
+	{"type-inference-columnar", func(c Config) bool { return c.TypeInference && c.TypeInferenceColumnar }},
+	{"matrix-columnar", func(c Config) bool { return c.MatrixColumnar }},
+	{"run-length-encode", func(c Config) bool { return c.RunLengthEncode }},
+	{"defaults", func(c Config) bool { return len(c.Defaults) > 0 || c.DetectDefaults }},
+	{"null-compression", func(c Config) bool { return c.NullCompression }},
+	{"bool-compression", func(c Config) bool { return c.BoolCompression }},
+	{"bool-array-packing", func(c Config) bool { return c.BoolArrayPacking }},
+	{"timestamp-compression", func(c Config) bool { return c.TimestampCompression }},
+	{"string-pooling", func(c Config) bool { return c.StringPooling }},
+	{"number-delta", func(c Config) bool { return c.NumberDeltaEncoding }},
+	{"enum-detection", func(c Config) bool { return c.EnumDetection }},
+	{"shorten-keys", func(c Config) bool { return c.ShortenKeys }},
+	{"flatten", func(c Config) bool { return c.Flatten }},
+	{"flatten-single-key-chains", func(c Config) bool { return c.FlattenSingleKeyChains }},
+	{"truncation-summaries", func(c Config) bool { return c.TruncationSummaries }},
+	{"report-depth-elision", func(c Config) bool { return c.ReportDepthElision }},
+	{"sample-counts", func(c Config) bool { return c.SampleCounts }},
+	{"checksum", func(c Config) bool { return c.Checksum }},
+}
+
+// features returns the config keys of the format-changing options enabled in
+// c or any of its rules
+func (c Config) features() []string {
+	var features []string
+	for _, f := range formatFeatures {
+		enabled := f.enabled(c)
+		for _, r := range c.Rules {
+			enabled = enabled || f.enabled(r.Config)
+		}
+		if enabled {
+			features = append(features, f.key)
+		}
+	}
+	return features
+}
+
+// versionMarker returns the _slimjson marker for output slimmed with c, or nil
+// if c enables no format-changing options
+func versionMarker(c Config) map[string]interface{} {
+	features := c.features()
+	if len(features) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"v": FormatVersion, "features": features}
+}
+
+// checkVersion returns an error if marker, a _slimjson value, is invalid or
+// newer than FormatVersion
+func checkVersion(marker interface{}) error {
+	m, ok := marker.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid _slimjson: expected an object, got %T", marker)
+	}
+	v, ok := toFloat(m["v"])
+	if !ok {
+		return fmt.Errorf("invalid _slimjson: missing version")
+	}
+	if v != FormatVersion {
+		return fmt.Errorf("unsupported _slimjson version %v (supported: %d)", m["v"], FormatVersion)
+	}
+	return nil
+}
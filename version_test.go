@@ -0,0 +1,112 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEmitVersion(t *testing.T) {
+	input := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"id": 1.0, "role": "admin"},
+			map[string]interface{}{"id": 2.0, "role": "admin"},
+			map[string]interface{}{"id": 3.0, "role": "user"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected []string // nil for no marker
+	}{
+		{
+			name:     "Enabled features in order",
+			config:   Config{StringPooling: true, TypeInference: true, ShortenKeys: true},
+			expected: []string{"type-inference", "string-pooling", "shorten-keys"},
+		},
+		{
+			name:     "Features enabled by rules",
+			config:   Config{BoolCompression: true, Rules: []PathRule{{Path: "users", Config: Config{TypeInference: true, TypeInferenceColumnar: true}}}},
+			expected: []string{"type-inference", "type-inference-columnar", "bool-compression"},
+		},
+		{
+			name:     "Defaults",
+			config:   Config{DetectDefaults: true},
+			expected: []string{"defaults"},
+		},
+		{
+			name:   "No format changes",
+			config: Config{MaxDepth: 3, SortKeys: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.EmitVersion = true
+			tt.config.DecimalPlaces = -1
+			result := roundTrip(t, New(tt.config).Slim(input)).(map[string]interface{})
+
+			marker, ok := result["_slimjson"].(map[string]interface{})
+			if tt.expected == nil {
+				if ok {
+					t.Errorf("Expected no _slimjson marker, got %v", marker)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Expected a _slimjson marker, got %v", result)
+			}
+			if marker["v"] != float64(FormatVersion) {
+				t.Errorf("Expected version %d, got %v", FormatVersion, marker["v"])
+			}
+			var features []string
+			for _, f := range marker["features"].([]interface{}) {
+				features = append(features, f.(string))
+			}
+			if !reflect.DeepEqual(features, tt.expected) {
+				t.Errorf("Expected features %v, got %v", tt.expected, features)
+			}
+
+			if _, err := Unslim(result); err != nil {
+				t.Errorf("Unslim() error: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnslimVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "Current version", input: `{"a":1,"_slimjson":{"v":1,"features":["string-pooling"]}}`},
+		{name: "Newer version", input: `{"a":1,"_slimjson":{"v":2,"features":["string-pooling"]}}`, wantErr: "unsupported _slimjson version 2 (supported: 1)"},
+		{name: "Missing version", input: `{"a":1,"_slimjson":{"features":[]}}`, wantErr: "invalid _slimjson: missing version"},
+		{name: "Not an object", input: `{"a":1,"_slimjson":1}`, wantErr: "invalid _slimjson: expected an object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+				t.Fatalf("Failed to unmarshal input: %v", err)
+			}
+			result, err := Unslim(data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Unslim() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unslim() error: %v", err)
+			}
+			if expected := map[string]interface{}{"a": 1.0}; !reflect.DeepEqual(result, expected) {
+				t.Errorf("Unslim() = %v, want %v", result, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a non-empty line of a YAML document with its comment removed
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// yamlParser parses the subset of YAML used by config files: block mappings and
+// sequences, flow sequences and mappings ([a, b], {a: 1}) and plain or quoted
+// scalars. Mappings are returned as *OrderedMap, sequences as []interface{} and
+// numbers as json.Number, like decodeOrdered with UseNumber.
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+// parseYAML parses a YAML document
+func parseYAML(data []byte) (interface{}, error) {
+	p := &yamlParser{}
+	for n, raw := range strings.Split(string(data), "\n") {
+		text := stripYAMLComment(strings.TrimRight(raw, " \t\r"))
+		trimmed := strings.TrimLeft(text, " ")
+		if strings.TrimSpace(trimmed) == "" || (p.lines == nil && trimmed == "---") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("invalid syntax at line %d: tabs are not allowed for indentation", n+1)
+		}
+		p.lines = append(p.lines, yamlLine{num: n + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+
+	v, err := p.parseNode(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.lines) {
+		return nil, fmt.Errorf("invalid syntax at line %d: unexpected indentation", p.lines[p.i].num)
+	}
+	return v, nil
+}
+
+// stripYAMLComment removes a # comment that is not inside quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return line
+}
+
+// parseNode parses the block mapping or sequence starting at the current line
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	if isYAMLSeqItem(p.lines[p.i].text) {
+		return p.parseSeq(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *yamlParser) parseMap(indent int) (interface{}, error) {
+	m := NewOrderedMap()
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && !isYAMLSeqItem(p.lines[p.i].text) {
+		line := p.lines[p.i]
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("invalid syntax at line %d: expected key: value, got %s", line.num, line.text)
+		}
+		if _, dup := m.Get(key); dup {
+			return nil, fmt.Errorf("invalid syntax at line %d: duplicate key %s", line.num, key)
+		}
+		p.i++
+
+		val, err := p.parseValue(line, rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		m.Set(key, val)
+	}
+	return m, nil
+}
+
+func (p *yamlParser) parseSeq(indent int) (interface{}, error) {
+	arr := make([]interface{}, 0)
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isYAMLSeqItem(p.lines[p.i].text) {
+		line := p.lines[p.i]
+		rest := strings.TrimSpace(line.text[1:])
+
+		if _, _, ok := splitYAMLKey(rest); isYAMLSeqItem(rest) || (ok && !strings.ContainsAny(rest[:1], "[{")) {
+			// "- key: value" or "- - item" starts a block indented to the item
+			itemIndent := indent + len(line.text) - len(rest)
+			p.lines[p.i] = yamlLine{num: line.num, indent: itemIndent, text: rest}
+			item, err := p.parseNode(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, item)
+			continue
+		}
+
+		p.i++
+		item, err := p.parseValue(line, rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, item)
+	}
+	return arr, nil
+}
+
+// parseValue parses the value after a key or "-": inline text, or else a nested
+// block. A sequence may start at the same indentation as its key.
+func (p *yamlParser) parseValue(line yamlLine, rest string, indent int) (interface{}, error) {
+	if rest != "" {
+		v, err := parseYAMLScalar(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syntax at line %d: %w", line.num, err)
+		}
+		return v, nil
+	}
+	if p.i < len(p.lines) {
+		next := p.lines[p.i]
+		if next.indent > indent || (next.indent == indent && isYAMLSeqItem(next.text) && !isYAMLSeqItem(line.text)) {
+			return p.parseNode(next.indent)
+		}
+	}
+	return nil, nil
+}
+
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits "key: value" (value may be empty). Keys may be quoted.
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	i := -1
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		end := strings.IndexByte(text[1:], text[0]) + 1
+		if end == 0 || !strings.HasPrefix(text[end+1:], ":") {
+			return "", "", false
+		}
+		k, err := parseYAMLScalar(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		key, i = k.(string), end+1
+	} else {
+		i = strings.Index(text, ": ")
+		if i < 0 && strings.HasSuffix(text, ":") {
+			i = len(text) - 1
+		}
+		if i <= 0 {
+			return "", "", false
+		}
+		key = strings.TrimSpace(text[:i])
+	}
+	rest = strings.TrimSpace(text[i+1:])
+	if rest != "" && text[i+1] != ' ' {
+		return "", "", false
+	}
+	return key, rest, true
+}
+
+// parseYAMLScalar parses an inline value: a quoted or plain scalar or a flow collection
+func parseYAMLScalar(s string) (interface{}, error) {
+	switch {
+	case s == "":
+		return nil, nil
+
+	case s[0] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return v, nil
+
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+
+	case s[0] == '[':
+		if s[len(s)-1] != ']' {
+			return nil, fmt.Errorf("unterminated flow sequence %s", s)
+		}
+		arr := make([]interface{}, 0)
+		for _, item := range splitYAMLFlow(s[1 : len(s)-1]) {
+			v, err := parseYAMLScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+
+	case s[0] == '{':
+		if s[len(s)-1] != '}' {
+			return nil, fmt.Errorf("unterminated flow mapping %s", s)
+		}
+		m := NewOrderedMap()
+		for _, item := range splitYAMLFlow(s[1 : len(s)-1]) {
+			key, rest, ok := splitYAMLKey(item)
+			if !ok {
+				return nil, fmt.Errorf("expected key: value, got %s", item)
+			}
+			v, err := parseYAMLScalar(rest)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, v)
+		}
+		return m, nil
+
+	case s[0] == '|' || s[0] == '>':
+		return nil, fmt.Errorf("block scalars are not supported")
+	}
+
+	switch s {
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if strings.ContainsAny(s[:1], "+-.0123456789") && strings.ContainsAny(s, "0123456789") {
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return json.Number(s), nil
+		}
+	}
+	return s, nil
+}
+
+// splitYAMLFlow splits the inside of a flow collection on top-level commas
+func splitYAMLFlow(s string) []string {
+	var items []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(items) > 0 {
+		items = append(items, last)
+	}
+	return items
+}
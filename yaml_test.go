@@ -0,0 +1,61 @@
+package slimjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string // JSON encoding of the parsed document
+	}{
+		{name: "Empty", input: "# only a comment\n", expected: `null`},
+		{name: "Scalars", input: "s: text\nq: \"a # b\"\nsq: 'it''s'\nn: 1.5\ni: -3\nb: true\nnil: ~\nnone:\nhash: a#b # comment\n",
+			expected: `{"s":"text","q":"a # b","sq":"it's","n":1.5,"i":-3,"b":true,"nil":null,"none":null,"hash":"a#b"}`},
+		{name: "Nested mappings", input: "---\na:\n  b:\n    c: 1\n  d: 2\ne: 3\n", expected: `{"a":{"b":{"c":1},"d":2},"e":3}`},
+		{name: "Sequences", input: "a:\n  - x\n  - 2\nb:\n- y\n- \nc: [p, 'q, r', [1]]\n", expected: `{"a":["x",2],"b":["y",null],"c":["p","q, r",[1]]}`},
+		{name: "Sequence of mappings", input: "r:\n  - path: $.a[*]\n    depth: 2\n  - path: b\n", expected: `{"r":[{"path":"$.a[*]","depth":2},{"path":"b"}]}`},
+		{name: "Flow mapping", input: "m: {a: 1, b: [x, y], c: {}}\n", expected: `{"m":{"a":1,"b":["x","y"],"c":{}}}`},
+		{name: "Quoted keys and colons in values", input: "\"a b\": x\nurl: http://host:80/p\n", expected: `{"a b":"x","url":"http://host:80/p"}`},
+		{name: "Top-level sequence", input: "- 1\n- - 2\n", expected: `[1,[2]]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseYAML([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("parseYAML() error: %v", err)
+			}
+			got, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("parseYAML() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "a: 1\n b: 2\n", expected: "invalid syntax at line 2: unexpected indentation"},
+		{input: "a: 1\na: 2\n", expected: "invalid syntax at line 2: duplicate key a"},
+		{input: "a:\n  just text\n", expected: "invalid syntax at line 2: expected key: value, got just text"},
+		{input: "a: \"open\n", expected: "invalid syntax at line 1: invalid quoted string \"open"},
+		{input: "a: [1, 2\n", expected: "invalid syntax at line 1: unterminated flow sequence [1, 2"},
+		{input: "a: |\n  text\n", expected: "invalid syntax at line 1: block scalars are not supported"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseYAML([]byte(tt.input))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("parseYAML(%q) error = %v, want %q", tt.input, err, tt.expected)
+		}
+	}
+}